@@ -78,7 +78,8 @@ require (
 	go.opentelemetry.io/otel v1.35.0
 	go.uber.org/zap v1.27.0
 	golang.org/x/crypto v0.28.0
-	golang.org/x/sync v0.8.0 // indirect
-	golang.org/x/text v0.19.0 // indirect
+	golang.org/x/image v0.18.0
+	golang.org/x/sync v0.8.0
+	golang.org/x/text v0.19.0
 	gorm.io/gorm v1.25.10
 )
@@ -1,10 +1,12 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"music-lib/internal/config"
 	"music-lib/internal/delivery/rest"
 	"music-lib/internal/infrastructure/email"
+	"music-lib/internal/infrastructure/storage"
 	"music-lib/internal/repository"
 	"music-lib/internal/service"
 	"music-lib/pkg/db"
@@ -35,23 +37,42 @@ func RunV1() {
 	// Repositories
 	repositories := repository.NewPostgresRepositories(db)
 
+	// File storage
+	fileStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		panic("storage unable " + err.Error())
+	}
+
 	// Services
 	services := service.NewServices(&service.Deps{
 		Event: eventBus,
 		Repositories: repositories,
+		Storage: fileStorage,
+		Upload: cfg.Upload,
+		Stream: cfg.Stream,
+		Trash: cfg.Trash,
+		Comment: cfg.Comment,
 		Logger: sugar,
 	})
 
-	// Handlers
-	handlers := rest.NewHandler(services, cfg, sugar)
-	router := handlers.Init(cfg)
-
-	sender, err := email.Load(cfg, eventBus)
+	sender, err := email.Load(cfg)
 	if err != nil {
 		panic("sender unable " + err.Error())
 	}
-	
-	go sender.Listen()
+
+	// Подписчики получают каналы до того, как начнут выходить релизы
+	go sender.Listen(eventBus.Subscribe())
+	go services.Follow.Listen(context.Background(), eventBus.Subscribe())
+	// Публикация альбомов, у которых наступила дата релиза
+	go services.Release.Run(context.Background(), cfg.Release.CheckInterval)
+	// Окончательное удаление записей из корзины
+	go services.Trash.Run(context.Background(), cfg.Trash.PurgeInterval)
+	// Отмена брошенных загрузок, чтобы они не занимали квоту
+	go services.Upload.Run(context.Background(), cfg.Upload.PurgeInterval)
+
+	// Handlers
+	handlers := rest.NewHandler(services, cfg, sugar)
+	router := handlers.Init(cfg)
 
 	// Router run
 	fmt.Println("Server started on port ", cfg.App.Port)
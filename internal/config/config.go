@@ -7,6 +7,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +18,12 @@ type Config struct {
 	Db     DbConfig
 	Auth   AuthConfig
 	Sender SenderConfig
+	Storage StorageConfig
+	Upload  UploadConfig
+	Stream  StreamConfig
+	Release ReleaseConfig
+	Trash   TrashConfig
+	Comment CommentConfig
 }
 
 type DbConfig struct {
@@ -38,6 +46,51 @@ type SenderConfig struct {
 	Port     string
 }
 
+type StorageConfig struct {
+	Driver    string // local или s3
+	LocalPath string
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // Адресация bucket в пути, нужна для MinIO
+}
+
+type UploadConfig struct {
+	MaxFileSize  int64 // Максимальный размер аудиофайла в байтах
+	UserQuota    int64 // Суммарный объем файлов пользователя в байтах
+	MaxImageSize int64 // Максимальный размер обложки или аватара в байтах
+	Duplicates   string // reject или warn: что делать с файлом, который уже есть в каталоге
+	// Незавершенная или не привязанная к песне загрузка отменяется через TTL после последнего изменения
+	TTL           time.Duration
+	PurgeInterval time.Duration // Как часто отменять просроченные загрузки
+}
+
+// Режимы обработки повторной загрузки того же звука
+const (
+	DuplicatesReject = "reject"
+	DuplicatesWarn   = "warn"
+)
+
+type StreamConfig struct {
+	Secret string        // Ключ подписи ссылок на прослушивание
+	URLTTL time.Duration // Время жизни подписанной ссылки
+}
+
+type ReleaseConfig struct {
+	CheckInterval time.Duration // Как часто искать альбомы, у которых наступила дата релиза
+}
+
+type TrashConfig struct {
+	Retention     time.Duration // Сколько удаленные записи хранятся в корзине
+	PurgeInterval time.Duration // Как часто удалять записи с истекшим сроком хранения
+}
+
+type CommentConfig struct {
+	EditWindow time.Duration // Сколько после публикации автор может править комментарий
+}
+
 func Load() (*Config, error) {
 	err := godotenv.Load(dir(".env"))
 	if err != nil {
@@ -60,6 +113,38 @@ func Load() (*Config, error) {
 			Address:  getEnv("ADDRESS", "smtp.mail.ru"),
 			Port:     getEnv("SMTP_PORT", "465"),
 		},
+		Storage: StorageConfig{
+			Driver:    getEnv("STORAGE_DRIVER", "local"),
+			LocalPath: getEnv("STORAGE_PATH", "./data"),
+			Endpoint:  getEnv("S3_ENDPOINT", ""),
+			Bucket:    getEnv("S3_BUCKET", ""),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("S3_SECRET_KEY", ""),
+			PathStyle: getEnvBool("S3_PATH_STYLE", true),
+		},
+		Upload: UploadConfig{
+			MaxFileSize:   getEnvInt64("UPLOAD_MAX_FILE_SIZE", 100<<20),
+			UserQuota:     getEnvInt64("UPLOAD_USER_QUOTA", 2<<30),
+			MaxImageSize:  getEnvInt64("UPLOAD_MAX_IMAGE_SIZE", 10<<20),
+			Duplicates:    getEnv("UPLOAD_DUPLICATES", DuplicatesReject),
+			TTL:           getEnvDuration("UPLOAD_TTL", 24*time.Hour),
+			PurgeInterval: getEnvDuration("UPLOAD_PURGE_INTERVAL", time.Hour),
+		},
+		Stream: StreamConfig{
+			Secret: getEnv("STREAM_SECRET", getEnv("SECRET", "")),
+			URLTTL: getEnvDuration("STREAM_URL_TTL", 15*time.Minute),
+		},
+		Release: ReleaseConfig{
+			CheckInterval: getEnvDuration("RELEASE_CHECK_INTERVAL", time.Minute),
+		},
+		Trash: TrashConfig{
+			Retention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+			PurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Comment: CommentConfig{
+			EditWindow: getEnvDuration("COMMENT_EDIT_WINDOW", 15*time.Minute),
+		},
 	}
 
 	if err := config.validate(); err != nil {
@@ -73,6 +158,39 @@ func (c *Config) validate() error {
 	if c.Db.Dsn == "" {
 		return errors.New("DSN is required")
 	}
+	switch c.Storage.Driver {
+	case "local":
+	case "s3":
+		if c.Storage.Endpoint == "" || c.Storage.Bucket == "" {
+			return errors.New("S3_ENDPOINT and S3_BUCKET are required for s3 storage")
+		}
+	default:
+		return fmt.Errorf("unknown storage driver %q", c.Storage.Driver)
+	}
+	if c.Upload.MaxFileSize <= 0 || c.Upload.UserQuota <= 0 || c.Upload.MaxImageSize <= 0 {
+		return errors.New("upload limits must be positive")
+	}
+	if c.Upload.Duplicates != DuplicatesReject && c.Upload.Duplicates != DuplicatesWarn {
+		return fmt.Errorf("unknown UPLOAD_DUPLICATES mode %q: expected reject or warn", c.Upload.Duplicates)
+	}
+	if c.Upload.TTL <= 0 || c.Upload.PurgeInterval <= 0 {
+		return errors.New("upload TTL and purge interval must be positive")
+	}
+	if c.Stream.Secret == "" {
+		return errors.New("STREAM_SECRET or SECRET is required to sign stream URLs")
+	}
+	if c.Stream.URLTTL <= 0 {
+		return errors.New("stream URL TTL must be positive")
+	}
+	if c.Release.CheckInterval <= 0 {
+		return errors.New("release check interval must be positive")
+	}
+	if c.Trash.Retention <= 0 || c.Trash.PurgeInterval <= 0 {
+		return errors.New("trash retention and purge interval must be positive")
+	}
+	if c.Comment.EditWindow <= 0 {
+		return errors.New("comment edit window must be positive")
+	}
 	return nil
 }
 
@@ -83,6 +201,36 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt64(key string, fallback int64) int64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+		log.Println("Invalid integer in env, using default.", "Key:", key)
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+		log.Println("Invalid boolean in env, using default.", "Key:", key)
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+		log.Println("Invalid duration in env, using default.", "Key:", key)
+	}
+	return fallback
+}
+
 func dir(envFile string) string {
 	currentDir, err := os.Getwd()
 	if err != nil {
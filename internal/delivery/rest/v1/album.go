@@ -7,6 +7,7 @@ import (
 	"music-lib/internal/model"
 	"music-lib/pkg/er"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,11 +15,21 @@ import (
 func (h *Handler) initAlbumRoutes(api *gin.RouterGroup) {
 	album := api.Group("/album")
 	{
-		album.GET("/:id", h.GetAlbum())
+		// Невышедшие альбомы видят только редакторы
+		album.GET("/:id", middleware.OptionalAuthMiddleware(h.config), h.GetAlbum())
+		album.GET("/by-upc/:upc", middleware.OptionalAuthMiddleware(h.config), h.GetAlbumByUPC())
 	}
 	album.Use(middleware.AuthMiddleware(h.config))
 	{
 		album.POST("", h.NewAlbum())
+		album.PATCH("/:id", h.UpdateAlbum())
+		album.POST("/:id/publish", h.PublishAlbum())
+		album.POST("/:id/unpublish", h.UnpublishAlbum())
+		album.DELETE("/:id", h.TrashEntry(model.AlbumResource))
+		album.POST("/:id/restore", h.RestoreEntry(model.AlbumResource))
+		album.POST("/:id/merge", h.MergeEntry(model.AlbumResource))
+		album.PUT("/:id/cover", h.UploadAlbumCover())
+		album.DELETE("/:id/cover", h.DeleteAlbumCover())
 	}
 }
 
@@ -56,27 +67,118 @@ func (h *Handler) GetAlbum() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		strID := ctx.Param("id")
 
-		album, err := h.services.Album.GetAlbum(ctx, strID)
+		album, err := h.services.Album.GetAlbum(ctx, strID, viewerID(ctx))
+		if err == nil {
+			err = h.services.Release.CheckAlbum(ctx, album, viewerID(ctx))
+		}
 		if err != nil {
+			if h.movedTo(ctx, model.AlbumResource, err) {
+				return
+			}
 			ctx.Error(err)
 			return
 		}
 
-		var songs []response.SongDTO
-		for _, song := range album.Songs {
-			songs = append(songs, response.SongDTO{
-				ID: song.ID,
-				Title: song.Title,
-				Duration: song.Duration,
-				FilePath: song.FilePath,
-			})
+		dto := toAlbumDTO(album)
+		dto.Rating = h.ratingSummary(ctx, model.AlbumResource, album.ID)
+		ctx.JSON(http.StatusOK, dto)
+	}
+}
+
+// GetAlbumByUPC ищет альбом по UPC-A или EAN-13
+func (h *Handler) GetAlbumByUPC() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		album, err := h.services.Album.GetAlbumByUPC(ctx, ctx.Param("upc"), viewerID(ctx))
+		if err == nil {
+			err = h.services.Release.CheckAlbum(ctx, album, viewerID(ctx))
 		}
-		ctx.JSON(http.StatusOK, response.AlbumDTO{
-			ID: album.ID,
-			Title: album.Title,
-			ReleaseDate: album.ReleaseDate,
-			CoverArtURL: album.CoverArtURL,
-			Songs: songs,
-		})
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		dto := toAlbumDTO(album)
+		dto.Rating = h.ratingSummary(ctx, model.AlbumResource, album.ID)
+		ctx.JSON(http.StatusOK, dto)
 	}
-}
\ No newline at end of file
+}
+
+// UpdateAlbum меняет тип альбома, UPC и названия дисков
+func (h *Handler) UpdateAlbum() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.editableAlbumID(ctx)
+		if !ok {
+			return
+		}
+
+		var body request.UpdateAlbumRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		album, err := h.services.Album.UpdateAlbum(ctx, id, body, viewerID(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		dto := toAlbumDTO(album)
+		dto.Rating = h.ratingSummary(ctx, model.AlbumResource, album.ID)
+		ctx.JSON(http.StatusOK, dto)
+	}
+}
+
+// toAlbumDTO раскладывает песни альбома по дискам, песни уже отсортированы по номеру дорожки
+func toAlbumDTO(album *model.Album) response.AlbumDTO {
+	titles := make(map[int]string)
+	for _, disc := range album.Discs {
+		titles[disc.Number] = disc.Title
+	}
+
+	var songs []response.SongDTO
+	var discs []response.DiscDTO
+	for _, song := range album.Songs {
+		dto := response.SongDTO{
+			ID:          song.ID,
+			Title:       song.Title,
+			Status:      string(song.Status),
+			ISRC:        song.ISRC,
+			DiscNumber:  song.DiscNumber,
+			TrackNumber: song.TrackNumber,
+			Duration:    song.Duration,
+			StreamURL:   response.SongStreamURL(song.ID, song.FilePath),
+			Private:     song.Private,
+		}
+		songs = append(songs, dto)
+
+		if len(discs) == 0 || discs[len(discs)-1].Number != song.DiscNumber {
+			discs = append(discs, response.DiscDTO{Number: song.DiscNumber, Title: titles[song.DiscNumber]})
+		}
+		last := &discs[len(discs)-1]
+		last.Songs = append(last.Songs, dto)
+	}
+
+	// Диски с названием, но еще без песен
+	for _, disc := range album.Discs {
+		if !slices.ContainsFunc(discs, func(d response.DiscDTO) bool { return d.Number == disc.Number }) {
+			discs = append(discs, response.DiscDTO{Number: disc.Number, Title: disc.Title, Songs: []response.SongDTO{}})
+		}
+	}
+	slices.SortStableFunc(discs, func(a, b response.DiscDTO) int {
+		return a.Number - b.Number
+	})
+
+	return response.AlbumDTO{
+		ID:          album.ID,
+		Title:       album.Title,
+		Status:      string(album.Status),
+		ReleaseDate: album.ReleaseDate,
+		CoverArtURL: album.CoverArtURL,
+		CoverArt:    response.NewImageDTO(album.CoverArtKey),
+		Type:        string(album.Type),
+		UPC:         album.UPC,
+		Songs:       songs,
+		Discs:       discs,
+	}
+}
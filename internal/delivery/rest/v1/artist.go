@@ -15,12 +15,21 @@ import (
 func (h *Handler) initArtistRoutes(api *gin.RouterGroup) {
 	artist := api.Group("/artist")
 	{
-		artist.GET("/:id", h.GetArtist())
+		// Альбомы и песни до релиза видят только редакторы
+		artist.GET("/:id", middleware.OptionalAuthMiddleware(h.config), h.GetArtist())
+		artist.GET("/:id/songs", middleware.OptionalAuthMiddleware(h.config), h.GetArtistSongs())
 	}
 	artist.Use(middleware.AuthMiddleware(h.config))
 	{
 		artist.POST("", h.NewArtist())
 		artist.PATCH("/:id", h.UpdateArtist())
+		artist.POST("/:id/publish", h.PublishArtist())
+		artist.POST("/:id/unpublish", h.UnpublishArtist())
+		artist.DELETE("/:id", h.TrashEntry(model.ArtistResource))
+		artist.POST("/:id/restore", h.RestoreEntry(model.ArtistResource))
+		artist.POST("/:id/merge", h.MergeEntry(model.ArtistResource))
+		artist.POST("/:id/follow", h.FollowArtist(true))
+		artist.DELETE("/:id/follow", h.FollowArtist(false))
 	}
 }
 
@@ -54,8 +63,10 @@ func (h *Handler) NewArtist() gin.HandlerFunc {
 		ctx.JSON(http.StatusCreated, response.ArtistDTO{
 			ID: artist.ID,
 			Name: artist.Name,
+			Status: string(artist.Status),
 			Description: artist.Description,
 			FormationYear: artist.FormationYear,
+			Followers: artist.Followers,
 		})
 	}
 }
@@ -65,7 +76,19 @@ func (h *Handler) GetArtist() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		strID := ctx.Param("id")
 
-		artist, err := h.services.Artist.GetArtist(ctx, strID)
+		artist, err := h.services.Artist.GetArtist(ctx, strID, viewerID(ctx))
+		if err == nil {
+			err = h.services.Release.CheckArtist(artist, viewerID(ctx))
+		}
+		if err != nil {
+			if h.movedTo(ctx, model.ArtistResource, err) {
+				return
+			}
+			ctx.Error(err)
+			return
+		}
+
+		following, err := h.services.Follow.IsFollowing(ctx, viewerID(ctx), artist.ID)
 		if err != nil {
 			ctx.Error(err)
 			return
@@ -76,8 +99,11 @@ func (h *Handler) GetArtist() gin.HandlerFunc {
 			albums = append(albums, response.AlbumDTO{
 				ID: album.ID,
 				Title: album.Title,
+				Status: string(album.Status),
 				ReleaseDate: album.ReleaseDate,
 				CoverArtURL: album.CoverArtURL,
+				Type: string(album.Type),
+				UPC: album.UPC,
 				Songs: nil,
 			})
 		}
@@ -85,8 +111,11 @@ func (h *Handler) GetArtist() gin.HandlerFunc {
 		ctx.JSON(http.StatusOK, response.ArtistDTO{
 			ID: artist.ID,
 			Name: artist.Name,
+			Status: string(artist.Status),
 			Description: artist.Description,
 			FormationYear: artist.FormationYear,
+			Followers: artist.Followers,
+			Following: following,
 			Albums: albums,
 		})
 	}
@@ -126,7 +155,7 @@ func (h *Handler) UpdateArtist() gin.HandlerFunc {
 			return
 		}
 
-		artist, err := h.services.Artist.UpdateArtist(ctx, uint(id), body)
+		artist, err := h.services.Artist.UpdateArtist(ctx, uint(id), body, user.Id)
 		if err != nil {
 			ctx.Error(err)
 			return		
@@ -135,8 +164,10 @@ func (h *Handler) UpdateArtist() gin.HandlerFunc {
 		ctx.JSON(http.StatusOK, response.ArtistDTO{
 			ID: artist.ID,
 			Name: artist.Name,
+			Status: string(artist.Status),
 			Description: artist.Description,
 			FormationYear: artist.FormationYear,
+			Followers: artist.Followers,
 		})
 	}
 }
@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initAuditRoutes(api *gin.RouterGroup) {
+	admin := api.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(h.config))
+	{
+		admin.GET("/audit", h.GetAudit())
+	}
+
+	me := api.Group("/me")
+	me.Use(middleware.AuthMiddleware(h.config))
+	{
+		me.GET("/audit", h.GetOwnAudit())
+	}
+}
+
+// adminID возвращает идентификатор пользователя, если он администратор
+func adminID(ctx *gin.Context) (uint, bool) {
+	user, ok := middleware.GetUserData(ctx)
+	if !ok {
+		ctx.Error(er.ErrNotAuthorized)
+		return 0, false
+	}
+	if user.Role != string(model.RoleAdmin) {
+		ctx.Error(er.ErrAdminOnly)
+		return 0, false
+	}
+	return user.Id, true
+}
+
+// GetAudit отдает администратору журнал изменений по любым записям и пользователям
+func (h *Handler) GetAudit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := adminID(ctx); !ok {
+			return
+		}
+
+		filter, ok := auditFilter(ctx)
+		if !ok {
+			return
+		}
+		if actor := ctx.Query("actor"); actor != "" {
+			actorID, err := strconv.Atoi(actor)
+			if err != nil || actorID < 1 {
+				ctx.Error(&er.ValidationError{Message: "invalid actor value"})
+				return
+			}
+			filter.ActorID = uint(actorID)
+		}
+
+		h.writeAudit(ctx, filter)
+	}
+}
+
+// GetOwnAudit отдает журнал изменений записей, которые пользователь может редактировать
+func (h *Handler) GetOwnAudit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		filter, ok := auditFilter(ctx)
+		if !ok {
+			return
+		}
+		filter.EditorID = user.Id
+
+		h.writeAudit(ctx, filter)
+	}
+}
+
+// auditFilter читает из запроса тип и идентификатор записи
+func auditFilter(ctx *gin.Context) (model.AuditFilter, bool) {
+	filter := model.AuditFilter{EntityType: model.Resource(ctx.Query("entity"))}
+	if entityID := ctx.Query("entity_id"); entityID != "" {
+		id, err := strconv.Atoi(entityID)
+		if err != nil || id < 1 {
+			ctx.Error(&er.ValidationError{Message: "invalid entity_id value"})
+			return filter, false
+		}
+		filter.EntityID = uint(id)
+	}
+	return filter, true
+}
+
+func (h *Handler) writeAudit(ctx *gin.Context, filter model.AuditFilter) {
+	limit, offset := validatePagination(ctx)
+	if len(ctx.Errors) > 0 {
+		return
+	}
+
+	entries, total, err := h.services.Audit.GetAudit(ctx, filter, limit, offset)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data := make([]response.AuditEntryDTO, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, response.AuditEntryDTO{
+			ID:        entry.ID,
+			Type:      string(entry.EntityType),
+			EntityID:  entry.EntityID,
+			ActorID:   entry.ActorID,
+			Action:    string(entry.Action),
+			Before:    entry.Before,
+			After:     entry.After,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, response.PaginatedResponse{
+		Data: data,
+		Pagination: response.Pagination{
+			Limit:  limit,
+			Offset: offset,
+			Total:  total,
+		},
+	})
+}
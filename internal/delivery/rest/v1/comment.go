@@ -0,0 +1,199 @@
+package v1
+
+import (
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"music-lib/pkg/timecode"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initCommentRoutes(api *gin.RouterGroup) {
+	for _, resource := range []model.Resource{model.SongResource, model.AlbumResource} {
+		group := api.Group("/" + string(resource))
+		group.GET("/:id/comments", middleware.OptionalAuthMiddleware(h.config), h.GetComments(resource))
+		group.POST("/:id/comments", middleware.AuthMiddleware(h.config), h.AddComment(resource))
+	}
+
+	comment := api.Group("/comment")
+	comment.Use(middleware.AuthMiddleware(h.config))
+	{
+		comment.PATCH("/:id", h.UpdateComment())
+		comment.DELETE("/:id", h.DeleteComment())
+		comment.POST("/:id/like", h.LikeComment(true))
+		comment.DELETE("/:id/like", h.LikeComment(false))
+	}
+}
+
+// GetComments возвращает комментарии верхнего уровня или, с ?parent=, ответы на комментарий.
+// sort: newest (по умолчанию), oldest или top.
+func (h *Handler) GetComments(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		var parentID uint
+		if parent := ctx.Query("parent"); parent != "" {
+			id, err := strconv.Atoi(parent)
+			if err != nil || id < 1 {
+				ctx.Error(&er.ValidationError{Message: "invalid parent value"})
+				return
+			}
+			parentID = uint(id)
+		}
+
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		sort := model.CommentSort(ctx.Query("sort"))
+		comments, total, err := h.services.Comment.GetComments(ctx, resource, id, parentID, viewerID(ctx), sort, limit, offset)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		data := make([]response.CommentDTO, 0, len(comments))
+		for i := range comments {
+			data = append(data, toCommentDTO(&comments[i]))
+		}
+
+		ctx.JSON(http.StatusOK, response.PaginatedResponse{
+			Data: data,
+			Pagination: response.Pagination{
+				Limit:  limit,
+				Offset: offset,
+				Total:  total,
+			},
+		})
+	}
+}
+
+func (h *Handler) AddComment(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body request.CommentRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		comment, err := h.services.Comment.AddComment(ctx, resource, id, viewerID(ctx), body)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, toCommentDTO(comment))
+	}
+}
+
+// UpdateComment меняет текст комментария, пока не истекло время на правку
+func (h *Handler) UpdateComment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		var body request.UpdateCommentRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		comment, err := h.services.Comment.UpdateComment(ctx, uint(id), viewerID(ctx), body.Text)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toCommentDTO(comment))
+	}
+}
+
+// DeleteComment удаляет комментарий, доступно автору и администраторам
+func (h *Handler) DeleteComment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		admin := user.Role == string(model.RoleAdmin)
+		if err := h.services.Comment.DeleteComment(ctx, uint(id), user.Id, admin); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// LikeComment ставит или снимает отметку "нравится"
+func (h *Handler) LikeComment(like bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		if like {
+			err = h.services.Comment.Like(ctx, uint(id), viewerID(ctx))
+		} else {
+			err = h.services.Comment.Unlike(ctx, uint(id), viewerID(ctx))
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func toCommentDTO(comment *model.Comment) response.CommentDTO {
+	dto := response.CommentDTO{
+		ID:        comment.ID,
+		ParentID:  comment.ParentID,
+		Likes:     comment.Likes,
+		Liked:     comment.Liked,
+		Replies:   comment.Replies,
+		CreatedAt: comment.CreatedAt,
+	}
+	if comment.DeletedAt.Valid {
+		dto.Deleted = true
+		return dto
+	}
+
+	dto.UserID = comment.UserID
+	dto.Author = comment.Author
+	dto.Text = comment.Text
+	dto.EditedAt = comment.EditedAt
+	if comment.AtSec != nil {
+		dto.At = timecode.Format(*comment.AtSec)
+		dto.AtSec = comment.AtSec
+	}
+	return dto
+}
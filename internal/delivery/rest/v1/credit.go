@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSongCredits заменяет список участников песни: приглашенных артистов, авторов, продюсеров
+func (h *Handler) SetSongCredits() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		var body request.SetCreditsRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		credits, err := h.services.Song.SetCredits(ctx, uint(id), body.Credits, user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		h.logger.Infow("Song credits updated",
+			"song id", id,
+			"user id", user.Id,
+			"credits", len(credits),
+		)
+
+		ctx.JSON(http.StatusOK, toCreditsDTO(credits))
+	}
+}
+
+// GetArtistSongs отдает песни артиста, включая песни других артистов, где он указан участником
+func (h *Handler) GetArtistSongs() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		sort := ctx.DefaultQuery("sort", "newest")
+		if sort != "newest" && sort != "oldest" && sort != "title" {
+			ctx.Error(&er.ValidationError{Message: "sort must be newest, oldest or title"})
+			return
+		}
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		songs, total, err := h.services.Song.GetArtistSongs(ctx, uint(id), viewerID(ctx), sort, limit, offset)
+		if err != nil {
+			if h.movedTo(ctx, model.ArtistResource, err) {
+				return
+			}
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, response.PaginatedResponse{
+			Data: toSongListDTO(songs),
+			Pagination: response.Pagination{
+				Limit:  limit,
+				Offset: offset,
+				Total:  total,
+			},
+		})
+	}
+}
+
+func toCreditsDTO(credits []model.SongCredit) []response.CreditDTO {
+	dto := make([]response.CreditDTO, 0, len(credits))
+	for _, credit := range credits {
+		c := response.CreditDTO{Name: credit.Name, Role: string(credit.Role)}
+		if credit.ArtistID != nil {
+			c.ArtistID = *credit.ArtistID
+			if credit.Artist != nil {
+				c.Name = credit.Artist.Name
+			}
+		}
+		dto = append(dto, c)
+	}
+	return dto
+}
+
+// toSongListDTO для постраничных списков песен, без текстов и жанров
+func toSongListDTO(songs []model.Song) []response.SongDTO {
+	data := make([]response.SongDTO, 0, len(songs))
+	for _, song := range songs {
+		data = append(data, response.SongDTO{
+			ID:          song.ID,
+			Title:       song.Title,
+			Status:      string(song.Status),
+			ISRC:        song.ISRC,
+			ArtistID:    song.ArtistID,
+			AlbumID:     song.AlbumID,
+			DiscNumber:  song.DiscNumber,
+			TrackNumber: song.TrackNumber,
+			Duration:    song.Duration,
+			StreamURL:   response.SongStreamURL(song.ID, song.FilePath),
+			Private:     song.Private,
+			Credits:     toCreditsDTO(song.Credits),
+		})
+	}
+	return data
+}
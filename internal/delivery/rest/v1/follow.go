@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initFollowRoutes(api *gin.RouterGroup) {
+	me := api.Group("/me")
+	me.Use(middleware.AuthMiddleware(h.config))
+	{
+		me.GET("/feed", h.GetFeed())
+	}
+}
+
+// FollowArtist подписывает на артиста или снимает подписку. Отписаться можно и от артиста,
+// которого пользователь больше не видит.
+func (h *Handler) FollowArtist(follow bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		if !follow {
+			if err := h.services.Follow.UnfollowArtist(ctx, viewerID(ctx), uint(id)); err != nil {
+				ctx.Error(err)
+				return
+			}
+			ctx.Status(http.StatusNoContent)
+			return
+		}
+
+		artist, err := h.services.Artist.GetArtist(ctx, ctx.Param("id"), viewerID(ctx))
+		if err == nil {
+			err = h.services.Release.CheckArtist(artist, viewerID(ctx))
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if err := h.services.Follow.FollowArtist(ctx, viewerID(ctx), artist.ID); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// GetFeed возвращает новые альбомы и песни артистов, на которых подписан пользователь, сначала новые
+func (h *Handler) GetFeed() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		items, total, err := h.services.Follow.GetFeed(ctx, viewerID(ctx), limit, offset)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		data := make([]response.FeedItemDTO, 0, len(items))
+		for _, item := range items {
+			data = append(data, response.FeedItemDTO{
+				Type:        string(item.ResourceType),
+				ID:          item.ResourceID,
+				Title:       item.Title,
+				ArtistID:    item.ArtistID,
+				AlbumID:     item.AlbumID,
+				ReleaseDate: item.ReleaseDate,
+				AddedAt:     item.CreatedAt,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, response.PaginatedResponse{
+			Data: data,
+			Pagination: response.Pagination{
+				Limit:  limit,
+				Offset: offset,
+				Total:  total,
+			},
+		})
+	}
+}
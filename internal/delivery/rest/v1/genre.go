@@ -2,7 +2,9 @@ package v1
 
 import (
 	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
 	"music-lib/internal/middleware"
+	"music-lib/internal/model"
 	"music-lib/pkg/er"
 	"net/http"
 	"strconv"
@@ -13,13 +15,18 @@ import (
 func (h *Handler) initGenreRoutes(api *gin.RouterGroup) {
 	genre := api.Group("/genre")
 	{
-		genre.GET("/:id", )
+		genre.GET("", h.GetGenres())
+		genre.GET("/tree", h.GetGenreTree())
+		genre.GET("/:id", h.GetGenre())
+		genre.GET("/:id/songs", middleware.OptionalAuthMiddleware(h.config), h.GetGenreSongs())
 	}
 	genre.Use(middleware.AuthMiddleware(h.config))
 	{
 		genre.POST("", h.NewGenre())
 		genre.PATCH("/:id", h.UpdateGenre())
-		genre.DELETE("",)
+		genre.DELETE("/:id", h.DeleteGenre())
+		genre.POST("/:id/restore", h.RestoreGenre())
+		genre.POST("/:id/merge", h.MergeEntry(model.GenreResource))
 	}
 }
 
@@ -32,13 +39,19 @@ func (h *Handler) NewGenre() gin.HandlerFunc {
 			return
 		}
 
-		err := h.services.Genre.NewGenre(ctx, body.Name)
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		genre, err := h.services.Genre.NewGenre(ctx, body, user.Id)
 		if err != nil {
 			ctx.Error(err)
 			return
 		}
 
-		ctx.JSON(http.StatusCreated, nil)
+		ctx.JSON(http.StatusCreated, toGenreDTO(genre))
 	}
 }
 
@@ -59,14 +72,186 @@ func (h *Handler) UpdateGenre() gin.HandlerFunc {
 			return
 		}
 
-		err = h.services.Genre.UpdateGenre(ctx, uint(id), body.NewName)
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		genre, err := h.services.Genre.UpdateGenre(ctx, uint(id), body, user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toGenreDTO(genre))
+	}
+}
+
+// GetGenres отдает все жанры или жанр, чье имя или синоним совпадает с q
+func (h *Handler) GetGenres() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		genres, err := h.services.Genre.GetGenres(ctx, ctx.Query("q"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		data := make([]response.GenreDTO, 0, len(genres))
+		for i := range genres {
+			data = append(data, toGenreDTO(&genres[i]))
+		}
+		ctx.JSON(http.StatusOK, data)
+	}
+}
+
+func (h *Handler) GetGenreTree() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tree, err := h.services.Genre.GetGenreTree(ctx)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toGenreTreeDTO(tree))
+	}
+}
+
+func (h *Handler) GetGenre() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		genre, err := h.services.Genre.GetGenre(ctx, uint(id))
+		if err != nil {
+			if h.movedTo(ctx, model.GenreResource, err) {
+				return
+			}
+			ctx.Error(err)
+			return
+		}
+
+		dto := toGenreDTO(genre)
+		for i := range genre.Children {
+			dto.Subgenres = append(dto.Subgenres, toGenreDTO(&genre.Children[i]))
+		}
+		ctx.JSON(http.StatusOK, dto)
+	}
+}
+
+// GetGenreSongs отдает песни жанра вместе с песнями поджанров
+func (h *Handler) GetGenreSongs() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		songs, total, err := h.services.Song.GetGenreSongs(ctx, uint(id), viewerID(ctx), limit, offset)
+		if err != nil {
+			if h.movedTo(ctx, model.GenreResource, err) {
+				return
+			}
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, response.PaginatedResponse{
+			Data: toSongListDTO(songs),
+			Pagination: response.Pagination{
+				Limit:  limit,
+				Offset: offset,
+				Total:  total,
+			},
+		})
+	}
+}
+
+// DeleteGenre удаляет жанр, доступно администраторам.
+// Песни переходят к жанру reassign_to, без него жанр просто снимается с песен.
+func (h *Handler) DeleteGenre() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := adminID(ctx)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		var replacementID int
+		if value := ctx.Query("reassign_to"); value != "" {
+			replacementID, err = strconv.Atoi(value)
+			if err != nil || replacementID < 1 {
+				ctx.Error(&er.ValidationError{Message: "invalid reassign_to value"})
+				return
+			}
+		}
+
+		if err := h.services.Genre.DeleteGenre(ctx, uint(id), uint(replacementID), userID); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// RestoreGenre возвращает удаленный жанр вместе с его песнями и синонимами, доступно администраторам
+func (h *Handler) RestoreGenre() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := adminID(ctx)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.Atoi(ctx.Param("id"))
 		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		if err := h.services.Trash.Restore(ctx, model.GenreResource, uint(id), userID); err != nil {
 			ctx.Error(err)
 			return
 		}
 
-		ctx.JSON(http.StatusOK, nil)
+		ctx.Status(http.StatusNoContent)
 	}
 }
 
+func toGenreDTO(genre *model.Genre) response.GenreDTO {
+	dto := response.GenreDTO{
+		ID:          genre.ID,
+		Name:        genre.Name,
+		Description: genre.Description,
+		ParentID:    genre.ParentID,
+	}
+	for _, alias := range genre.Aliases {
+		dto.Aliases = append(dto.Aliases, alias.Name)
+	}
+	return dto
+}
 
+func toGenreTreeDTO(tree []model.GenreTree) []response.GenreDTO {
+	data := make([]response.GenreDTO, 0, len(tree))
+	for i := range tree {
+		dto := toGenreDTO(&tree[i].Genre)
+		if len(tree[i].Subgenres) > 0 {
+			dto.Subgenres = toGenreTreeDTO(tree[i].Subgenres)
+		}
+		data = append(data, dto)
+	}
+	return data
+}
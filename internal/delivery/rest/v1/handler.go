@@ -28,9 +28,19 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 		h.initAuthRoutes(v1)
 		h.initSearchRoutes(v1)
 		h.initSongRoutes(v1)
+		h.initUploadRoutes(v1)
 		h.initProfileRoutes(v1)
+		h.initTrashRoutes(v1)
+		h.initAuditRoutes(v1)
 		h.initArtistRoutes(v1)
 		h.initAlbumRoutes(v1)
+		h.initImageRoutes(v1)
 		h.initGenreRoutes(v1)
+		h.initTagRoutes(v1)
+		h.initReviewRoutes(v1)
+		h.initCommentRoutes(v1)
+		h.initReportRoutes(v1)
+		h.initFollowRoutes(v1)
+		h.initUserRoutes(v1)
 	}
 }
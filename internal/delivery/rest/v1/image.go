@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Поле multipart формы с изображением
+const imageFormField = "image"
+
+func (h *Handler) initImageRoutes(api *gin.RouterGroup) {
+	api.GET("/image/*key", h.GetImage())
+}
+
+// GetImage отдает миниатюру. Ключ содержит хеш содержимого и не меняется, поэтому кеш бессрочный.
+func (h *Handler) GetImage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := strings.TrimPrefix(ctx.Param("key"), "/")
+
+		body, info, err := h.services.Image.Open(ctx, key)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer body.Close()
+
+		ctx.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, map[string]string{
+			"Cache-Control": "public, max-age=31536000, immutable",
+		})
+	}
+}
+
+// UploadAlbumCover принимает обложку в поле "image" multipart формы
+func (h *Handler) UploadAlbumCover() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.editableAlbumID(ctx)
+		if !ok {
+			return
+		}
+
+		file, _, err := formFile(ctx, imageFormField, h.config.Upload.MaxImageSize, er.ErrImageTooLarge)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer file.Close()
+
+		album, err := h.services.Image.SetAlbumCover(ctx, id, viewerID(ctx), file)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, response.NewImageDTO(album.CoverArtKey))
+	}
+}
+
+func (h *Handler) DeleteAlbumCover() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.editableAlbumID(ctx)
+		if !ok {
+			return
+		}
+
+		if err := h.services.Image.DeleteAlbumCover(ctx, id, viewerID(ctx)); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// UploadAvatar принимает аватар текущего пользователя в поле "image" multipart формы
+func (h *Handler) UploadAvatar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		file, _, err := formFile(ctx, imageFormField, h.config.Upload.MaxImageSize, er.ErrImageTooLarge)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer file.Close()
+
+		profile, err := h.services.Image.SetAvatar(ctx, user.Id, file)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, response.NewImageDTO(profile.AvatarKey))
+	}
+}
+
+func (h *Handler) DeleteAvatar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if err := h.services.Image.DeleteAvatar(ctx, user.Id); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// Идентификатор альбома из пути, если у пользователя есть право на его редактирование
+func (h *Handler) editableAlbumID(ctx *gin.Context) (uint, bool) {
+	return h.editableID(ctx, model.AlbumResource)
+}
@@ -0,0 +1,337 @@
+package v1
+
+import (
+	"io"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Максимальный размер загружаемого LRC файла
+const maxLyricsSize = 1 << 20
+
+func (h *Handler) GetLyrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+		if !h.releasedSong(ctx, uint(id)) {
+			return
+		}
+
+		accept := lyricsLanguage(ctx)
+		kind := model.LyricsKind(ctx.Query("kind"))
+		ctx.Header("Vary", "Accept-Language")
+
+		switch ctx.DefaultQuery("format", "json") {
+		case "json":
+			lyrics, err := h.services.Lyrics.GetLyrics(ctx, uint(id), accept, kind)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			ctx.Header("Content-Language", lyrics.Language)
+			ctx.JSON(http.StatusOK, toLyricsDTO(lyrics))
+		case "lrc":
+			data, err := h.services.Lyrics.ExportLRC(ctx, uint(id), accept, kind)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			ctx.Data(http.StatusOK, "application/x-lrc; charset=utf-8", []byte(data))
+		case "txt":
+			data, err := h.services.Lyrics.ExportText(ctx, uint(id), accept, kind)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(data))
+		default:
+			ctx.Error(er.ErrLyricsFormat)
+		}
+	}
+}
+
+func (h *Handler) SetLyrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		// Формат задает ?format=json или lrc. Без него JSON ожидается при Content-Type application/json, иначе LRC
+		format := ctx.Query("format")
+		if format == "" {
+			format = "lrc"
+			if ctx.ContentType() == gin.MIMEJSON {
+				format = "json"
+			}
+		}
+
+		var lyrics *model.Lyrics
+		switch format {
+		case "json":
+			var body request.AddLyrics
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				ctx.Error(&er.ValidationError{Message: err.Error()})
+				return
+			}
+			if body.Language == "" {
+				body.Language = ctx.Query("lang")
+			}
+			if body.Kind == "" {
+				body.Kind = ctx.Query("kind")
+			}
+			lyrics, err = h.services.Lyrics.SetLyrics(ctx, uint(id), user.Id, body)
+		case "lrc":
+			data, readErr := io.ReadAll(io.LimitReader(ctx.Request.Body, maxLyricsSize))
+			if readErr != nil {
+				ctx.Error(&er.ValidationError{Message: readErr.Error()})
+				return
+			}
+			lyrics, err = h.services.Lyrics.ImportLRC(ctx, uint(id), user.Id, ctx.Query("lang"), ctx.Query("kind"), string(data))
+		default:
+			ctx.Error(er.ErrLyricsFormat)
+			return
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		h.logger.Infow("Lyrics updated",
+			"song id", id,
+			"user id", user.Id,
+			"format", format,
+			"language", lyrics.Language,
+			"kind", lyrics.Kind,
+		)
+
+		ctx.JSON(http.StatusOK, toLyricsDTO(lyrics))
+	}
+}
+
+func (h *Handler) DeleteLyrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		err = h.services.Lyrics.DeleteLyrics(ctx, uint(id), user.Id, ctx.Query("lang"), ctx.Query("kind"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, nil)
+	}
+}
+
+func (h *Handler) GetLyricsRevisions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+		if !h.releasedSong(ctx, uint(id)) {
+			return
+		}
+
+		revisions, err := h.services.Lyrics.GetRevisions(ctx, uint(id), ctx.Query("lang"), ctx.Query("kind"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		dtos := make([]response.LyricsRevisionDTO, 0, len(revisions))
+		for _, revision := range revisions {
+			dtos = append(dtos, response.LyricsRevisionDTO{
+				ID:               revision.ID,
+				Language:         revision.Language,
+				Kind:             string(revision.Kind),
+				AuthorID:         revision.AuthorID,
+				Author:           revision.Author,
+				Action:           string(revision.Action),
+				SourceRevisionID: revision.SourceRevisionID,
+				CreatedAt:        revision.CreatedAt,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, dtos)
+	}
+}
+
+func (h *Handler) DiffLyricsRevisions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+		if !h.releasedSong(ctx, uint(id)) {
+			return
+		}
+
+		from, err := strconv.Atoi(ctx.Query("from"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: "invalid from revision id"})
+			return
+		}
+		to, err := strconv.Atoi(ctx.Query("to"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: "invalid to revision id"})
+			return
+		}
+
+		changes, err := h.services.Lyrics.DiffRevisions(ctx, uint(id), uint(from), uint(to))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		dto := response.LyricsDiffDTO{
+			From:    uint(from),
+			To:      uint(to),
+			Changes: make([]response.CoupletChangeDTO, 0, len(changes)),
+		}
+		for _, change := range changes {
+			item := response.CoupletChangeDTO{Op: string(change.Op)}
+			if change.Old != nil {
+				old := toCoupletDTO(change.Old)
+				item.Old = &old
+			}
+			if change.New != nil {
+				updated := toCoupletDTO(change.New)
+				item.New = &updated
+			}
+			dto.Changes = append(dto.Changes, item)
+		}
+
+		ctx.JSON(http.StatusOK, dto)
+	}
+}
+
+func (h *Handler) RollbackLyrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		revisionID, err := strconv.Atoi(ctx.Param("revision_id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		lyrics, err := h.services.Lyrics.Rollback(ctx, uint(id), uint(revisionID), user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		h.logger.Infow("Lyrics rolled back",
+			"song id", id,
+			"revision id", revisionID,
+			"user id", user.Id,
+		)
+
+		ctx.JSON(http.StatusOK, toLyricsDTO(lyrics))
+	}
+}
+
+// Языковые предпочтения клиента: ?lang= приоритетнее заголовка Accept-Language
+func lyricsLanguage(ctx *gin.Context) string {
+	if lang := ctx.Query("lang"); lang != "" {
+		return lang
+	}
+	return ctx.GetHeader("Accept-Language")
+}
+
+func toLyricsVersionsDTO(versions []model.Lyrics) []response.LyricsVersionDTO {
+	var dtos []response.LyricsVersionDTO
+	for _, version := range versions {
+		dtos = append(dtos, response.LyricsVersionDTO{
+			Language: version.Language,
+			Kind:     string(version.Kind),
+		})
+	}
+	return dtos
+}
+
+func toCoupletDTO(couplet *model.Couplet) response.CoupletDTO {
+	var words []response.WordDTO
+	for _, word := range couplet.Words {
+		words = append(words, response.WordDTO{
+			TimeMs: word.StartMs,
+			Word:   word.Text,
+		})
+	}
+	return response.CoupletDTO{
+		Number:  couplet.Number,
+		Couplet: couplet.Text,
+		TimeMs:  couplet.StartMs,
+		Words:   words,
+	}
+}
+
+func toLyricsDTO(lyrics *model.Lyrics) response.LyricsDTO {
+	var couplets []response.CoupletDTO
+	for i := range lyrics.Couplets {
+		couplets = append(couplets, toCoupletDTO(&lyrics.Couplets[i]))
+	}
+
+	return response.LyricsDTO{
+		Language: lyrics.Language,
+		Kind:     string(lyrics.Kind),
+		Synced:   lyrics.IsSynced(),
+		OffsetMs: lyrics.OffsetMs,
+		Couplets: couplets,
+	}
+}
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"errors"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MergeEntry вливает дубль артиста, альбома или жанра в другую запись, доступно администраторам
+func (h *Handler) MergeEntry(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := adminID(ctx)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		var body request.MergeRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		if err := h.services.Merge.Merge(ctx, resource, uint(id), body.TargetID, userID); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// movedTo отвечает 301 на запрос к записи, которую влили в другую.
+// Вызывается, когда запись из пути не найдена.
+func (h *Handler) movedTo(ctx *gin.Context, resource model.Resource, err error) bool {
+	var notFound *er.NotFoundError
+	if !errors.As(err, &notFound) {
+		return false
+	}
+
+	id, convErr := strconv.Atoi(ctx.Param("id"))
+	if convErr != nil {
+		return false
+	}
+	targetID, ok := h.services.Merge.Resolve(ctx, resource, uint(id))
+	if !ok {
+		return false
+	}
+
+	location := strings.Replace(ctx.FullPath(), ":id", strconv.Itoa(int(targetID)), 1)
+	if ctx.Request.URL.RawQuery != "" {
+		location += "?" + ctx.Request.URL.RawQuery
+	}
+	ctx.Redirect(http.StatusMovedPermanently, location)
+	return true
+}
@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSongListener открывает пользователю доступ к закрытой песне или закрывает его.
+// Управлять доступом могут редакторы песни.
+func (h *Handler) SetSongListener(grant bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		if grant {
+			err = h.services.Permission.GrantView(ctx, model.SongResource, uint(id), ctx.Param("name"), user.Id)
+		} else {
+			err = h.services.Permission.RevokeView(ctx, model.SongResource, uint(id), ctx.Param("name"), user.Id)
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		h.logger.Infow("Song listener updated",
+			"song id", id,
+			"user id", user.Id,
+			"listener", ctx.Param("name"),
+			"granted", grant,
+		)
+		ctx.Status(http.StatusNoContent)
+	}
+}
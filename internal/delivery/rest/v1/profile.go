@@ -2,9 +2,11 @@ package v1
 
 import (
 	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
 	"music-lib/internal/middleware"
 	"music-lib/pkg/er"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,6 +17,10 @@ func (h *Handler) initProfileRoutes(api *gin.RouterGroup) {
 	{
 		profile.POST("", h.NewProfile())
 		profile.GET("", h.GetProfile())
+		profile.PUT("/avatar", h.UploadAvatar())
+		profile.DELETE("/avatar", h.DeleteAvatar())
+		profile.PATCH("/privacy", h.UpdatePrivacy())
+		profile.PATCH("/collections/:id", h.SetCollectionVisibility())
 	}
 }
 
@@ -59,6 +65,57 @@ func (h *Handler) GetProfile() gin.HandlerFunc {
 			return
 		}
 
-		ctx.JSON(http.StatusOK, profile)
+		ctx.JSON(http.StatusOK, response.ProfileDTO{
+			Profile: profile,
+			Avatar:  response.NewImageDTO(profile.AvatarKey),
+		})
+	}
+}
+
+
+// UpdatePrivacy меняет видимость разделов публичной страницы
+func (h *Handler) UpdatePrivacy() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		body := request.ProfilePrivacyRequest{}
+
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		profile, err := h.services.Profile.UpdatePrivacy(ctx, viewerID(ctx), body)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, response.ProfileDTO{
+			Profile: profile,
+			Avatar:  response.NewImageDTO(profile.AvatarKey),
+		})
+	}
+}
+
+
+// SetCollectionVisibility открывает коллекцию на публичной странице или скрывает ее
+func (h *Handler) SetCollectionVisibility() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		body := request.CollectionVisibilityRequest{}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		if err := h.services.Profile.SetCollectionVisibility(ctx, viewerID(ctx), uint(id), body.Visibility); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,28 @@
+package v1
+
+import (
+	"music-lib/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// viewerID возвращает пользователя из необязательного токена, 0 - анонимный запрос
+func viewerID(ctx *gin.Context) uint {
+	if user, ok := middleware.GetUserData(ctx); ok {
+		return user.Id
+	}
+	return 0
+}
+
+// releasedSong проверяет, что песня существует и уже вышла для пользователя, иначе записывает ошибку
+func (h *Handler) releasedSong(ctx *gin.Context, songID uint) bool {
+	song, err := h.services.Song.GetSong(ctx, songID)
+	if err == nil {
+		err = h.services.Release.CheckSong(ctx, song, viewerID(ctx))
+	}
+	if err != nil {
+		ctx.Error(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initReportRoutes(api *gin.RouterGroup) {
+	api.POST("/report", middleware.AuthMiddleware(h.config), h.Report())
+
+	admin := api.Group("/admin/reports")
+	admin.Use(middleware.AuthMiddleware(h.config))
+	{
+		admin.GET("", h.GetReports())
+		admin.POST("/resolve", h.ResolveReports())
+	}
+}
+
+// Report принимает жалобу на артиста, альбом, песню, жанр, рецензию или комментарий
+func (h *Handler) Report() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body request.ReportRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		report, err := h.services.Report.Report(ctx, viewerID(ctx), body)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, toReportDTO(report))
+	}
+}
+
+// GetReports отдает администратору очередь жалоб. Фильтры: state, type и entity_id.
+func (h *Handler) GetReports() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := adminID(ctx); !ok {
+			return
+		}
+
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		filter := model.ReportFilter{
+			State:        model.ReportState(ctx.Query("state")),
+			ResourceType: model.Resource(ctx.Query("type")),
+		}
+		if entity := ctx.Query("entity_id"); entity != "" {
+			id, err := strconv.Atoi(entity)
+			if err != nil || id < 1 {
+				ctx.Error(&er.ValidationError{Message: "invalid entity_id value"})
+				return
+			}
+			filter.ResourceID = uint(id)
+		}
+
+		reports, total, err := h.services.Report.GetReports(ctx, filter, limit, offset)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		data := make([]response.ReportDTO, 0, len(reports))
+		for i := range reports {
+			data = append(data, toReportDTO(&reports[i]))
+		}
+
+		ctx.JSON(http.StatusOK, response.PaginatedResponse{
+			Data: data,
+			Pagination: response.Pagination{
+				Limit:  limit,
+				Offset: offset,
+				Total:  total,
+			},
+		})
+	}
+}
+
+// ResolveReports закрывает несколько жалоб сразу и при необходимости скрывает записи
+func (h *Handler) ResolveReports() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := adminID(ctx)
+		if !ok {
+			return
+		}
+
+		var body request.ResolveReportsRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		reports, err := h.services.Report.Resolve(ctx, body, userID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		data := make([]response.ReportDTO, 0, len(reports))
+		for i := range reports {
+			data = append(data, toReportDTO(&reports[i]))
+		}
+		ctx.JSON(http.StatusOK, data)
+	}
+}
+
+func toReportDTO(report *model.Report) response.ReportDTO {
+	return response.ReportDTO{
+		ID:         report.ID,
+		ReporterID: report.ReporterID,
+		Type:       string(report.ResourceType),
+		EntityID:   report.ResourceID,
+		Reason:     string(report.Reason),
+		Details:    report.Details,
+		State:      string(report.State),
+		ResolvedBy: report.ResolvedBy,
+		ResolvedAt: report.ResolvedAt,
+		Note:       report.Note,
+		TakenDown:  report.TakenDown,
+		CreatedAt:  report.CreatedAt,
+	}
+}
@@ -0,0 +1,279 @@
+package v1
+
+import (
+	"math"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initReviewRoutes(api *gin.RouterGroup) {
+	for _, resource := range []model.Resource{model.SongResource, model.AlbumResource} {
+		group := api.Group("/" + string(resource))
+		group.GET("/:id/rating", middleware.OptionalAuthMiddleware(h.config), h.GetRating(resource))
+		group.PUT("/:id/rating", middleware.AuthMiddleware(h.config), h.Rate(resource))
+		group.DELETE("/:id/rating", middleware.AuthMiddleware(h.config), h.Unrate(resource))
+		group.GET("/:id/reviews", middleware.OptionalAuthMiddleware(h.config), h.GetReviews(resource))
+		group.POST("/:id/reviews", middleware.AuthMiddleware(h.config), h.AddReview(resource))
+	}
+
+	review := api.Group("/review")
+	review.Use(middleware.AuthMiddleware(h.config))
+	{
+		review.PATCH("/:id", h.UpdateReview())
+		review.DELETE("/:id", h.DeleteReview())
+		review.POST("/:id/hide", h.HideReview(true))
+		review.DELETE("/:id/hide", h.HideReview(false))
+	}
+
+	api.GET("/users/:name/reviews", middleware.OptionalAuthMiddleware(h.config), h.GetUserReviews())
+}
+
+// GetRating возвращает среднюю оценку песни или альбома и распределение оценок
+func (h *Handler) GetRating(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		summary, err := h.services.Review.GetSummary(ctx, resource, id, viewerID(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toRatingSummaryDTO(summary))
+	}
+}
+
+// Rate ставит оценку или меняет прежнюю
+func (h *Handler) Rate(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body request.RatingRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		if err := h.services.Review.Rate(ctx, resource, id, viewerID(ctx), body.Stars); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		summary, err := h.services.Review.GetSummary(ctx, resource, id, viewerID(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toRatingSummaryDTO(summary))
+	}
+}
+
+func (h *Handler) Unrate(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		if err := h.services.Review.Unrate(ctx, resource, id, viewerID(ctx)); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// GetReviews возвращает рецензии на песню или альбом, начиная с новых
+func (h *Handler) GetReviews(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		reviews, total, err := h.services.Review.GetReviews(ctx, resource, id, viewerID(ctx), limit, offset)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		writeReviews(ctx, reviews, total, limit, offset)
+	}
+}
+
+func (h *Handler) AddReview(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body request.ReviewRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		review, err := h.services.Review.AddReview(ctx, resource, id, viewerID(ctx), body.Text)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, toReviewDTO(review))
+	}
+}
+
+// UpdateReview меняет текст рецензии, доступно только автору
+func (h *Handler) UpdateReview() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		var body request.ReviewRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		review, err := h.services.Review.UpdateReview(ctx, uint(id), viewerID(ctx), body.Text)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toReviewDTO(review))
+	}
+}
+
+// DeleteReview удаляет рецензию, доступно только автору
+func (h *Handler) DeleteReview() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		if err := h.services.Review.DeleteReview(ctx, uint(id), viewerID(ctx)); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// HideReview скрывает рецензию от всех, кроме автора, или возвращает ее, доступно администраторам
+func (h *Handler) HideReview(hidden bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := adminID(ctx)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		if err := h.services.Review.HideReview(ctx, uint(id), userID, hidden); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// GetUserReviews возвращает рецензии пользователя, начиная с новых
+func (h *Handler) GetUserReviews() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		reviews, total, err := h.services.Review.GetUserReviews(ctx, ctx.Param("name"), viewerID(ctx), limit, offset)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		writeReviews(ctx, reviews, total, limit, offset)
+	}
+}
+
+// ratingSummary дополняет карточку песни или альбома сводкой оценок, при сбое сводки нет
+func (h *Handler) ratingSummary(ctx *gin.Context, resource model.Resource, id uint) *response.RatingSummaryDTO {
+	summary, err := h.services.Review.GetSummary(ctx, resource, id, viewerID(ctx))
+	if err != nil {
+		return nil
+	}
+	dto := toRatingSummaryDTO(summary)
+	return &dto
+}
+
+func writeReviews(ctx *gin.Context, reviews []model.Review, total int64, limit, offset int) {
+	data := make([]response.ReviewDTO, 0, len(reviews))
+	for i := range reviews {
+		data = append(data, toReviewDTO(&reviews[i]))
+	}
+
+	ctx.JSON(http.StatusOK, response.PaginatedResponse{
+		Data: data,
+		Pagination: response.Pagination{
+			Limit:  limit,
+			Offset: offset,
+			Total:  total,
+		},
+	})
+}
+
+func toRatingSummaryDTO(summary *model.RatingSummary) response.RatingSummaryDTO {
+	return response.RatingSummaryDTO{
+		Average:   math.Round(summary.Average*100) / 100,
+		Count:     summary.Count,
+		Histogram: summary.Histogram,
+		Own:       summary.Own,
+	}
+}
+
+func toReviewDTO(review *model.Review) response.ReviewDTO {
+	return response.ReviewDTO{
+		ID:        review.ID,
+		Type:      string(review.ResourceType),
+		EntityID:  review.ResourceID,
+		UserID:    review.UserID,
+		Author:    review.Author,
+		Stars:     review.Stars,
+		Text:      review.Text,
+		Hidden:    review.HiddenAt != nil,
+		CreatedAt: review.CreatedAt,
+		UpdatedAt: review.UpdatedAt,
+	}
+}
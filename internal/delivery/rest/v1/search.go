@@ -1,8 +1,11 @@
 package v1
 
 import (
+	"music-lib/internal/middleware"
+	"music-lib/internal/service"
 	"music-lib/pkg/er"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -12,14 +15,24 @@ import (
 func (h *Handler) initSearchRoutes(api *gin.RouterGroup) {
 	search := api.Group("/search")
 	{
-		search.GET("", h.Search())
+		search.GET("", middleware.OptionalAuthMiddleware(h.config), h.Search())
 	}
 }
 
 func (h *Handler) Search() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		query := c.Query("q")
-		types := strings.Split(c.DefaultQuery("type", "artist,album,song"), ",")
+		tags, err := searchTags(c.Query("tags"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		// Метки есть только у альбомов и песен
+		defaultTypes := "artist,album,song"
+		if len(tags) > 0 {
+			defaultTypes = "album,song"
+		}
+		types := strings.Split(c.DefaultQuery("type", defaultTypes), ",")
 		limit, offset := validatePagination(c)
 
 		validTypes := map[string]bool{"artist": true, "album": true, "song": true}
@@ -31,12 +44,29 @@ func (h *Handler) Search() gin.HandlerFunc {
 			}
 		}
 
-		result := h.services.Search.Search(c, types, query, limit, offset)
+		result := h.services.Search.Search(c, types, query, tags, viewerID(c), limit, offset)
 
 		c.JSON(http.StatusOK, result)
 	}
 }
 
+// searchTags разбирает список меток через запятую, найдутся записи со всеми метками
+func searchTags(value string) ([]string, error) {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		name, err := service.NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(tags, name) {
+			tags = append(tags, name)
+		}
+	}
+	return tags, nil
+}
 
 func validatePagination(c *gin.Context) (limit, offset int) {
     limitStr := c.DefaultQuery("limit", "10")
@@ -45,13 +75,13 @@ func validatePagination(c *gin.Context) (limit, offset int) {
     // Валидация параметров
     limit, err := strconv.Atoi(limitStr)
     if err != nil || limit < 1 || limit > 100 {
-		c.Error(er.ValidationError{Message: "invalid limit value (1-100)"})
+		c.Error(&er.ValidationError{Message: "invalid limit value (1-100)"})
         return
     }
 
     offset, err = strconv.Atoi(offsetStr)
     if err != nil || offset < 0 {
-		c.Error(er.ValidationError{Message: "invalid offset value"})
+		c.Error(&er.ValidationError{Message: "invalid offset value"})
         return
     }
 
@@ -14,20 +14,46 @@ import (
 
 func (h *Handler) initSongRoutes(api *gin.RouterGroup) {
 	song := api.Group("/song")
-	song.GET("/:id", h.GetSong())
+	// Песни невышедших альбомов видят только редакторы, поэтому токен читается и здесь
+	song.GET("/:id", middleware.OptionalAuthMiddleware(h.config), h.GetSong())
+	song.GET("/by-isrc/:isrc", middleware.OptionalAuthMiddleware(h.config), h.GetSongByISRC())
+	song.GET("/:id/lyrics", middleware.OptionalAuthMiddleware(h.config), h.GetLyrics())
+	song.GET("/:id/lyrics/revisions", middleware.OptionalAuthMiddleware(h.config), h.GetLyricsRevisions())
+	song.GET("/:id/lyrics/diff", middleware.OptionalAuthMiddleware(h.config), h.DiffLyricsRevisions())
+	song.GET("/:id/stream", middleware.OptionalAuthMiddleware(h.config), h.StreamSong())
+	song.HEAD("/:id/stream", middleware.OptionalAuthMiddleware(h.config), h.StreamSong())
+	song.GET("/:id/waveform", middleware.OptionalAuthMiddleware(h.config), h.GetSongWaveform())
 	song.Use(middleware.AuthMiddleware(h.config))
 	{
-		song.POST("/:album_id", h.AddSong())
+		// Параметр называется :id, т.к. gin требует одно имя параметра для всех маршрутов /song/:id,
+		// здесь это идентификатор альбома
+		song.POST("/:id", h.AddSong())
+		song.PUT("/:id/lyrics", h.SetLyrics())
+		song.DELETE("/:id/lyrics", h.DeleteLyrics())
+		song.POST("/:id/lyrics/revisions/:revision_id/rollback", h.RollbackLyrics())
+		song.POST("/:id/file", h.UploadSongFile())
+		song.POST("/:id/uploads", h.CreateUpload())
+		song.POST("/:id/stream-url", h.SignStreamURL())
+		// Слушатели закрытой песни
+		song.PUT("/:id/listeners/:name", h.SetSongListener(true))
+		song.DELETE("/:id/listeners/:name", h.SetSongListener(false))
+		song.PUT("/:id/credits", h.SetSongCredits())
+		song.PATCH("/:id", h.UpdateSong())
+		song.PUT("/:id/track", h.SetSongTrack())
+		song.POST("/:id/publish", h.PublishSong())
+		song.POST("/:id/unpublish", h.UnpublishSong())
+		song.DELETE("/:id", h.TrashEntry(model.SongResource))
+		song.POST("/:id/restore", h.RestoreEntry(model.SongResource))
 	}
 }
 
 
 func (h *Handler) AddSong() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		albumID, err := strconv.Atoi(ctx.Param("album_id"))
+		albumID, err := strconv.Atoi(ctx.Param("id"))
 		if err != nil {
 			h.logger.Debug("Invalid album ID format",
-                "received", ctx.Param("album_id"),
+                "received", ctx.Param("id"),
                 "error", err,
             )
 			ctx.Error(&er.ValidationError{Message: err.Error()})
@@ -61,7 +87,7 @@ func (h *Handler) AddSong() gin.HandlerFunc {
             "artist_id", album.ArtistID,
         )
 
-		song, err := h.services.Song.AddSong(ctx, album, body)
+		song, err := h.services.Song.AddSong(ctx, album, body, user.Id)
 		if err != nil {
 			ctx.Error(err)
 			return
@@ -74,6 +100,10 @@ func (h *Handler) AddSong() gin.HandlerFunc {
 			return
 		}
 
+		if body.UploadID != "" {
+			h.useUploadCover(ctx, album, body.UploadID, user.Id)
+		}
+
 		ctx.JSON(http.StatusCreated, response.AddSongResponse{
 			AlbumID: album.ID,
 			AlbumName: album.Title,
@@ -96,30 +126,151 @@ func (h *Handler) GetSong() gin.HandlerFunc {
 		}
 
 		song, err := h.services.Song.GetSong(ctx, uint(id))
+		if err == nil {
+			err = h.services.Release.CheckSong(ctx, song, viewerID(ctx))
+		}
 		if err != nil {
 			ctx.Error(err)
 			return
 		}
 
-		var couplets []response.CoupletDTO
-		for _, couplet := range song.Lyrics.Couplets {
-			couplets = append(couplets, response.CoupletDTO{
-				Number: couplet.Number,
-				Couplet: couplet.Text,
-			})
+		h.writeSong(ctx, song)
+	}
+}
+
+// GetSongByISRC ищет песню по ISRC, дефисы в коде необязательны
+func (h *Handler) GetSongByISRC() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		song, err := h.services.Song.GetSongByISRC(ctx, ctx.Param("isrc"))
+		if err == nil {
+			err = h.services.Release.CheckSong(ctx, song, viewerID(ctx))
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
 		}
 
-		ctx.JSON(http.StatusOK, response.SongDTO{
-			ID: song.ID,
-			Title: song.Title,
-			AlbumID: song.AlbumID,
-			Duration: song.Duration,
-			FilePath: song.FilePath,
-			Lyrics: response.LyricsDTO{
-				Couplets: couplets,
-			},
-		})
+		h.writeSong(ctx, song)
 	}
 }
 
+// UpdateSong меняет ISRC песни
+func (h *Handler) UpdateSong() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
 
+		var body request.UpdateSongRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		song, err := h.services.Song.UpdateSong(ctx, uint(id), body, user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		h.writeSong(ctx, song)
+	}
+}
+
+// writeSong отдает песню с текстом на языке из Accept-Language
+func (h *Handler) writeSong(ctx *gin.Context, song *model.Song) {
+	dto := response.SongDTO{
+		ID: song.ID,
+		Title: song.Title,
+		Status: string(song.Status),
+		ISRC: song.ISRC,
+		ArtistID: song.ArtistID,
+		AlbumID: song.AlbumID,
+		DiscNumber: song.DiscNumber,
+		TrackNumber: song.TrackNumber,
+		Duration: song.Duration,
+		StreamURL: response.SongStreamURL(song.ID, song.FilePath),
+		Private: song.Private,
+		FileSize: song.FileSize,
+		FileHash: song.FileHash,
+		MimeType: song.MimeType,
+		Credits: toCreditsDTO(song.Credits),
+		LyricsVersions: toLyricsVersionsDTO(song.Lyrics),
+	}
+
+	ctx.Header("Vary", "Accept-Language")
+	if lyrics := h.services.Lyrics.Select(song.Lyrics, lyricsLanguage(ctx), ""); lyrics != nil {
+		ctx.Header("Content-Language", lyrics.Language)
+		dto.Lyrics = toLyricsDTO(lyrics)
+	}
+	// Метки дополняют карточку песни, их сбой не должен ее ломать
+	if tags, err := h.services.Tag.GetTags(ctx, model.SongResource, song.ID, viewerID(ctx)); err == nil && len(tags) > 0 {
+		dto.Tags = toTagsDTO(tags)
+	}
+	dto.Rating = h.ratingSummary(ctx, model.SongResource, song.ID)
+
+	ctx.JSON(http.StatusOK, dto)
+}
+
+
+
+// SetSongTrack меняет номер диска и дорожки песни в альбоме
+func (h *Handler) SetSongTrack() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		var body request.SetTrackRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		song, err := h.services.Song.SetTrack(ctx, uint(id), body, user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, response.SongDTO{
+			ID:          song.ID,
+			Title:       song.Title,
+			Status:      string(song.Status),
+			ISRC:        song.ISRC,
+			ArtistID:    song.ArtistID,
+			AlbumID:     song.AlbumID,
+			DiscNumber:  song.DiscNumber,
+			TrackNumber: song.TrackNumber,
+			Duration:    song.Duration,
+			StreamURL:   response.SongStreamURL(song.ID, song.FilePath),
+			Private:     song.Private,
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/internal/service"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// editableID проверяет идентификатор из пути и право пользователя редактировать ресурс
+func (h *Handler) editableID(ctx *gin.Context, resource model.Resource) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(&er.ValidationError{Message: err.Error()})
+		return 0, false
+	}
+
+	user, ok := middleware.GetUserData(ctx)
+	if !ok {
+		ctx.Error(er.ErrNotAuthorized)
+		return 0, false
+	}
+
+	if !h.services.Permission.HasPermission(user.Id, uint(id), resource, model.EditPermission) {
+		ctx.Error(er.ErrWrongUserCredentials)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// unpublishedStatus читает из необязательного тела статус, в который переводится запись
+func unpublishedStatus(ctx *gin.Context) (model.Status, bool) {
+	var body request.UnpublishRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return "", false
+		}
+	}
+
+	status, err := service.UnpublishedStatus(body.Status)
+	if err != nil {
+		ctx.Error(err)
+		return "", false
+	}
+	return status, true
+}
+
+func (h *Handler) PublishArtist() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		h.setArtistStatus(ctx, model.StatusPublished)
+	}
+}
+
+// UnpublishArtist возвращает артиста в черновики или переводит в статус из тела запроса
+func (h *Handler) UnpublishArtist() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if status, ok := unpublishedStatus(ctx); ok {
+			h.setArtistStatus(ctx, status)
+		}
+	}
+}
+
+func (h *Handler) setArtistStatus(ctx *gin.Context, status model.Status) {
+	id, ok := h.editableID(ctx, model.ArtistResource)
+	if !ok {
+		return
+	}
+
+	artist, err := h.services.Artist.SetStatus(ctx, id, status, viewerID(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ArtistDTO{
+		ID:            artist.ID,
+		Name:          artist.Name,
+		Status:        string(artist.Status),
+		Description:   artist.Description,
+		FormationYear: artist.FormationYear,
+		Followers:     artist.Followers,
+	})
+}
+
+func (h *Handler) PublishAlbum() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		h.setAlbumStatus(ctx, model.StatusPublished)
+	}
+}
+
+// UnpublishAlbum возвращает альбом в черновики или переводит в статус из тела запроса
+func (h *Handler) UnpublishAlbum() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if status, ok := unpublishedStatus(ctx); ok {
+			h.setAlbumStatus(ctx, status)
+		}
+	}
+}
+
+func (h *Handler) setAlbumStatus(ctx *gin.Context, status model.Status) {
+	id, ok := h.editableID(ctx, model.AlbumResource)
+	if !ok {
+		return
+	}
+
+	album, err := h.services.Album.SetStatus(ctx, id, status, viewerID(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, toAlbumDTO(album))
+}
+
+func (h *Handler) PublishSong() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		h.setSongStatus(ctx, model.StatusPublished)
+	}
+}
+
+// UnpublishSong возвращает песню в черновики или переводит в статус из тела запроса
+func (h *Handler) UnpublishSong() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if status, ok := unpublishedStatus(ctx); ok {
+			h.setSongStatus(ctx, status)
+		}
+	}
+}
+
+func (h *Handler) setSongStatus(ctx *gin.Context, status model.Status) {
+	id, ok := h.editableID(ctx, model.SongResource)
+	if !ok {
+		return
+	}
+
+	song, err := h.services.Song.SetStatus(ctx, id, status, viewerID(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	h.writeSong(ctx, song)
+}
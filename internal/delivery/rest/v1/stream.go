@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/pkg/er"
+	"music-lib/pkg/httprange"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamSong отдает аудиофайл целиком или по заголовку Range.
+// Приватные песни доступны по токену или подписанной ссылке пользователя с правом view.
+func (h *Handler) StreamSong() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		userID, signed, err := h.services.Stream.VerifyURL(uint(id), ctx.Request.URL.Query())
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		if !signed {
+			if user, ok := middleware.GetUserData(ctx); ok {
+				userID = user.Id
+			}
+		}
+
+		song, err := h.services.Stream.Open(ctx, uint(id), userID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		contentType := song.MimeType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		var etag string
+		if song.FileHash != "" {
+			etag = `"` + song.FileHash + `"`
+			ctx.Header("ETag", etag)
+		}
+		ctx.Header("Accept-Ranges", "bytes")
+		if song.Private {
+			ctx.Header("Cache-Control", "private, no-cache")
+		} else {
+			ctx.Header("Cache-Control", "no-cache")
+		}
+
+		if etag != "" && etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
+		// If-Range сравнивается строго: при другой версии файла отдается весь файл
+		rangeHeader := ctx.GetHeader("Range")
+		if ifRange := ctx.GetHeader("If-Range"); ifRange != "" && (etag == "" || ifRange != etag) {
+			rangeHeader = ""
+		}
+
+		status, offset, length := http.StatusOK, int64(0), song.FileSize
+		part, err := httprange.Parse(rangeHeader, song.FileSize)
+		if errors.Is(err, httprange.ErrUnsatisfiable) {
+			ctx.Header("Content-Range", httprange.Unsatisfied(song.FileSize))
+			ctx.Error(er.ErrRangeNotSatisfiable)
+			return
+		}
+		if part != nil {
+			status, offset, length = http.StatusPartialContent, part.Start, part.Length
+			ctx.Header("Content-Range", part.ContentRange(song.FileSize))
+		}
+
+		if ctx.Request.Method == http.MethodHead || length == 0 {
+			ctx.Header("Content-Type", contentType)
+			ctx.Header("Content-Length", strconv.FormatInt(length, 10))
+			ctx.Status(status)
+			return
+		}
+
+		body, err := h.services.Stream.Read(ctx, song, offset, length)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer body.Close()
+
+		h.services.Stream.RecordPlay(ctx, song, userID, offset, length)
+
+		ctx.DataFromReader(status, length, contentType, body, nil)
+	}
+}
+
+// SignStreamURL выдает короткоживущую ссылку для плееров, которые не умеют передавать заголовок Authorization
+func (h *Handler) SignStreamURL() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		song, err := h.services.Stream.Open(ctx, uint(id), user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		query, expires := h.services.Stream.SignURL(song.ID, user.Id)
+		ctx.JSON(http.StatusOK, response.StreamURLResponse{
+			URL:       fmt.Sprintf("%s?%s", response.SongStreamURL(song.ID, song.FilePath), query.Encode()),
+			ExpiresAt: expires,
+		})
+	}
+}
+
+// Слабое сравнение для If-None-Match: список тегов или "*"
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
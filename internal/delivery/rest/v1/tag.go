@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initTagRoutes(api *gin.RouterGroup) {
+	api.GET("/tag/cloud", h.GetTagCloud())
+
+	for _, resource := range []model.Resource{model.SongResource, model.AlbumResource} {
+		group := api.Group("/" + string(resource))
+		group.GET("/:id/tags", middleware.OptionalAuthMiddleware(h.config), h.GetTags(resource))
+		group.POST("/:id/tags", middleware.AuthMiddleware(h.config), h.VoteTag(resource))
+		group.DELETE("/:id/tags/:tag", middleware.AuthMiddleware(h.config), h.UnvoteTag(resource))
+	}
+}
+
+// GetTags возвращает метки песни или альбома с весами и голосом текущего пользователя
+func (h *Handler) GetTags(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		tags, err := h.services.Tag.GetTags(ctx, resource, id, viewerID(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toTagsDTO(tags))
+	}
+}
+
+// VoteTag ставит метку или меняет голос за нее, голос без vote считается голосом "за"
+func (h *Handler) VoteTag(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body request.TagVoteRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+		if body.Vote == 0 {
+			body.Vote = 1
+		}
+
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		if err := h.services.Tag.Vote(ctx, resource, id, viewerID(ctx), body.Tag, body.Vote); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		tags, err := h.services.Tag.GetTags(ctx, resource, id, viewerID(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toTagsDTO(tags))
+	}
+}
+
+// UnvoteTag отзывает голос текущего пользователя за метку
+func (h *Handler) UnvoteTag(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.visibleEntry(ctx, resource)
+		if !ok {
+			return
+		}
+
+		if err := h.services.Tag.Unvote(ctx, resource, id, viewerID(ctx), ctx.Param("tag")); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// GetTagCloud возвращает самые распространенные метки песен (type=song) или альбомов (type=album)
+func (h *Handler) GetTagCloud() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			ctx.Error(&er.ValidationError{Message: "invalid limit value (1-200)"})
+			return
+		}
+
+		resource := model.Resource(ctx.DefaultQuery("type", string(model.SongResource)))
+		tags, err := h.services.Tag.GetCloud(ctx, resource, limit)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, toTagsDTO(tags))
+	}
+}
+
+// visibleEntry проверяет, что песня или альбом из пути видны текущему пользователю
+func (h *Handler) visibleEntry(ctx *gin.Context, resource model.Resource) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(&er.ValidationError{Message: err.Error()})
+		return 0, false
+	}
+
+	if resource == model.SongResource {
+		return uint(id), h.releasedSong(ctx, uint(id))
+	}
+
+	album, err := h.services.Album.GetAlbum(ctx, ctx.Param("id"), viewerID(ctx))
+	if err == nil {
+		err = h.services.Release.CheckAlbum(ctx, album, viewerID(ctx))
+	}
+	if err != nil {
+		ctx.Error(err)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func toTagsDTO(tags []model.TagWeight) []response.TagDTO {
+	var top int64
+	for _, tag := range tags {
+		top = max(top, tag.Weight)
+	}
+
+	dtos := make([]response.TagDTO, 0, len(tags))
+	for _, tag := range tags {
+		dto := response.TagDTO{
+			Name:   tag.Name,
+			Weight: tag.Weight,
+			Vote:   tag.Vote,
+		}
+		if top > 0 && tag.Weight > 0 {
+			dto.Scale = float64(tag.Weight) / float64(top)
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos
+}
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initTrashRoutes(api *gin.RouterGroup) {
+	me := api.Group("/me")
+	me.Use(middleware.AuthMiddleware(h.config))
+	{
+		me.GET("/trash", h.GetTrash())
+	}
+}
+
+func (h *Handler) GetTrash() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		items, err := h.services.Trash.GetTrash(ctx, user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		data := make([]response.TrashItemDTO, 0, len(items))
+		for _, item := range items {
+			data = append(data, response.TrashItemDTO{
+				Type:      string(item.Resource),
+				ID:        item.ID,
+				Title:     item.Title,
+				ParentID:  item.ParentID,
+				DeletedAt: item.DeletedAt,
+				PurgeAt:   item.PurgeAt,
+			})
+		}
+		ctx.JSON(http.StatusOK, data)
+	}
+}
+
+// TrashEntry переносит артиста, альбом или песню в корзину
+func (h *Handler) TrashEntry(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.editableID(ctx, resource)
+		if !ok {
+			return
+		}
+
+		if err := h.services.Trash.Trash(ctx, resource, id, viewerID(ctx)); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// RestoreEntry возвращает запись из корзины
+func (h *Handler) RestoreEntry(resource model.Resource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := h.editableID(ctx, resource)
+		if !ok {
+			return
+		}
+
+		if err := h.services.Trash.Restore(ctx, resource, id, viewerID(ctx)); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,353 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"music-lib/pkg/er"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Запас на заголовки и границы multipart формы сверх размера файла
+const multipartOverhead = 1 << 20
+
+func (h *Handler) initUploadRoutes(api *gin.RouterGroup) {
+	upload := api.Group("/upload")
+	upload.Use(middleware.AuthMiddleware(h.config))
+	{
+		upload.POST("", h.StageUpload())
+		upload.GET("/:upload_id", h.GetUpload())
+		upload.GET("/:upload_id/cover", h.GetUploadCover())
+		upload.PATCH("/:upload_id", h.AppendUpload())
+		upload.DELETE("/:upload_id", h.AbortUpload())
+	}
+}
+
+// UploadSongFile принимает аудиофайл в поле "file" multipart формы
+func (h *Handler) UploadSongFile() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		file, size, err := formFile(ctx, "file", h.config.Upload.MaxFileSize, er.ErrFileTooLarge)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer file.Close()
+
+		song, upload, err := h.services.Upload.UploadFile(ctx, uint(id), user.Id, file, size)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		h.logger.Infow("Song file uploaded",
+			"song id", song.ID,
+			"user id", user.Id,
+			"size", song.FileSize,
+			"mime type", song.MimeType,
+			"duplicate of", upload.DuplicateOf,
+		)
+
+		ctx.JSON(http.StatusOK, response.SongDTO{
+			ID:          song.ID,
+			Title:       song.Title,
+			Status:      string(song.Status),
+			AlbumID:     song.AlbumID,
+			Duration:    song.Duration,
+			StreamURL:   response.SongStreamURL(song.ID, song.FilePath),
+			Private:     song.Private,
+			FileSize:    song.FileSize,
+			FileHash:    song.FileHash,
+			MimeType:    song.MimeType,
+			DuplicateOf: upload.DuplicateOf,
+		})
+	}
+}
+
+// StageUpload принимает файл до создания песни и возвращает извлеченные из него теги.
+// multipart форма загружает файл сразу, JSON с размером начинает возобновляемую загрузку.
+func (h *Handler) StageUpload() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if ctx.ContentType() != gin.MIMEMultipartPOSTForm {
+			var body request.NewUploadRequest
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				ctx.Error(&er.ValidationError{Message: err.Error()})
+				return
+			}
+
+			upload, err := h.services.Upload.CreateUpload(ctx, 0, user.Id, body.Size)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+
+			ctx.Header("Location", "/api/v1/upload/"+upload.ID)
+			ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+			ctx.JSON(http.StatusCreated, toUploadDTO(upload))
+			return
+		}
+
+		file, size, err := formFile(ctx, "file", h.config.Upload.MaxFileSize, er.ErrFileTooLarge)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer file.Close()
+
+		upload, _, err := h.services.Upload.StageFile(ctx, user.Id, file, size)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, toUploadDTO(upload))
+	}
+}
+
+// CreateUpload начинает возобновляемую загрузку, части передаются через PATCH /upload/:upload_id
+func (h *Handler) CreateUpload() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		var body request.NewUploadRequest
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		if !h.services.Permission.HasPermission(user.Id, uint(id), model.SongResource, model.EditPermission) {
+			ctx.Error(er.ErrWrongUserCredentials)
+			return
+		}
+
+		upload, err := h.services.Upload.CreateUpload(ctx, uint(id), user.Id, body.Size)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Header("Location", "/api/v1/upload/"+upload.ID)
+		ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		ctx.JSON(http.StatusCreated, toUploadDTO(upload))
+	}
+}
+
+func (h *Handler) GetUpload() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		upload, err := h.services.Upload.GetUpload(ctx, ctx.Param("upload_id"), user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		ctx.JSON(http.StatusOK, toUploadDTO(upload))
+	}
+}
+
+// GetUploadCover отдает обложку из тегов файла, чтобы ее можно было показать до создания песни
+func (h *Handler) GetUploadCover() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		body, info, err := h.services.Upload.OpenCover(ctx, ctx.Param("upload_id"), user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer body.Close()
+
+		ctx.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, nil)
+	}
+}
+
+// AppendUpload дописывает тело запроса к загрузке. Заголовок Upload-Offset
+// должен совпадать с числом уже полученных байт, иначе ответ 409.
+func (h *Handler) AppendUpload() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+		if err != nil || offset < 0 {
+			ctx.Error(&er.ValidationError{Message: "invalid Upload-Offset header"})
+			return
+		}
+
+		length := ctx.Request.ContentLength
+		if length < 0 {
+			ctx.Error(&er.ValidationError{Message: "Content-Length header is required"})
+			return
+		}
+
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, length)
+		upload, err := h.services.Upload.AppendChunk(ctx, ctx.Param("upload_id"), user.Id, offset, body, length)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if upload.Status == model.UploadComplete {
+			h.logger.Infow("Song file uploaded",
+				"song id", upload.SongID,
+				"upload id", upload.ID,
+				"user id", user.Id,
+				"size", upload.Size,
+			)
+		}
+
+		ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		ctx.JSON(http.StatusOK, toUploadDTO(upload))
+	}
+}
+
+func (h *Handler) AbortUpload() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := middleware.GetUserData(ctx)
+		if !ok {
+			ctx.Error(er.ErrNotAuthorized)
+			return
+		}
+
+		err := h.services.Upload.AbortUpload(ctx, ctx.Param("upload_id"), user.Id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, nil)
+	}
+}
+
+// useUploadCover делает обложку из тегов загруженного файла обложкой альбома, если своей у него нет.
+// Песня к этому моменту уже создана, поэтому ошибки только логируются.
+func (h *Handler) useUploadCover(ctx *gin.Context, album *model.Album, uploadID string, userID uint) {
+	cover, err := h.services.Upload.TakeCover(ctx, uploadID, userID)
+	if err != nil {
+		h.logger.Errorw("Failed to read upload cover",
+			"upload id", uploadID,
+			"error", err.Error(),
+		)
+		return
+	}
+	if cover == nil || album.CoverArtKey != "" {
+		return
+	}
+
+	if _, err := h.services.Image.SetAlbumCover(ctx, album.ID, userID, bytes.NewReader(cover)); err != nil {
+		h.logger.Debugw("Embedded cover not used",
+			"album id", album.ID,
+			"upload id", uploadID,
+			"error", err.Error(),
+		)
+	}
+}
+
+// Файл из поля multipart формы с ограничением размера тела запроса
+func formFile(ctx *gin.Context, field string, limit int64, tooLargeErr error) (multipart.File, int64, error) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit+multipartOverhead)
+	header, err := ctx.FormFile(field)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, 0, tooLargeErr
+		}
+		return nil, 0, &er.ValidationError{Message: err.Error()}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return file, header.Size, nil
+}
+
+func toUploadDTO(upload *model.Upload) response.UploadDTO {
+	dto := response.UploadDTO{
+		ID:          upload.ID,
+		SongID:      upload.SongID,
+		Size:        upload.Size,
+		Offset:      upload.Offset,
+		Status:      string(upload.Status),
+		CreatedAt:   upload.CreatedAt,
+		DuplicateOf: upload.DuplicateOf,
+	}
+
+	if meta := upload.Metadata; meta != nil {
+		dto.Metadata = &response.AudioMetadataDTO{
+			Title:          meta.Title,
+			Artist:         meta.Artist,
+			Album:          meta.Album,
+			AlbumArtist:    meta.AlbumArtist,
+			Genre:          meta.Genre,
+			Year:           meta.Year,
+			TrackNumber:    meta.TrackNumber,
+			TrackTotal:     meta.TrackTotal,
+			DiscNumber:     meta.DiscNumber,
+			DiscTotal:      meta.DiscTotal,
+			DurationSec:    int((meta.DurationMs + 500) / 1000),
+			SampleRate:     meta.SampleRate,
+			Channels:       meta.Channels,
+			Bitrate:        meta.Bitrate,
+			Lyrics:         meta.Lyrics,
+			LyricsLanguage: meta.LyricsLanguage,
+			HasCover:       meta.HasCover,
+		}
+		if meta.HasCover && upload.Status == model.UploadStaged {
+			dto.Metadata.CoverURL = "/api/v1/upload/" + upload.ID + "/cover"
+		}
+	}
+	return dto
+}
@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"music-lib/internal/dto/response"
+	"music-lib/internal/middleware"
+	"music-lib/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initUserRoutes(api *gin.RouterGroup) {
+	users := api.Group("/users/:name")
+	{
+		users.GET("", middleware.OptionalAuthMiddleware(h.config), h.GetPublicProfile())
+		users.GET("/followers", middleware.OptionalAuthMiddleware(h.config), h.GetUserFollows(true))
+		users.GET("/following", middleware.OptionalAuthMiddleware(h.config), h.GetUserFollows(false))
+		users.POST("/follow", middleware.AuthMiddleware(h.config), h.FollowUser(true))
+		users.DELETE("/follow", middleware.AuthMiddleware(h.config), h.FollowUser(false))
+	}
+}
+
+// GetPublicProfile возвращает страницу пользователя с учетом его настроек приватности
+func (h *Handler) GetPublicProfile() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, err := h.services.Profile.GetPublicProfile(ctx, ctx.Param("name"), viewerID(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		dto := response.PublicProfileDTO{
+			Name:            page.User.Name,
+			Playlists:       make([]response.PlaylistDTO, 0, len(page.Playlists)),
+			RecentFavorites: make([]response.FavoriteDTO, 0, len(page.Favorites)),
+			RecentPlays:     make([]response.PlayDTO, 0, len(page.Plays)),
+			TopArtists:      make([]response.TopArtistDTO, 0, len(page.TopArtists)),
+			Followers:       page.Followers,
+			Following:       page.Following,
+			Followed:        page.Followed,
+			Hidden:          page.Hidden,
+		}
+		if page.Profile != nil {
+			dto.Bio = page.Profile.Bio
+			dto.AvatarURL = page.Profile.AvatarURL
+			dto.Avatar = response.NewImageDTO(page.Profile.AvatarKey)
+		}
+		for _, collection := range page.Playlists {
+			dto.Playlists = append(dto.Playlists, response.PlaylistDTO{
+				ID:          collection.ID,
+				Name:        collection.Name,
+				Description: collection.Description,
+				Tracks:      collection.Tracks,
+				Visibility:  string(collection.Visibility),
+			})
+		}
+		for _, favorite := range page.Favorites {
+			dto.RecentFavorites = append(dto.RecentFavorites, response.FavoriteDTO{
+				Type:    favorite.ObjectType,
+				ID:      favorite.ObjectID,
+				Title:   favorite.Title,
+				AddedAt: favorite.CreatedAt,
+			})
+		}
+		for _, play := range page.Plays {
+			dto.RecentPlays = append(dto.RecentPlays, response.PlayDTO{
+				SongID:   play.SongID,
+				Title:    play.Title,
+				PlayedAt: play.PlayedAt,
+			})
+		}
+		for _, artist := range page.TopArtists {
+			dto.TopArtists = append(dto.TopArtists, response.TopArtistDTO{
+				ID:    artist.ArtistID,
+				Name:  artist.Name,
+				Plays: artist.Plays,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, dto)
+	}
+}
+
+// GetUserFollows возвращает подписчиков пользователя или его подписки
+func (h *Handler) GetUserFollows(followers bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, offset := validatePagination(ctx)
+		if len(ctx.Errors) > 0 {
+			return
+		}
+
+		var (
+			entries []model.FollowEntry
+			total   int64
+			err     error
+		)
+		if followers {
+			entries, total, err = h.services.Follow.GetFollowers(ctx, ctx.Param("name"), viewerID(ctx), limit, offset)
+		} else {
+			entries, total, err = h.services.Follow.GetFollowing(ctx, ctx.Param("name"), viewerID(ctx), limit, offset)
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		data := make([]response.FollowDTO, 0, len(entries))
+		for _, entry := range entries {
+			data = append(data, response.FollowDTO{
+				Name:       entry.Name,
+				AvatarURL:  entry.AvatarURL,
+				Avatar:     response.NewImageDTO(entry.AvatarKey),
+				FollowedAt: entry.FollowedAt,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, response.PaginatedResponse{
+			Data: data,
+			Pagination: response.Pagination{
+				Limit:  limit,
+				Offset: offset,
+				Total:  total,
+			},
+		})
+	}
+}
+
+// FollowUser подписывает на пользователя или снимает подписку
+func (h *Handler) FollowUser(follow bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var err error
+		if follow {
+			err = h.services.Follow.FollowUser(ctx, viewerID(ctx), ctx.Param("name"))
+		} else {
+			err = h.services.Follow.UnfollowUser(ctx, viewerID(ctx), ctx.Param("name"))
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"fmt"
+	"music-lib/internal/middleware"
+	"music-lib/pkg/er"
+	"music-lib/pkg/waveform"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSongWaveform отдает пики файла песни в JSON или двоичном формате audiowaveform (.dat).
+// Формат выбирается параметром format или заголовком Accept, масштаб - параметром samples_per_pixel.
+func (h *Handler) GetSongWaveform() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Error(&er.ValidationError{Message: err.Error()})
+			return
+		}
+
+		samplesPerPixel := waveform.DefaultSamplesPerPixel
+		if value := ctx.Query("samples_per_pixel"); value != "" {
+			if samplesPerPixel, err = strconv.Atoi(value); err != nil {
+				ctx.Error(er.ErrWaveformResolution)
+				return
+			}
+		}
+
+		binary := strings.Contains(ctx.GetHeader("Accept"), "application/octet-stream")
+		switch ctx.Query("format") {
+		case "":
+		case "json":
+			binary = false
+		case "dat":
+			binary = true
+		default:
+			ctx.Error(&er.ValidationError{Message: "format must be json or dat"})
+			return
+		}
+
+		var userID uint
+		if user, ok := middleware.GetUserData(ctx); ok {
+			userID = user.Id
+		}
+		song, err := h.services.Stream.Open(ctx, uint(id), userID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		var etag string
+		if song.FileHash != "" {
+			etag = fmt.Sprintf(`"%s-%d"`, song.FileHash, samplesPerPixel)
+			ctx.Header("ETag", etag)
+		}
+		ctx.Header("Vary", "Accept")
+		if song.Private {
+			ctx.Header("Cache-Control", "private, no-cache")
+		} else {
+			ctx.Header("Cache-Control", "no-cache")
+		}
+
+		if etag != "" && etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
+		w, err := h.services.Waveform.Get(ctx, song, samplesPerPixel)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if !binary {
+			ctx.JSON(http.StatusOK, w)
+			return
+		}
+		data, err := w.MarshalBinary()
+		if err != nil {
+			ctx.Error(&er.InternalError{Message: err.Error()})
+			return
+		}
+		ctx.Data(http.StatusOK, "application/octet-stream", data)
+	}
+}
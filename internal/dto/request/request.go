@@ -34,17 +34,73 @@ type UpdateArtistRequest struct {
 }
 
 type NewAlbumRequest struct {
-	Title       string `json:"title" binding:"required"`
-	ReleaseDate string `json:"release_date" binding:"required"`
-	CoverArtURL string `json:"cover_art_url" binding:"required"`
+	Title       string      `json:"title" binding:"required"`
+	ReleaseDate string      `json:"release_date" binding:"required" example:"2025-03-01T00:00:00+03:00"` // YYYY-MM-DD или RFC 3339; до этой даты альбом видят только редакторы
+	CoverArtURL string      `json:"cover_art_url" binding:"required"`
+	Type        string      `json:"type,omitempty" example:"lp"`          // single, ep, lp, compilation или live, по умолчанию lp
+	UPC         string      `json:"upc,omitempty" example:"036000291452"` // UPC-A или EAN-13
+	Discs       []AlbumDisc `json:"discs,omitempty"`                      // Названия дисков многодискового издания
+}
+
+// Отсутствующие поля не меняются, пустой список discs удаляет названия дисков
+type UpdateAlbumRequest struct {
+	Type  string       `json:"type,omitempty" example:"ep"`
+	UPC   *string      `json:"upc,omitempty" example:"036000291452"` // Пустая строка удаляет код
+	Discs *[]AlbumDisc `json:"discs,omitempty"`
+}
+
+type AlbumDisc struct {
+	Number int    `json:"number" binding:"required" example:"2"`
+	Title  string `json:"title" binding:"required" example:"Live at Wembley"`
 }
 
 type NewSongRequest struct {
-	Title    string    `json:"title" binding:"required"`
-	Genres   []Genres  `json:"genres" binding:"required"`
-	Duration int       `json:"duration_sec" binding:"required"`
-	FilePath string    `json:"file_path" binding:"required"`
-	Lyrics   AddLyrics `json:"lyrics" binding:"required"`
+	Title    string   `json:"title" binding:"required"`
+	ISRC     string   `json:"isrc,omitempty" example:"US-S1Z-99-00001"`
+	Genres   []Genres `json:"genres" binding:"required"`
+	Duration int      `json:"duration_sec" binding:"required"`
+	UploadID string   `json:"upload_id,omitempty" example:"7f9c2ba4-e88f-4a1d-9d3b-2c5f1e0a6b7d"` // Файл, загруженный через POST /upload
+	Private  bool     `json:"private"`
+	// Номер дорожки на диске, без него песня встает в конец диска
+	TrackNumber int       `json:"track_number,omitempty" example:"3"`
+	DiscNumber  int       `json:"disc_number,omitempty" example:"1"` // По умолчанию 1
+	Lyrics      AddLyrics `json:"lyrics" binding:"required"`
+	Credits     []Credit  `json:"credits,omitempty"` // Участники помимо артиста альбома
+}
+
+// Отсутствующие поля не меняются
+type UpdateSongRequest struct {
+	ISRC *string `json:"isrc,omitempty" example:"US-S1Z-99-00001"` // Пустая строка удаляет код
+}
+
+// Без статуса запись возвращается в черновики
+type UnpublishRequest struct {
+	Status string `json:"status,omitempty" example:"archived"` // draft, in_review, unlisted или archived
+}
+
+// Запись из пути вливается в запись target_id
+type MergeRequest struct {
+	TargetID uint `json:"target_id" binding:"required" example:"42"`
+}
+
+type SetTrackRequest struct {
+	DiscNumber  int `json:"disc_number,omitempty" example:"1"` // По умолчанию 1
+	TrackNumber int `json:"track_number" binding:"required" example:"3"`
+}
+
+type NewUploadRequest struct {
+	Size int64 `json:"size" binding:"required" example:"5242880"` // Полный размер файла в байтах
+}
+
+// Участник песни: артист из каталога по artist_id или человек по имени
+type Credit struct {
+	ArtistID uint   `json:"artist_id,omitempty" example:"3"`
+	Name     string `json:"name,omitempty" example:"Jane Doe"`
+	Role     string `json:"role" binding:"required" example:"featured"` // primary, featured, composer, lyricist, producer или remixer
+}
+
+type SetCreditsRequest struct {
+	Credits []Credit `json:"credits"`
 }
 
 type Genres struct {
@@ -52,17 +108,86 @@ type Genres struct {
 }
 
 type AddLyrics struct {
-	Text []Couplet `json:"text" binding:"required"`
+	Language string    `json:"language,omitempty" example:"ru"`   // Тег BCP-47, по умолчанию "und"
+	Kind     string    `json:"kind,omitempty" example:"original"` // original, translation или transliteration
+	OffsetMs int64     `json:"offset_ms,omitempty" example:"0"`
+	Text     []Couplet `json:"text" binding:"required"`
 }
 
 type Couplet struct {
-	Text string `json:"couplet" binding:"required"`
+	Text   string `json:"couplet" binding:"required"`
+	TimeMs *int64 `json:"time_ms,omitempty" example:"12500"` // Время начала строки для синхронизированного текста
+	Words  []Word `json:"words,omitempty"`
+}
+
+type Word struct {
+	TimeMs int64  `json:"time_ms" example:"12750"`
+	Text   string `json:"word" binding:"required"`
 }
 
 type NewGenreRequest struct {
-	Name string `json:"genre_name" binding:"required"`
+	Name        string   `json:"genre_name" binding:"required"`
+	Description string   `json:"description,omitempty"`
+	ParentID    uint     `json:"parent_id,omitempty" example:"3"`     // Жанр, поджанром которого станет новый
+	Aliases     []string `json:"aliases,omitempty" example:"hip hop"` // Другие написания для поиска жанра
 }
 
+// Пустые поля не меняются
 type UpdateGenreRequest struct {
-	NewName string `json:"genre_name_update" binding:"required"`
-}
\ No newline at end of file
+	NewName     string   `json:"genre_name_update,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	ParentID    *uint    `json:"parent_id,omitempty"` // 0 делает жанр корневым
+	Aliases     []string `json:"aliases,omitempty"`   // Заменяют прежний список, [] удаляет все
+}
+
+type TagVoteRequest struct {
+	Tag  string `json:"tag" binding:"required" example:"rainy day"`
+	Vote int    `json:"vote,omitempty" example:"1"` // 1 - метка подходит, -1 - нет, по умолчанию 1
+}
+
+type RatingRequest struct {
+	Stars int `json:"stars" binding:"required" example:"4"` // От 1 до 5
+}
+
+type ReviewRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+type CommentRequest struct {
+	Text     string `json:"text" binding:"required"`
+	ParentID uint   `json:"parent_id,omitempty" example:"12"` // Комментарий, на который это ответ
+	At       string `json:"at,omitempty" example:"1:23"`      // Момент песни: секунды, m:ss или h:mm:ss
+}
+
+type UpdateCommentRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+type ReportRequest struct {
+	Type    string `json:"type" binding:"required" example:"album"` // artist, album, song, genre, review или comment
+	ID      uint   `json:"id" binding:"required" example:"12"`
+	Reason  string `json:"reason" binding:"required" example:"spam"` // spam, abuse, copyright, inappropriate, misleading или other
+	Details string `json:"details,omitempty"`
+}
+
+// Решение по нескольким жалобам сразу
+type ResolveReportsRequest struct {
+	IDs      []uint `json:"ids" binding:"required"`
+	State    string `json:"state" binding:"required" example:"actioned"` // actioned или dismissed
+	TakeDown bool   `json:"take_down,omitempty"`                         // Скрыть записи, на которые пожаловались
+	Note     string `json:"note,omitempty"`                              // Уходит владельцам скрытых записей
+}
+
+// Настройки приватности публичной страницы. Пустое поле не меняет настройку.
+type ProfilePrivacyRequest struct {
+	Bio            string `json:"bio,omitempty" example:"public"` // public, followers или private
+	Avatar         string `json:"avatar,omitempty"`
+	Favorites      string `json:"favorites,omitempty"`
+	History        string `json:"history,omitempty" example:"followers"`
+	TopArtists     string `json:"top_artists,omitempty"`     // Прежнее имя history, учитывается, если history не задан
+	PrivateSession *bool  `json:"private_session,omitempty"` // true - не записывать прослушивания в историю
+}
+
+type CollectionVisibilityRequest struct {
+	Visibility string `json:"visibility" binding:"required" example:"public"` // public, followers или private
+}
@@ -1,6 +1,12 @@
 package response
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"music-lib/internal/model"
+	"music-lib/pkg/imaging"
+	"time"
+)
 
 type PaginatedResponse struct {
 	Data       any        `json:"data"`
@@ -21,6 +27,9 @@ type ArtistDTO struct {
 	Name          string     `json:"name"`
 	Description   string     `json:"description"`
 	FormationYear time.Time  `json:"formation_year"`
+	Status        string     `json:"status"` // draft, in_review, published, unlisted или archived
+	Followers     int64      `json:"followers"`
+	Following     bool       `json:"following,omitempty"` // Текущий пользователь подписан на артиста
 	Albums        []AlbumDTO `json:"albums,omitempty"`
 }
 
@@ -30,27 +39,180 @@ type AlbumDTO struct {
 	Title       string    `json:"title"`
 	ReleaseDate time.Time `json:"release_date"` // Формат: "2006-01-02"
 	CoverArtURL string    `json:"cover_art_url"`
+	CoverArt    *ImageDTO `json:"cover_art,omitempty"` // Миниатюры загруженной обложки
+	Type        string    `json:"type"`                // single, ep, lp, compilation или live
+	UPC         string    `json:"upc,omitempty"`       // EAN-13
+	Status      string    `json:"status"`
 	Songs       []SongDTO `json:"songs,omitempty"`
+	// Песни по дискам в порядке дорожек
+	Discs  []DiscDTO         `json:"discs,omitempty"`
+	Rating *RatingSummaryDTO `json:"rating,omitempty"`
+}
+
+type DiscDTO struct {
+	Number int       `json:"number"`
+	Title  string    `json:"title,omitempty"`
+	Songs  []SongDTO `json:"songs"`
+}
+
+// Ссылки на квадратные миниатюры изображения
+type ImageDTO struct {
+	Small  string `json:"small"`  // 64x64
+	Medium string `json:"medium"` // 300x300
+	Large  string `json:"large"`  // 1000x1000
+}
+
+// NewImageDTO строит ссылки на миниатюры по ключу набора, nil, если изображение не загружено
+func NewImageDTO(key string) *ImageDTO {
+	if key == "" {
+		return nil
+	}
+	url := func(size int) string {
+		return "/api/v1/image/" + imaging.ThumbnailKey(key, size)
+	}
+	return &ImageDTO{
+		Small:  url(imaging.ThumbnailSizes[0]),
+		Medium: url(imaging.ThumbnailSizes[1]),
+		Large:  url(imaging.ThumbnailSizes[2]),
+	}
+}
+
+// Профиль с миниатюрами аватара
+type ProfileDTO struct {
+	*model.Profile
+	Avatar *ImageDTO `json:"avatar,omitempty"`
 }
 
 // Для ответов с деталями песни
 type SongDTO struct {
-	ID       uint      `json:"id"`
-	Title    string    `json:"title"`
-	AlbumID  uint      `json:"album_id"`
-	Duration int       `json:"duration"`
-	FilePath string    `json:"file_path"` // или URL для скачивания
-	Lyrics   LyricsDTO `json:"lyrics,omitempty"`
+	ID          uint   `json:"id"`
+	Title       string `json:"title"`
+	ISRC        string `json:"isrc,omitempty"`
+	Status      string `json:"status"`
+	ArtistID    uint   `json:"artist_id,omitempty"`
+	AlbumID     uint   `json:"album_id"`
+	DiscNumber  int    `json:"disc_number,omitempty"`
+	TrackNumber int    `json:"track_number,omitempty"`
+	Duration    int    `json:"duration"`
+	StreamURL   string `json:"stream_url,omitempty"` // Есть, только если загружен аудиофайл
+	Private     bool   `json:"private"`
+	FileSize    int64  `json:"file_size,omitempty"`
+	FileHash    string `json:"file_hash,omitempty"` // SHA-256 аудиофайла
+	MimeType    string `json:"mime_type,omitempty"`
+	// Песня с тем же звуком, если загрузка дубликатов разрешена
+	DuplicateOf uint        `json:"duplicate_of,omitempty"`
+	Credits     []CreditDTO `json:"credits,omitempty"`
+	Lyrics      LyricsDTO   `json:"lyrics,omitempty"`
+	// Доступные версии текста
+	LyricsVersions []LyricsVersionDTO `json:"lyrics_versions,omitempty"`
+	Tags           []TagDTO           `json:"tags,omitempty"`
+	Rating         *RatingSummaryDTO  `json:"rating,omitempty"`
+}
+
+// Участник песни, ArtistID пустой для человека не из каталога
+type CreditDTO struct {
+	ArtistID uint   `json:"artist_id,omitempty"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// SongStreamURL - путь для прослушивания песни, пустой, если аудиофайл не загружен
+func SongStreamURL(songID uint, filePath string) string {
+	if filePath == "" {
+		return ""
+	}
+	return fmt.Sprintf("/api/v1/song/%d/stream", songID)
+}
+
+// Подписанная ссылка на прослушивание без заголовка Authorization
+type StreamURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Состояние загрузки аудиофайла
+type UploadDTO struct {
+	ID        string    `json:"id"`
+	SongID    uint      `json:"song_id"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	// Песня с тем же файлом или звуком, загрузка принята с предупреждением
+	DuplicateOf uint `json:"duplicate_of,omitempty"`
+	// Теги из файла для подтверждения перед созданием песни
+	Metadata *AudioMetadataDTO `json:"metadata,omitempty"`
+}
+
+type AudioMetadataDTO struct {
+	Title          string `json:"title,omitempty"`
+	Artist         string `json:"artist,omitempty"`
+	Album          string `json:"album,omitempty"`
+	AlbumArtist    string `json:"album_artist,omitempty"`
+	Genre          string `json:"genre,omitempty"`
+	Year           int    `json:"year,omitempty"`
+	TrackNumber    int    `json:"track_number,omitempty"`
+	TrackTotal     int    `json:"track_total,omitempty"`
+	DiscNumber     int    `json:"disc_number,omitempty"`
+	DiscTotal      int    `json:"disc_total,omitempty"`
+	DurationSec    int    `json:"duration_sec,omitempty"`
+	SampleRate     int    `json:"sample_rate,omitempty"`
+	Channels       int    `json:"channels,omitempty"`
+	Bitrate        int    `json:"bitrate_kbps,omitempty"`
+	Lyrics         string `json:"lyrics,omitempty"`
+	LyricsLanguage string `json:"lyrics_language,omitempty"`
+	HasCover       bool   `json:"has_cover"`
+	CoverURL       string `json:"cover_url,omitempty"` // Пока файл ждет создания песни
 }
 
 // Для ответов с текстом песни
 type LyricsDTO struct {
+	Language string       `json:"language,omitempty"`
+	Kind     string       `json:"kind,omitempty"`
+	Synced   bool         `json:"synced"`
+	OffsetMs int64        `json:"offset_ms,omitempty"`
 	Couplets []CoupletDTO `json:"text"`
 }
 
 type CoupletDTO struct {
-	Number  uint   `json:"number"`
-	Couplet string `json:"couplet"`
+	Number  uint      `json:"number"`
+	Couplet string    `json:"couplet"`
+	TimeMs  *int64    `json:"time_ms,omitempty"`
+	Words   []WordDTO `json:"words,omitempty"`
+}
+
+type LyricsVersionDTO struct {
+	Language string `json:"language"`
+	Kind     string `json:"kind"`
+}
+
+type LyricsRevisionDTO struct {
+	ID               uint      `json:"id"`
+	Language         string    `json:"language"`
+	Kind             string    `json:"kind"`
+	AuthorID         uint      `json:"author_id"`
+	Author           string    `json:"author"`
+	Action           string    `json:"action"`
+	SourceRevisionID *uint     `json:"source_revision_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Сравнение двух ревизий текста по куплетам
+type LyricsDiffDTO struct {
+	From    uint               `json:"from"`
+	To      uint               `json:"to"`
+	Changes []CoupletChangeDTO `json:"changes"`
+}
+
+type CoupletChangeDTO struct {
+	Op  string      `json:"op"` // equal, insert, delete, replace
+	Old *CoupletDTO `json:"old,omitempty"`
+	New *CoupletDTO `json:"new,omitempty"`
+}
+
+type WordDTO struct {
+	TimeMs int64  `json:"time_ms"`
+	Word   string `json:"word"`
 }
 
 type LoginResponse struct {
@@ -75,3 +237,159 @@ type AddSongResponse struct {
 	AlbumName string `json:"album_name"`
 	ArtistID  uint   `json:"artist_id"`
 }
+
+// Запись в корзине пользователя
+type TrashItemDTO struct {
+	Type      string    `json:"type"` // artist, album или song
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	ParentID  uint      `json:"parent_id,omitempty"` // Альбом песни или артист альбома
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // После этого момента запись удаляется окончательно
+}
+
+// Запись журнала изменений
+type AuditEntryDTO struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"` // artist, album, song или genre
+	EntityID  uint            `json:"entity_id"`
+	ActorID   uint            `json:"actor_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type GenreDTO struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	ParentID    *uint      `json:"parent_id,omitempty"`
+	Aliases     []string   `json:"aliases,omitempty"`
+	Subgenres   []GenreDTO `json:"subgenres,omitempty"` // Прямые поджанры или все дерево для /genre/tree
+}
+
+// Метка записи или облака. Scale - вес относительно самой весомой метки списка, от 0 до 1.
+type TagDTO struct {
+	Name   string  `json:"name"`
+	Weight int64   `json:"weight"`
+	Scale  float64 `json:"scale"`
+	Vote   int     `json:"vote,omitempty"` // Голос текущего пользователя
+}
+
+// Сводка оценок: средняя, число оценок и распределение от 1 до 5 звезд
+type RatingSummaryDTO struct {
+	Average   float64  `json:"average"`
+	Count     int64    `json:"count"`
+	Histogram [5]int64 `json:"histogram"`
+	Own       int      `json:"own,omitempty"` // Оценка текущего пользователя
+}
+
+type ReviewDTO struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"` // song или album
+	EntityID  uint      `json:"entity_id"`
+	UserID    uint      `json:"user_id"`
+	Author    string    `json:"author"`
+	Stars     int       `json:"stars,omitempty"` // Оценка автора, если он ее поставил
+	Text      string    `json:"text"`
+	Hidden    bool      `json:"hidden,omitempty"` // Скрыта модератором, видна только автору
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Комментарий. У удаленного комментария, оставленного ради ответов, нет текста и автора.
+type CommentDTO struct {
+	ID        uint       `json:"id"`
+	ParentID  *uint      `json:"parent_id,omitempty"`
+	UserID    uint       `json:"user_id,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Text      string     `json:"text"`
+	At        string     `json:"at,omitempty"` // Момент песни, например "1:23"
+	AtSec     *int       `json:"at_sec,omitempty"`
+	Likes     int        `json:"likes"`
+	Liked     bool       `json:"liked,omitempty"`
+	Replies   int64      `json:"replies"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ReportDTO struct {
+	ID         uint       `json:"id"`
+	ReporterID uint       `json:"reporter_id"`
+	Type       string     `json:"type"`
+	EntityID   uint       `json:"entity_id"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	State      string     `json:"state"`
+	ResolvedBy uint       `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	TakenDown  bool       `json:"taken_down,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Запись ленты новинок от артистов, на которых подписан пользователь
+type FeedItemDTO struct {
+	Type        string    `json:"type"` // album или song
+	ID          uint      `json:"id"`
+	Title       string    `json:"title"`
+	ArtistID    uint      `json:"artist_id"`
+	AlbumID     uint      `json:"album_id"`
+	ReleaseDate time.Time `json:"release_date"`
+	AddedAt     time.Time `json:"added_at"` // Когда запись попала в ленту
+}
+
+// Публичная страница пользователя. Разделы из Hidden владелец скрыл настройками приватности.
+type PublicProfileDTO struct {
+	Name            string         `json:"name"`
+	Bio             string         `json:"bio,omitempty"`
+	AvatarURL       string         `json:"avatar_url,omitempty"`
+	Avatar          *ImageDTO      `json:"avatar,omitempty"`
+	Playlists       []PlaylistDTO  `json:"playlists"`
+	RecentFavorites []FavoriteDTO  `json:"recent_favorites"`
+	RecentPlays     []PlayDTO      `json:"recent_plays"`
+	TopArtists      []TopArtistDTO `json:"top_artists"`
+	Followers       int64          `json:"followers"`
+	Following       int64          `json:"following"`
+	Followed        bool           `json:"followed,omitempty"` // Текущий пользователь подписан на владельца
+	Hidden          []string       `json:"hidden,omitempty"`   // bio, avatar, favorites или history
+}
+
+// Коллекция на странице пользователя
+type PlaylistDTO struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Tracks      int64  `json:"tracks"`
+	Visibility  string `json:"visibility"`
+}
+
+type FavoriteDTO struct {
+	Type    string    `json:"type"` // song, album или artist
+	ID      uint      `json:"id"`
+	Title   string    `json:"title"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Прослушивание из истории пользователя
+type PlayDTO struct {
+	SongID   uint      `json:"song_id"`
+	Title    string    `json:"title"`
+	PlayedAt time.Time `json:"played_at"`
+}
+
+type TopArtistDTO struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Plays int64  `json:"plays"`
+}
+
+// Пользователь в списке подписчиков или подписок
+type FollowDTO struct {
+	Name       string    `json:"name"`
+	AvatarURL  string    `json:"avatar_url,omitempty"`
+	Avatar     *ImageDTO `json:"avatar,omitempty"`
+	FollowedAt time.Time `json:"followed_at"`
+}
@@ -14,7 +14,6 @@ import (
 )
 
 type Sender struct {
-	EventBus *event.EventBus
 	Config    *config.Config
 	Server    string
 	Port      string
@@ -29,7 +28,7 @@ type Addressee struct {
 	Text string
 }
 
-func Load(conf *config.Config, event *event.EventBus) (*Sender, error) {
+func Load(conf *config.Config) (*Sender, error) {
 	// Настройки SMTP
 	server := conf.Sender.Address
 	port := conf.Sender.Port
@@ -76,7 +75,6 @@ func Load(conf *config.Config, event *event.EventBus) (*Sender, error) {
 		Address:   address,
 		TlsConfig: tlsConfig,
 		Auth:      auth,
-		EventBus: event,
 	}, nil
 }
 
@@ -91,8 +89,10 @@ func validate(server string, port string) error {
 	return nil
 }
 
-func (send *Sender) Listen() {
-	for msg := range send.EventBus.Subscribe() {
+// Listen отправляет письма из событий events. Канал нужно получить до запуска издателей,
+// иначе события, опубликованные раньше подписки, потеряются.
+func (send *Sender) Listen(events <-chan event.Event) {
+	for msg := range events {
 		if msg.Type == event.EventSendEmail {
 			addressee, ok := msg.Data.(Addressee) 
 			if !ok {
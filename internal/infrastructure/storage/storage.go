@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"music-lib/internal/config"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// Метаданные объекта
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Storage - хранилище файлов, ключи вида "songs/1/file.mp3"
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// GetRange читает length байт начиная с offset
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New создает хранилище по конфигурации
+func New(conf config.StorageConfig) (Storage, error) {
+	switch conf.Driver {
+	case "local":
+		return NewLocalStorage(conf.LocalPath)
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:  conf.Endpoint,
+			Bucket:    conf.Bucket,
+			Region:    conf.Region,
+			AccessKey: conf.AccessKey,
+			SecretKey: conf.SecretKey,
+			PathStyle: conf.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", conf.Driver)
+	}
+}
+
+// Часть объекта: читается ограниченно, закрывается исходный поток
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func limitReadCloser(rc io.ReadCloser, length int64) io.ReadCloser {
+	return limitedReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}
+}
+
+func validateRange(offset, length int64) error {
+	if offset < 0 || length <= 0 {
+		return fmt.Errorf("storage: invalid range %d+%d", offset, length)
+	}
+	return nil
+}
+
+// Ключ не должен выходить за пределы хранилища
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
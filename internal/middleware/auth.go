@@ -48,6 +48,33 @@ func AuthMiddleware(config *config.Config) gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware записывает пользователя в контекст, если передан действительный токен.
+// Запрос без токена или с неверным либо истекшим токеном проходит как анонимный:
+// публичные данные не должны становиться недоступными из-за устаревшей сессии.
+func OptionalAuthMiddleware(config *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			c.Next()
+			return
+		}
+
+		isValid, data := jwt.NewJwt(config.Auth.Secret).Parse(strings.TrimPrefix(authHeader, "Bearer "))
+		if !isValid {
+			c.Next()
+			return
+		}
+
+		c.Set(string(ContextUserDataKey), UserData{
+			Id:    data.Id,
+			Email: data.Email,
+			Role:  data.Role,
+		})
+
+		c.Next()
+	}
+}
+
 // Вспомогательная функция для обработки неавторизованных запросов
 func abortWithUnauthorized(c *gin.Context) {
 	c.Error(&er.UnauthorizedError{Message: "Can't authorize, bad token"})
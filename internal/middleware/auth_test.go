@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"music-lib/internal/config"
+	"music-lib/pkg/jwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionalAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conf := &config.Config{Auth: config.AuthConfig{Secret: "secret"}}
+
+	router := gin.New()
+	router.GET("/", OptionalAuthMiddleware(conf), func(c *gin.Context) {
+		user, _ := GetUserData(c)
+		c.JSON(http.StatusOK, gin.H{"id": user.Id, "errors": len(c.Errors)})
+	})
+
+	valid, err := jwt.NewJwt("secret").Create(jwt.JWTData{Id: 7, Email: "user@example.com", Role: "user"})
+	assert.NoError(t, err)
+	foreign, err := jwt.NewJwt("other").Create(jwt.JWTData{Id: 7, Email: "user@example.com", Role: "user"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+	}{
+		{"no token", "", `{"errors":0,"id":0}`},
+		{"valid token", "Bearer " + valid, `{"errors":0,"id":7}`},
+		// Неверный токен не закрывает публичные данные, запрос проходит как анонимный
+		{"invalid token", "Bearer " + foreign, `{"errors":0,"id":0}`},
+		{"malformed token", "Bearer garbage", `{"errors":0,"id":0}`},
+		{"other scheme", "Basic dXNlcjpwYXNz", `{"errors":0,"id":0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, tt.body, rec.Body.String())
+		})
+	}
+}
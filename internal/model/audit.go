@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate   AuditAction = "create"
+	AuditUpdate   AuditAction = "update"
+	AuditStatus   AuditAction = "status" // Публикация или снятие с публикации
+	AuditDelete   AuditAction = "delete" // Перенос в корзину
+	AuditRestore  AuditAction = "restore"
+	AuditMerge    AuditAction = "merge"    // До - слитая запись, после - запись, в которую ее влили
+	AuditTakedown AuditAction = "takedown" // Скрыта модератором по жалобе
+)
+
+// Запись журнала изменений каталога. Журнал только дополняется.
+type AuditEntry struct {
+	ID         uint            `gorm:"primaryKey"`
+	ActorID    uint            `gorm:"index;not null"`
+	EntityType Resource        `gorm:"type:varchar(20);index:idx_audit_entity;not null"`
+	EntityID   uint            `gorm:"index:idx_audit_entity;not null"`
+	Action     AuditAction     `gorm:"type:varchar(20);not null"`
+	Before     json.RawMessage `gorm:"type:jsonb"` // Пусто при создании
+	After      json.RawMessage `gorm:"type:jsonb"` // Пусто при удалении
+	CreatedAt  time.Time       `gorm:"index"`
+}
+
+// Условия выборки журнала, нулевые поля не ограничивают выборку
+type AuditFilter struct {
+	EntityType Resource
+	EntityID   uint
+	ActorID    uint
+	// Только изменения записей, которые может редактировать этот пользователь
+	EditorID uint
+}
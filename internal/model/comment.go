@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Комментарий к песне или альбому. Ответ ссылается на родительский комментарий той же записи.
+// Удаленный комментарий с живыми ответами остается в ветке без текста и автора.
+type Comment struct {
+	ID           uint     `gorm:"primaryKey"`
+	UserID       uint     `gorm:"index;not null"`
+	ResourceType Resource `gorm:"type:varchar(20);index:idx_comment_target;not null"`
+	ResourceID   uint     `gorm:"index:idx_comment_target;not null"`
+	ParentID     *uint    `gorm:"index"`
+	AtSec        *int     // Момент песни, о котором комментарий, в секундах
+	Text         string   `gorm:"type:text;not null"`
+	Likes        int      `gorm:"not null;default:0"`
+	EditedAt     *time.Time
+	DeletedBy    uint
+	Author       string `gorm:"->;-:migration"` // Имя автора, читается из users
+	Liked        bool   `gorm:"->;-:migration"` // Текущий пользователь отметил комментарий
+	Replies      int64  `gorm:"->;-:migration"` // Число живых ответов
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+// Отметка "нравится" под комментарием
+type CommentLike struct {
+	CommentID uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// Порядок комментариев в ветке
+type CommentSort string
+
+const (
+	CommentsNewest CommentSort = "newest"
+	CommentsOldest CommentSort = "oldest"
+	CommentsTop    CommentSort = "top" // Сначала с большим числом отметок
+)
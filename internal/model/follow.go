@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Подписка пользователя на артиста
+type ArtistFollow struct {
+	UserID    uint `gorm:"primaryKey"`
+	ArtistID  uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// Подписка одного пользователя на другого
+type UserFollow struct {
+	FollowerID uint `gorm:"primaryKey"`
+	FolloweeID uint `gorm:"primaryKey;index"`
+	CreatedAt  time.Time
+}
+
+// Пользователь в списке подписчиков или подписок. Аватар пуст, если владелец его скрыл.
+type FollowEntry struct {
+	UserID     uint
+	Name       string
+	AvatarKey  string
+	AvatarURL  string
+	FollowedAt time.Time
+}
+
+// Запись ленты новинок. Добавляется подписчикам артиста, когда приходит событие о релизе,
+// поэтому лента не пересчитывается по каталогу при каждом запросе.
+type FeedItem struct {
+	ID           uint     `gorm:"primaryKey"`
+	UserID       uint     `gorm:"uniqueIndex:idx_feed_item;index:idx_feed_user;not null"`
+	ResourceType Resource `gorm:"type:varchar(20);uniqueIndex:idx_feed_item;not null"` // album или song
+	ResourceID   uint     `gorm:"uniqueIndex:idx_feed_item;not null"`
+	ArtistID     uint     `gorm:"index;not null"`
+	AlbumID      uint     // Для песни - ее альбом
+	Title        string   `gorm:"not null"`
+	ReleaseDate  time.Time
+	CreatedAt    time.Time `gorm:"index:idx_feed_user"` // Когда запись попала в ленту
+}
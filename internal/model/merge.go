@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Перенаправление со слитой записи на запись, в которую ее влили.
+// Старый идентификатор продолжает открываться и отвечает 301.
+type MergeAlias struct {
+	ID           uint     `gorm:"primaryKey"`
+	ResourceType Resource `gorm:"type:varchar(20);uniqueIndex:idx_merge_alias;not null"`
+	SourceID     uint     `gorm:"uniqueIndex:idx_merge_alias;not null"`
+	TargetID     uint     `gorm:"index;not null"`
+	MergedBy     uint     `gorm:"not null"`
+	CreatedAt    time.Time
+}
@@ -1,68 +1,312 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Статус публикации артиста, альбома или песни
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusInReview  Status = "in_review"
+	StatusPublished Status = "published"
+	StatusUnlisted  Status = "unlisted" // Открывается по прямой ссылке, но не попадает в поиск и списки
+	StatusArchived  Status = "archived"
+	// Скрыта модератором по жалобе, редакторы не могут сменить ее статус
+	StatusTakenDown Status = "taken_down"
 )
 
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusDraft, StatusInReview, StatusPublished, StatusUnlisted, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// Listed сообщает, показывается ли запись в поиске и списках
+func (s Status) Listed() bool {
+	return s == StatusPublished
+}
+
+// Public сообщает, открывается ли запись по прямой ссылке
+func (s Status) Public() bool {
+	return s == StatusPublished || s == StatusUnlisted
+}
+
 // Артист
 type Artist struct {
 	ID            uint   `gorm:"primaryKey"`
 	Name          string `gorm:"index;not null"`
+	Status        Status `gorm:"type:varchar(20);not null;default:'published';index"` // Записи до появления статусов считаются опубликованными
 	Description   string
 	FormationYear time.Time
 	Albums        []Album `gorm:"foreignKey:ArtistID"`
 	UserID        uint    `gorm:"index;not null"`
+	Followers     int64   `gorm:"not null;default:0;<-:false"` // Счетчик подписок, меняет только FollowRepository
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"` // Запись в корзине, см. TrashItem
+}
+
+type AlbumType string
+
+const (
+	AlbumSingle      AlbumType = "single"
+	AlbumEP          AlbumType = "ep"
+	AlbumLP          AlbumType = "lp"
+	AlbumCompilation AlbumType = "compilation"
+	AlbumLive        AlbumType = "live"
+)
+
+func (t AlbumType) IsValid() bool {
+	switch t {
+	case AlbumSingle, AlbumEP, AlbumLP, AlbumCompilation, AlbumLive:
+		return true
+	}
+	return false
 }
 
 // Альбом
 type Album struct {
-	ID          uint   `gorm:"primaryKey"`
-	Title       string `gorm:"index"`
-	ArtistID    uint   `gorm:"index"`
-	Songs       []Song `gorm:"foreignKey:AlbumID"`
-	ReleaseDate time.Time
-	CoverArtURL string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID          uint        `gorm:"primaryKey"`
+	Title       string      `gorm:"index"`
+	ArtistID    uint        `gorm:"index"`
+	Type        AlbumType   `gorm:"type:varchar(20);not null;default:'lp'"`
+	Status      Status      `gorm:"type:varchar(20);not null;default:'published';index"`
+	UPC         string      `gorm:"type:varchar(13);uniqueIndex:idx_album_upc_active,where:upc <> '' AND deleted_at IS NULL"` // EAN-13, UPC-A хранится с ведущим нулем
+	Songs       []Song      `gorm:"foreignKey:AlbumID"`
+	Discs       []AlbumDisc `gorm:"foreignKey:AlbumID;constraint:OnDelete:CASCADE"`
+	ReleaseDate time.Time   `gorm:"index"` // До этого момента альбом и его песни видны только редакторам
+	// Дата релиза еще не наступила, событие о выходе не отправлено
+	ReleasePending bool `gorm:"not null;default:false;index"`
+	CoverArtURL    string
+	CoverArtKey    string // Набор миниатюр загруженной обложки, см. imaging.ThumbnailKey
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+// Embargoed сообщает, что альбом еще не вышел
+func (a *Album) Embargoed(now time.Time) bool {
+	return a.ReleaseDate.After(now)
+}
+
+// Название диска многодискового издания, например "Live at Wembley"
+type AlbumDisc struct {
+	ID      uint   `gorm:"primaryKey"`
+	AlbumID uint   `gorm:"uniqueIndex:idx_album_disc;not null"`
+	Number  int    `gorm:"uniqueIndex:idx_album_disc;not null"`
+	Title   string `gorm:"not null"`
 }
 
 // Песня
 type Song struct {
-	ID         uint        `gorm:"primaryKey"`
-	Title      string      `gorm:"index"`
-	ArtistID   uint        `gorm:"index"`
-	AlbumID    uint        `gorm:"index"`
-	SongGenres []SongGenre `gorm:"foreignKey:SongID"`
-	Duration   int
-	FilePath   string
-	Lyrics     Lyrics `gorm:"foreignKey:SongID;references:ID;constraint:OnDelete:CASCADE"`
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-}
-
-// Текст песни
+	ID          uint        `gorm:"primaryKey"`
+	Title       string      `gorm:"index"`
+	ISRC        string      `gorm:"type:varchar(12);uniqueIndex:idx_song_isrc_active,where:isrc <> '' AND deleted_at IS NULL"` // Без дефисов
+	Status      Status      `gorm:"type:varchar(20);not null;default:'published';index"`
+	ArtistID    uint        `gorm:"index"`
+	AlbumID     uint        `gorm:"index;uniqueIndex:idx_song_track_active,where:track_number > 0 AND deleted_at IS NULL"`
+	DiscNumber  int         `gorm:"uniqueIndex:idx_song_track_active,where:track_number > 0 AND deleted_at IS NULL;not null;default:1"`
+	TrackNumber int         `gorm:"uniqueIndex:idx_song_track_active,where:track_number > 0 AND deleted_at IS NULL;not null;default:0"` // Уникален на диске альбома, 0 - без номера
+	SongGenres  []SongGenre `gorm:"foreignKey:SongID"`
+	Duration    int
+	FilePath    string // Ключ аудиофайла в хранилище
+	FileSize    int64
+	FileHash    string       `gorm:"type:varchar(64);index"` // SHA-256 содержимого в hex
+	AudioHash   string       `gorm:"type:varchar(64);index"` // SHA-256 декодированного звука, пустой для Ogg и MP4
+	MimeType    string       `gorm:"type:varchar(50)"`
+	Private     bool         `gorm:"not null;default:false"` // Видят и слушают только редакторы и пользователи с правом view
+	Lyrics      []Lyrics     `gorm:"foreignKey:SongID;references:ID;constraint:OnDelete:CASCADE"`
+	Credits     []SongCredit `gorm:"foreignKey:SongID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+type CreditRole string
+
+const (
+	CreditPrimary  CreditRole = "primary"
+	CreditFeatured CreditRole = "featured"
+	CreditComposer CreditRole = "composer"
+	CreditLyricist CreditRole = "lyricist"
+	CreditProducer CreditRole = "producer"
+	CreditRemixer  CreditRole = "remixer"
+)
+
+func (r CreditRole) IsValid() bool {
+	switch r {
+	case CreditPrimary, CreditFeatured, CreditComposer, CreditLyricist, CreditProducer, CreditRemixer:
+		return true
+	}
+	return false
+}
+
+// Участник песни: артист из каталога или человек, указанный только по имени
+type SongCredit struct {
+	ID       uint       `gorm:"primaryKey"`
+	SongID   uint       `gorm:"index;not null"`
+	ArtistID *uint      `gorm:"index"` // nil, если участника нет в каталоге
+	Artist   *Artist    `gorm:"foreignKey:ArtistID"`
+	Name     string     // Имя участника не из каталога
+	Role     CreditRole `gorm:"type:varchar(20);not null"`
+	Position int        `gorm:"not null;default:0"` // Порядок в списке участников
+}
+
+type LyricsKind string
+
+const (
+	LyricsOriginal        LyricsKind = "original"
+	LyricsTranslation     LyricsKind = "translation"
+	LyricsTransliteration LyricsKind = "transliteration"
+)
+
+func (k LyricsKind) IsValid() bool {
+	switch k {
+	case LyricsOriginal, LyricsTranslation, LyricsTransliteration:
+		return true
+	}
+	return false
+}
+
+// Версия текста песни: оригинал, перевод или транслитерация
 type Lyrics struct {
-	SongID    uint      `gorm:"primaryKey;autoIncrement:false"`
-	Couplets  []Couplet `gorm:"foreignKey:LyricsID;constraint:OnDelete:CASCADE"`
+	ID        uint       `gorm:"primaryKey"`
+	SongID    uint       `gorm:"uniqueIndex:idx_lyrics_version;not null"`
+	Language  string     `gorm:"uniqueIndex:idx_lyrics_version;type:varchar(35);not null;default:'und'"` // Тег BCP-47
+	Kind      LyricsKind `gorm:"uniqueIndex:idx_lyrics_version;type:varchar(20);not null;default:'original'"`
+	Couplets  []Couplet  `gorm:"foreignKey:LyricsID;constraint:OnDelete:CASCADE"`
+	OffsetMs  int64      // Сдвиг синхронизации из заголовка [offset:] LRC
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+type RevisionAction string
+
+const (
+	RevisionEdit     RevisionAction = "edit"
+	RevisionDelete   RevisionAction = "delete"
+	RevisionRollback RevisionAction = "rollback"
+)
+
+// Ревизия версии текста: снимок куплетов после каждого изменения
+type LyricsRevision struct {
+	ID               uint           `gorm:"primaryKey"`
+	SongID           uint           `gorm:"index:idx_lyrics_revision;not null"`
+	Language         string         `gorm:"index:idx_lyrics_revision;type:varchar(35);not null"`
+	Kind             LyricsKind     `gorm:"index:idx_lyrics_revision;type:varchar(20);not null"`
+	AuthorID         uint           `gorm:"index"`
+	Action           RevisionAction `gorm:"type:varchar(20);not null"`
+	SourceRevisionID *uint          // Ревизия, к которой был сделан откат
+	OffsetMs         int64
+	Snapshot         string `gorm:"type:jsonb;not null"` // Куплеты в JSON
+	Author           string `gorm:"->;-:migration"`      // Имя автора, читается из users
+	CreatedAt        time.Time
+}
+
+// NewLyricsRevision делает снимок текущего состояния версии текста
+func NewLyricsRevision(lyrics *Lyrics, authorID uint, action RevisionAction) (*LyricsRevision, error) {
+	couplets := make([]Couplet, 0, len(lyrics.Couplets))
+	if action != RevisionDelete {
+		for _, c := range lyrics.Couplets {
+			c.ID, c.LyricsID = 0, 0
+			words := make([]CoupletWord, 0, len(c.Words))
+			for _, w := range c.Words {
+				w.ID, w.CoupletID = 0, 0
+				words = append(words, w)
+			}
+			c.Words = words
+			couplets = append(couplets, c)
+		}
+	}
+
+	snapshot, err := json.Marshal(couplets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LyricsRevision{
+		SongID:   lyrics.SongID,
+		Language: lyrics.Language,
+		Kind:     lyrics.Kind,
+		AuthorID: authorID,
+		Action:   action,
+		OffsetMs: lyrics.OffsetMs,
+		Snapshot: string(snapshot),
+	}, nil
+}
+
+// Couplets восстанавливает куплеты из снимка
+func (r *LyricsRevision) Couplets() ([]Couplet, error) {
+	var couplets []Couplet
+	if err := json.Unmarshal([]byte(r.Snapshot), &couplets); err != nil {
+		return nil, err
+	}
+	return couplets, nil
+}
+
 // Куплеты песни
 type Couplet struct {
 	ID       uint `gorm:"primaryKey"`
 	LyricsID uint `gorm:"index"`
 	Number   uint
 	Text     string
+	StartMs  *int64        // Время начала строки, nil для несинхронизированного текста
+	Words    []CoupletWord `gorm:"foreignKey:CoupletID;constraint:OnDelete:CASCADE"`
+}
+
+// Слово куплета с меткой времени (enhanced LRC)
+type CoupletWord struct {
+	ID        uint `gorm:"primaryKey"`
+	CoupletID uint `gorm:"index"`
+	Position  uint
+	StartMs   int64
+	Text      string
+}
+
+// IsSynced сообщает, что у каждой строки текста есть метка времени
+func (l *Lyrics) IsSynced() bool {
+	if len(l.Couplets) == 0 {
+		return false
+	}
+	for _, c := range l.Couplets {
+		if c.StartMs == nil {
+			return false
+		}
+	}
+	return true
 }
 
 // Жанр
 type Genre struct {
-	ID         uint        `gorm:"primaryKey"`
-	Name       string      `gorm:"uniqueIndex"`
-	SongGenres []SongGenre `gorm:"foreignKey:GenreID"` // Связь один-ко-многим
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"uniqueIndex:idx_genre_name_active,where:deleted_at IS NULL"`
+	Description string
+	ParentID    *uint          `gorm:"index"` // nil у корневого жанра
+	Children    []Genre        `gorm:"foreignKey:ParentID"`
+	Aliases     []GenreAlias   `gorm:"foreignKey:GenreID;constraint:OnDelete:CASCADE"`
+	SongGenres  []SongGenre    `gorm:"foreignKey:GenreID"` // Связь один-ко-многим
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+// Другое написание жанра, например "hip hop" для "Hip-Hop".
+// Имена и синонимы сравниваются без учета регистра, пробелов и знаков препинания.
+type GenreAlias struct {
+	ID      uint   `gorm:"primaryKey"`
+	GenreID uint   `gorm:"index;not null"`
+	Name    string `gorm:"not null"`
+}
+
+// Жанр с поджанрами
+type GenreTree struct {
+	Genre
+	Subgenres []GenreTree
 }
 
 // Промежуточная таблица
@@ -9,6 +9,10 @@ const (
 	SongResource   Resource = "song"
 	AlbumResource  Resource = "album"
 	ArtistResource Resource = "artist"
+	GenreResource  Resource = "genre" // Для журнала изменений и жалоб, права на жанры не выдаются
+	// Только для жалоб
+	ReviewResource  Resource = "review"
+	CommentResource Resource = "comment"
 
 	EditPermission Permission = "edit"
 	ViewPermission Permission = "view"
@@ -7,11 +7,48 @@ type Profile struct {
 	UserID      uint         `gorm:"primaryKey" json:"user_id"`
 	Bio         string       `json:"bio"`
 	AvatarURL   string       `json:"avatar_url"`
+	AvatarKey   string       `json:"-"` // Набор миниатюр загруженного аватара
 	Favorites   []Favorite   `gorm:"foreignKey:ProfileID"`
 	Collections []Collection `gorm:"foreignKey:ProfileID"`
 	History     []History    `gorm:"foreignKey:ProfileID"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// Кто видит разделы публичной страницы /users/:name
+	BioVisibility       Visibility `gorm:"type:varchar(20);not null;default:'public'" json:"bio_visibility"`
+	AvatarVisibility    Visibility `gorm:"type:varchar(20);not null;default:'public'" json:"avatar_visibility"`
+	FavoritesVisibility Visibility `gorm:"type:varchar(20);not null;default:'private'" json:"favorites_visibility"`
+	HistoryVisibility   Visibility `gorm:"type:varchar(20);not null;default:'private'" json:"history_visibility"` // Последние прослушивания и топ артистов
+	PrivateSession      bool       `gorm:"not null;default:false" json:"private_session"`                         // Прослушивания не попадают в историю
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// Кто видит раздел профиля или коллекцию
+type Visibility string
+
+const (
+	VisibilityPublic    Visibility = "public"
+	VisibilityFollowers Visibility = "followers" // Владелец и его подписчики
+	VisibilityPrivate   Visibility = "private"   // Только владелец
+)
+
+func (v Visibility) IsValid() bool {
+	switch v {
+	case VisibilityPublic, VisibilityFollowers, VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+// Visible сообщает, видит ли раздел другой пользователь. follower - он подписан на владельца.
+func (v Visibility) Visible(follower bool) bool {
+	return v == VisibilityPublic || v == VisibilityFollowers && follower
+}
+
+// VisibleLevels возвращает уровни видимости, открытые другому пользователю
+func VisibleLevels(follower bool) []Visibility {
+	if follower {
+		return []Visibility{VisibilityPublic, VisibilityFollowers}
+	}
+	return []Visibility{VisibilityPublic}
 }
 
 // Избранное
@@ -20,6 +57,7 @@ type Favorite struct {
 	ProfileID  uint   `gorm:"index;not null"`
 	ObjectType string `gorm:"not null"` // song, artist, album
 	ObjectID   uint   `gorm:"not null"`
+	Title      string `gorm:"->;-:migration"` // Название записи, читается из каталога
 	CreatedAt  time.Time
 }
 
@@ -29,7 +67,9 @@ type Collection struct {
 	ProfileID   uint   `gorm:"index;not null"`
 	Name        string `gorm:"not null"`
 	Description string
+	Visibility  Visibility       `gorm:"type:varchar(20);not null;default:'private'"`
 	Items       []CollectionItem `gorm:"foreignKey:CollectionID"`
+	Tracks      int64            `gorm:"->;-:migration"` // Число песен, читается подзапросом
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -47,4 +87,26 @@ type History struct {
 	ProfileID uint      `gorm:"index;not null"`
 	SongID    uint      `gorm:"index;not null"`
 	PlayedAt  time.Time `gorm:"index"`
+	Title     string    `gorm:"->;-:migration"` // Название песни, читается из каталога
+}
+
+// Артист из истории прослушиваний с числом прослушанных песен
+type TopArtist struct {
+	ArtistID uint
+	Name     string
+	Plays    int64
+}
+
+// Публичная страница пользователя. Разделы, которые владелец скрыл, пусты и перечислены в Hidden.
+type PublicProfile struct {
+	User       *User
+	Profile    *Profile // nil, если пользователь не заводил профиль
+	Playlists  []Collection
+	Favorites  []Favorite
+	Plays      []History
+	TopArtists []TopArtist
+	Followers  int64
+	Following  int64
+	Followed   bool // Текущий пользователь подписан на владельца страницы
+	Hidden     []string
 }
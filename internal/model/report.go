@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// Причина жалобы
+type ReportReason string
+
+const (
+	ReasonSpam          ReportReason = "spam"
+	ReasonAbuse         ReportReason = "abuse" // Оскорбления, травля
+	ReasonCopyright     ReportReason = "copyright"
+	ReasonInappropriate ReportReason = "inappropriate"
+	ReasonMisleading    ReportReason = "misleading" // Чужое имя, ложные сведения о записи
+	ReasonOther         ReportReason = "other"
+)
+
+func (r ReportReason) IsValid() bool {
+	switch r {
+	case ReasonSpam, ReasonAbuse, ReasonCopyright, ReasonInappropriate, ReasonMisleading, ReasonOther:
+		return true
+	}
+	return false
+}
+
+// Состояние жалобы в очереди модерации
+type ReportState string
+
+const (
+	ReportOpen      ReportState = "open"
+	ReportActioned  ReportState = "actioned" // Модератор принял меры
+	ReportDismissed ReportState = "dismissed"
+)
+
+// Жалоба пользователя на запись каталога, рецензию или комментарий.
+// У пользователя может быть одна открытая жалоба на запись.
+type Report struct {
+	ID           uint         `gorm:"primaryKey"`
+	ReporterID   uint         `gorm:"index;uniqueIndex:idx_report_open,where:state = 'open';not null"`
+	ResourceType Resource     `gorm:"type:varchar(20);index:idx_report_target;uniqueIndex:idx_report_open,where:state = 'open';not null"`
+	ResourceID   uint         `gorm:"index:idx_report_target;uniqueIndex:idx_report_open,where:state = 'open';not null"`
+	Reason       ReportReason `gorm:"type:varchar(20);not null"`
+	Details      string       `gorm:"type:text"`
+	State        ReportState  `gorm:"type:varchar(20);not null;default:'open';index"`
+	ResolvedBy   uint
+	ResolvedAt   *time.Time
+	Note         string // Пояснение модератора, уходит владельцу вместе с уведомлением
+	TakenDown    bool   // Запись скрыта по этой жалобе
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Условия выборки очереди, нулевые поля не ограничивают выборку
+type ReportFilter struct {
+	State        ReportState
+	ResourceType Resource
+	ResourceID   uint
+}
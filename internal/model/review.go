@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// Оценка песни или альбома пользователем, по одной на запись
+type Rating struct {
+	ID           uint     `gorm:"primaryKey"`
+	UserID       uint     `gorm:"uniqueIndex:idx_rating;index;not null"`
+	ResourceType Resource `gorm:"type:varchar(20);uniqueIndex:idx_rating;index:idx_rating_target;not null"`
+	ResourceID   uint     `gorm:"uniqueIndex:idx_rating;index:idx_rating_target;not null"`
+	Stars        int      `gorm:"not null"` // От 1 до 5
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Сводка оценок записи
+type RatingSummary struct {
+	Count     int64
+	Average   float64
+	Histogram [5]int64 // Histogram[i] - число оценок в i+1 звезд
+	Own       int      // Оценка текущего пользователя, 0 - не оценивал
+}
+
+// Рецензия пользователя на песню или альбом, по одной на запись.
+// Скрытую модератором рецензию видит только ее автор.
+type Review struct {
+	ID           uint     `gorm:"primaryKey"`
+	UserID       uint     `gorm:"uniqueIndex:idx_review;index;not null"`
+	ResourceType Resource `gorm:"type:varchar(20);uniqueIndex:idx_review;index:idx_review_target;not null"`
+	ResourceID   uint     `gorm:"uniqueIndex:idx_review;index:idx_review_target;not null"`
+	Text         string   `gorm:"type:text;not null"`
+	HiddenAt     *time.Time
+	HiddenBy     uint
+	Stars        int    `gorm:"->;-:migration"` // Оценка автора, читается из ratings
+	Author       string `gorm:"->;-:migration"` // Имя автора, читается из users
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Add учитывает count оценок в stars звезд
+func (s *RatingSummary) Add(stars int, count int64) {
+	if stars < 1 || stars > len(s.Histogram) || count <= 0 {
+		return
+	}
+	s.Histogram[stars-1] += count
+	s.Average = (s.Average*float64(s.Count) + float64(stars)*float64(count)) / float64(s.Count+count)
+	s.Count += count
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Метка, которую слушатели ставят песням и альбомам: настроение или ситуация ("workout", "rainy day").
+// В отличие от жанров метки не курируются, их вес определяют голоса.
+type Tag struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(40);uniqueIndex;not null"` // Нормализованное имя, см. tagname.Normalize
+	CreatedAt time.Time
+}
+
+// Голос пользователя за метку песни или альбома: 1 - метка подходит, -1 - не подходит
+type TagVote struct {
+	ID           uint     `gorm:"primaryKey"`
+	TagID        uint     `gorm:"uniqueIndex:idx_tag_vote;not null"`
+	ResourceType Resource `gorm:"type:varchar(20);uniqueIndex:idx_tag_vote;index:idx_tag_vote_target;not null"`
+	ResourceID   uint     `gorm:"uniqueIndex:idx_tag_vote;index:idx_tag_vote_target;not null"`
+	UserID       uint     `gorm:"uniqueIndex:idx_tag_vote;index;not null"`
+	Value        int      `gorm:"not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Метка с суммой голосов
+type TagWeight struct {
+	Name   string
+	Weight int64 // Сумма голосов записи или число записей с меткой для облака всего каталога
+	Vote   int   // Голос текущего пользователя, 0 - не голосовал
+}
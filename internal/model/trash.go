@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Запись в корзине. Удаленные артисты, альбомы и песни скрыты отовсюду, пока их не восстановят,
+// а по истечении срока хранения удаляются окончательно вместе с избранным, историей и элементами коллекций.
+type TrashItem struct {
+	Resource  Resource
+	ID        uint
+	Title     string
+	ParentID  uint // Альбом песни, артист альбома или родитель жанра
+	UserID    uint // Владелец артиста
+	DeletedAt time.Time
+	PurgeAt   time.Time `gorm:"-"` // Когда запись будет удалена окончательно
+}
+
+// Записи, удаленные окончательно при очистке корзины. Их файлы нужно убрать из хранилища.
+type PurgedEntries struct {
+	Artists int64
+	Albums  []Album
+	Songs   []Song
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+type UploadStatus string
+
+const (
+	UploadPending  UploadStatus = "pending"  // Части файла еще загружаются
+	UploadStaged   UploadStatus = "staged"   // Файл загружен до создания песни и ждет подтверждения тегов
+	UploadComplete UploadStatus = "complete" // Файл собран и привязан к песне
+	UploadReplaced UploadStatus = "replaced" // Файл песни заменен более новой загрузкой
+	UploadAborted  UploadStatus = "aborted"
+)
+
+// Загрузка аудиофайла песни. Возобновляемая загрузка принимает файл частями,
+// Offset - сколько байт уже получено.
+type Upload struct {
+	ID          string         `gorm:"primaryKey;type:uuid"`
+	UserID      uint           `gorm:"index;not null"`
+	SongID      uint           `gorm:"index;not null"` // 0, пока файл не привязан к песне
+	Size        int64          `gorm:"not null"`       // Объявленный размер файла
+	Offset      int64          `gorm:"not null;default:0"`
+	Parts       int            `gorm:"not null;default:0"`
+	StorageKey  string         // Ключ итогового файла в хранилище
+	MimeType    string         `gorm:"type:varchar(50)"`
+	Hash        string         `gorm:"type:varchar(64)"` // SHA-256 в hex
+	AudioHash   string         `gorm:"type:varchar(64)"` // SHA-256 декодированного звука
+	DuplicateOf uint           // Песня с тем же файлом или звуком, найденная при загрузке
+	Status      UploadStatus   `gorm:"type:varchar(20);index;not null"`
+	Metadata    *AudioMetadata `gorm:"serializer:json;type:jsonb"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Теги и параметры аудиофайла, извлеченные при загрузке.
+// Обложка файла без песни хранится отдельно до создания песни.
+type AudioMetadata struct {
+	Title          string `json:"title,omitempty"`
+	Artist         string `json:"artist,omitempty"`
+	Album          string `json:"album,omitempty"`
+	AlbumArtist    string `json:"album_artist,omitempty"`
+	Genre          string `json:"genre,omitempty"`
+	Year           int    `json:"year,omitempty"`
+	TrackNumber    int    `json:"track_number,omitempty"`
+	TrackTotal     int    `json:"track_total,omitempty"`
+	DiscNumber     int    `json:"disc_number,omitempty"`
+	DiscTotal      int    `json:"disc_total,omitempty"`
+	DurationMs     int64  `json:"duration_ms,omitempty"`
+	SampleRate     int    `json:"sample_rate,omitempty"`
+	Channels       int    `json:"channels,omitempty"`
+	Bitrate        int    `json:"bitrate,omitempty"` // кбит/с
+	Lyrics         string `json:"lyrics,omitempty"`
+	LyricsLanguage string `json:"lyrics_language,omitempty"`
+	HasCover       bool   `json:"has_cover,omitempty"`
+	CoverMimeType  string `json:"cover_mime_type,omitempty"` // Определен по содержимому обложки
+}
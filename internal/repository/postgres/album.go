@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"music-lib/internal/model"
 	"music-lib/pkg/db"
+	"music-lib/pkg/identifier"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -33,12 +35,20 @@ func (r *AlbumRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&model.Album{}, id).Error
 }
 
-func (r *AlbumRepository) Search(ctx context.Context, query string, limit, offset int) ([]model.Album, int64, error) {
+func (r *AlbumRepository) Search(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Album, int64, error) {
 	var albums []model.Album
-	db := r.db.WithContext(ctx).Model(&model.Album{})
+	db := r.db.WithContext(ctx).Model(&model.Album{}).Scopes(listedAlbums(viewerID))
 
 	if query != "" {
-		db = db.Where("LOWER(title) LIKE LOWER(?)", "%"+query+"%")
+		// Запрос, похожий на UPC или EAN, ищет еще и по коду
+		if upc, err := identifier.NormalizeUPC(query); err == nil {
+			db = db.Where("LOWER(albums.title) LIKE LOWER(?) OR albums.upc = ?", "%"+query+"%", upc)
+		} else {
+			db = db.Where("LOWER(albums.title) LIKE LOWER(?)", "%"+query+"%")
+		}
+	}
+	if len(tags) > 0 {
+		db = db.Where("albums.id IN (?)", taggedIDs(db, model.AlbumResource, tags))
 	}
 
 	var total int64
@@ -47,7 +57,7 @@ func (r *AlbumRepository) Search(ctx context.Context, query string, limit, offse
 	}
 
 	err := db.Limit(limit).
-		Preload("Songs").
+		Preload("Songs", albumSongs(viewerID)).
 		Offset(offset).
 		Order("title ASC").
 		Find(&albums).Error
@@ -68,12 +78,30 @@ func (r *AlbumRepository) GetByID(ctx context.Context, id uint) (*model.Album, e
 }
 
 
-func (r *AlbumRepository) GetWithSongs(ctx context.Context, id uint) (*model.Album, error) {
+func (r *AlbumRepository) GetByUPC(ctx context.Context, upc string) (*model.Album, error) {
+	var album *model.Album
+	err := r.db.WithContext(ctx).
+		Where("upc = ?", upc).
+		First(&album).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return album, nil
+}
+
+// GetWithSongs возвращает альбом с песнями, которые видит пользователь viewerID
+func (r *AlbumRepository) GetWithSongs(ctx context.Context, id, viewerID uint) (*model.Album, error) {
 	var album *model.Album
 	err := r.db.WithContext(ctx).
-		Preload("Songs", func(db *db.Db) *gorm.DB {
-			return db.DB.Order("created_at DESC")
-		}(r.db)).
+		Preload("Songs", func(db *gorm.DB) *gorm.DB {
+			// Песни без номера идут в конце диска
+			return db.Scopes(albumSongs(viewerID)).
+				Order("disc_number ASC, track_number = 0, track_number ASC, created_at ASC")
+		}).
+		Preload("Discs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("album_discs.number ASC")
+		}).
 		First(&album, id).Error
 
 	if err != nil {
@@ -81,4 +109,39 @@ func (r *AlbumRepository) GetWithSongs(ctx context.Context, id uint) (*model.Alb
 	}
 
 	return album, nil
-}
\ No newline at end of file
+}
+
+// ReplaceDiscs заменяет названия дисков альбома
+func (r *AlbumRepository) ReplaceDiscs(ctx context.Context, albumID uint, discs []model.AlbumDisc) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("album_id = ?", albumID).Delete(&model.AlbumDisc{}).Error; err != nil {
+			return err
+		}
+		if len(discs) == 0 {
+			return nil
+		}
+		for i := range discs {
+			discs[i].AlbumID = albumID
+		}
+		return tx.Create(&discs).Error
+	})
+}
+
+// GetDueReleases возвращает опубликованные альбомы, дата релиза которых наступила, а событие о выходе еще не отправлено
+func (r *AlbumRepository) GetDueReleases(ctx context.Context, now time.Time) ([]model.Album, error) {
+	var albums []model.Album
+	err := r.db.WithContext(ctx).
+		Where("release_pending AND release_date <= ? AND status = ?", now, model.StatusPublished).
+		Order("release_date ASC").
+		Find(&albums).Error
+	return albums, err
+}
+
+// MarkReleased снимает признак ожидания релиза. false, если альбом уже отметил другой процесс.
+func (r *AlbumRepository) MarkReleased(ctx context.Context, id uint) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.Album{}).
+		Where("id = ? AND release_pending", id).
+		Update("release_pending", false)
+	return res.RowsAffected > 0, res.Error
+}
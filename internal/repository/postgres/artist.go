@@ -37,12 +37,16 @@ func (r *ArtistRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&model.Artist{}, id).Error
 }
 
-func (r *ArtistRepository) Search(ctx context.Context, query string, limit, offset int) ([]model.Artist, int64, error) {
+func (r *ArtistRepository) Search(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Artist, int64, error) {
 	var artists []model.Artist
-	db := r.db.WithContext(ctx).Model(&model.Artist{})
+	// Артистов не помечают, под фильтр по меткам не подходит никто
+	if len(tags) > 0 {
+		return artists, 0, nil
+	}
+	db := r.db.WithContext(ctx).Model(&model.Artist{}).Scopes(listedArtists(viewerID))
 
 	if query != "" {
-		db = db.Where("LOWER(name) LIKE LOWER(?)", "%"+query+"%")
+		db = db.Where("LOWER(artists.name) LIKE LOWER(?)", "%"+query+"%")
 	}
 
 	var total int64
@@ -84,12 +88,13 @@ func (r *ArtistRepository) GetByUserID(ctx context.Context, userID uint) (*model
 	return artist, nil
 }
 
-func (r *ArtistRepository) GetWithAlbums(ctx context.Context, id uint) (*model.Artist,  error) {
+// GetWithAlbums возвращает артиста с альбомами, которые видит пользователь viewerID
+func (r *ArtistRepository) GetWithAlbums(ctx context.Context, id, viewerID uint) (*model.Artist, error) {
 	var artist *model.Artist
 	err := r.db.WithContext(ctx).
-		Preload("Albums", func(db *db.Db) *gorm.DB {
-			return db.DB.Order("release_date DESC")
-		}(r.db)).
+		Preload("Albums", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(listedAlbums(viewerID)).Order("release_date DESC")
+		}).
 		First(&artist, id).Error
 
 	if err != nil {
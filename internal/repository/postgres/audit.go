@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+)
+
+type AuditRepository struct {
+	db *db.Db
+}
+
+func NewAuditRepository(db *db.Db) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *model.AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// Find возвращает записи журнала, начиная с последних
+func (r *AuditRepository) Find(ctx context.Context, filter model.AuditFilter, limit, offset int) ([]model.AuditEntry, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.AuditEntry{})
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		db = db.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID != 0 {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.EditorID != 0 {
+		db = db.Where("(entity_type = ? AND entity_id IN (?)) OR (entity_type = ? AND entity_id IN (?)) OR (entity_type = ? AND entity_id IN (?))",
+			model.ArtistResource, editableIDs(db, filter.EditorID, model.ArtistResource),
+			model.AlbumResource, editableIDs(db, filter.EditorID, model.AlbumResource),
+			model.SongResource, editableIDs(db, filter.EditorID, model.SongResource),
+		)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("error counting audit entries: %w", err)
+	}
+
+	var entries []model.AuditEntry
+	err := db.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error
+
+	return entries, total, err
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommentRepository struct {
+	db *db.Db
+}
+
+func NewCommentRepository(db *db.Db) *CommentRepository {
+	return &CommentRepository{
+		db: db,
+	}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+// GetByID возвращает неудаленный комментарий с автором, числом ответов и отметкой viewerID
+func (r *CommentRepository) GetByID(ctx context.Context, id, viewerID uint) (*model.Comment, error) {
+	var comment model.Comment
+	err := r.db.WithContext(ctx).
+		Scopes(commentDetails(viewerID)).
+		First(&comment, "comments.id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) UpdateText(ctx context.Context, id uint, text string, editedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Comment{ID: id}).
+		Updates(map[string]any{"text": text, "edited_at": editedAt}).Error
+}
+
+// Delete помечает комментарий удаленным, ответы на него остаются
+func (r *CommentRepository) Delete(ctx context.Context, id, deletedBy uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Comment{ID: id}).UpdateColumn("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Comment{}, id).Error
+	})
+}
+
+// GetThread возвращает комментарии верхнего уровня (parentID 0) или ответы на parentID.
+// Удаленные комментарии попадают в выборку, только если у них есть живые ответы.
+func (r *CommentRepository) GetThread(ctx context.Context, resource model.Resource, resourceID, parentID, viewerID uint, sort model.CommentSort, limit, offset int) ([]model.Comment, int64, error) {
+	db := r.db.WithContext(ctx)
+	replied := newQuery(db).Table("comments AS replies").Select("1").
+		Where("replies.parent_id = comments.id AND replies.deleted_at IS NULL")
+
+	query := db.Unscoped().
+		Model(&model.Comment{}).
+		Where("comments.resource_type = ? AND comments.resource_id = ?", resource, resourceID).
+		Where("comments.deleted_at IS NULL OR EXISTS (?)", replied)
+	if parentID == 0 {
+		query = query.Where("comments.parent_id IS NULL")
+	} else {
+		query = query.Where("comments.parent_id = ?", parentID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "comments.created_at DESC, comments.id DESC"
+	switch sort {
+	case model.CommentsOldest:
+		order = "comments.created_at ASC, comments.id ASC"
+	case model.CommentsTop:
+		order = "comments.likes DESC, comments.created_at DESC, comments.id DESC"
+	}
+
+	var comments []model.Comment
+	err := query.Scopes(commentDetails(viewerID)).
+		Order(order).
+		Limit(limit).
+		Offset(offset).
+		Find(&comments).Error
+	return comments, total, err
+}
+
+// Like отмечает комментарий, повторная отметка ничего не меняет
+func (r *CommentRepository) Like(ctx context.Context, id, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.CommentLike{CommentID: id, UserID: userID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&model.Comment{ID: id}).UpdateColumn("likes", gorm.Expr("likes + 1")).Error
+	})
+}
+
+// Unlike снимает отметку, если она была
+func (r *CommentRepository) Unlike(ctx context.Context, id, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("comment_id = ? AND user_id = ?", id, userID).Delete(&model.CommentLike{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&model.Comment{ID: id}).UpdateColumn("likes", gorm.Expr("likes - 1")).Error
+	})
+}
+
+// commentDetails добавляет к комментарию имя автора, число живых ответов и отметку viewerID
+func commentDetails(viewerID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Select("comments.*, COALESCE(users.name, '') AS author, "+
+				"EXISTS (SELECT 1 FROM comment_likes WHERE comment_likes.comment_id = comments.id AND comment_likes.user_id = ?) AS liked, "+
+				"(SELECT COUNT(*) FROM comments AS replies WHERE replies.parent_id = comments.id AND replies.deleted_at IS NULL) AS replies", viewerID).
+			Joins("LEFT JOIN users ON users.id = comments.user_id")
+	}
+}
+
+// deleteComments окончательно удаляет комментарии записей вместе с отметками
+func deleteComments(tx *gorm.DB, resource model.Resource, ids []uint) error {
+	comments := newQuery(tx).Unscoped().Model(&model.Comment{}).Select("id").
+		Where("resource_type = ? AND resource_id IN ?", resource, ids)
+	if err := tx.Where("comment_id IN (?)", comments).Delete(&model.CommentLike{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("resource_type = ? AND resource_id IN ?", resource, ids).Delete(&model.Comment{}).Error
+}
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Подписки на артистов и пользователей, лента новинок
+type FollowRepository struct {
+	db *db.Db
+}
+
+func NewFollowRepository(db *db.Db) *FollowRepository {
+	return &FollowRepository{
+		db: db,
+	}
+}
+
+// FollowArtist подписывает пользователя на артиста, повторная подписка ничего не меняет
+func (r *FollowRepository) FollowArtist(ctx context.Context, userID, artistID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.ArtistFollow{UserID: userID, ArtistID: artistID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Exec("UPDATE artists SET followers = followers + 1 WHERE id = ?", artistID).Error
+	})
+}
+
+// UnfollowArtist снимает подписку и убирает из ленты пользователя новинки артиста
+func (r *FollowRepository) UnfollowArtist(ctx context.Context, userID, artistID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND artist_id = ?", userID, artistID).Delete(&model.ArtistFollow{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Exec("UPDATE artists SET followers = followers - 1 WHERE id = ?", artistID).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND artist_id = ?", userID, artistID).Delete(&model.FeedItem{}).Error
+	})
+}
+
+func (r *FollowRepository) IsFollowing(ctx context.Context, userID, artistID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.ArtistFollow{}).
+		Where("user_id = ? AND artist_id = ?", userID, artistID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FollowUser подписывает followerID на followeeID, повторная подписка ничего не меняет
+func (r *FollowRepository) FollowUser(ctx context.Context, followerID, followeeID uint) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserFollow{FollowerID: followerID, FolloweeID: followeeID}).Error
+}
+
+func (r *FollowRepository) UnfollowUser(ctx context.Context, followerID, followeeID uint) error {
+	return r.db.WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&model.UserFollow{}).Error
+}
+
+func (r *FollowRepository) IsFollowingUser(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.UserFollow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountUserFollows возвращает число подписчиков пользователя и число его подписок
+func (r *FollowRepository) CountUserFollows(ctx context.Context, userID uint) (int64, int64, error) {
+	var counts struct {
+		Followers int64
+		Following int64
+	}
+	err := r.db.WithContext(ctx).Raw(
+		"SELECT (SELECT COUNT(*) FROM user_follows WHERE followee_id = ?) AS followers, "+
+			"(SELECT COUNT(*) FROM user_follows WHERE follower_id = ?) AS following",
+		userID, userID,
+	).Scan(&counts).Error
+	return counts.Followers, counts.Following, err
+}
+
+// GetFollowers возвращает подписчиков пользователя, сначала новые
+func (r *FollowRepository) GetFollowers(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error) {
+	return r.followEntries(ctx, "followee_id", "follower_id", userID, viewerID, limit, offset)
+}
+
+// GetFollowing возвращает пользователей, на которых подписан userID, сначала новые подписки
+func (r *FollowRepository) GetFollowing(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error) {
+	return r.followEntries(ctx, "follower_id", "followee_id", userID, viewerID, limit, offset)
+}
+
+// followEntries выбирает подписки, где column = userID, и пользователей из колонки other.
+// Аватар виден viewerID, если он открыт всем, его подписчикам при подписке или это сам viewerID.
+func (r *FollowRepository) followEntries(ctx context.Context, column, other string, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error) {
+	db := r.db.WithContext(ctx).
+		Model(&model.UserFollow{}).
+		Joins("JOIN users ON users.id = user_follows."+other+" AND users.deleted_at IS NULL").
+		Where("user_follows."+column+" = ?", userID)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	avatar := "profiles.avatar_visibility = ? OR users.id = ? OR " +
+		"(profiles.avatar_visibility = ? AND EXISTS (SELECT 1 FROM user_follows AS viewer_follows " +
+		"WHERE viewer_follows.follower_id = ? AND viewer_follows.followee_id = users.id))"
+	avatarArgs := []any{model.VisibilityPublic, viewerID, model.VisibilityFollowers, viewerID}
+
+	var entries []model.FollowEntry
+	err := db.
+		Select("users.id AS user_id, users.name, user_follows.created_at AS followed_at, "+
+			"CASE WHEN "+avatar+" THEN profiles.avatar_key ELSE '' END AS avatar_key, "+
+			"CASE WHEN "+avatar+" THEN profiles.avatar_url ELSE '' END AS avatar_url",
+			append(avatarArgs, avatarArgs...)...).
+		Joins("LEFT JOIN profiles ON profiles.user_id = users.id").
+		Order("user_follows.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&entries).Error
+	return entries, total, err
+}
+
+// AddToFeed добавляет запись в ленты всех подписчиков item.ArtistID и возвращает их число.
+// Повторное событие о той же записи ленту не меняет.
+func (r *FollowRepository) AddToFeed(ctx context.Context, item *model.FeedItem) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(
+		"INSERT INTO feed_items (user_id, resource_type, resource_id, artist_id, album_id, title, release_date, created_at) "+
+			"SELECT user_id, ?, ?, ?, ?, ?, ?, NOW() FROM artist_follows WHERE artist_id = ? "+
+			"ON CONFLICT (user_id, resource_type, resource_id) DO NOTHING",
+		item.ResourceType, item.ResourceID, item.ArtistID, item.AlbumID, item.Title, item.ReleaseDate, item.ArtistID,
+	)
+	return result.RowsAffected, result.Error
+}
+
+// GetFeed возвращает ленту пользователя от новых записей к старым. Записи, которые с тех пор
+// сняли с публикации или удалили, не показываются.
+func (r *FollowRepository) GetFeed(ctx context.Context, userID uint, limit, offset int) ([]model.FeedItem, int64, error) {
+	db := r.db.WithContext(ctx)
+	albums := newQuery(db).Model(&model.Album{}).Select("albums.id").Scopes(listedAlbums(userID))
+	songs := newQuery(db).Model(&model.Song{}).Select("songs.id").Scopes(listedSongs(userID))
+
+	query := db.Model(&model.FeedItem{}).
+		Where("user_id = ?", userID).
+		Where("(resource_type = ? AND resource_id IN (?)) OR (resource_type = ? AND resource_id IN (?))",
+			model.AlbumResource, albums, model.SongResource, songs)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []model.FeedItem
+	err := query.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&items).Error
+	return items, total, err
+}
+
+// repointFollows переносит подписчиков исходного артиста к целевому и пересчитывает счетчик
+func repointFollows(tx *gorm.DB, sourceID, targetID uint) error {
+	following := newQuery(tx).Model(&model.ArtistFollow{}).Select("user_id").Where("artist_id = ?", targetID)
+	if err := tx.Where("artist_id = ? AND user_id IN (?)", sourceID, following).Delete(&model.ArtistFollow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.ArtistFollow{}).Where("artist_id = ?", sourceID).Update("artist_id", targetID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.FeedItem{}).Where("artist_id = ?", sourceID).Update("artist_id", targetID).Error; err != nil {
+		return err
+	}
+	return tx.Exec("UPDATE artists SET followers = (SELECT COUNT(*) FROM artist_follows WHERE artist_id = ?) WHERE id = ?",
+		targetID, targetID).Error
+}
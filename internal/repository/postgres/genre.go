@@ -2,10 +2,19 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"music-lib/internal/model"
 	"music-lib/pkg/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// genreKey приводит имя жанра к виду для сравнения: "Hip-Hop" и "hip hop" совпадают
+func genreKey(column string) string {
+	return fmt.Sprintf("regexp_replace(lower(%s), '[^[:alnum:]]+', '', 'g')", column)
+}
+
 type GenreRepository struct {
 	db *db.Db
 }
@@ -21,8 +30,24 @@ func (r *GenreRepository) Create(ctx context.Context, entity *model.Genre) (*mod
 	return entity, err
 }
 
+// Update сохраняет жанр и заменяет его синонимы на entity.Aliases
 func (r *GenreRepository) Update(ctx context.Context, entity *model.Genre) (*model.Genre, error) {
-	err := r.db.WithContext(ctx).Save(entity).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(entity).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("genre_id = ?", entity.ID).Delete(&model.GenreAlias{}).Error; err != nil {
+			return err
+		}
+		if len(entity.Aliases) == 0 {
+			return nil
+		}
+		for i := range entity.Aliases {
+			entity.Aliases[i].ID = 0
+			entity.Aliases[i].GenreID = entity.ID
+		}
+		return tx.Create(&entity.Aliases).Error
+	})
 	return entity, err
 }
 
@@ -30,11 +55,12 @@ func (r *GenreRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&model.Genre{}, id).Error
 }
 
+// IsExists проверяет, занято ли имя другим жанром или его синонимом
 func (r *GenreRepository) IsExists(ctx context.Context, name string) bool {
 	var count int64
 	err := r.db.WithContext(ctx).
 		Model(&model.Genre{}).
-		Where("LOWER(name) = LOWER(?)", name).
+		Scopes(genreNamed(name)).
 		Limit(1).
 		Count(&count).
 		Error
@@ -50,6 +76,7 @@ func (r *GenreRepository) IsExists(ctx context.Context, name string) bool {
 func (r *GenreRepository) GetById(ctx context.Context, id uint) (*model.Genre, error) {
 	var genre *model.Genre
 	err := r.db.WithContext(ctx).
+		Preload("Aliases").
 		First(&genre, id).Error
 
 	if err != nil {
@@ -75,3 +102,92 @@ func (r *GenreRepository) GetByIds(ctx context.Context, ids []uint) ([]model.Gen
     
     return genres, nil
 }
+
+// GetWithChildren возвращает жанр с синонимами и прямыми поджанрами
+func (r *GenreRepository) GetWithChildren(ctx context.Context, id uint) (*model.Genre, error) {
+	var genre model.Genre
+	err := r.db.WithContext(ctx).
+		Preload("Aliases").
+		Preload("Children", func(db *gorm.DB) *gorm.DB {
+			return db.Order("name ASC")
+		}).
+		First(&genre, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &genre, nil
+}
+
+func (r *GenreRepository) GetAll(ctx context.Context) ([]model.Genre, error) {
+	var genres []model.Genre
+	err := r.db.WithContext(ctx).
+		Preload("Aliases").
+		Order("name ASC").
+		Find(&genres).Error
+	return genres, err
+}
+
+// FindByName ищет жанр по имени или синониму
+func (r *GenreRepository) FindByName(ctx context.Context, name string) (*model.Genre, error) {
+	var genre model.Genre
+	err := r.db.WithContext(ctx).
+		Preload("Aliases").
+		Scopes(genreNamed(name)).
+		First(&genre).Error
+	if err != nil {
+		return nil, err
+	}
+	return &genre, nil
+}
+
+// GetSubtreeIDs возвращает жанр и все его поджанры на любой глубине
+func (r *GenreRepository) GetSubtreeIDs(ctx context.Context, id uint) ([]uint, error) {
+	var ids []uint
+	err := genreSubtree(r.db.WithContext(ctx), id).Scan(&ids).Error
+	return ids, err
+}
+
+// DeleteAndReassign переносит жанр в корзину и его песни на replacementID. При 0 песни и синонимы
+// остаются за жанром до очистки корзины и возвращаются вместе с ним. Поджанры переходят к родителю удаленного жанра.
+func (r *GenreRepository) DeleteAndReassign(ctx context.Context, id, replacementID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var genre model.Genre
+		if err := tx.First(&genre, id).Error; err != nil {
+			return err
+		}
+
+		if replacementID != 0 {
+			tagged := newQuery(tx).Model(&model.SongGenre{}).Select("song_id").Where("genre_id = ?", replacementID)
+			if err := tx.Where("genre_id = ? AND song_id IN (?)", id, tagged).Delete(&model.SongGenre{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&model.SongGenre{}).Where("genre_id = ?", id).Update("genre_id", replacementID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Model(&model.Genre{}).Where("parent_id = ?", id).Update("parent_id", genre.ParentID).Error; err != nil {
+			return err
+		}
+		// Синонимы удаленного жанра не мешают новым: поиск по имени учитывает только живые жанры
+		return tx.Delete(&genre).Error
+	})
+}
+
+func genreNamed(name string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		aliased := newQuery(db).Model(&model.GenreAlias{}).
+			Select("genre_id").
+			Where(genreKey("name")+" = "+genreKey("?"), name)
+		return db.Where(genreKey("genres.name")+" = "+genreKey("?")+" OR genres.id IN (?)", name, aliased)
+	}
+}
+
+func genreSubtree(db *gorm.DB, id uint) *gorm.DB {
+	return newQuery(db).Raw(`WITH RECURSIVE subtree AS (
+			SELECT id FROM genres WHERE id = ? AND deleted_at IS NULL
+			UNION
+			SELECT genres.id FROM genres JOIN subtree ON genres.parent_id = subtree.id WHERE genres.deleted_at IS NULL
+		)
+		SELECT id FROM subtree`, id)
+}
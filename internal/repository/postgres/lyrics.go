@@ -6,7 +6,6 @@ import (
 	"music-lib/pkg/db"
 
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 type LyricsRepository struct {
@@ -19,41 +18,123 @@ func NewLyricsRepository(db *db.Db) *LyricsRepository {
 	}
 }
 
-func (r *LyricsRepository) GetBySongID(ctx context.Context, songID uint) (*model.Lyrics, error) {
-	var lyrics model.Lyrics
+func (r *LyricsRepository) GetBySongID(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+	var lyrics []model.Lyrics
 	err := r.db.WithContext(ctx).
-		Preload("Couplets").
+		Preload("Couplets", func(db *gorm.DB) *gorm.DB {
+			return db.Order("couplets.number ASC")
+		}).
+		Preload("Couplets.Words", func(db *gorm.DB) *gorm.DB {
+			return db.Order("couplet_words.position ASC")
+		}).
 		Where("song_id = ?", songID).
-		First(&lyrics).Error
+		Order("id ASC").
+		Find(&lyrics).Error
 
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
 		return nil, err
 	}
-	return &lyrics, nil
+	return lyrics, nil
 }
 
-func (r *LyricsRepository) Upsert(ctx context.Context, lyrics *model.Lyrics) error {
-	return r.db.WithContext(ctx).
-		Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "song_id"}},
-			UpdateAll: true,
-		}).
-		Create(&lyrics).Error
+// Upsert создает версию текста или, если задан ID, заменяет ее куплеты.
+// Ревизия сохраняется в той же транзакции.
+func (r *LyricsRepository) Upsert(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if lyrics.ID == 0 {
+			if err := tx.Create(lyrics).Error; err != nil {
+				return err
+			}
+		} else {
+			// Куплеты заменяются целиком, слова удаляются каскадно
+			err := tx.Where("lyrics_id = ?", lyrics.ID).
+				Delete(&model.Couplet{}).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Session(&gorm.Session{FullSaveAssociations: true}).
+				Save(lyrics).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(revision).Error
+	})
 }
 
-func (r *LyricsRepository) DeleteBySongID(ctx context.Context, songID uint) error {
-	result := r.db.WithContext(ctx).
-		Where("song_id = ?", songID).
-		Delete(&model.Lyrics{})
-	
-	if result.Error != nil {
-		return result.Error
+func (r *LyricsRepository) Delete(ctx context.Context, id uint, revision *model.LyricsRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.Lyrics{}, id).Error; err != nil {
+			return err
+		}
+		return tx.Create(revision).Error
+	})
+}
+
+func (r *LyricsRepository) GetRevisions(
+	ctx context.Context,
+	songID uint,
+	language string,
+	kind model.LyricsKind,
+) ([]model.LyricsRevision, error) {
+	var revisions []model.LyricsRevision
+	db := r.db.WithContext(ctx).
+		Select("lyrics_revisions.*, COALESCE(users.name, '') AS author").
+		Joins("LEFT JOIN users ON users.id = lyrics_revisions.author_id").
+		Where("lyrics_revisions.song_id = ?", songID)
+
+	if language != "" {
+		db = db.Where("lyrics_revisions.language = ?", language)
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	if kind != "" {
+		db = db.Where("lyrics_revisions.kind = ?", kind)
+	}
+
+	err := db.Order("lyrics_revisions.created_at DESC").
+		Order("lyrics_revisions.id DESC").
+		Find(&revisions).Error
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return revisions, nil
+}
+
+func (r *LyricsRepository) GetRevision(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error) {
+	var revision model.LyricsRevision
+	err := r.db.WithContext(ctx).
+		Where("song_id = ?", songID).
+		First(&revision, revisionID).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// DeleteBySongID удаляет все версии текста песни и пишет ревизию удаления для каждой
+func (r *LyricsRepository) DeleteBySongID(ctx context.Context, songID, authorID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var versions []model.Lyrics
+		if err := tx.Where("song_id = ?", songID).Find(&versions).Error; err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		for _, version := range versions {
+			revision, err := model.NewLyricsRevision(&version, authorID, model.RevisionDelete)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(revision).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("song_id = ?", songID).
+			Delete(&model.Lyrics{}).Error
+	})
 }
\ No newline at end of file
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+// Слияние дублей. Все, что ссылалось на исходную запись, переносится на целевую,
+// исходная запись удаляется, а ее идентификатор остается в перенаправлениях.
+type MergeRepository struct {
+	db *db.Db
+}
+
+func NewMergeRepository(db *db.Db) *MergeRepository {
+	return &MergeRepository{
+		db: db,
+	}
+}
+
+func (r *MergeRepository) Merge(ctx context.Context, resource model.Resource, sourceID, targetID, actorID uint) error {
+	entity, err := mergeModel(resource)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		switch resource {
+		case model.ArtistResource:
+			err = mergeArtists(tx, sourceID, targetID)
+		case model.AlbumResource:
+			err = mergeAlbums(tx, sourceID, targetID)
+		case model.GenreResource:
+			err = mergeGenres(tx, sourceID, targetID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if resource != model.GenreResource {
+			if err := repointFavorites(tx, resource, sourceID, targetID); err != nil {
+				return err
+			}
+			if err := repointPermissions(tx, resource, sourceID, targetID); err != nil {
+				return err
+			}
+			if err := repointTagVotes(tx, resource, sourceID, targetID); err != nil {
+				return err
+			}
+			if err := repointUserEntries(tx, &model.Rating{}, resource, sourceID, targetID); err != nil {
+				return err
+			}
+			if err := repointUserEntries(tx, &model.Review{}, resource, sourceID, targetID); err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&model.Comment{}).
+				Where("resource_type = ? AND resource_id = ?", resource, sourceID).
+				Update("resource_id", targetID).Error; err != nil {
+				return err
+			}
+			// Вливаемый альбом уже был в лентах, целевой там есть или появится по своему событию
+			if err := tx.Where("resource_type = ? AND resource_id = ?", resource, sourceID).Delete(&model.FeedItem{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Перенаправления на исходную запись теперь ведут сразу на целевую
+		if err := tx.Model(&model.MergeAlias{}).
+			Where("resource_type = ? AND target_id = ?", resource, sourceID).
+			Update("target_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.MergeAlias{
+			ResourceType: resource,
+			SourceID:     sourceID,
+			TargetID:     targetID,
+			MergedBy:     actorID,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(entity, sourceID).Error
+	})
+}
+
+// Resolve возвращает запись, в которую влита запись id
+func (r *MergeRepository) Resolve(ctx context.Context, resource model.Resource, id uint) (uint, error) {
+	var alias model.MergeAlias
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ? AND source_id = ?", resource, id).
+		First(&alias).Error
+	if err != nil {
+		return 0, err
+	}
+	return alias.TargetID, nil
+}
+
+// Альбомы и песни в корзине тоже переносятся, чтобы их можно было восстановить у целевого артиста
+func mergeArtists(tx *gorm.DB, sourceID, targetID uint) error {
+	if err := tx.Unscoped().Model(&model.Album{}).Where("artist_id = ?", sourceID).Update("artist_id", targetID).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&model.Song{}).Where("artist_id = ?", sourceID).Update("artist_id", targetID).Error; err != nil {
+		return err
+	}
+	if err := repointFollows(tx, sourceID, targetID); err != nil {
+		return err
+	}
+	return tx.Model(&model.SongCredit{}).Where("artist_id = ?", sourceID).Update("artist_id", targetID).Error
+}
+
+// Песни с номером дорожки, уже занятым в целевом альбоме, переносятся без номера
+func mergeAlbums(tx *gorm.DB, sourceID, targetID uint) error {
+	taken := newQuery(tx).Table("songs AS t").Select("1").
+		Where("t.album_id = ? AND t.disc_number = songs.disc_number AND t.track_number = songs.track_number", targetID)
+	if err := tx.Unscoped().Model(&model.Song{}).
+		Where("album_id = ? AND track_number > 0 AND EXISTS (?)", sourceID, taken).
+		Update("track_number", 0).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Model(&model.Song{}).Where("album_id = ?", sourceID).Updates(map[string]any{
+		"album_id":  targetID,
+		"artist_id": gorm.Expr("(SELECT artist_id FROM albums WHERE id = ?)", targetID),
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Where("album_id = ?", sourceID).Delete(&model.AlbumDisc{}).Error
+}
+
+// Имя и синонимы исходного жанра становятся синонимами целевого, поджанры переходят к целевому
+func mergeGenres(tx *gorm.DB, sourceID, targetID uint) error {
+	tagged := newQuery(tx).Model(&model.SongGenre{}).Select("song_id").Where("genre_id = ?", targetID)
+	if err := tx.Where("genre_id = ? AND song_id IN (?)", sourceID, tagged).Delete(&model.SongGenre{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.SongGenre{}).Where("genre_id = ?", sourceID).Update("genre_id", targetID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.GenreAlias{}).Where("genre_id = ?", sourceID).Update("genre_id", targetID).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(fmt.Sprintf(`INSERT INTO genre_aliases (genre_id, name)
+		SELECT ?, name FROM genres WHERE id = ? AND %s <> (SELECT %s FROM genres WHERE id = ?)`,
+		genreKey("name"), genreKey("name")), targetID, sourceID, targetID).Error; err != nil {
+		return err
+	}
+
+	// Целевой жанр из поддерева исходного поднимается на его место, иначе получится цикл
+	if err := tx.Model(&model.Genre{}).
+		Where("id = ? AND id IN (?)", targetID, genreSubtree(tx, sourceID)).
+		Update("parent_id", newQuery(tx).Unscoped().Model(&model.Genre{}).Select("parent_id").Where("id = ?", sourceID)).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&model.Genre{}).
+		Where("parent_id = ? AND id <> ?", sourceID, targetID).
+		Update("parent_id", targetID).Error
+}
+
+// Избранное, где уже есть целевая запись, не дублируется
+func repointFavorites(tx *gorm.DB, resource model.Resource, sourceID, targetID uint) error {
+	favored := newQuery(tx).Model(&model.Favorite{}).Select("profile_id").
+		Where("object_type = ? AND object_id = ?", resource, targetID)
+	if err := tx.Where("object_type = ? AND object_id = ? AND profile_id IN (?)", resource, sourceID, favored).
+		Delete(&model.Favorite{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.Favorite{}).
+		Where("object_type = ? AND object_id = ?", resource, sourceID).
+		Update("object_id", targetID).Error
+}
+
+func repointPermissions(tx *gorm.DB, resource model.Resource, sourceID, targetID uint) error {
+	granted := newQuery(tx).Model(&model.ResourcePermission{}).Select("user_id, permission").
+		Where("resource_type = ? AND resource_id = ?", resource, targetID)
+	if err := tx.Where("resource_type = ? AND resource_id = ? AND (user_id, permission) IN (?)", resource, sourceID, granted).
+		Delete(&model.ResourcePermission{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.ResourcePermission{}).
+		Where("resource_type = ? AND resource_id = ?", resource, sourceID).
+		Update("resource_id", targetID).Error
+}
+
+// Голос пользователя за метку, которую он уже оценил у целевой записи, не переносится
+func repointTagVotes(tx *gorm.DB, resource model.Resource, sourceID, targetID uint) error {
+	voted := newQuery(tx).Model(&model.TagVote{}).Select("tag_id, user_id").
+		Where("resource_type = ? AND resource_id = ?", resource, targetID)
+	if err := tx.Where("resource_type = ? AND resource_id = ? AND (tag_id, user_id) IN (?)", resource, sourceID, voted).
+		Delete(&model.TagVote{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.TagVote{}).
+		Where("resource_type = ? AND resource_id = ?", resource, sourceID).
+		Update("resource_id", targetID).Error
+}
+
+// repointUserEntries переносит оценки или рецензии; если пользователь уже оставил такую у целевой записи, остается она
+func repointUserEntries(tx *gorm.DB, entity any, resource model.Resource, sourceID, targetID uint) error {
+	authors := newQuery(tx).Model(entity).Select("user_id").
+		Where("resource_type = ? AND resource_id = ?", resource, targetID)
+	if err := tx.Where("resource_type = ? AND resource_id = ? AND user_id IN (?)", resource, sourceID, authors).
+		Delete(entity).Error; err != nil {
+		return err
+	}
+	return tx.Model(entity).
+		Where("resource_type = ? AND resource_id = ?", resource, sourceID).
+		Update("resource_id", targetID).Error
+}
+
+func mergeModel(resource model.Resource) (any, error) {
+	switch resource {
+	case model.ArtistResource:
+		return &model.Artist{}, nil
+	case model.AlbumResource:
+		return &model.Album{}, nil
+	case model.GenreResource:
+		return &model.Genre{}, nil
+	}
+	return nil, fmt.Errorf("resource %q can't be merged", resource)
+}
@@ -30,6 +30,19 @@ func (r *PermissionRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&model.ResourcePermission{}, id).Error
 }
 
+// Revoke удаляет право пользователя на ресурс
+func (r *PermissionRepository) Revoke(
+	ctx context.Context,
+	userID, resourceID uint,
+	resourceType model.Resource,
+	permission model.Permission,
+) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND resource_id = ? AND resource_type = ? AND permission = ?",
+			userID, resourceID, resourceType, permission).
+		Delete(&model.ResourcePermission{}).Error
+}
+
 func (r *PermissionRepository) HasPermission(
 	userID, resourceID uint,
 	resourceType model.Resource,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"music-lib/internal/model"
 	"music-lib/pkg/db"
+	"time"
 )
 
 type ProfileRepository struct {
@@ -44,4 +45,107 @@ func (r *ProfileRepository) GetByUserID(ctx context.Context, userID uint) (*mode
     }
 
     return &profile, nil
-}
\ No newline at end of file
+}
+
+// UpdatePrivacy сохраняет настройки приватности профиля
+func (r *ProfileRepository) UpdatePrivacy(ctx context.Context, profile *model.Profile) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Profile{UserID: profile.UserID}).
+		Select("bio_visibility", "avatar_visibility", "favorites_visibility", "history_visibility", "private_session").
+		Updates(profile).Error
+}
+
+// SetCollectionVisibility меняет видимость коллекции профиля. false, если у профиля нет такой коллекции.
+func (r *ProfileRepository) SetCollectionVisibility(ctx context.Context, profileID, collectionID uint, visibility model.Visibility) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Collection{}).
+		Where("id = ? AND profile_id = ?", collectionID, profileID).
+		Update("visibility", visibility)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetCollections возвращает коллекции профиля с числом песен. Если visible не пуст,
+// только коллекции с этими уровнями видимости.
+func (r *ProfileRepository) GetCollections(ctx context.Context, profileID uint, visible []model.Visibility) ([]model.Collection, error) {
+	db := r.db.WithContext(ctx).
+		Select("collections.*, (SELECT COUNT(*) FROM collection_items WHERE collection_items.collection_id = collections.id) AS tracks").
+		Where("profile_id = ?", profileID)
+	if len(visible) > 0 {
+		db = db.Where("visibility IN ?", visible)
+	}
+
+	var collections []model.Collection
+	err := db.Order("updated_at DESC").Find(&collections).Error
+	return collections, err
+}
+
+// RecentFavorites возвращает последние записи из избранного, которые видит пользователь viewerID
+func (r *ProfileRepository) RecentFavorites(ctx context.Context, profileID, viewerID uint, limit int) ([]model.Favorite, error) {
+	db := r.db.WithContext(ctx)
+	artists := newQuery(db).Model(&model.Artist{}).Select("artists.id").Scopes(listedArtists(viewerID))
+	albums := newQuery(db).Model(&model.Album{}).Select("albums.id").Scopes(listedAlbums(viewerID))
+	songs := newQuery(db).Model(&model.Song{}).Select("songs.id").Scopes(listedSongs(viewerID))
+
+	var favorites []model.Favorite
+	err := db.
+		Select("favorites.*, COALESCE(artists.name, albums.title, songs.title, '') AS title").
+		Joins("LEFT JOIN artists ON favorites.object_type = ? AND artists.id = favorites.object_id", model.ArtistResource).
+		Joins("LEFT JOIN albums ON favorites.object_type = ? AND albums.id = favorites.object_id", model.AlbumResource).
+		Joins("LEFT JOIN songs ON favorites.object_type = ? AND songs.id = favorites.object_id", model.SongResource).
+		Where("favorites.profile_id = ?", profileID).
+		Where("(favorites.object_type = ? AND favorites.object_id IN (?)) OR "+
+			"(favorites.object_type = ? AND favorites.object_id IN (?)) OR "+
+			"(favorites.object_type = ? AND favorites.object_id IN (?))",
+			model.ArtistResource, artists, model.AlbumResource, albums, model.SongResource, songs).
+		Order("favorites.created_at DESC").
+		Limit(limit).
+		Find(&favorites).Error
+	return favorites, err
+}
+
+// AddPlay записывает прослушивание песни в историю пользователя. false, если у пользователя
+// нет профиля, включена приватная сессия или песня уже записана после since.
+func (r *ProfileRepository) AddPlay(ctx context.Context, userID, songID uint, since time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(
+		"INSERT INTO histories (profile_id, song_id, played_at) "+
+			"SELECT user_id, ?, NOW() FROM profiles WHERE user_id = ? AND NOT private_session "+
+			"AND NOT EXISTS (SELECT 1 FROM histories WHERE profile_id = profiles.user_id AND song_id = ? AND played_at > ?)",
+		songID, userID, songID, since,
+	)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecentPlays возвращает последние прослушивания профиля среди песен, которые видит viewerID
+func (r *ProfileRepository) RecentPlays(ctx context.Context, profileID, viewerID uint, limit int) ([]model.History, error) {
+	db := r.db.WithContext(ctx)
+	songs := newQuery(db).Model(&model.Song{}).Select("songs.id").Scopes(listedSongs(viewerID))
+
+	var plays []model.History
+	err := db.
+		Select("histories.*, songs.title").
+		Joins("JOIN songs ON songs.id = histories.song_id").
+		Where("histories.profile_id = ?", profileID).
+		Where("histories.song_id IN (?)", songs).
+		Order("histories.played_at DESC").
+		Limit(limit).
+		Find(&plays).Error
+	return plays, err
+}
+
+// TopArtists возвращает артистов, чьи песни профиль слушал чаще всего, среди тех, кого видит viewerID
+func (r *ProfileRepository) TopArtists(ctx context.Context, profileID, viewerID uint, limit int) ([]model.TopArtist, error) {
+	db := r.db.WithContext(ctx)
+	var artists []model.TopArtist
+	err := db.
+		Model(&model.History{}).
+		Select("artists.id AS artist_id, artists.name, COUNT(*) AS plays").
+		Joins("JOIN songs ON songs.id = histories.song_id AND songs.deleted_at IS NULL").
+		Joins("JOIN artists ON artists.id = songs.artist_id AND artists.deleted_at IS NULL").
+		Where("histories.profile_id = ?", profileID).
+		Scopes(listedArtists(viewerID)).
+		Group("artists.id, artists.name").
+		Order("plays DESC, artists.name ASC").
+		Limit(limit).
+		Scan(&artists).Error
+	return artists, err
+}
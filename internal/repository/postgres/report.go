@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Жалобы и очередь модерации
+type ReportRepository struct {
+	db *db.Db
+}
+
+func NewReportRepository(db *db.Db) *ReportRepository {
+	return &ReportRepository{
+		db: db,
+	}
+}
+
+// Create сохраняет жалобу, вторая открытая жалоба пользователя на ту же запись нарушает уникальный индекс
+func (r *ReportRepository) Create(ctx context.Context, report *model.Report) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+// Find возвращает очередь жалоб, начиная со старых
+func (r *ReportRepository) Find(ctx context.Context, filter model.ReportFilter, limit, offset int) ([]model.Report, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.Report{})
+	if filter.State != "" {
+		db = db.Where("state = ?", filter.State)
+	}
+	if filter.ResourceType != "" {
+		db = db.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != 0 {
+		db = db.Where("resource_id = ?", filter.ResourceID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reports []model.Report
+	err := db.Order("created_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&reports).Error
+	return reports, total, err
+}
+
+func (r *ReportRepository) GetByIDs(ctx context.Context, ids []uint) ([]model.Report, error) {
+	var reports []model.Report
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id ASC").Find(&reports).Error
+	return reports, err
+}
+
+// Resolve закрывает открытые жалобы из ids, уже закрытые не меняются
+func (r *ReportRepository) Resolve(ctx context.Context, ids []uint, state model.ReportState, moderatorID uint, note string, takenDown bool) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Report{}).
+		Where("id IN ? AND state = ?", ids, model.ReportOpen).
+		Updates(map[string]any{
+			"state":       state,
+			"resolved_by": moderatorID,
+			"resolved_at": time.Now(),
+			"note":        note,
+			"taken_down":  takenDown,
+		}).Error
+}
+
+// Exists сообщает, есть ли запись, на которую жалуются. Записи в корзине и удаленные комментарии не считаются.
+func (r *ReportRepository) Exists(ctx context.Context, resource model.Resource, id uint) (bool, error) {
+	entity, err := reportedModel(resource)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	err = r.db.WithContext(ctx).Model(entity).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// TakeDown скрывает запись от всех, кроме ее редакторов: артисты, альбомы и песни получают
+// статус taken_down, рецензии скрываются, комментарии удаляются от имени модератора
+func (r *ReportRepository) TakeDown(ctx context.Context, resource model.Resource, id, moderatorID uint) error {
+	db := r.db.WithContext(ctx)
+	switch resource {
+	case model.ArtistResource, model.AlbumResource, model.SongResource:
+		entity, _ := reportedModel(resource)
+		return db.Model(entity).Where("id = ?", id).Update("status", model.StatusTakenDown).Error
+	case model.ReviewResource:
+		return db.Model(&model.Review{ID: id}).Updates(map[string]any{"hidden_at": time.Now(), "hidden_by": moderatorID}).Error
+	case model.CommentResource:
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&model.Comment{ID: id}).UpdateColumn("deleted_by", moderatorID).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&model.Comment{}, id).Error
+		})
+	}
+	return fmt.Errorf("resource %q can't be taken down", resource)
+}
+
+// GetOwners возвращает авторов рецензии или комментария и редакторов записи каталога
+func (r *ReportRepository) GetOwners(ctx context.Context, resource model.Resource, id uint) ([]model.User, error) {
+	db := r.db.WithContext(ctx)
+
+	var owners *gorm.DB
+	switch resource {
+	case model.ReviewResource:
+		owners = newQuery(db).Model(&model.Review{}).Select("user_id").Where("id = ?", id)
+	case model.CommentResource:
+		owners = newQuery(db).Unscoped().Model(&model.Comment{}).Select("user_id").Where("id = ?", id)
+	default:
+		owners = newQuery(db).Model(&model.ResourcePermission{}).Select("user_id").
+			Where("resource_type = ? AND resource_id = ? AND permission = ?", resource, id, model.EditPermission)
+	}
+
+	var users []model.User
+	err := db.Where("id IN (?)", owners).Find(&users).Error
+	return users, err
+}
+
+func reportedModel(resource model.Resource) (any, error) {
+	switch resource {
+	case model.ArtistResource:
+		return &model.Artist{}, nil
+	case model.AlbumResource:
+		return &model.Album{}, nil
+	case model.SongResource:
+		return &model.Song{}, nil
+	case model.GenreResource:
+		return &model.Genre{}, nil
+	case model.ReviewResource:
+		return &model.Review{}, nil
+	case model.CommentResource:
+		return &model.Comment{}, nil
+	}
+	return nil, fmt.Errorf("resource %q can't be reported", resource)
+}
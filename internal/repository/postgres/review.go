@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Оценки и рецензии песен и альбомов
+type ReviewRepository struct {
+	db *db.Db
+}
+
+func NewReviewRepository(db *db.Db) *ReviewRepository {
+	return &ReviewRepository{
+		db: db,
+	}
+}
+
+// SetRating сохраняет оценку пользователя, заменяя прежнюю
+func (r *ReviewRepository) SetRating(ctx context.Context, rating *model.Rating) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "resource_type"}, {Name: "resource_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"stars", "updated_at"}),
+	}).Create(rating).Error
+}
+
+// DeleteRating удаляет оценку пользователя, gorm.ErrRecordNotFound - оценки не было
+func (r *ReviewRepository) DeleteRating(ctx context.Context, resource model.Resource, resourceID, userID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ? AND user_id = ?", resource, resourceID, userID).
+		Delete(&model.Rating{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetSummary считает оценки записи по числу звезд
+func (r *ReviewRepository) GetSummary(ctx context.Context, resource model.Resource, resourceID, viewerID uint) (*model.RatingSummary, error) {
+	var rows []struct {
+		Stars int
+		Count int64
+		Own   bool
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.Rating{}).
+		Select("stars, COUNT(*) AS count, BOOL_OR(user_id = ?) AS own", viewerID).
+		Where("resource_type = ? AND resource_id = ?", resource, resourceID).
+		Group("stars").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.RatingSummary{}
+	for _, row := range rows {
+		summary.Add(row.Stars, row.Count)
+		if row.Own {
+			summary.Own = row.Stars
+		}
+	}
+	return summary, nil
+}
+
+// CreateReview сохраняет рецензию, вторая рецензия пользователя на ту же запись нарушает уникальный индекс
+func (r *ReviewRepository) CreateReview(ctx context.Context, review *model.Review) error {
+	return r.db.WithContext(ctx).Create(review).Error
+}
+
+func (r *ReviewRepository) UpdateReview(ctx context.Context, review *model.Review) error {
+	return r.db.WithContext(ctx).Model(review).Update("text", review.Text).Error
+}
+
+func (r *ReviewRepository) DeleteReview(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Review{}, id).Error
+}
+
+// SetHidden скрывает рецензию от всех, кроме автора, или возвращает ее
+func (r *ReviewRepository) SetHidden(ctx context.Context, id, moderatorID uint, hidden bool) error {
+	updates := map[string]any{"hidden_at": nil, "hidden_by": 0}
+	if hidden {
+		updates = map[string]any{"hidden_at": time.Now(), "hidden_by": moderatorID}
+	}
+	return r.db.WithContext(ctx).Model(&model.Review{ID: id}).Updates(updates).Error
+}
+
+func (r *ReviewRepository) GetReview(ctx context.Context, id uint) (*model.Review, error) {
+	var review model.Review
+	err := r.db.WithContext(ctx).
+		Scopes(reviewDetails).
+		First(&review, "reviews.id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetReviews возвращает рецензии записи, начиная с новых. Скрытые видны только их авторам.
+func (r *ReviewRepository) GetReviews(ctx context.Context, resource model.Resource, resourceID, viewerID uint, limit, offset int) ([]model.Review, int64, error) {
+	db := r.db.WithContext(ctx).
+		Model(&model.Review{}).
+		Where("reviews.resource_type = ? AND reviews.resource_id = ?", resource, resourceID).
+		Where("reviews.hidden_at IS NULL OR reviews.user_id = ?", viewerID)
+	return findReviews(db, limit, offset)
+}
+
+// GetUserReviews возвращает рецензии пользователя на записи, которые видит viewerID
+func (r *ReviewRepository) GetUserReviews(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.Review, int64, error) {
+	db := r.db.WithContext(ctx)
+	songs := newQuery(db).Model(&model.Song{}).Select("songs.id").Scopes(listedSongs(viewerID))
+	albums := newQuery(db).Model(&model.Album{}).Select("albums.id").Scopes(listedAlbums(viewerID))
+
+	db = db.Model(&model.Review{}).
+		Where("reviews.user_id = ?", userID).
+		Where("reviews.hidden_at IS NULL OR reviews.user_id = ?", viewerID).
+		Where("(reviews.resource_type = ? AND reviews.resource_id IN (?)) OR (reviews.resource_type = ? AND reviews.resource_id IN (?))",
+			model.SongResource, songs, model.AlbumResource, albums)
+	return findReviews(db, limit, offset)
+}
+
+func findReviews(db *gorm.DB, limit, offset int) ([]model.Review, int64, error) {
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []model.Review
+	err := db.Scopes(reviewDetails).
+		Order("reviews.created_at DESC, reviews.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&reviews).Error
+	return reviews, total, err
+}
+
+// reviewDetails добавляет к рецензии имя автора и его оценку записи
+func reviewDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Select("reviews.*, COALESCE(ratings.stars, 0) AS stars, COALESCE(users.name, '') AS author").
+		Joins("LEFT JOIN ratings ON ratings.user_id = reviews.user_id AND ratings.resource_type = reviews.resource_type AND ratings.resource_id = reviews.resource_id").
+		Joins("LEFT JOIN users ON users.id = reviews.user_id")
+}
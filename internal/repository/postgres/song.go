@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"music-lib/internal/model"
 	"music-lib/pkg/db"
+	"music-lib/pkg/identifier"
 
 	"gorm.io/gorm"
 )
@@ -33,12 +34,20 @@ func (r *SongRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&model.Song{}, id).Error
 }
 
-func (r *SongRepository) Search(ctx context.Context, query string, limit, offset int) ([]model.Song, int64, error) {
+func (r *SongRepository) Search(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
 	var songs []model.Song
-	db := r.db.WithContext(ctx).Model(&model.Song{})
+	db := r.db.WithContext(ctx).Model(&model.Song{}).Scopes(listedSongs(viewerID))
 
 	if query != "" {
-		db = db.Where("LOWER(title) LIKE LOWER(?)", "%"+query+"%")
+		// Запрос, похожий на ISRC, ищет еще и по коду
+		if isrc, err := identifier.NormalizeISRC(query); err == nil {
+			db = db.Where("LOWER(songs.title) LIKE LOWER(?) OR songs.isrc = ?", "%"+query+"%", isrc)
+		} else {
+			db = db.Where("LOWER(songs.title) LIKE LOWER(?)", "%"+query+"%")
+		}
+	}
+	if len(tags) > 0 {
+		db = db.Where("songs.id IN (?)", taggedIDs(db, model.SongResource, tags))
 	}
 
 	var total int64
@@ -72,6 +81,44 @@ func (r *SongRepository) ExistsInAlbum(ctx context.Context, albumID uint, songNa
     return count > 0
 }
 
+func (r *SongRepository) TrackExists(ctx context.Context, albumID uint, disc, track int, excludeID uint) bool {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.Song{}).
+		Where("album_id = ? AND disc_number = ? AND track_number = ?", albumID, disc, track).
+		Where("id <> ?", excludeID).
+		Count(&count).Error
+
+	return err == nil && count > 0
+}
+
+// NextTrackNumber возвращает номер дорожки после последней на диске
+func (r *SongRepository) NextTrackNumber(ctx context.Context, albumID uint, disc int) (int, error) {
+	var last int
+	err := r.db.WithContext(ctx).
+		Model(&model.Song{}).
+		Where("album_id = ? AND disc_number = ?", albumID, disc).
+		Select("COALESCE(MAX(track_number), 0)").
+		Scan(&last).Error
+
+	return last + 1, err
+}
+
+// FindDuplicate возвращает самую раннюю песню с тем же файлом или, если хеш звука известен, с тем же звуком
+func (r *SongRepository) FindDuplicate(ctx context.Context, fileHash, audioHash string, excludeID uint) (*model.Song, error) {
+	db := r.db.WithContext(ctx).Where("id <> ?", excludeID)
+	if audioHash != "" {
+		db = db.Where("(file_hash = ? OR audio_hash = ?)", fileHash, audioHash)
+	} else {
+		db = db.Where("file_hash = ?", fileHash)
+	}
+
+	var song model.Song
+	if err := db.Order("id ASC").First(&song).Error; err != nil {
+		return nil, err
+	}
+	return &song, nil
+}
 
 func (r *SongRepository) GetByID(ctx context.Context, id uint) (*model.Song, error) {
     var song *model.Song
@@ -79,6 +126,13 @@ func (r *SongRepository) GetByID(ctx context.Context, id uint) (*model.Song, err
         Preload("Lyrics.Couplets", func(db *gorm.DB) *gorm.DB {
             return db.Order("couplets.number ASC")
         }).
+        Preload("Lyrics.Couplets.Words", func(db *gorm.DB) *gorm.DB {
+            return db.Order("couplet_words.position ASC")
+        }).
+		Preload("Credits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("song_credits.position ASC")
+		}).
+		Preload("Credits.Artist").
         First(&song, id).Error
 
     if err != nil {
@@ -87,12 +141,100 @@ func (r *SongRepository) GetByID(ctx context.Context, id uint) (*model.Song, err
     return song, nil
 }
 
-func (r *SongRepository) GetByArtistID(ctx context.Context, artistID uint, sort string, limit, offset int) ([]model.Song, int64, error){
-	panic("SongRepository Implement GetByArtistID")
+// GetByArtistID возвращает дискографию артиста: его собственные песни и песни, где он указан участником.
+// sort: newest (по умолчанию), oldest или title.
+func (r *SongRepository) GetByArtistID(ctx context.Context, artistID, viewerID uint, sort string, limit, offset int) ([]model.Song, int64, error) {
+	credited := r.db.Model(&model.SongCredit{}).
+		Select("song_id").
+		Where("artist_id = ?", artistID)
+	db := r.db.WithContext(ctx).
+		Model(&model.Song{}).
+		Where("songs.artist_id = ? OR songs.id IN (?)", artistID, credited).
+		Scopes(listedSongs(viewerID))
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("error counting songs: %w", err)
+	}
+
+	order := "created_at DESC, id DESC"
+	switch sort {
+	case "oldest":
+		order = "created_at ASC, id ASC"
+	case "title":
+		order = "LOWER(title) ASC, id ASC"
+	}
+
+	var songs []model.Song
+	err := db.Preload("Credits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("song_credits.position ASC")
+	}).
+		Preload("Credits.Artist").
+		Order(order).
+		Limit(limit).
+		Offset(offset).
+		Find(&songs).Error
+
+	return songs, total, err
+}
+// GetByGenreIDs возвращает песни с любым из жанров, начиная с новых
+func (r *SongRepository) GetByGenreIDs(ctx context.Context, genreIDs []uint, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
+	tagged := r.db.Model(&model.SongGenre{}).
+		Select("song_id").
+		Where("genre_id IN ?", genreIDs)
+	db := r.db.WithContext(ctx).
+		Model(&model.Song{}).
+		Where("songs.id IN (?)", tagged).
+		Scopes(listedSongs(viewerID))
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("error counting songs: %w", err)
+	}
+
+	var songs []model.Song
+	err := db.Preload("Credits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("song_credits.position ASC")
+	}).
+		Preload("Credits.Artist").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&songs).Error
+
+	return songs, total, err
 }
+
 func (r *SongRepository) GetByAlbumID(ctx context.Context, albumID uint, sort string, limit, offset int) ([]model.Song, int64, error){
 	panic("SongRepository Implement GetByAlbumID")
 }
 func (r *SongRepository) GetFullInfo(ctx context.Context, id uint) (*model.Song, *model.Artist, *model.Album, error) {
-	panic("SongRepository Implement GetFullInfo")
-}
\ No newline at end of file
+	song, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var artist model.Artist
+	if err := r.db.WithContext(ctx).First(&artist, song.ArtistID).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	var album model.Album
+	if err := r.db.WithContext(ctx).First(&album, song.AlbumID).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	return song, &artist, &album, nil
+}
+
+func (r *SongRepository) GetByISRC(ctx context.Context, isrc string) (*model.Song, error) {
+	var song *model.Song
+	err := r.db.WithContext(ctx).
+		Where("isrc = ?", isrc).
+		First(&song).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return song, nil
+}
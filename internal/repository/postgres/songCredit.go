@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+type SongCreditRepository struct {
+	db *db.Db
+}
+
+func NewSongCreditRepository(db *db.Db) *SongCreditRepository {
+	return &SongCreditRepository{
+		db: db,
+	}
+}
+
+func (r *SongCreditRepository) GetBySongID(ctx context.Context, songID uint) ([]model.SongCredit, error) {
+	var credits []model.SongCredit
+	err := r.db.WithContext(ctx).
+		Preload("Artist").
+		Where("song_id = ?", songID).
+		Order("position ASC").
+		Find(&credits).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+// Replace заменяет список участников песни целиком
+func (r *SongCreditRepository) Replace(ctx context.Context, songID uint, credits []model.SongCredit) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("song_id = ?", songID).Delete(&model.SongCredit{}).Error; err != nil {
+			return err
+		}
+		if len(credits) == 0 {
+			return nil
+		}
+		return tx.Omit("Artist").Create(&credits).Error
+	})
+}
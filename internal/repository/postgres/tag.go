@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository struct {
+	db *db.Db
+}
+
+func NewTagRepository(db *db.Db) *TagRepository {
+	return &TagRepository{
+		db: db,
+	}
+}
+
+// Vote создает метку, если ее еще нет, и сохраняет голос пользователя, заменяя прежний
+func (r *TagRepository) Vote(ctx context.Context, resource model.Resource, resourceID, userID uint, name string, value int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tag := model.Tag{Name: name}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("name = ?", name).First(&tag).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tag_id"}, {Name: "resource_type"}, {Name: "resource_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&model.TagVote{
+			TagID:        tag.ID,
+			ResourceType: resource,
+			ResourceID:   resourceID,
+			UserID:       userID,
+			Value:        value,
+		}).Error
+	})
+}
+
+// Unvote удаляет голос пользователя, gorm.ErrRecordNotFound - голоса не было
+func (r *TagRepository) Unvote(ctx context.Context, resource model.Resource, resourceID, userID uint, name string) error {
+	tag := r.db.Model(&model.Tag{}).Select("id").Where("name = ?", name)
+	result := r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ? AND user_id = ? AND tag_id IN (?)", resource, resourceID, userID, tag).
+		Delete(&model.TagVote{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetWeights возвращает метки записи с положительным весом, начиная с самых весомых.
+// Метки, за которые голосовал viewerID, возвращаются при любом весе, чтобы он мог изменить голос.
+func (r *TagRepository) GetWeights(ctx context.Context, resource model.Resource, resourceID, viewerID uint) ([]model.TagWeight, error) {
+	var weights []model.TagWeight
+	err := r.db.WithContext(ctx).
+		Model(&model.TagVote{}).
+		Select("tags.name AS name, SUM(tag_votes.value) AS weight, "+
+			"COALESCE(MAX(CASE WHEN tag_votes.user_id = ? THEN tag_votes.value END), 0) AS vote", viewerID).
+		Joins("JOIN tags ON tags.id = tag_votes.tag_id").
+		Where("tag_votes.resource_type = ? AND tag_votes.resource_id = ?", resource, resourceID).
+		Group("tags.name").
+		Having("SUM(tag_votes.value) > 0 OR SUM(CASE WHEN tag_votes.user_id = ? THEN 1 ELSE 0 END) > 0", viewerID).
+		Order("weight DESC, tags.name ASC").
+		Scan(&weights).Error
+	return weights, err
+}
+
+// GetCloud возвращает самые частые метки: вес - число записей, на которых метка набрала положительный вес
+func (r *TagRepository) GetCloud(ctx context.Context, resource model.Resource, limit int) ([]model.TagWeight, error) {
+	db := r.db.WithContext(ctx)
+	accepted := positiveTags(db, resource, nil)
+
+	var weights []model.TagWeight
+	err := newQuery(db).
+		Table("(?) AS accepted", accepted).
+		Select("tags.name AS name, COUNT(*) AS weight").
+		Joins("JOIN tags ON tags.id = accepted.tag_id").
+		Group("tags.name").
+		Order("weight DESC, tags.name ASC").
+		Limit(limit).
+		Scan(&weights).Error
+	return weights, err
+}
+
+// positiveTags выбирает пары resource_id, tag_id, где сумма голосов за метку положительна
+func positiveTags(db *gorm.DB, resource model.Resource, names []string) *gorm.DB {
+	query := newQuery(db).
+		Model(&model.TagVote{}).
+		Select("tag_votes.resource_id, tag_votes.tag_id").
+		Where("tag_votes.resource_type = ?", resource)
+	if names != nil {
+		query = query.Joins("JOIN tags ON tags.id = tag_votes.tag_id").Where("tags.name IN ?", names)
+	}
+	return query.Group("tag_votes.resource_id, tag_votes.tag_id").Having("SUM(tag_votes.value) > 0")
+}
+
+// taggedIDs выбирает записи, на которых каждая из меток names набрала положительный вес
+func taggedIDs(db *gorm.DB, resource model.Resource, names []string) *gorm.DB {
+	return newQuery(db).
+		Table("(?) AS accepted", positiveTags(db, resource, names)).
+		Select("resource_id").
+		Group("resource_id").
+		Having("COUNT(*) = ?", len(names))
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Корзина. Вместе с артистом в корзину попадают его альбомы, вместе с альбомом - песни.
+// Им ставится одно и то же время удаления, по нему восстановление возвращает только удаленное вместе с записью.
+type TrashRepository struct {
+	db *db.Db
+}
+
+func NewTrashRepository(db *db.Db) *TrashRepository {
+	return &TrashRepository{
+		db: db,
+	}
+}
+
+func (r *TrashRepository) Trash(ctx context.Context, resource model.Resource, id uint) error {
+	entity, err := trashModel(resource)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(entity).Where("id = ?", id).Update("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		switch resource {
+		case model.ArtistResource:
+			if err := tx.Model(&model.Album{}).Where("artist_id = ?", id).Update("deleted_at", now).Error; err != nil {
+				return err
+			}
+			return tx.Model(&model.Song{}).
+				Where("album_id IN (?)", newQuery(tx).Unscoped().Model(&model.Album{}).Select("id").Where("artist_id = ?", id)).
+				Update("deleted_at", now).Error
+		case model.AlbumResource:
+			return tx.Model(&model.Song{}).Where("album_id = ?", id).Update("deleted_at", now).Error
+		}
+		return nil
+	})
+}
+
+func (r *TrashRepository) Restore(ctx context.Context, resource model.Resource, id uint) error {
+	entity, err := trashModel(resource)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var deleted []time.Time
+		if err := tx.Unscoped().Model(entity).Where("id = ? AND deleted_at IS NOT NULL", id).Pluck("deleted_at", &deleted).Error; err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		deletedAt := deleted[0]
+
+		if err := tx.Unscoped().Model(entity).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		switch resource {
+		case model.ArtistResource:
+			if err := tx.Unscoped().Model(&model.Album{}).
+				Where("artist_id = ? AND deleted_at = ?", id, deletedAt).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Model(&model.Song{}).
+				Where("album_id IN (?) AND deleted_at = ?", newQuery(tx).Model(&model.Album{}).Select("id").Where("artist_id = ?", id), deletedAt).
+				Update("deleted_at", nil).Error
+		case model.AlbumResource:
+			return tx.Unscoped().Model(&model.Song{}).
+				Where("album_id = ? AND deleted_at = ?", id, deletedAt).
+				Update("deleted_at", nil).Error
+		}
+		return nil
+	})
+}
+
+func (r *TrashRepository) GetByID(ctx context.Context, resource model.Resource, id uint) (*model.TrashItem, error) {
+	query, err := trashQuery(r.db.WithContext(ctx), resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var item model.TrashItem
+	result := query.Where("id = ?", id).Scan(&item)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &item, nil
+}
+
+// GetByUserID возвращает записи, которые пользователь может редактировать, начиная с последних удаленных.
+// Песни удаленного альбома и альбомы удаленного артиста не перечисляются: они восстанавливаются вместе с ним.
+func (r *TrashRepository) GetByUserID(ctx context.Context, userID uint) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	for _, resource := range []model.Resource{model.ArtistResource, model.AlbumResource, model.SongResource} {
+		db := r.db.WithContext(ctx)
+		query, err := trashQuery(db, resource)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("id IN (?)", editableIDs(db, userID, resource))
+
+		switch resource {
+		case model.AlbumResource:
+			query = query.Where("artist_id NOT IN (?)", trashedIDs(db, &model.Artist{}))
+		case model.SongResource:
+			query = query.Where("album_id NOT IN (?)", trashedIDs(db, &model.Album{}))
+		}
+
+		var found []model.TrashItem
+		if err := query.Scan(&found).Error; err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+
+	slices.SortFunc(items, func(a, b model.TrashItem) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return items, nil
+}
+
+// Purge окончательно удаляет записи, попавшие в корзину раньше before, вместе со всем, что на них ссылается.
+// Участники песен из удаленных артистов остаются в списке по имени.
+func (r *TrashRepository) Purge(ctx context.Context, before time.Time) (*model.PurgedEntries, error) {
+	purged := &model.PurgedEntries{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var artistIDs []uint
+		if err := tx.Unscoped().Model(&model.Artist{}).Where("deleted_at < ?", before).Pluck("id", &artistIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("deleted_at < ? OR artist_id IN ?", before, artistIDs).Find(&purged.Albums).Error; err != nil {
+			return err
+		}
+		albumIDs := make([]uint, 0, len(purged.Albums))
+		for _, album := range purged.Albums {
+			albumIDs = append(albumIDs, album.ID)
+		}
+		if err := tx.Unscoped().Where("deleted_at < ? OR album_id IN ?", before, albumIDs).Find(&purged.Songs).Error; err != nil {
+			return err
+		}
+		songIDs := make([]uint, 0, len(purged.Songs))
+		for _, song := range purged.Songs {
+			songIDs = append(songIDs, song.ID)
+		}
+
+		if len(songIDs) > 0 {
+			for _, dependent := range []any{
+				&model.SongGenre{},
+				&model.SongCredit{},
+				&model.Lyrics{},
+				&model.LyricsRevision{},
+				&model.CollectionItem{},
+				&model.History{},
+				&model.Upload{},
+			} {
+				if err := tx.Where("song_id IN ?", songIDs).Delete(dependent).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if len(artistIDs) > 0 {
+			if err := tx.Where("artist_id IN ?", artistIDs).Delete(&model.ArtistFollow{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&model.SongCredit{}).Where("artist_id IN ?", artistIDs).Updates(map[string]any{
+				"name":      gorm.Expr("(SELECT name FROM artists WHERE artists.id = song_credits.artist_id)"),
+				"artist_id": nil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, entries := range []struct {
+			resource model.Resource
+			entity   any
+			ids      []uint
+		}{
+			{model.SongResource, &model.Song{}, songIDs},
+			{model.AlbumResource, &model.Album{}, albumIDs},
+			{model.ArtistResource, &model.Artist{}, artistIDs},
+		} {
+			if len(entries.ids) == 0 {
+				continue
+			}
+			if err := tx.Where("object_type = ? AND object_id IN ?", entries.resource, entries.ids).Delete(&model.Favorite{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("resource_type = ? AND resource_id IN ?", entries.resource, entries.ids).Delete(&model.ResourcePermission{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("resource_type = ? AND resource_id IN ?", entries.resource, entries.ids).Delete(&model.TagVote{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("resource_type = ? AND resource_id IN ?", entries.resource, entries.ids).Delete(&model.Rating{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("resource_type = ? AND resource_id IN ?", entries.resource, entries.ids).Delete(&model.Review{}).Error; err != nil {
+				return err
+			}
+			if err := deleteComments(tx, entries.resource, entries.ids); err != nil {
+				return err
+			}
+			if err := tx.Where("resource_type = ? AND resource_id IN ?", entries.resource, entries.ids).Delete(&model.FeedItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(entries.entity, entries.ids).Error; err != nil {
+				return err
+			}
+		}
+		purged.Artists = int64(len(artistIDs))
+
+		// Жанры не попадают в корзину пользователя, но хранятся тот же срок вместе с песнями и синонимами
+		genres := newQuery(tx).Unscoped().Model(&model.Genre{}).Select("id").Where("deleted_at < ?", before)
+		if err := tx.Where("genre_id IN (?)", genres).Delete(&model.SongGenre{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("genre_id IN (?)", genres).Delete(&model.GenreAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("deleted_at < ?", before).Delete(&model.Genre{}).Error
+	})
+	return purged, err
+}
+
+func trashModel(resource model.Resource) (any, error) {
+	switch resource {
+	case model.ArtistResource:
+		return &model.Artist{}, nil
+	case model.AlbumResource:
+		return &model.Album{}, nil
+	case model.SongResource:
+		return &model.Song{}, nil
+	case model.GenreResource:
+		return &model.Genre{}, nil
+	}
+	return nil, fmt.Errorf("resource %q can't be trashed", resource)
+}
+
+// trashQuery выбирает удаленные записи ресурса в виде model.TrashItem
+func trashQuery(db *gorm.DB, resource model.Resource) (*gorm.DB, error) {
+	entity, err := trashModel(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := "id, title, 0 AS parent_id, deleted_at"
+	switch resource {
+	case model.ArtistResource:
+		columns = "id, name AS title, 0 AS parent_id, user_id, deleted_at"
+	case model.AlbumResource:
+		columns = "id, title, artist_id AS parent_id, deleted_at"
+	case model.SongResource:
+		columns = "id, title, album_id AS parent_id, deleted_at"
+	case model.GenreResource:
+		columns = "id, name AS title, COALESCE(parent_id, 0) AS parent_id, deleted_at"
+	}
+	return db.Unscoped().
+		Model(entity).
+		Select(fmt.Sprintf("'%s' AS resource, %s", resource, columns)).
+		Where("deleted_at IS NOT NULL"), nil
+}
+
+func trashedIDs(db *gorm.DB, entity any) *gorm.DB {
+	return newQuery(db).Unscoped().Model(entity).Select("id").Where("deleted_at IS NOT NULL")
+}
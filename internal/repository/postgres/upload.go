@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/model"
+	"music-lib/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadRepository struct {
+	db *db.Db
+}
+
+func NewUploadRepository(db *db.Db) *UploadRepository {
+	return &UploadRepository{
+		db: db,
+	}
+}
+
+func (r *UploadRepository) Create(ctx context.Context, entity *model.Upload) (*model.Upload, error) {
+	err := r.db.WithContext(ctx).Create(entity).Error
+	return entity, err
+}
+
+func (r *UploadRepository) Update(ctx context.Context, entity *model.Upload) (*model.Upload, error) {
+	err := r.db.WithContext(ctx).Save(entity).Error
+	return entity, err
+}
+
+func (r *UploadRepository) GetByID(ctx context.Context, id string) (*model.Upload, error) {
+	var upload model.Upload
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&upload).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// MoveOffset переносит смещение загрузки с from на to вместе с числом частей.
+// Возвращает false, если смещение уже изменил другой запрос или загрузка больше не ожидает частей.
+func (r *UploadRepository) MoveOffset(ctx context.Context, id string, from, to int64, parts int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Upload{}).
+		Where(`id = ? AND "offset" = ? AND status = ?`, id, from, model.UploadPending).
+		Updates(map[string]any{"offset": to, "parts": parts})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UsedBytes считает объем файлов пользователя: действующие и ожидающие привязки файлы
+// и место, зарезервированное незавершенными загрузками
+func (r *UploadRepository) UsedBytes(ctx context.Context, userID uint) (int64, error) {
+	var used int64
+	err := r.db.WithContext(ctx).
+		Model(&model.Upload{}).
+		Where("user_id = ?", userID).
+		Where("status IN ?", []model.UploadStatus{model.UploadPending, model.UploadStaged, model.UploadComplete}).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&used).Error
+
+	return used, err
+}
+
+// Complete завершает загрузку и привязывает файл к песне.
+// Предыдущие загрузки песни помечаются замененными в той же транзакции.
+func (r *UploadRepository) Complete(ctx context.Context, upload *model.Upload, song *model.Song) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Upload{}).
+			Where("song_id = ? AND status = ? AND id <> ?", upload.SongID, model.UploadComplete, upload.ID).
+			Update("status", model.UploadReplaced).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Save(upload).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(song).
+			Select("file_path", "file_size", "file_hash", "audio_hash", "mime_type").
+			Updates(song).Error
+	})
+}
+
+// errUploadTaken откатывает создание песни, если загрузку уже забрали
+var errUploadTaken = errors.New("upload is no longer staged")
+
+// CreateSong создает песню из промежуточной загрузки и в той же транзакции забирает загрузку.
+// Возвращает false и не создает песню, если загрузку уже привязал или отменил другой запрос.
+func (r *UploadRepository) CreateSong(ctx context.Context, upload *model.Upload, song *model.Song) (bool, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(song).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.Upload{}).
+			Where("id = ? AND user_id = ? AND status = ?", upload.ID, upload.UserID, model.UploadStaged).
+			Updates(map[string]any{"status": model.UploadComplete, "song_id": song.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errUploadTaken
+		}
+		return nil
+	})
+	if errors.Is(err, errUploadTaken) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	upload.SongID = song.ID
+	upload.Status = model.UploadComplete
+	return true, nil
+}
+
+// AbortStale отменяет незавершенные и не привязанные к песне загрузки, которые не менялись
+// с before, и возвращает их. Квота освобождается сразу, файлы удаляет вызывающий.
+func (r *UploadRepository) AbortStale(ctx context.Context, before time.Time) ([]model.Upload, error) {
+	var uploads []model.Upload
+	err := r.db.WithContext(ctx).
+		Model(&uploads).
+		Clauses(clause.Returning{}).
+		Where("status IN ? AND updated_at < ?", []model.UploadStatus{model.UploadPending, model.UploadStaged}, before).
+		Update("status", model.UploadAborted).Error
+	return uploads, err
+}
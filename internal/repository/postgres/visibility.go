@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"music-lib/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Правила видимости в поиске и списках: опубликованные записи видны всем, черновики, записи на проверке,
+// скрытые из списков и архивные - только редакторам. userID 0 - анонимный пользователь.
+
+// editableIDs - подзапрос идентификаторов ресурсов, которые пользователь может редактировать
+func editableIDs(db *gorm.DB, userID uint, resource model.Resource) *gorm.DB {
+	return newQuery(db).
+		Model(&model.ResourcePermission{}).
+		Select("resource_id").
+		Where("user_id = ? AND resource_type = ? AND permission = ?", userID, resource, model.EditPermission)
+}
+
+func newQuery(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true})
+}
+
+// listedArtists оставляет опубликованных артистов
+func listedArtists(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("artists.status = ? OR artists.id IN (?)",
+			model.StatusPublished, editableIDs(db, userID, model.ArtistResource))
+	}
+}
+
+// listedAlbums оставляет опубликованные вышедшие альбомы артистов, открытых по ссылке
+func listedAlbums(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		artists := newQuery(db).
+			Model(&model.Artist{}).
+			Select("artists.id").
+			Where("artists.status IN ? OR artists.id IN (?)",
+				[]model.Status{model.StatusPublished, model.StatusUnlisted}, editableIDs(db, userID, model.ArtistResource))
+		return db.Where("(albums.status = ? AND albums.release_date <= ? AND albums.artist_id IN (?)) OR albums.id IN (?)",
+			model.StatusPublished, time.Now(), artists, editableIDs(db, userID, model.AlbumResource))
+	}
+}
+
+// albumSongs оставляет опубликованные песни в списке песен альбома, который пользователь уже видит
+func albumSongs(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("songs.status = ? OR songs.id IN (?) OR songs.album_id IN (?)",
+			model.StatusPublished, editableIDs(db, userID, model.SongResource), editableIDs(db, userID, model.AlbumResource))
+	}
+}
+
+// listedSongs оставляет опубликованные песни из альбомов, которые показываются в списках
+func listedSongs(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		albums := newQuery(db).
+			Model(&model.Album{}).
+			Select("albums.id").
+			Scopes(listedAlbums(userID))
+		return db.Where("(songs.status = ? AND songs.album_id IN (?)) OR songs.id IN (?) OR songs.album_id IN (?)",
+			model.StatusPublished, albums, editableIDs(db, userID, model.SongResource), editableIDs(db, userID, model.AlbumResource))
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"music-lib/internal/model"
 	"music-lib/internal/repository/postgres"
 	"music-lib/pkg/db"
+	"time"
 )
 
 const (
 	EmailKey     = "email"
 	PhoneKey     = "phone"
 	SessionIdKey = "session_id"
+	NameKey      = "name"
 )
 
 // Базовый интерфейс для всех репозиториев
@@ -21,7 +23,8 @@ type Repository[T any] interface {
 }
 
 type Searchable[T any] interface {
-	Search(ctx context.Context, query string, limit, offset int) ([]T, int64, error)
+	// Search ищет среди записей, которые видит пользователь viewerID, 0 - анонимный
+	Search(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]T, int64, error)
 }
 
 // Репозиторий артистов
@@ -31,7 +34,7 @@ type IArtistRepository interface {
 
 	GetByID(ctx context.Context, id uint) (*model.Artist, error)
 	GetByUserID(ctx context.Context, userID uint) (*model.Artist, error)
-	GetWithAlbums(ctx context.Context, id uint) (*model.Artist, error)
+	GetWithAlbums(ctx context.Context, id, viewerID uint) (*model.Artist, error)
 	GetArtistAlbumByUserID(ctx context.Context, userID uint, albumID uint) (*model.Album, int, error)
 	IsExists(ctx context.Context, name string) bool
 }
@@ -42,7 +45,11 @@ type IAlbumRepository interface {
 	Searchable[model.Album]
 
 	GetByID(ctx context.Context, id uint) (*model.Album, error)
-	GetWithSongs(ctx context.Context, id uint) (*model.Album, error)
+	GetWithSongs(ctx context.Context, id, viewerID uint) (*model.Album, error)
+	GetByUPC(ctx context.Context, upc string) (*model.Album, error)
+	ReplaceDiscs(ctx context.Context, albumID uint, discs []model.AlbumDisc) error
+	GetDueReleases(ctx context.Context, now time.Time) ([]model.Album, error)
+	MarkReleased(ctx context.Context, id uint) (bool, error)
 }
 
 // Репозиторий песен
@@ -51,17 +58,41 @@ type ISongRepository interface {
 	Searchable[model.Song]
 
 	ExistsInAlbum(ctx context.Context, albumID uint, songName string) bool
+	// TrackExists проверяет, занят ли номер дорожки на диске альбома другой песней
+	TrackExists(ctx context.Context, albumID uint, disc, track int, excludeID uint) bool
+	NextTrackNumber(ctx context.Context, albumID uint, disc int) (int, error)
+	// FindDuplicate ищет по всему каталогу другую песню с тем же файлом или звуком
+	FindDuplicate(ctx context.Context, fileHash, audioHash string, excludeID uint) (*model.Song, error)
 	GetByID(ctx context.Context, id uint) (*model.Song, error)
-	GetByArtistID(ctx context.Context, artistID uint, sort string, limit, offset int) ([]model.Song, int64, error)
+	GetByISRC(ctx context.Context, isrc string) (*model.Song, error)
+	GetByArtistID(ctx context.Context, artistID, viewerID uint, sort string, limit, offset int) ([]model.Song, int64, error)
+	GetByGenreIDs(ctx context.Context, genreIDs []uint, viewerID uint, limit, offset int) ([]model.Song, int64, error)
 	GetByAlbumID(ctx context.Context, albumID uint, sort string, limit, offset int) ([]model.Song, int64, error)
 	GetFullInfo(ctx context.Context, id uint) (*model.Song, *model.Artist, *model.Album, error)
 }
 
 // Репозиторий текстов песен
+// Изменения версий текста сохраняются вместе с ревизией в одной транзакции
 type ILyricsRepository interface {
-	GetBySongID(ctx context.Context, songID uint) (*model.Lyrics, error)
-	Upsert(ctx context.Context, lyrics *model.Lyrics) error
-	DeleteBySongID(ctx context.Context, songID uint) error
+	GetBySongID(ctx context.Context, songID uint) ([]model.Lyrics, error)
+	Upsert(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error
+	Delete(ctx context.Context, id uint, revision *model.LyricsRevision) error
+	DeleteBySongID(ctx context.Context, songID, authorID uint) error
+
+	GetRevisions(ctx context.Context, songID uint, language string, kind model.LyricsKind) ([]model.LyricsRevision, error)
+	GetRevision(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error)
+}
+
+// Репозиторий загрузок аудиофайлов
+type IUploadRepository interface {
+	Create(ctx context.Context, entity *model.Upload) (*model.Upload, error)
+	Update(ctx context.Context, entity *model.Upload) (*model.Upload, error)
+	GetByID(ctx context.Context, id string) (*model.Upload, error)
+	MoveOffset(ctx context.Context, id string, from, to int64, parts int) (bool, error)
+	UsedBytes(ctx context.Context, userID uint) (int64, error)
+	Complete(ctx context.Context, upload *model.Upload, song *model.Song) error
+	CreateSong(ctx context.Context, upload *model.Upload, song *model.Song) (bool, error)
+	AbortStale(ctx context.Context, before time.Time) ([]model.Upload, error)
 }
 
 type IUserRepository interface {
@@ -74,6 +105,15 @@ type IProfileRepository interface {
 	Repository[model.Profile]
 
 	GetByUserID(ctx context.Context, userID uint) (*model.Profile, error)
+	UpdatePrivacy(ctx context.Context, profile *model.Profile) error
+	SetCollectionVisibility(ctx context.Context, profileID, collectionID uint, visibility model.Visibility) (bool, error)
+	GetCollections(ctx context.Context, profileID uint, visible []model.Visibility) ([]model.Collection, error)
+	// AddPlay пропускает запись, если включена приватная сессия или песня уже записана после since
+	AddPlay(ctx context.Context, userID, songID uint, since time.Time) (bool, error)
+	// RecentFavorites, RecentPlays и TopArtists пропускают записи, которые не видит viewerID
+	RecentFavorites(ctx context.Context, profileID, viewerID uint, limit int) ([]model.Favorite, error)
+	RecentPlays(ctx context.Context, profileID, viewerID uint, limit int) ([]model.History, error)
+	TopArtists(ctx context.Context, profileID, viewerID uint, limit int) ([]model.TopArtist, error)
 }
 
 type IGenreRepository interface {
@@ -81,6 +121,11 @@ type IGenreRepository interface {
 
 	GetById(ctx context.Context, id uint) (*model.Genre, error)
 	GetByIds(ctx context.Context, ids []uint) ([]model.Genre, error)
+	GetWithChildren(ctx context.Context, id uint) (*model.Genre, error)
+	GetAll(ctx context.Context) ([]model.Genre, error)
+	FindByName(ctx context.Context, name string) (*model.Genre, error)
+	GetSubtreeIDs(ctx context.Context, id uint) ([]uint, error)
+	DeleteAndReassign(ctx context.Context, id, replacementID uint) error
 	IsExists(ctx context.Context, name string) bool
 }
 
@@ -88,26 +133,132 @@ type ISongGenreRepository interface {
 	Repository[model.SongGenre]
 }
 
+// Репозиторий участников песен
+type ISongCreditRepository interface {
+	GetBySongID(ctx context.Context, songID uint) ([]model.SongCredit, error)
+	Replace(ctx context.Context, songID uint, credits []model.SongCredit) error
+}
+
+// Корзина артистов, альбомов и песен
+type ITrashRepository interface {
+	Trash(ctx context.Context, resource model.Resource, id uint) error
+	Restore(ctx context.Context, resource model.Resource, id uint) error
+	GetByID(ctx context.Context, resource model.Resource, id uint) (*model.TrashItem, error)
+	GetByUserID(ctx context.Context, userID uint) ([]model.TrashItem, error)
+	Purge(ctx context.Context, before time.Time) (*model.PurgedEntries, error)
+}
+
+// Журнал изменений каталога, записи не меняются и не удаляются
+type IAuditRepository interface {
+	Create(ctx context.Context, entry *model.AuditEntry) error
+	Find(ctx context.Context, filter model.AuditFilter, limit, offset int) ([]model.AuditEntry, int64, error)
+}
+
+// Слияние дублей артистов, альбомов и жанров
+type IMergeRepository interface {
+	Merge(ctx context.Context, resource model.Resource, sourceID, targetID, actorID uint) error
+	// Resolve возвращает запись, в которую влита запись id, или gorm.ErrRecordNotFound
+	Resolve(ctx context.Context, resource model.Resource, id uint) (uint, error)
+}
+
+// Пользовательские метки песен и альбомов с голосами
+type ITagRepository interface {
+	Vote(ctx context.Context, resource model.Resource, resourceID, userID uint, name string, value int) error
+	// Unvote возвращает gorm.ErrRecordNotFound, если пользователь не голосовал за метку
+	Unvote(ctx context.Context, resource model.Resource, resourceID, userID uint, name string) error
+	GetWeights(ctx context.Context, resource model.Resource, resourceID, viewerID uint) ([]model.TagWeight, error)
+	GetCloud(ctx context.Context, resource model.Resource, limit int) ([]model.TagWeight, error)
+}
+
+// Оценки и рецензии песен и альбомов
+type IReviewRepository interface {
+	SetRating(ctx context.Context, rating *model.Rating) error
+	// DeleteRating возвращает gorm.ErrRecordNotFound, если пользователь не оценивал запись
+	DeleteRating(ctx context.Context, resource model.Resource, resourceID, userID uint) error
+	GetSummary(ctx context.Context, resource model.Resource, resourceID, viewerID uint) (*model.RatingSummary, error)
+	CreateReview(ctx context.Context, review *model.Review) error
+	UpdateReview(ctx context.Context, review *model.Review) error
+	DeleteReview(ctx context.Context, id uint) error
+	SetHidden(ctx context.Context, id, moderatorID uint, hidden bool) error
+	GetReview(ctx context.Context, id uint) (*model.Review, error)
+	GetReviews(ctx context.Context, resource model.Resource, resourceID, viewerID uint, limit, offset int) ([]model.Review, int64, error)
+	GetUserReviews(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.Review, int64, error)
+}
+
+// Комментарии к песням и альбомам
+type ICommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	GetByID(ctx context.Context, id, viewerID uint) (*model.Comment, error)
+	UpdateText(ctx context.Context, id uint, text string, editedAt time.Time) error
+	Delete(ctx context.Context, id, deletedBy uint) error
+	GetThread(ctx context.Context, resource model.Resource, resourceID, parentID, viewerID uint, sort model.CommentSort, limit, offset int) ([]model.Comment, int64, error)
+	Like(ctx context.Context, id, userID uint) error
+	Unlike(ctx context.Context, id, userID uint) error
+}
+
+// Жалобы пользователей и очередь модерации
+type IReportRepository interface {
+	Create(ctx context.Context, report *model.Report) error
+	Find(ctx context.Context, filter model.ReportFilter, limit, offset int) ([]model.Report, int64, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]model.Report, error)
+	Resolve(ctx context.Context, ids []uint, state model.ReportState, moderatorID uint, note string, takenDown bool) error
+	Exists(ctx context.Context, resource model.Resource, id uint) (bool, error)
+	TakeDown(ctx context.Context, resource model.Resource, id, moderatorID uint) error
+	// GetOwners возвращает пользователей, которых уведомляют о снятии записи
+	GetOwners(ctx context.Context, resource model.Resource, id uint) ([]model.User, error)
+}
+
+// Подписки на артистов и пользователей, лента новинок
+type IFollowRepository interface {
+	FollowArtist(ctx context.Context, userID, artistID uint) error
+	UnfollowArtist(ctx context.Context, userID, artistID uint) error
+	IsFollowing(ctx context.Context, userID, artistID uint) (bool, error)
+	// AddToFeed добавляет запись в ленты подписчиков item.ArtistID
+	AddToFeed(ctx context.Context, item *model.FeedItem) (int64, error)
+	GetFeed(ctx context.Context, userID uint, limit, offset int) ([]model.FeedItem, int64, error)
+
+	FollowUser(ctx context.Context, followerID, followeeID uint) error
+	UnfollowUser(ctx context.Context, followerID, followeeID uint) error
+	IsFollowingUser(ctx context.Context, followerID, followeeID uint) (bool, error)
+	// CountUserFollows возвращает число подписчиков и подписок пользователя
+	CountUserFollows(ctx context.Context, userID uint) (int64, int64, error)
+	// GetFollowers и GetFollowing показывают аватары, открытые viewerID
+	GetFollowers(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error)
+	GetFollowing(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error)
+}
+
 type IPermissionRepository interface {
 	Repository[model.ResourcePermission]
 
 	HasPermission(userID, resourceID uint, resourceType model.Resource, permission model.Permission) bool
+	Revoke(ctx context.Context, userID, resourceID uint, resourceType model.Resource, permission model.Permission) error
 }
 
 type Repositories struct {
 	// User
 	User IUserRepository
 	// Music
-	Song      ISongRepository
-	Album     IAlbumRepository
-	Artist    IArtistRepository
-	Lyrics    ILyricsRepository
-	Genre     IGenreRepository
-	SongGenre ISongGenreRepository
+	Song       ISongRepository
+	Album      IAlbumRepository
+	Artist     IArtistRepository
+	Lyrics     ILyricsRepository
+	Genre      IGenreRepository
+	SongGenre  ISongGenreRepository
+	SongCredit ISongCreditRepository
+	Upload     IUploadRepository
+	Trash      ITrashRepository
+	Merge      IMergeRepository
+	Tag        ITagRepository
+	Review     IReviewRepository
+	Comment    ICommentRepository
+	Report     IReportRepository
 	// Profile
 	Profile IProfileRepository
+	Follow  IFollowRepository
 	// Permission
 	Permission IPermissionRepository
+	// Audit
+	Audit IAuditRepository
 }
 
 func NewPostgresRepositories(db *db.Db) *Repositories {
@@ -115,15 +266,26 @@ func NewPostgresRepositories(db *db.Db) *Repositories {
 		// User
 		User: postgres.NewUserRepository(db),
 		// Music
-		Artist:    postgres.NewArtistRepository(db),
-		Album:     postgres.NewAlbumRepository(db),
-		Song:      postgres.NewSongRepository(db),
-		Genre:     postgres.NewGenreRepository(db),
-		SongGenre: postgres.NewSongGenreRepository(db),
-		Lyrics:    postgres.NewLyricsRepository(db),
+		Artist:     postgres.NewArtistRepository(db),
+		Album:      postgres.NewAlbumRepository(db),
+		Song:       postgres.NewSongRepository(db),
+		Genre:      postgres.NewGenreRepository(db),
+		SongGenre:  postgres.NewSongGenreRepository(db),
+		SongCredit: postgres.NewSongCreditRepository(db),
+		Lyrics:     postgres.NewLyricsRepository(db),
+		Upload:     postgres.NewUploadRepository(db),
+		Trash:      postgres.NewTrashRepository(db),
+		Merge:      postgres.NewMergeRepository(db),
+		Tag:        postgres.NewTagRepository(db),
+		Review:     postgres.NewReviewRepository(db),
+		Comment:    postgres.NewCommentRepository(db),
+		Report:     postgres.NewReportRepository(db),
 		//Profile
 		Profile: postgres.NewProfileRepository(db),
+		Follow:  postgres.NewFollowRepository(db),
 		// Permission
 		Permission: postgres.NewPermissionRepository(db),
+		// Audit
+		Audit: postgres.NewAuditRepository(db),
 	}
 }
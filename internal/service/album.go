@@ -7,22 +7,32 @@ import (
 	"music-lib/internal/model"
 	"music-lib/internal/repository"
 	"music-lib/pkg/er"
+	"music-lib/pkg/identifier"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type AlbumService struct {
 	albumRepository  repository.IAlbumRepository
 	artistRepository repository.IArtistRepository
+	audit            auditLog
 }
 
-func NewAlbumService(album repository.IAlbumRepository, artist repository.IArtistRepository) *AlbumService {
+func NewAlbumService(
+	album repository.IAlbumRepository,
+	artist repository.IArtistRepository,
+	audit repository.IAuditRepository,
+	logger *zap.SugaredLogger,
+) *AlbumService {
 	return &AlbumService{
 		artistRepository: artist,
 		albumRepository:  album,
+		audit:            newAuditLog(audit, logger),
 	}
 }
 
@@ -35,9 +45,9 @@ func (s *AlbumService) NewAlbum(ctx *gin.Context, body request.NewAlbumRequest,
 		return nil, &er.InternalError{Message: fmt.Sprintf("NewAlbum: can't get artist: %s", err.Error())}
 	}
 
-	formationDate, err := time.Parse("2006-01-02", body.ReleaseDate)
+	releaseDate, err := parseReleaseDate(body.ReleaseDate)
 	if err != nil {
-		return nil, er.ErrDateFormat
+		return nil, err
 	}
 
 	for _, album := range artist.Albums {
@@ -46,27 +56,48 @@ func (s *AlbumService) NewAlbum(ctx *gin.Context, body request.NewAlbumRequest,
 		}
 	}
 
+	albumType, err := albumTypeOf(body.Type)
+	if err != nil {
+		return nil, err
+	}
+	discs, err := buildDiscs(body.Discs)
+	if err != nil {
+		return nil, err
+	}
+	upc, err := s.checkUPC(ctx, body.UPC, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	album, err := s.albumRepository.Create(ctx, &model.Album{
 		Title:       body.Title,
 		ArtistID:    artist.ID,
+		Status:      model.StatusDraft,
+		Type:        albumType,
+		UPC:         upc,
 		Songs:       nil,
-		ReleaseDate: formationDate,
-		CoverArtURL: body.CoverArtURL,
+		Discs:       discs,
+		ReleaseDate: releaseDate,
+		// О выходе сообщит ReleaseService, когда альбом опубликуют и наступит дата релиза
+		ReleasePending: true,
+		CoverArtURL:    body.CoverArtURL,
 	})
 
 	if err != nil {
 		return nil, &er.InternalError{Message: fmt.Sprintf("NewAlbum: can't create album: %s", err.Error())}
 	}
+	s.audit.record(ctx, userID, model.AlbumResource, album.ID, model.AuditCreate, nil, albumState(album))
 	return album, nil
 }
 
-func (s *AlbumService) GetAlbum(ctx *gin.Context, strID string) (*model.Album, error) {
+// GetAlbum возвращает альбом с песнями, которые видит пользователь viewerID
+func (s *AlbumService) GetAlbum(ctx *gin.Context, strID string, viewerID uint) (*model.Album, error) {
 	id, err := strconv.Atoi(strID)
 	if err != nil {
 		return nil, &er.ValidationError{Message: err.Error()}
 	}
 
-	album, err := s.albumRepository.GetWithSongs(ctx, uint(id))
+	album, err := s.albumRepository.GetWithSongs(ctx, uint(id), viewerID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, er.ErrAlbumNotExists
@@ -78,6 +109,156 @@ func (s *AlbumService) GetAlbum(ctx *gin.Context, strID string) (*model.Album, e
 	return album, nil
 }
 
+// UpdateAlbum меняет тип альбома, UPC и названия дисков
+func (s *AlbumService) UpdateAlbum(ctx *gin.Context, id uint, body request.UpdateAlbumRequest, userID uint) (*model.Album, error) {
+	current, err := s.albumRepository.GetWithSongs(ctx, id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrAlbumNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	before := albumState(current)
+	// Песни и диски не сохраняются вместе с альбомом, диски заменяются через ReplaceDiscs
+	album := &model.Album{}
+	*album = before
+	album.Discs = nil
+
+	if body.Type != "" {
+		if album.Type, err = albumTypeOf(body.Type); err != nil {
+			return nil, err
+		}
+	}
+	if body.UPC != nil {
+		if album.UPC, err = s.checkUPC(ctx, *body.UPC, id); err != nil {
+			return nil, err
+		}
+	}
+	if body.Type != "" || body.UPC != nil {
+		if _, err := s.albumRepository.Update(ctx, album); err != nil {
+			return nil, &er.InternalError{Message: fmt.Sprintf("UpdateAlbum: can't update album: %s", err.Error())}
+		}
+	}
+
+	if body.Discs != nil {
+		discs, err := buildDiscs(*body.Discs)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.albumRepository.ReplaceDiscs(ctx, id, discs); err != nil {
+			return nil, &er.InternalError{Message: fmt.Sprintf("UpdateAlbum: can't replace discs: %s", err.Error())}
+		}
+	}
+
+	updated, err := s.GetAlbum(ctx, strconv.Itoa(int(id)), userID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.record(ctx, userID, model.AlbumResource, id, model.AuditUpdate, before, albumState(updated))
+	return updated, nil
+}
+
+// SetStatus публикует альбом или снимает его с публикации
+func (s *AlbumService) SetStatus(ctx *gin.Context, id uint, status model.Status, userID uint) (*model.Album, error) {
+	album, err := s.albumRepository.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrAlbumNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	if album.Status == model.StatusTakenDown {
+		return nil, er.ErrTakenDown
+	}
+
+	before := albumState(album)
+	album.Status = status
+	if _, err := s.albumRepository.Update(ctx, album); err != nil {
+		return nil, &er.InternalError{Message: fmt.Sprintf("SetStatus: can't update album: %s", err.Error())}
+	}
+	s.audit.record(ctx, userID, model.AlbumResource, id, model.AuditStatus, before, albumState(album))
+	return s.GetAlbum(ctx, strconv.Itoa(int(id)), userID)
+}
+
+// GetAlbumByUPC ищет альбом по UPC или EAN
+func (s *AlbumService) GetAlbumByUPC(ctx *gin.Context, value string, viewerID uint) (*model.Album, error) {
+	upc, err := identifier.NormalizeUPC(value)
+	if err != nil {
+		return nil, er.ErrUPCFormat
+	}
+
+	album, err := s.albumRepository.GetByUPC(ctx, upc)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrAlbumNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	return s.GetAlbum(ctx, strconv.Itoa(int(album.ID)), viewerID)
+}
+
+// checkUPC нормализует код и проверяет, что он не занят другим альбомом
+func (s *AlbumService) checkUPC(ctx *gin.Context, value string, albumID uint) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	upc, err := identifier.NormalizeUPC(value)
+	if err != nil {
+		return "", er.ErrUPCFormat
+	}
+
+	album, err := s.albumRepository.GetByUPC(ctx, upc)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return upc, nil
+	case err != nil:
+		return "", &er.InternalError{Message: err.Error()}
+	case album.ID != albumID:
+		return "", er.ErrUPCTaken
+	}
+	return upc, nil
+}
+
+// Дата релиза без времени наступает в полночь UTC, для точного момента выхода нужен RFC 3339
+func parseReleaseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, er.ErrDateFormat
+	}
+	return date.UTC(), nil
+}
+
+// Без типа альбом считается полноформатным
+func albumTypeOf(value string) (model.AlbumType, error) {
+	if value == "" {
+		return model.AlbumLP, nil
+	}
+	albumType := model.AlbumType(value)
+	if !albumType.IsValid() {
+		return "", er.ErrAlbumType
+	}
+	return albumType, nil
+}
+
+// buildDiscs проверяет номера и названия дисков
+func buildDiscs(req []request.AlbumDisc) ([]model.AlbumDisc, error) {
+	discs := make([]model.AlbumDisc, 0, len(req))
+	seen := make(map[int]bool)
+	for _, d := range req {
+		title := strings.TrimSpace(d.Title)
+		if d.Number < 1 || title == "" || seen[d.Number] {
+			return nil, er.ErrAlbumDiscs
+		}
+		seen[d.Number] = true
+		discs = append(discs, model.AlbumDisc{Number: d.Number, Title: title})
+	}
+	return discs, nil
+}
 
 func (s *AlbumService) GetArtistAlbum(ctx *gin.Context, userID uint, albumID uint) (*model.Album, error) {
 	album, count, err := s.artistRepository.GetArtistAlbumByUserID(ctx, userID, albumID)
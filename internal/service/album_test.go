@@ -24,7 +24,7 @@ func TestNewAlbum_ArtistNotExists(t *testing.T) {
 			return nil, gorm.ErrRecordNotFound
 		},
 	}
-	service := NewAlbumService(nil, mockArtistRepo)
+	service := NewAlbumService(nil, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewAlbumRequest{
@@ -47,7 +47,7 @@ func TestNewAlbum_InvalidDateFormat(t *testing.T) {
 			return &model.Artist{}, nil
 		},
 	}
-	service := NewAlbumService(nil, mockArtistRepo)
+	service := NewAlbumService(nil, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewAlbumRequest{
@@ -72,7 +72,7 @@ func TestNewAlbum_AlbumExists(t *testing.T) {
 			}, nil
 		},
 	}
-	service := NewAlbumService(nil, mockArtistRepo)
+	service := NewAlbumService(nil, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewAlbumRequest{
@@ -95,7 +95,7 @@ func TestNewAlbum_InternalErrorOnArtistFetch(t *testing.T) {
 			return nil, errors.New("database error")
 		},
 	}
-	service := NewAlbumService(nil, mockArtistRepo)
+	service := NewAlbumService(nil, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewAlbumRequest{
@@ -129,7 +129,7 @@ func TestNewAlbum_Success(t *testing.T) {
 			}, nil
 		},
 	}
-	service := NewAlbumService(mockAlbumRepo, mockArtistRepo)
+	service := NewAlbumService(mockAlbumRepo, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewAlbumRequest{
@@ -153,12 +153,12 @@ func TestNewAlbum_Success(t *testing.T) {
 func TestGetAlbum_InvalidID(t *testing.T) {
 	// Arrange
 	mockAlbumRepo := &mocks.MockAlbumRepo{}
-	service := NewAlbumService(mockAlbumRepo, nil)
+	service := NewAlbumService(mockAlbumRepo, nil, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
 	// Act
-	album, err := service.GetAlbum(ctx, "invalid-id")
+	album, err := service.GetAlbum(ctx, "invalid-id", 0)
 
 	// Assert
 	assert.Nil(t, album)
@@ -169,16 +169,16 @@ func TestGetAlbum_InvalidID(t *testing.T) {
 func TestGetAlbum_AlbumNotFound(t *testing.T) {
 	// Arrange
 	mockAlbumRepo := &mocks.MockAlbumRepo{
-		GetWithSongsFunc: func(ctx context.Context, id uint) (*model.Album, error) {
+		GetWithSongsFunc: func(ctx context.Context, id, viewerID uint) (*model.Album, error) {
 			return nil, gorm.ErrRecordNotFound
 		},
 	}
-	service := NewAlbumService(mockAlbumRepo, nil)
+	service := NewAlbumService(mockAlbumRepo, nil, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
 	// Act
-	album, err := service.GetAlbum(ctx, "1")
+	album, err := service.GetAlbum(ctx, "1", 0)
 
 	// Assert
 	assert.Nil(t, album)
@@ -188,19 +188,19 @@ func TestGetAlbum_AlbumNotFound(t *testing.T) {
 func TestGetAlbum_Success(t *testing.T) {
 	// Arrange
 	mockAlbumRepo := &mocks.MockAlbumRepo{
-		GetWithSongsFunc: func(ctx context.Context, id uint) (*model.Album, error) {
+		GetWithSongsFunc: func(ctx context.Context, id, viewerID uint) (*model.Album, error) {
 			return &model.Album{
 				ID:    1,
 				Title: "Test Album",
 			}, nil
 		},
 	}
-	service := NewAlbumService(mockAlbumRepo, nil)
+	service := NewAlbumService(mockAlbumRepo, nil, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
 	// Act
-	album, err := service.GetAlbum(ctx, "1")
+	album, err := service.GetAlbum(ctx, "1", 0)
 
 	// Assert
 	assert.NoError(t, err)
@@ -216,7 +216,7 @@ func TestGetArtistAlbum_AlbumNotFound(t *testing.T) {
 			return nil, 0, gorm.ErrRecordNotFound
 		},
 	}
-	service := NewAlbumService(nil, mockArtistRepo)
+	service := NewAlbumService(nil, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
@@ -235,7 +235,7 @@ func TestGetArtistAlbum_NoAlbums(t *testing.T) {
 			return nil, 0, nil
 		},
 	}
-	service := NewAlbumService(nil, mockArtistRepo)
+	service := NewAlbumService(nil, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
@@ -257,7 +257,7 @@ func TestGetArtistAlbum_Success(t *testing.T) {
 			}, 1, nil
 		},
 	}
-	service := NewAlbumService(nil, mockArtistRepo)
+	service := NewAlbumService(nil, mockArtistRepo, nil, nil)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
@@ -269,4 +269,115 @@ func TestGetArtistAlbum_Success(t *testing.T) {
 	assert.NotNil(t, album)
 	assert.Equal(t, uint(1), album.ID)
 	assert.Equal(t, "Test Album", album.Title)
-}
\ No newline at end of file
+}
+
+func TestNewAlbum_TypeAndDiscs(t *testing.T) {
+	// Arrange
+	mockArtistRepo := &mocks.MockArtistRepo{
+		GetByUserIDFunc: func(ctx context.Context, userID uint) (*model.Artist, error) {
+			return &model.Artist{ID: 1}, nil
+		},
+	}
+	var created *model.Album
+	mockAlbumRepo := &mocks.MockAlbumRepo{
+		CreateFunc: func(ctx context.Context, entity *model.Album) (*model.Album, error) {
+			created = entity
+			return entity, nil
+		},
+	}
+	service := NewAlbumService(mockAlbumRepo, mockArtistRepo, nil, nil)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	newRequest := func(albumType string, discs ...request.AlbumDisc) request.NewAlbumRequest {
+		return request.NewAlbumRequest{Title: "Test Album", ReleaseDate: "2023-01-01", Type: albumType, Discs: discs}
+	}
+
+	// Act & Assert
+	_, err := service.NewAlbum(ctx, newRequest("mixtape"), 1)
+	assert.Equal(t, er.ErrAlbumType, err)
+
+	_, err = service.NewAlbum(ctx, newRequest("", request.AlbumDisc{Number: 1, Title: "A"}, request.AlbumDisc{Number: 1, Title: "B"}), 1)
+	assert.Equal(t, er.ErrAlbumDiscs, err)
+
+	_, err = service.NewAlbum(ctx, newRequest("", request.AlbumDisc{Number: 0, Title: "A"}), 1)
+	assert.Equal(t, er.ErrAlbumDiscs, err)
+
+	_, err = service.NewAlbum(ctx, newRequest(""), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AlbumLP, created.Type)
+
+	_, err = service.NewAlbum(ctx, newRequest("live", request.AlbumDisc{Number: 2, Title: " Encore "}), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AlbumLive, created.Type)
+	assert.Equal(t, []model.AlbumDisc{{Number: 2, Title: "Encore"}}, created.Discs)
+}
+
+func TestNewAlbum_UPC(t *testing.T) {
+	// Arrange
+	mockArtistRepo := &mocks.MockArtistRepo{
+		GetByUserIDFunc: func(ctx context.Context, userID uint) (*model.Artist, error) {
+			return &model.Artist{ID: 1}, nil
+		},
+	}
+	var created *model.Album
+	mockAlbumRepo := &mocks.MockAlbumRepo{
+		CreateFunc: func(ctx context.Context, entity *model.Album) (*model.Album, error) {
+			created = entity
+			return entity, nil
+		},
+		GetByUPCFunc: func(ctx context.Context, upc string) (*model.Album, error) {
+			if upc == "4006381333931" {
+				return &model.Album{ID: 2}, nil
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+	}
+	service := NewAlbumService(mockAlbumRepo, mockArtistRepo, nil, nil)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	newRequest := func(upc string) request.NewAlbumRequest {
+		return request.NewAlbumRequest{Title: "Test Album", ReleaseDate: "2023-01-01", UPC: upc}
+	}
+
+	// Act & Assert
+	_, err := service.NewAlbum(ctx, newRequest("036000291453"), 1)
+	assert.Equal(t, er.ErrUPCFormat, err)
+
+	_, err = service.NewAlbum(ctx, newRequest("4006381333931"), 1)
+	assert.Equal(t, er.ErrUPCTaken, err)
+
+	// UPC-A хранится как EAN-13
+	_, err = service.NewAlbum(ctx, newRequest("036000291452"), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0036000291452", created.UPC)
+}
+
+func TestNewAlbum_ScheduledRelease(t *testing.T) {
+	// Arrange
+	mockArtistRepo := &mocks.MockArtistRepo{
+		GetByUserIDFunc: func(ctx context.Context, userID uint) (*model.Artist, error) {
+			return &model.Artist{ID: 1}, nil
+		},
+	}
+	var created *model.Album
+	mockAlbumRepo := &mocks.MockAlbumRepo{
+		CreateFunc: func(ctx context.Context, entity *model.Album) (*model.Album, error) {
+			created = entity
+			return entity, nil
+		},
+	}
+	service := NewAlbumService(mockAlbumRepo, mockArtistRepo, nil, nil)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	releaseAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	// Act & Assert
+	_, err := service.NewAlbum(ctx, request.NewAlbumRequest{Title: "Next", ReleaseDate: releaseAt.Format(time.RFC3339)}, 1)
+	assert.NoError(t, err)
+	assert.True(t, releaseAt.Equal(created.ReleaseDate))
+	assert.True(t, created.ReleasePending)
+
+	assert.Equal(t, model.StatusDraft, created.Status)
+
+	// Уже вышедший альбом тоже ждет публикации, после нее ReleaseService сообщит о выходе
+	_, err = service.NewAlbum(ctx, request.NewAlbumRequest{Title: "Old", ReleaseDate: "2023-01-01"}, 1)
+	assert.NoError(t, err)
+	assert.True(t, created.ReleasePending)
+}
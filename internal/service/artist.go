@@ -16,13 +16,15 @@ import (
 
 type ArtistService struct {
 	artistRepository repository.IArtistRepository
+	audit            auditLog
 
 	logger *zap.SugaredLogger
 }
 
-func NewArtistService(artist repository.IArtistRepository, log *zap.SugaredLogger) *ArtistService {
+func NewArtistService(artist repository.IArtistRepository, audit repository.IAuditRepository, log *zap.SugaredLogger) *ArtistService {
 	return &ArtistService{
 		artistRepository: artist,
+		audit: newAuditLog(audit, log),
 		logger: log,
 	}
 }
@@ -51,6 +53,7 @@ func (s *ArtistService) NewArtist(ctx *gin.Context, body request.NewArtistReques
 		Description:   body.Description,
 		FormationYear: formationDate,
 		UserID:        userID,
+		Status:        model.StatusDraft,
 	})
 	if err != nil {
 		return nil, &er.InternalError{Message: err.Error()}
@@ -60,18 +63,20 @@ func (s *ArtistService) NewArtist(ctx *gin.Context, body request.NewArtistReques
 		"Name", artist.Name,
 		"user id", artist.UserID,
 	)
+	s.audit.record(ctx, userID, model.ArtistResource, artist.ID, model.AuditCreate, nil, artistState(artist))
 
 	return artist, nil
 }
 
 
-func (s *ArtistService) GetArtist(ctx *gin.Context, strID string) (*model.Artist, error) {
+// GetArtist возвращает артиста с альбомами, которые видит пользователь viewerID
+func (s *ArtistService) GetArtist(ctx *gin.Context, strID string, viewerID uint) (*model.Artist, error) {
 	id, err := strconv.Atoi(strID)
 	if err != nil {
 		return nil, &er.ValidationError{Message: err.Error()}
 	}
 
-	artist, err := s.artistRepository.GetWithAlbums(ctx, uint(id))
+	artist, err := s.artistRepository.GetWithAlbums(ctx, uint(id), viewerID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, er.ErrArtistNotExists
@@ -83,7 +88,7 @@ func (s *ArtistService) GetArtist(ctx *gin.Context, strID string) (*model.Artist
 }
 
 
-func (s *ArtistService) UpdateArtist(ctx *gin.Context, id uint, req request.UpdateArtistRequest) (*model.Artist, error) {
+func (s *ArtistService) UpdateArtist(ctx *gin.Context, id uint, req request.UpdateArtistRequest, userID uint) (*model.Artist, error) {
 	s.logger.Debugw("Attempting to get artist",
 		"id", id,
 	)
@@ -111,10 +116,44 @@ func (s *ArtistService) UpdateArtist(ctx *gin.Context, id uint, req request.Upda
 		"Previous formation year", artist.FormationYear,
 		"Updated formation year", req.FormationYear,
 	)
+	before := artistState(artist)
 	artist.Name = req.ArtistName
 	artist.Description = req.Description
 	artist.FormationYear = formationDate
 
+	updated, err := s.artistRepository.Update(ctx, artist)
+	if err != nil {
+		return nil, err
+	}
 	s.logger.Debug("Artist updated successfully")
-	return s.artistRepository.Update(ctx, artist)
-}
\ No newline at end of file
+	s.audit.record(ctx, userID, model.ArtistResource, id, model.AuditUpdate, before, artistState(updated))
+	return updated, nil
+}
+
+// SetStatus публикует артиста или снимает его с публикации
+func (s *ArtistService) SetStatus(ctx *gin.Context, id uint, status model.Status, userID uint) (*model.Artist, error) {
+	artist, err := s.artistRepository.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrArtistNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	if artist.Status == model.StatusTakenDown {
+		return nil, er.ErrTakenDown
+	}
+
+	s.logger.Debugw("Changing artist status",
+		"id", id,
+		"previous status", artist.Status,
+		"status", status,
+	)
+	before := artistState(artist)
+	artist.Status = status
+	if _, err := s.artistRepository.Update(ctx, artist); err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	s.audit.record(ctx, userID, model.ArtistResource, id, model.AuditStatus, before, artistState(artist))
+	return artist, nil
+}
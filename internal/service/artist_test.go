@@ -24,7 +24,7 @@ func TestNewArtist_UserAlreadyLinked(t *testing.T) {
 			return &model.Artist{}, nil
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewArtistRequest{
@@ -48,7 +48,7 @@ func TestNewArtist_ArtistExists(t *testing.T) {
 			return true
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewArtistRequest{
@@ -72,7 +72,7 @@ func TestNewArtist_InvalidDateFormat(t *testing.T) {
 			return false
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewArtistRequest{
@@ -106,7 +106,7 @@ func TestNewArtist_Success(t *testing.T) {
 			return expectedArtist, nil
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.NewArtistRequest{
@@ -123,10 +123,10 @@ func TestNewArtist_Success(t *testing.T) {
 func TestGetArtist_InvalidID(t *testing.T) {
 	logger := zap.NewNop().Sugar()
 	mockRepo := &mocks.MockArtistRepo{}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
-	artist, err := service.GetArtist(ctx, "abc")
+	artist, err := service.GetArtist(ctx, "abc", 0)
 	assert.Nil(t, artist)
 	assert.Error(t, err)
 	assert.IsType(t, &er.ValidationError{}, err)
@@ -135,14 +135,14 @@ func TestGetArtist_InvalidID(t *testing.T) {
 func TestGetArtist_NotFound(t *testing.T) {
 	logger := zap.NewNop().Sugar()
 	mockRepo := &mocks.MockArtistRepo{
-		GetWithAlbumsFunc: func(ctx context.Context, id uint) (*model.Artist, error) {
+		GetWithAlbumsFunc: func(ctx context.Context, id, viewerID uint) (*model.Artist, error) {
 			return nil, gorm.ErrRecordNotFound
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
-	artist, err := service.GetArtist(ctx, "1")
+	artist, err := service.GetArtist(ctx, "1", 0)
 	assert.Nil(t, artist)
 	assert.Error(t, err)
 	assert.Equal(t, er.ErrArtistNotExists, err)
@@ -154,14 +154,14 @@ func TestGetArtist_Success(t *testing.T) {
 		ID: 1,
 	}
 	mockRepo := &mocks.MockArtistRepo{
-		GetWithAlbumsFunc: func(ctx context.Context, id uint) (*model.Artist, error) {
+		GetWithAlbumsFunc: func(ctx context.Context, id, viewerID uint) (*model.Artist, error) {
 			return expectedArtist, nil
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
-	artist, err := service.GetArtist(ctx, "1")
+	artist, err := service.GetArtist(ctx, "1", 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, artist)
 	assert.Equal(t, expectedArtist, artist)
@@ -174,7 +174,7 @@ func TestUpdateArtist_NotFound(t *testing.T) {
 			return nil, gorm.ErrRecordNotFound
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.UpdateArtistRequest{
@@ -182,7 +182,7 @@ func TestUpdateArtist_NotFound(t *testing.T) {
 		Description:   "Updated Description",
 		FormationYear: "2021-01-01",
 	}
-	artist, err := service.UpdateArtist(ctx, 1, req)
+	artist, err := service.UpdateArtist(ctx, 1, req, 1)
 	assert.Nil(t, artist)
 	assert.Error(t, err)
 	assert.Equal(t, er.ErrArtistNotExists, err)
@@ -195,7 +195,7 @@ func TestUpdateArtist_InvalidDateFormat(t *testing.T) {
 			return &model.Artist{}, nil
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	service := NewArtistService(mockRepo, nil, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.UpdateArtistRequest{
@@ -203,7 +203,7 @@ func TestUpdateArtist_InvalidDateFormat(t *testing.T) {
 		Description:   "Updated Description",
 		FormationYear: "invalid-date",
 	}
-	artist, err := service.UpdateArtist(ctx, 1, req)
+	artist, err := service.UpdateArtist(ctx, 1, req, 1)
 	assert.Nil(t, artist)
 	assert.Error(t, err)
 	assert.Equal(t, er.ErrDateFormat, err)
@@ -231,7 +231,14 @@ func TestUpdateArtist_Success(t *testing.T) {
 			return updatedArtist, nil
 		},
 	}
-	service := NewArtistService(mockRepo, logger)
+	var entries []*model.AuditEntry
+	auditRepo := &mocks.MockAuditRepo{
+		CreateFunc: func(ctx context.Context, entry *model.AuditEntry) error {
+			entries = append(entries, entry)
+			return nil
+		},
+	}
+	service := NewArtistService(mockRepo, auditRepo, logger)
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	req := request.UpdateArtistRequest{
@@ -239,8 +246,15 @@ func TestUpdateArtist_Success(t *testing.T) {
 		Description:   "Updated Description",
 		FormationYear: "2021-01-01",
 	}
-	artist, err := service.UpdateArtist(ctx, 1, req)
+	artist, err := service.UpdateArtist(ctx, 1, req, 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, artist)
 	assert.Equal(t, updatedArtist, artist)
+
+	// Изменение попало в журнал со снимками до и после
+	assert.Len(t, entries, 1)
+	assert.Equal(t, model.AuditUpdate, entries[0].Action)
+	assert.Equal(t, uint(1), entries[0].ActorID)
+	assert.Contains(t, string(entries[0].Before), `"Name":"Original Artist"`)
+	assert.Contains(t, string(entries[0].After), `"Name":"Updated Artist"`)
 }
\ No newline at end of file
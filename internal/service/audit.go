@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+
+	"go.uber.org/zap"
+)
+
+// AuditService отдает журнал изменений каталога администраторам и редакторам записей
+type AuditService struct {
+	auditRepo repository.IAuditRepository
+}
+
+func NewAuditService(audit repository.IAuditRepository) *AuditService {
+	return &AuditService{
+		auditRepo: audit,
+	}
+}
+
+// GetAudit возвращает журнал по фильтру. Пустой EntityType - записи любого типа.
+func (s *AuditService) GetAudit(ctx context.Context, filter model.AuditFilter, limit, offset int) ([]model.AuditEntry, int64, error) {
+	switch filter.EntityType {
+	case "", model.ArtistResource, model.AlbumResource, model.SongResource, model.GenreResource:
+	default:
+		return nil, 0, er.ErrAuditEntity
+	}
+
+	entries, total, err := s.auditRepo.Find(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return entries, total, nil
+}
+
+// auditLog дописывает изменения в журнал из сервисов каталога.
+// Ошибка записи не отменяет уже сделанное изменение и только логируется.
+type auditLog struct {
+	repo   repository.IAuditRepository
+	logger *zap.SugaredLogger
+}
+
+func newAuditLog(repo repository.IAuditRepository, logger *zap.SugaredLogger) auditLog {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	return auditLog{repo: repo, logger: logger}
+}
+
+// record сохраняет снимки записи до и после изменения, nil - записи не было или ее удалили
+func (a auditLog) record(ctx context.Context, actorID uint, entity model.Resource, entityID uint, action model.AuditAction, before, after any) {
+	if a.repo == nil {
+		return
+	}
+
+	entry := &model.AuditEntry{
+		ActorID:    actorID,
+		EntityType: entity,
+		EntityID:   entityID,
+		Action:     action,
+		Before:     a.snapshot(before),
+		After:      a.snapshot(after),
+	}
+	if err := a.repo.Create(ctx, entry); err != nil {
+		a.logger.Errorw("Failed to write audit entry",
+			"entity", entity,
+			"entity id", entityID,
+			"action", action,
+			"actor id", actorID,
+			"error", err.Error(),
+		)
+	}
+}
+
+func (a auditLog) snapshot(state any) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		a.logger.Errorw("Failed to encode audit snapshot",
+			"error", err.Error(),
+		)
+		return nil
+	}
+	return data
+}
+
+// Снимки без связанных записей: у альбомов, песен и текстов своя история
+func artistState(artist *model.Artist) model.Artist {
+	state := *artist
+	state.Albums = nil
+	return state
+}
+
+func albumState(album *model.Album) model.Album {
+	state := *album
+	state.Songs = nil
+	return state
+}
+
+func songState(song *model.Song) model.Song {
+	state := *song
+	state.SongGenres = nil
+	state.Lyrics = nil
+	return state
+}
+
+func genreState(genre *model.Genre) model.Genre {
+	state := *genre
+	state.Children = nil
+	state.SongGenres = nil
+	return state
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGetAudit(t *testing.T) {
+	var got model.AuditFilter
+	auditRepo := &mocks.MockAuditRepo{
+		FindFunc: func(ctx context.Context, filter model.AuditFilter, limit, offset int) ([]model.AuditEntry, int64, error) {
+			got = filter
+			return []model.AuditEntry{{ID: 1, EntityType: filter.EntityType}}, 1, nil
+		},
+	}
+	service := NewAuditService(auditRepo)
+
+	_, _, err := service.GetAudit(context.Background(), model.AuditFilter{EntityType: "user"}, 10, 0)
+	assert.Equal(t, er.ErrAuditEntity, err)
+
+	filter := model.AuditFilter{EntityType: model.GenreResource, ActorID: 3}
+	entries, total, err := service.GetAudit(context.Background(), filter, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, filter, got)
+}
+
+func TestAuditLogRecord(t *testing.T) {
+	var entries []*model.AuditEntry
+	auditRepo := &mocks.MockAuditRepo{
+		CreateFunc: func(ctx context.Context, entry *model.AuditEntry) error {
+			entries = append(entries, entry)
+			return nil
+		},
+	}
+	audit := newAuditLog(auditRepo, zap.NewNop().Sugar())
+
+	before := &model.Artist{ID: 4, Name: "Old", Albums: []model.Album{{ID: 1}}}
+	after := &model.Artist{ID: 4, Name: "New"}
+	audit.record(context.Background(), 2, model.ArtistResource, 4, model.AuditUpdate, artistState(before), artistState(after))
+	audit.record(context.Background(), 2, model.ArtistResource, 4, model.AuditDelete, nil, nil)
+
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, uint(2), entries[0].ActorID)
+		assert.Contains(t, string(entries[0].Before), `"Name":"Old"`)
+		// Альбомы в снимок артиста не попадают
+		assert.Contains(t, string(entries[0].Before), `"Albums":null`)
+		assert.Contains(t, string(entries[0].After), `"Name":"New"`)
+		assert.Nil(t, entries[1].Before)
+		assert.Nil(t, entries[1].After)
+	}
+
+	// Сбой журнала не должен ронять изменение
+	auditRepo.CreateFunc = func(ctx context.Context, entry *model.AuditEntry) error {
+		return errors.New("db is down")
+	}
+	assert.NotPanics(t, func() {
+		audit.record(context.Background(), 2, model.ArtistResource, 4, model.AuditCreate, nil, artistState(after))
+	})
+	// Без репозитория журнал не ведется
+	assert.NotPanics(t, func() {
+		newAuditLog(nil, nil).record(context.Background(), 2, model.ArtistResource, 4, model.AuditCreate, nil, nil)
+	})
+}
+
+func TestAuditLyricsAndPermissions(t *testing.T) {
+	var entries []*model.AuditEntry
+	auditRepo := &mocks.MockAuditRepo{
+		CreateFunc: func(ctx context.Context, entry *model.AuditEntry) error {
+			entries = append(entries, entry)
+			return nil
+		},
+	}
+	ctx := context.Background()
+
+	original := model.Lyrics{ID: 2, SongID: 3, Language: "en", Kind: model.LyricsOriginal, Couplets: []model.Couplet{{Text: "Old line"}}}
+	lyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return []model.Lyrics{original}, nil
+		},
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			return nil
+		},
+	}
+	lyrics := NewLyricsService(lyricsRepo, nil, auditRepo, zap.NewNop().Sugar())
+	_, err := lyrics.SetLyrics(ctx, 3, 5, request.AddLyrics{Language: "en", Text: []request.Couplet{{Text: "New line"}}})
+	assert.NoError(t, err)
+
+	// Изменение текста попадает в журнал песни с прежней и новой версией
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, model.SongResource, entries[0].EntityType)
+		assert.Equal(t, uint(3), entries[0].EntityID)
+		assert.Equal(t, model.AuditUpdate, entries[0].Action)
+		assert.Equal(t, uint(5), entries[0].ActorID)
+		assert.Contains(t, string(entries[0].Before), "Old line")
+		assert.Contains(t, string(entries[0].After), "New line")
+	}
+
+	permissions, _ := newPermissionService()
+	permissions.audit = newAuditLog(auditRepo, zap.NewNop().Sugar())
+	assert.NoError(t, permissions.GrantView(ctx, model.SongResource, 3, "listener", 5))
+	assert.NoError(t, permissions.RevokeView(ctx, model.SongResource, 3, "listener", 5))
+	// Отзыв права, которого нет, журнал не трогает
+	assert.NoError(t, permissions.RevokeView(ctx, model.SongResource, 3, "listener", 5))
+
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, model.AuditCreate, entries[1].Action)
+		assert.Contains(t, string(entries[1].After), `"UserID":8`)
+		assert.Equal(t, model.AuditDelete, entries[2].Action)
+		assert.Contains(t, string(entries[2].Before), `"Permission":"view"`)
+		assert.Nil(t, entries[2].After)
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/config"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"music-lib/pkg/timecode"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Наибольшая длина комментария в символах
+const maxCommentLength = 2000
+
+// CommentService ведет ветки комментариев к песням и альбомам.
+// Видимость самой записи проверяет вызывающий.
+type CommentService struct {
+	commentRepo repository.ICommentRepository
+	songRepo    repository.ISongRepository
+	conf        config.CommentConfig
+
+	logger *zap.SugaredLogger
+	now    func() time.Time
+}
+
+func NewCommentService(
+	comment repository.ICommentRepository,
+	song repository.ISongRepository,
+	conf config.CommentConfig,
+	logger *zap.SugaredLogger,
+) *CommentService {
+	return &CommentService{
+		commentRepo: comment,
+		songRepo:    song,
+		conf:        conf,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// AddComment публикует комментарий или ответ. Момент песни указывается только для песен
+// и не может быть дальше ее длительности.
+func (s *CommentService) AddComment(ctx context.Context, resource model.Resource, id, userID uint, req request.CommentRequest) (*model.Comment, error) {
+	text, err := commentText(req.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &model.Comment{
+		UserID:       userID,
+		ResourceType: resource,
+		ResourceID:   id,
+		Text:         text,
+	}
+
+	if req.ParentID != 0 {
+		parent, err := s.getComment(ctx, req.ParentID, userID)
+		if err != nil {
+			if errors.Is(err, er.ErrCommentNotExists) {
+				return nil, er.ErrCommentParent
+			}
+			return nil, err
+		}
+		if parent.ResourceType != resource || parent.ResourceID != id {
+			return nil, er.ErrCommentParent
+		}
+		comment.ParentID = &parent.ID
+	}
+
+	if req.At != "" {
+		at, err := s.anchor(ctx, resource, id, req.At)
+		if err != nil {
+			return nil, err
+		}
+		comment.AtSec = &at
+	}
+
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		s.logger.Errorw("Failed to create comment",
+			"resource", resource,
+			"id", id,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return s.getComment(ctx, comment.ID, userID)
+}
+
+// UpdateComment меняет текст комментария. Править может только автор и только в первые минуты после публикации.
+func (s *CommentService) UpdateComment(ctx context.Context, id, userID uint, text string) (*model.Comment, error) {
+	comment, err := s.getComment(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, er.ErrCommentAuthor
+	}
+	now := s.now()
+	if now.Sub(comment.CreatedAt) > s.conf.EditWindow {
+		return nil, er.ErrCommentEditWindow
+	}
+	if text, err = commentText(text); err != nil {
+		return nil, err
+	}
+
+	if err := s.commentRepo.UpdateText(ctx, id, text, now); err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return s.getComment(ctx, id, userID)
+}
+
+// DeleteComment удаляет комментарий автора или, для администратора, любой
+func (s *CommentService) DeleteComment(ctx context.Context, id, userID uint, admin bool) error {
+	comment, err := s.getComment(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID && !admin {
+		return er.ErrCommentOwner
+	}
+
+	if err := s.commentRepo.Delete(ctx, id, userID); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Comment deleted",
+		"comment id", id,
+		"user id", userID,
+		"by author", comment.UserID == userID,
+	)
+	return nil
+}
+
+func (s *CommentService) Like(ctx context.Context, id, userID uint) error {
+	if _, err := s.getComment(ctx, id, userID); err != nil {
+		return err
+	}
+	if err := s.commentRepo.Like(ctx, id, userID); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *CommentService) Unlike(ctx context.Context, id, userID uint) error {
+	if _, err := s.getComment(ctx, id, userID); err != nil {
+		return err
+	}
+	if err := s.commentRepo.Unlike(ctx, id, userID); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// GetComments возвращает комментарии верхнего уровня (parentID 0) или ответы на parentID
+func (s *CommentService) GetComments(ctx context.Context, resource model.Resource, id, parentID, viewerID uint, sort model.CommentSort, limit, offset int) ([]model.Comment, int64, error) {
+	switch sort {
+	case "":
+		sort = model.CommentsNewest
+	case model.CommentsNewest, model.CommentsOldest, model.CommentsTop:
+	default:
+		return nil, 0, er.ErrCommentSort
+	}
+
+	comments, total, err := s.commentRepo.GetThread(ctx, resource, id, parentID, viewerID, sort, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return comments, total, nil
+}
+
+func (s *CommentService) getComment(ctx context.Context, id, viewerID uint) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, id, viewerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrCommentNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return comment, nil
+}
+
+// anchor разбирает момент песни и проверяет, что он не дальше ее конца
+func (s *CommentService) anchor(ctx context.Context, resource model.Resource, id uint, value string) (int, error) {
+	if resource != model.SongResource {
+		return 0, er.ErrCommentAnchor
+	}
+	at, err := timecode.Parse(value)
+	if err != nil {
+		return 0, er.ErrCommentAnchor
+	}
+
+	song, err := s.songRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, er.ErrSongNotExists
+		}
+		return 0, &er.InternalError{Message: err.Error()}
+	}
+	if at > song.Duration {
+		return 0, er.ErrCommentAnchor
+	}
+	return at, nil
+}
+
+func commentText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxCommentLength {
+		return "", er.ErrCommentText
+	}
+	return text, nil
+}
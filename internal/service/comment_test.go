@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/config"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Комментарий 1 пользователя 2 к песне 7 длиной 3:00, опубликован в 12:00
+func newCommentService() (*CommentService, *[]uint) {
+	posted := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	comments := map[uint]*model.Comment{
+		1: {ID: 1, UserID: 2, ResourceType: model.SongResource, ResourceID: 7, Text: "Nice", CreatedAt: posted},
+	}
+	var deleted []uint
+	commentRepo := &mocks.MockCommentRepo{
+		CreateFunc: func(ctx context.Context, comment *model.Comment) error {
+			comment.ID = uint(len(comments) + 1)
+			comment.CreatedAt = posted
+			comments[comment.ID] = comment
+			return nil
+		},
+		GetByIDFunc: func(ctx context.Context, id, viewerID uint) (*model.Comment, error) {
+			comment, ok := comments[id]
+			if !ok {
+				return nil, gorm.ErrRecordNotFound
+			}
+			copied := *comment
+			return &copied, nil
+		},
+		UpdateTextFunc: func(ctx context.Context, id uint, text string, editedAt time.Time) error {
+			comments[id].Text = text
+			comments[id].EditedAt = &editedAt
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, id, deletedBy uint) error {
+			deleted = append(deleted, id)
+			return nil
+		},
+	}
+	songRepo := &mocks.MockSongRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Song, error) {
+			if id != 7 {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return &model.Song{ID: 7, Duration: 180}, nil
+		},
+	}
+
+	service := NewCommentService(commentRepo, songRepo, config.CommentConfig{EditWindow: 15 * time.Minute}, zap.NewNop().Sugar())
+	service.now = func() time.Time { return posted.Add(10 * time.Minute) }
+	return service, &deleted
+}
+
+func TestAddComment(t *testing.T) {
+	service, _ := newCommentService()
+	ctx := context.Background()
+
+	_, err := service.AddComment(ctx, model.SongResource, 7, 3, request.CommentRequest{Text: " "})
+	assert.Equal(t, er.ErrCommentText, err)
+	_, err = service.AddComment(ctx, model.SongResource, 7, 3, request.CommentRequest{Text: "Drop", At: "3:01"})
+	assert.Equal(t, er.ErrCommentAnchor, err)
+	_, err = service.AddComment(ctx, model.SongResource, 7, 3, request.CommentRequest{Text: "Drop", At: "1:5"})
+	assert.Equal(t, er.ErrCommentAnchor, err)
+	_, err = service.AddComment(ctx, model.AlbumResource, 7, 3, request.CommentRequest{Text: "Drop", At: "1:00"})
+	assert.Equal(t, er.ErrCommentAnchor, err)
+	// Ответ должен относиться к той же записи
+	_, err = service.AddComment(ctx, model.SongResource, 8, 3, request.CommentRequest{Text: "Agree", ParentID: 1})
+	assert.Equal(t, er.ErrCommentParent, err)
+	_, err = service.AddComment(ctx, model.SongResource, 7, 3, request.CommentRequest{Text: "Agree", ParentID: 9})
+	assert.Equal(t, er.ErrCommentParent, err)
+
+	comment, err := service.AddComment(ctx, model.SongResource, 7, 3, request.CommentRequest{Text: " Agree ", ParentID: 1, At: "1:23"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Agree", comment.Text)
+	assert.Equal(t, uint(1), *comment.ParentID)
+	assert.Equal(t, 83, *comment.AtSec)
+}
+
+func TestUpdateComment(t *testing.T) {
+	service, _ := newCommentService()
+	ctx := context.Background()
+
+	_, err := service.UpdateComment(ctx, 1, 3, "Not mine")
+	assert.Equal(t, er.ErrCommentAuthor, err)
+
+	comment, err := service.UpdateComment(ctx, 1, 2, "Nice indeed")
+	assert.NoError(t, err)
+	assert.Equal(t, "Nice indeed", comment.Text)
+	assert.NotNil(t, comment.EditedAt)
+
+	service.now = func() time.Time { return comment.CreatedAt.Add(16 * time.Minute) }
+	_, err = service.UpdateComment(ctx, 1, 2, "Too late")
+	assert.Equal(t, er.ErrCommentEditWindow, err)
+}
+
+func TestDeleteComment(t *testing.T) {
+	service, deleted := newCommentService()
+	ctx := context.Background()
+
+	assert.Equal(t, er.ErrCommentOwner, service.DeleteComment(ctx, 1, 3, false))
+	assert.Equal(t, er.ErrCommentNotExists, service.DeleteComment(ctx, 9, 2, true))
+	assert.NoError(t, service.DeleteComment(ctx, 1, 3, true))
+	assert.NoError(t, service.DeleteComment(ctx, 1, 2, false))
+	assert.Equal(t, []uint{1, 1}, *deleted)
+}
+
+func TestGetComments_Sort(t *testing.T) {
+	var got model.CommentSort
+	commentRepo := &mocks.MockCommentRepo{
+		GetThreadFunc: func(ctx context.Context, resource model.Resource, resourceID, parentID, viewerID uint, sort model.CommentSort, limit, offset int) ([]model.Comment, int64, error) {
+			got = sort
+			return nil, 0, nil
+		},
+	}
+	service := NewCommentService(commentRepo, nil, config.CommentConfig{}, zap.NewNop().Sugar())
+
+	_, _, err := service.GetComments(context.Background(), model.SongResource, 7, 0, 0, "best", 10, 0)
+	assert.Equal(t, er.ErrCommentSort, err)
+
+	_, _, err = service.GetComments(context.Background(), model.SongResource, 7, 0, 0, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, model.CommentsNewest, got)
+	_, _, err = service.GetComments(context.Background(), model.SongResource, 7, 0, 0, model.CommentsTop, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, model.CommentsTop, got)
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"music-lib/pkg/event"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FollowService ведет подписки на артистов и пользователей и собирает ленту новинок из событий о релизах.
+// Видимость артиста при подписке проверяет вызывающий.
+type FollowService struct {
+	followRepo repository.IFollowRepository
+	userRepo   repository.IUserRepository
+
+	logger *zap.SugaredLogger
+}
+
+func NewFollowService(follow repository.IFollowRepository, user repository.IUserRepository, logger *zap.SugaredLogger) *FollowService {
+	return &FollowService{
+		followRepo: follow,
+		userRepo:   user,
+		logger:     logger,
+	}
+}
+
+func (s *FollowService) FollowArtist(ctx context.Context, userID, artistID uint) error {
+	if err := s.followRepo.FollowArtist(ctx, userID, artistID); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// UnfollowArtist снимает подписку, новинки артиста пропадают из ленты
+func (s *FollowService) UnfollowArtist(ctx context.Context, userID, artistID uint) error {
+	if err := s.followRepo.UnfollowArtist(ctx, userID, artistID); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// IsFollowing сообщает, подписан ли пользователь на артиста. userID 0 - анонимный пользователь.
+func (s *FollowService) IsFollowing(ctx context.Context, userID, artistID uint) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+	following, err := s.followRepo.IsFollowing(ctx, userID, artistID)
+	if err != nil {
+		return false, &er.InternalError{Message: err.Error()}
+	}
+	return following, nil
+}
+
+// FollowUser подписывает followerID на пользователя name
+func (s *FollowService) FollowUser(ctx context.Context, followerID uint, name string) error {
+	user, err := s.findUser(name)
+	if err != nil {
+		return err
+	}
+	if user.ID == followerID {
+		return er.ErrFollowSelf
+	}
+
+	if err := s.followRepo.FollowUser(ctx, followerID, user.ID); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *FollowService) UnfollowUser(ctx context.Context, followerID uint, name string) error {
+	user, err := s.findUser(name)
+	if err != nil {
+		return err
+	}
+	if err := s.followRepo.UnfollowUser(ctx, followerID, user.ID); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// GetFollowers возвращает подписчиков пользователя name. Аватары показываются так, как их видит viewerID.
+func (s *FollowService) GetFollowers(ctx context.Context, name string, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error) {
+	user, err := s.findUser(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, total, err := s.followRepo.GetFollowers(ctx, user.ID, viewerID, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return entries, total, nil
+}
+
+// GetFollowing возвращает пользователей, на которых подписан name
+func (s *FollowService) GetFollowing(ctx context.Context, name string, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error) {
+	user, err := s.findUser(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, total, err := s.followRepo.GetFollowing(ctx, user.ID, viewerID, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return entries, total, nil
+}
+
+func (s *FollowService) findUser(name string) (*model.User, error) {
+	user, err := s.userRepo.FindByKey(repository.NameKey, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrUserNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return user, nil
+}
+
+// GetFeed возвращает ленту новинок пользователя, сначала новые
+func (s *FollowService) GetFeed(ctx context.Context, userID uint, limit, offset int) ([]model.FeedItem, int64, error) {
+	items, total, err := s.followRepo.GetFeed(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return items, total, nil
+}
+
+// Listen раскладывает новинки по лентам подписчиков, пока не закроется events или не отменен ctx
+func (s *FollowService) Listen(ctx context.Context, events <-chan event.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-events:
+			if !ok {
+				return
+			}
+			if item := feedItem(msg); item != nil {
+				s.deliver(ctx, item)
+			}
+		}
+	}
+}
+
+func (s *FollowService) deliver(ctx context.Context, item *model.FeedItem) {
+	delivered, err := s.followRepo.AddToFeed(ctx, item)
+	if err != nil {
+		s.logger.Errorw("Failed to add release to feeds",
+			"resource", item.ResourceType,
+			"id", item.ResourceID,
+			"artist id", item.ArtistID,
+			"error", err.Error(),
+		)
+		return
+	}
+	s.logger.Debugw("Release added to feeds",
+		"resource", item.ResourceType,
+		"id", item.ResourceID,
+		"followers", delivered,
+	)
+}
+
+// feedItem возвращает запись ленты для события о релизе, для остальных событий - nil
+func feedItem(msg event.Event) *model.FeedItem {
+	switch data := msg.Data.(type) {
+	case event.Released:
+		if msg.Type != event.EventReleased {
+			return nil
+		}
+		return &model.FeedItem{
+			ResourceType: model.AlbumResource,
+			ResourceID:   data.AlbumID,
+			ArtistID:     data.ArtistID,
+			AlbumID:      data.AlbumID,
+			Title:        data.Title,
+			ReleaseDate:  data.ReleaseDate,
+		}
+	case event.SongReleased:
+		if msg.Type != event.EventSongReleased {
+			return nil
+		}
+		return &model.FeedItem{
+			ResourceType: model.SongResource,
+			ResourceID:   data.SongID,
+			ArtistID:     data.ArtistID,
+			AlbumID:      data.AlbumID,
+			Title:        data.Title,
+			ReleaseDate:  data.ReleaseDate,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/event"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestFollowService_Listen(t *testing.T) {
+	var delivered []model.FeedItem
+	followRepo := &mocks.MockFollowRepo{
+		AddToFeedFunc: func(ctx context.Context, item *model.FeedItem) (int64, error) {
+			delivered = append(delivered, *item)
+			return 2, nil
+		},
+	}
+	service := NewFollowService(followRepo, nil, zap.NewNop().Sugar())
+
+	released := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	events := make(chan event.Event, 3)
+	events <- event.Event{Type: event.EventReleased, Data: event.Released{AlbumID: 3, ArtistID: 5, Title: "LP", ReleaseDate: released}}
+	events <- event.Event{Type: event.EventSendEmail, Data: "not a release"}
+	events <- event.Event{Type: event.EventSongReleased, Data: event.SongReleased{SongID: 9, AlbumID: 3, ArtistID: 5, Title: "Bonus"}}
+	close(events)
+
+	service.Listen(context.Background(), events)
+
+	assert.Equal(t, []model.FeedItem{
+		{ResourceType: model.AlbumResource, ResourceID: 3, ArtistID: 5, AlbumID: 3, Title: "LP", ReleaseDate: released},
+		{ResourceType: model.SongResource, ResourceID: 9, ArtistID: 5, AlbumID: 3, Title: "Bonus"},
+	}, delivered)
+}
+
+func TestIsFollowing_Anonymous(t *testing.T) {
+	service := NewFollowService(&mocks.MockFollowRepo{}, nil, zap.NewNop().Sugar())
+
+	following, err := service.IsFollowing(context.Background(), 0, 5)
+	assert.NoError(t, err)
+	assert.False(t, following)
+}
+
+func TestSetStatus_AnnouncesSong(t *testing.T) {
+	albums := map[uint]*model.Album{
+		1: {ID: 1, ArtistID: 5, Status: model.StatusPublished},
+		2: {ID: 2, ArtistID: 5, Status: model.StatusPublished, ReleasePending: true},
+	}
+	songRepo := &mocks.MockSongRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Song, error) {
+			return &model.Song{ID: id, AlbumID: id, Title: "Bonus", Status: model.StatusDraft}, nil
+		},
+		UpdateFunc: func(ctx context.Context, song *model.Song) (*model.Song, error) {
+			return song, nil
+		},
+	}
+	albumRepo := &mocks.MockAlbumRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Album, error) {
+			return albums[id], nil
+		},
+	}
+	bus := event.NewEventBus()
+	events := bus.Subscribe()
+	service := NewSongService(songRepo, albumRepo, nil, nil, nil, nil, nil, nil, nil, bus, zap.NewNop().Sugar())
+
+	// Песня из альбома, который еще ждет релиза, попадет в ленту вместе с альбомом
+	_, err := service.SetStatus(context.Background(), 2, model.StatusPublished, 1)
+	assert.NoError(t, err)
+	_, err = service.SetStatus(context.Background(), 1, model.StatusPublished, 1)
+	assert.NoError(t, err)
+
+	select {
+	case msg := <-events:
+		assert.Equal(t, event.EventSongReleased, msg.Type)
+		assert.Equal(t, event.SongReleased{SongID: 1, AlbumID: 1, ArtistID: 5, Title: "Bonus"}, msg.Data)
+	case <-time.After(time.Second):
+		t.Fatal("song release was not announced")
+	}
+	select {
+	case msg := <-events:
+		t.Fatalf("unexpected event %v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
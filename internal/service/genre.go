@@ -1,10 +1,14 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"music-lib/internal/dto/request"
 	"music-lib/internal/model"
 	"music-lib/internal/repository"
 	"music-lib/pkg/er"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -13,31 +17,52 @@ import (
 
 type GenreService struct {
 	genreRepo repository.IGenreRepository
+	audit     auditLog
 
 	logger *zap.SugaredLogger
 }
 
 func NewGenreService(
 	genre repository.IGenreRepository,
+	audit repository.IAuditRepository,
 	sugar *zap.SugaredLogger,) *GenreService {
 	return &GenreService{
 		genreRepo: genre,
+		audit: newAuditLog(audit, sugar),
 		logger: sugar,
 	}
 }
 
 
-func (s *GenreService) NewGenre(ctx *gin.Context, genreName string) error {
+// NewGenre создает жанр. Имя не должно совпадать с именем или синонимом другого жанра.
+func (s *GenreService) NewGenre(ctx *gin.Context, req request.NewGenreRequest, userID uint) (*model.Genre, error) {
+	genreName := strings.TrimSpace(req.Name)
 	if s.genreRepo.IsExists(ctx, genreName) {
 		s.logger.Debugw("Genre already exists",
 			"genre name", genreName,
 		)
-		return er.ErrGenreExists
+		return nil, er.ErrGenreExists
 	}
 
-	_, err := s.genreRepo.Create(ctx, &model.Genre{
-		Name: genreName,
-		SongGenres: nil,
+	aliases, err := s.aliases(ctx, 0, genreName, req.Aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	var parentID *uint
+	if req.ParentID != 0 {
+		if err := s.checkParent(ctx, 0, req.ParentID); err != nil {
+			return nil, err
+		}
+		parentID = &req.ParentID
+	}
+
+	genre, err := s.genreRepo.Create(ctx, &model.Genre{
+		Name:        genreName,
+		Description: req.Description,
+		ParentID:    parentID,
+		Aliases:     aliases,
+		SongGenres:  nil,
 	})
 
 	if err != nil {
@@ -46,17 +71,142 @@ func (s *GenreService) NewGenre(ctx *gin.Context, genreName string) error {
 			"error type", "internal",
 			"error", err.Error(),
 		)
-		return &er.InternalError{Message: err.Error()}
+		return nil, &er.InternalError{Message: err.Error()}
 	}
 
 	s.logger.Debugw("New genre added successfully",
 		"genre name", genreName,
 	)
-	return nil
+	s.audit.record(ctx, userID, model.GenreResource, genre.ID, model.AuditCreate, nil, genreState(genre))
+	return genre, nil
+}
+
+// UpdateGenre меняет имя, описание, родителя и синонимы жанра
+func (s *GenreService) UpdateGenre(ctx *gin.Context, id uint, req request.UpdateGenreRequest, userID uint) (*model.Genre, error) {
+	genre, err := s.getGenre(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := genreState(genre)
+
+	if name := strings.TrimSpace(req.NewName); name != "" {
+		found, err := s.genreRepo.FindByName(ctx, name)
+		if err == nil && found.ID != id {
+			return nil, er.ErrGenreExists
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &er.InternalError{Message: err.Error()}
+		}
+		genre.Name = name
+	}
+	if req.Description != nil {
+		genre.Description = *req.Description
+	}
+	if req.ParentID != nil {
+		genre.ParentID = nil
+		if *req.ParentID != 0 {
+			if err := s.checkParent(ctx, id, *req.ParentID); err != nil {
+				return nil, err
+			}
+			genre.ParentID = req.ParentID
+		}
+	}
+	if req.Aliases != nil {
+		if genre.Aliases, err = s.aliases(ctx, id, genre.Name, req.Aliases); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = s.genreRepo.Update(ctx, genre)
+	if err != nil {
+		s.logger.Errorw("Error while updating genre",
+			"error type", "internal",
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debug("genre updated successfully")
+	s.audit.record(ctx, userID, model.GenreResource, id, model.AuditUpdate, before, genreState(genre))
+	return genre, nil
+}
+
+// GetGenre возвращает жанр с синонимами и прямыми поджанрами
+func (s *GenreService) GetGenre(ctx context.Context, id uint) (*model.Genre, error) {
+	genre, err := s.genreRepo.GetWithChildren(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrGenreNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return genre, nil
 }
 
+// GetGenres возвращает все жанры по алфавиту или жанр, чье имя или синоним совпадает с query
+func (s *GenreService) GetGenres(ctx context.Context, query string) ([]model.Genre, error) {
+	if query = strings.TrimSpace(query); query == "" {
+		genres, err := s.genreRepo.GetAll(ctx)
+		if err != nil {
+			return nil, &er.InternalError{Message: err.Error()}
+		}
+		return genres, nil
+	}
+
+	genre, err := s.genreRepo.FindByName(ctx, query)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return []model.Genre{}, nil
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return []model.Genre{*genre}, nil
+}
 
-func (s *GenreService) UpdateGenre(ctx *gin.Context, id uint, nameToUpdate string) error {
+func (s *GenreService) GetGenreTree(ctx context.Context) ([]model.GenreTree, error) {
+	genres, err := s.genreRepo.GetAll(ctx)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return buildGenreTree(genres), nil
+}
+
+// DeleteGenre удаляет жанр. Его песни переходят к жанру replacementID, при 0 - теряют этот жанр.
+func (s *GenreService) DeleteGenre(ctx context.Context, id, replacementID, userID uint) error {
+	genre, err := s.getGenre(ctx, id)
+	if err != nil {
+		return err
+	}
+	if replacementID != 0 {
+		if replacementID == id {
+			return er.ErrGenreReplacement
+		}
+		if _, err := s.genreRepo.GetById(ctx, replacementID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return er.ErrGenreReplacement
+			}
+			return &er.InternalError{Message: err.Error()}
+		}
+	}
+
+	if err := s.genreRepo.DeleteAndReassign(ctx, id, replacementID); err != nil {
+		s.logger.Errorw("Error while deleting genre",
+			"genre id", id,
+			"replacement id", replacementID,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Infow("Genre deleted",
+		"genre id", id,
+		"replacement id", replacementID,
+	)
+	s.audit.record(ctx, userID, model.GenreResource, id, model.AuditDelete, genreState(genre), nil)
+	return nil
+}
+
+func (s *GenreService) getGenre(ctx context.Context, id uint) (*model.Genre, error) {
 	genre, err := s.genreRepo.GetById(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -64,25 +214,89 @@ func (s *GenreService) UpdateGenre(ctx *gin.Context, id uint, nameToUpdate strin
 				"genre id", id,
 				"error", err.Error(),
 			)
-			return er.ErrGenreNotExists
+			return nil, er.ErrGenreNotExists
 		}
 		s.logger.Errorw("Error while finding genre",
 			"error type", "internal",
 			"error", err.Error(),
 		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return genre, nil
+}
+
+// checkParent проверяет, что родитель существует и не входит в поддерево жанра id
+func (s *GenreService) checkParent(ctx context.Context, id, parentID uint) error {
+	if _, err := s.genreRepo.GetById(ctx, parentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return er.ErrGenreParent
+		}
 		return &er.InternalError{Message: err.Error()}
 	}
+	if id == 0 {
+		return nil
+	}
 
-	genre.Name = nameToUpdate
-	_, err = s.genreRepo.Update(ctx, genre)
+	subtree, err := s.genreRepo.GetSubtreeIDs(ctx, id)
 	if err != nil {
-		s.logger.Errorw("Error while updating genre",
-			"error type", "internal",
-			"error", err.Error(),
-		)
 		return &er.InternalError{Message: err.Error()}
 	}
-
-	s.logger.Debug("genre updated successfully")
+	if slices.Contains(subtree, parentID) {
+		return er.ErrGenreCycle
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// aliases убирает повторы и проверяет, что синонимы не заняты другими жанрами
+func (s *GenreService) aliases(ctx context.Context, genreID uint, name string, values []string) ([]model.GenreAlias, error) {
+	aliases := make([]model.GenreAlias, 0, len(values))
+	seen := []string{name}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || slices.ContainsFunc(seen, func(s string) bool { return strings.EqualFold(s, value) }) {
+			continue
+		}
+		seen = append(seen, value)
+
+		found, err := s.genreRepo.FindByName(ctx, value)
+		if err == nil && found.ID != genreID {
+			return nil, er.ErrGenreAliasTaken
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &er.InternalError{Message: err.Error()}
+		}
+		aliases = append(aliases, model.GenreAlias{GenreID: genreID, Name: value})
+	}
+	return aliases, nil
+}
+
+// buildGenreTree раскладывает жанры по родителям, порядок внутри уровня сохраняется.
+// Жанр, чей родитель не найден, становится корневым.
+func buildGenreTree(genres []model.Genre) []model.GenreTree {
+	known := make(map[uint]bool, len(genres))
+	for _, genre := range genres {
+		known[genre.ID] = true
+	}
+
+	children := make(map[uint][]model.Genre)
+	var roots []model.Genre
+	for _, genre := range genres {
+		if genre.ParentID == nil || !known[*genre.ParentID] || *genre.ParentID == genre.ID {
+			roots = append(roots, genre)
+			continue
+		}
+		children[*genre.ParentID] = append(children[*genre.ParentID], genre)
+	}
+
+	var build func(level []model.Genre) []model.GenreTree
+	build = func(level []model.Genre) []model.GenreTree {
+		tree := make([]model.GenreTree, 0, len(level))
+		for _, genre := range level {
+			sub := children[genre.ID]
+			delete(children, genre.ID)
+			tree = append(tree, model.GenreTree{Genre: genre, Subgenres: build(sub)})
+		}
+		return tree
+	}
+	return build(roots)
+}
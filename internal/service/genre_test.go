@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+// Rock(1) -> Punk(2) -> Post-Punk(3), Hip-Hop(4) с синонимом "hip hop"
+func newGenreRepo() *mocks.MockGenreRepo {
+	genres := []*model.Genre{
+		{ID: 1, Name: "Rock"},
+		{ID: 2, Name: "Punk", ParentID: uintPtr(1)},
+		{ID: 3, Name: "Post-Punk", ParentID: uintPtr(2)},
+		{ID: 4, Name: "Hip-Hop", Aliases: []model.GenreAlias{{GenreID: 4, Name: "hip hop"}}},
+	}
+	key := func(name string) string {
+		return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(name))
+	}
+	return &mocks.MockGenreRepo{
+		GetByIdFunc: func(ctx context.Context, id uint) (*model.Genre, error) {
+			for _, genre := range genres {
+				if genre.ID == id {
+					copied := *genre
+					return &copied, nil
+				}
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+		FindByNameFunc: func(ctx context.Context, name string) (*model.Genre, error) {
+			for _, genre := range genres {
+				if key(genre.Name) == key(name) {
+					return genre, nil
+				}
+				for _, alias := range genre.Aliases {
+					if key(alias.Name) == key(name) {
+						return genre, nil
+					}
+				}
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+		IsExistsFunc: func(ctx context.Context, name string) bool {
+			for _, genre := range genres {
+				if key(genre.Name) == key(name) {
+					return true
+				}
+			}
+			return false
+		},
+		GetSubtreeIDsFunc: func(ctx context.Context, id uint) ([]uint, error) {
+			subtree := map[uint][]uint{1: {1, 2, 3}, 2: {2, 3}, 3: {3}, 4: {4}}
+			return subtree[id], nil
+		},
+		CreateFunc: func(ctx context.Context, entity *model.Genre) (*model.Genre, error) {
+			entity.ID = 5
+			return entity, nil
+		},
+		UpdateFunc: func(ctx context.Context, entity *model.Genre) (*model.Genre, error) {
+			return entity, nil
+		},
+	}
+}
+
+func TestNewGenre(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	service := NewGenreService(newGenreRepo(), nil, zap.NewNop().Sugar())
+
+	_, err := service.NewGenre(ctx, request.NewGenreRequest{Name: "hip hop"}, 1)
+	assert.Equal(t, er.ErrGenreExists, err)
+	_, err = service.NewGenre(ctx, request.NewGenreRequest{Name: "Rap", Aliases: []string{"Hip Hop"}}, 1)
+	assert.Equal(t, er.ErrGenreAliasTaken, err)
+	_, err = service.NewGenre(ctx, request.NewGenreRequest{Name: "Shoegaze", ParentID: 9}, 1)
+	assert.Equal(t, er.ErrGenreParent, err)
+
+	genre, err := service.NewGenre(ctx, request.NewGenreRequest{
+		Name:     " Shoegaze ",
+		ParentID: 1,
+		Aliases:  []string{"shoegazing", "Shoegazing", "shoegaze", ""},
+	}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Shoegaze", genre.Name)
+	assert.Equal(t, uint(1), *genre.ParentID)
+	if assert.Len(t, genre.Aliases, 1) {
+		assert.Equal(t, "shoegazing", genre.Aliases[0].Name)
+	}
+}
+
+func TestUpdateGenre_Parent(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	service := NewGenreService(newGenreRepo(), nil, zap.NewNop().Sugar())
+
+	// Rock не может стать поджанром своего же Post-Punk
+	_, err := service.UpdateGenre(ctx, 1, request.UpdateGenreRequest{ParentID: uintPtr(3)}, 1)
+	assert.Equal(t, er.ErrGenreCycle, err)
+	_, err = service.UpdateGenre(ctx, 2, request.UpdateGenreRequest{ParentID: uintPtr(2)}, 1)
+	assert.Equal(t, er.ErrGenreCycle, err)
+
+	genre, err := service.UpdateGenre(ctx, 3, request.UpdateGenreRequest{ParentID: uintPtr(1)}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), *genre.ParentID)
+
+	genre, err = service.UpdateGenre(ctx, 2, request.UpdateGenreRequest{ParentID: uintPtr(0)}, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, genre.ParentID)
+
+	// Свой синоним можно оставить, чужое имя занять нельзя
+	_, err = service.UpdateGenre(ctx, 4, request.UpdateGenreRequest{Aliases: []string{"hip hop", "rap"}}, 1)
+	assert.NoError(t, err)
+	_, err = service.UpdateGenre(ctx, 4, request.UpdateGenreRequest{NewName: "rock"}, 1)
+	assert.Equal(t, er.ErrGenreExists, err)
+}
+
+func TestDeleteGenre(t *testing.T) {
+	var deleted [2]uint
+	genreRepo := newGenreRepo()
+	genreRepo.DeleteAndReassignFunc = func(ctx context.Context, id, replacementID uint) error {
+		deleted = [2]uint{id, replacementID}
+		return nil
+	}
+	service := NewGenreService(genreRepo, nil, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	assert.Equal(t, er.ErrGenreNotExists, service.DeleteGenre(ctx, 9, 0, 1))
+	assert.Equal(t, er.ErrGenreReplacement, service.DeleteGenre(ctx, 2, 2, 1))
+	assert.Equal(t, er.ErrGenreReplacement, service.DeleteGenre(ctx, 2, 9, 1))
+
+	assert.NoError(t, service.DeleteGenre(ctx, 2, 1, 1))
+	assert.Equal(t, [2]uint{2, 1}, deleted)
+	assert.NoError(t, service.DeleteGenre(ctx, 3, 0, 1))
+	assert.Equal(t, [2]uint{3, 0}, deleted)
+}
+
+func TestBuildGenreTree(t *testing.T) {
+	tree := buildGenreTree([]model.Genre{
+		{ID: 4, Name: "Hip-Hop"},
+		{ID: 3, Name: "Post-Punk", ParentID: uintPtr(2)},
+		{ID: 2, Name: "Punk", ParentID: uintPtr(1)},
+		{ID: 1, Name: "Rock"},
+		// Родитель удален
+		{ID: 6, Name: "Trap", ParentID: uintPtr(5)},
+	})
+
+	if assert.Len(t, tree, 3) {
+		assert.Equal(t, "Hip-Hop", tree[0].Name)
+		assert.Equal(t, "Rock", tree[1].Name)
+		assert.Equal(t, "Trap", tree[2].Name)
+		if assert.Len(t, tree[1].Subgenres, 1) {
+			assert.Equal(t, "Punk", tree[1].Subgenres[0].Name)
+			assert.Equal(t, "Post-Punk", tree[1].Subgenres[0].Subgenres[0].Name)
+		}
+	}
+}
+
+func TestGetGenreSongs(t *testing.T) {
+	var requested []uint
+	songRepo := &mocks.MockSongRepo{
+		GetByGenreIDsFunc: func(ctx context.Context, genreIDs []uint, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
+			requested = genreIDs
+			return []model.Song{{ID: 1}}, 1, nil
+		},
+	}
+	service := NewSongService(songRepo, nil, nil, newGenreRepo(), nil, nil, nil, nil, nil, nil, zap.NewNop().Sugar())
+
+	_, _, err := service.GetGenreSongs(context.Background(), 9, 0, 10, 0)
+	assert.Equal(t, er.ErrGenreNotExists, err)
+
+	// Песни поджанров входят в выборку родителя
+	_, total, err := service.GetGenreSongs(context.Background(), 1, 0, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []uint{1, 2, 3}, requested)
+}
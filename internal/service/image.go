@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"music-lib/pkg/imaging"
+	"path"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Виды изображений: первая часть ключа набора миниатюр
+const (
+	imageAlbumCover = "album"
+	imageAvatar     = "avatar"
+)
+
+// Меньшее изображение нельзя показать даже в самой маленькой миниатюре
+const minImageSide = 64
+
+type ImageService struct {
+	albumRepo   repository.IAlbumRepository
+	profileRepo repository.IProfileRepository
+	audit       auditLog
+	storage     storage.Storage
+	limits      config.UploadConfig
+
+	logger *zap.SugaredLogger
+}
+
+func NewImageService(
+	album repository.IAlbumRepository,
+	profile repository.IProfileRepository,
+	audit repository.IAuditRepository,
+	store storage.Storage,
+	limits config.UploadConfig,
+	logger *zap.SugaredLogger,
+) *ImageService {
+	return &ImageService{
+		albumRepo:   album,
+		profileRepo: profile,
+		audit:       newAuditLog(audit, logger),
+		storage:     store,
+		limits:      limits,
+		logger:      logger,
+	}
+}
+
+// SetAlbumCover заменяет обложку альбома загруженным изображением
+func (s *ImageService) SetAlbumCover(ctx context.Context, albumID, userID uint, r io.Reader) (*model.Album, error) {
+	album, err := s.albumRepo.GetByID(ctx, albumID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrAlbumNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	key, err := s.store(ctx, imageAlbumCover, album.ID, album.CoverArtKey, r)
+	if err != nil {
+		return nil, err
+	}
+	// То же изображение загружено повторно
+	if key == album.CoverArtKey {
+		return album, nil
+	}
+
+	before := albumState(album)
+	previous := album.CoverArtKey
+	album.CoverArtKey = key
+	if _, err := s.albumRepo.Update(ctx, album); err != nil {
+		s.deleteImage(ctx, key)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Album cover updated",
+		"album id", album.ID,
+		"key", key,
+	)
+	s.audit.record(ctx, userID, model.AlbumResource, album.ID, model.AuditUpdate, before, albumState(album))
+	s.deleteImage(ctx, previous)
+	return album, nil
+}
+
+// DeleteAlbumCover убирает загруженную обложку альбома
+func (s *ImageService) DeleteAlbumCover(ctx context.Context, albumID, userID uint) error {
+	album, err := s.albumRepo.GetByID(ctx, albumID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return er.ErrAlbumNotExists
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+	if album.CoverArtKey == "" {
+		return er.ErrImageNotExists
+	}
+
+	before := albumState(album)
+	previous := album.CoverArtKey
+	album.CoverArtKey = ""
+	if _, err := s.albumRepo.Update(ctx, album); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	s.audit.record(ctx, userID, model.AlbumResource, album.ID, model.AuditUpdate, before, albumState(album))
+	s.deleteImage(ctx, previous)
+	return nil
+}
+
+// SetAvatar заменяет аватар профиля пользователя
+func (s *ImageService) SetAvatar(ctx context.Context, userID uint, r io.Reader) (*model.Profile, error) {
+	profile, err := s.getProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.store(ctx, imageAvatar, userID, profile.AvatarKey, r)
+	if err != nil {
+		return nil, err
+	}
+	if key == profile.AvatarKey {
+		return profile, nil
+	}
+
+	previous := profile.AvatarKey
+	profile.AvatarKey = key
+	if _, err := s.profileRepo.Update(ctx, profile); err != nil {
+		s.deleteImage(ctx, key)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Avatar updated",
+		"user id", userID,
+		"key", key,
+	)
+	s.deleteImage(ctx, previous)
+	return profile, nil
+}
+
+// DeleteAvatar убирает загруженный аватар
+func (s *ImageService) DeleteAvatar(ctx context.Context, userID uint) error {
+	profile, err := s.getProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if profile.AvatarKey == "" {
+		return er.ErrImageNotExists
+	}
+
+	previous := profile.AvatarKey
+	profile.AvatarKey = ""
+	if _, err := s.profileRepo.Update(ctx, profile); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	s.deleteImage(ctx, previous)
+	return nil
+}
+
+// Open открывает миниатюру по ключу вида "album/1/abc/300.jpg"
+func (s *ImageService) Open(ctx context.Context, key string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	info, err := s.storage.Stat(ctx, imageStorageKey(key))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			return nil, nil, er.ErrImageNotExists
+		}
+		return nil, nil, &er.InternalError{Message: err.Error()}
+	}
+
+	body, err := s.storage.Get(ctx, imageStorageKey(key))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, er.ErrImageNotExists
+		}
+		return nil, nil, &er.InternalError{Message: err.Error()}
+	}
+
+	if info.ContentType == "" {
+		info.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	return body, info, nil
+}
+
+func (s *ImageService) getProfile(ctx context.Context, userID uint) (*model.Profile, error) {
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrProfileNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return profile, nil
+}
+
+// store проверяет изображение и сохраняет набор миниатюр.
+// Ключ набора содержит хеш содержимого, поэтому миниатюры можно кешировать бессрочно.
+// Если ключ совпал с current, набор уже сохранен и не перезаписывается.
+func (s *ImageService) store(ctx context.Context, kind string, id uint, current string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.limits.MaxImageSize+1))
+	if err != nil {
+		return "", &er.InternalError{Message: err.Error()}
+	}
+	if int64(len(data)) > s.limits.MaxImageSize {
+		return "", er.ErrImageTooLarge
+	}
+
+	img, _, err := imaging.Decode(data)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		return "", er.ErrUnsupportedImage
+	case errors.Is(err, imaging.ErrTooLarge):
+		return "", er.ErrImageDimensions
+	case err != nil:
+		return "", &er.ValidationError{Message: err.Error()}
+	}
+	if bounds := img.Bounds(); min(bounds.Dx(), bounds.Dy()) < minImageSide {
+		return "", er.ErrImageDimensions
+	}
+
+	format := imaging.Format(img)
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s/%d/%s%s", kind, id, hex.EncodeToString(sum[:8]), imaging.Extension(format))
+	if key == current {
+		return key, nil
+	}
+
+	for _, size := range imaging.ThumbnailSizes {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, imaging.Thumbnail(img, size), format); err != nil {
+			s.deleteImage(ctx, key)
+			return "", &er.InternalError{Message: err.Error()}
+		}
+
+		thumbKey := imageStorageKey(imaging.ThumbnailKey(key, size))
+		if err := s.storage.Put(ctx, thumbKey, &buf, int64(buf.Len()), format); err != nil {
+			s.logger.Errorw("Failed to store thumbnail",
+				"key", thumbKey,
+				"error", err.Error(),
+			)
+			s.deleteImage(ctx, key)
+			return "", &er.InternalError{Message: err.Error()}
+		}
+	}
+	return key, nil
+}
+
+// Удаление набора миниатюр. Ошибка только логируется: запись уже не ссылается на набор.
+func (s *ImageService) deleteImage(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	for _, size := range imaging.ThumbnailSizes {
+		thumbKey := imageStorageKey(imaging.ThumbnailKey(key, size))
+		if err := s.storage.Delete(ctx, thumbKey); err != nil {
+			s.logger.Errorw("Failed to delete thumbnail",
+				"key", thumbKey,
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
+func imageStorageKey(key string) string {
+	return "images/" + key
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"music-lib/pkg/imaging"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var testImageLimits = config.UploadConfig{MaxImageSize: 1 << 20}
+
+func pngData(t *testing.T, w, h int, c color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func newImageAlbumRepo(album *model.Album) *mocks.MockAlbumRepo {
+	return &mocks.MockAlbumRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Album, error) {
+			if id != album.ID {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return album, nil
+		},
+		UpdateFunc: func(ctx context.Context, entity *model.Album) (*model.Album, error) {
+			return entity, nil
+		},
+	}
+}
+
+func TestSetAlbumCover_StoresThumbnails(t *testing.T) {
+	store := newTestStorage(t)
+	album := &model.Album{ID: 1}
+	service := NewImageService(newImageAlbumRepo(album), nil, nil, store, testImageLimits, zap.NewNop().Sugar())
+
+	_, err := service.SetAlbumCover(context.Background(), 1, 1, bytes.NewReader(pngData(t, 400, 200, color.White)))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(album.CoverArtKey, "album/1/"))
+	assert.True(t, strings.HasSuffix(album.CoverArtKey, ".jpg"))
+	first := album.CoverArtKey
+
+	for _, size := range imaging.ThumbnailSizes {
+		body, info, err := service.Open(context.Background(), imaging.ThumbnailKey(first, size))
+		assert.NoError(t, err)
+		assert.Equal(t, imaging.MimeJPEG, info.ContentType)
+		data, _ := io.ReadAll(body)
+		body.Close()
+
+		img, _, err := imaging.Decode(data)
+		assert.NoError(t, err)
+		// Исходник 200 пикселей по меньшей стороне не увеличивается
+		side := min(size, 200)
+		assert.Equal(t, image.Rect(0, 0, side, side), img.Bounds())
+	}
+
+	// Новая обложка заменяет старую, старые миниатюры удаляются
+	_, err = service.SetAlbumCover(context.Background(), 1, 1, bytes.NewReader(pngData(t, 100, 100, color.Black)))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, album.CoverArtKey)
+	_, err = store.Stat(context.Background(), imageStorageKey(imaging.ThumbnailKey(first, 64)))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestSetAlbumCover_SameImage(t *testing.T) {
+	store := newTestStorage(t)
+	album := &model.Album{ID: 1}
+	service := NewImageService(newImageAlbumRepo(album), nil, nil, store, testImageLimits, zap.NewNop().Sugar())
+	data := pngData(t, 100, 100, color.White)
+
+	_, err := service.SetAlbumCover(context.Background(), 1, 1, bytes.NewReader(data))
+	assert.NoError(t, err)
+	first := album.CoverArtKey
+
+	// Повторная загрузка дает тот же ключ и не удаляет действующие миниатюры
+	_, err = service.SetAlbumCover(context.Background(), 1, 1, bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, first, album.CoverArtKey)
+	for _, size := range imaging.ThumbnailSizes {
+		_, err = store.Stat(context.Background(), imageStorageKey(imaging.ThumbnailKey(first, size)))
+		assert.NoError(t, err)
+	}
+}
+
+func TestSetAlbumCover_Invalid(t *testing.T) {
+	album := &model.Album{ID: 1}
+	service := NewImageService(newImageAlbumRepo(album), nil, nil, newTestStorage(t), testImageLimits, zap.NewNop().Sugar())
+
+	_, err := service.SetAlbumCover(context.Background(), 1, 1, strings.NewReader("GIF89a not supported"))
+	assert.Equal(t, er.ErrUnsupportedImage, err)
+
+	_, err = service.SetAlbumCover(context.Background(), 1, 1, bytes.NewReader(pngData(t, 32, 32, color.White)))
+	assert.Equal(t, er.ErrImageDimensions, err)
+
+	_, err = service.SetAlbumCover(context.Background(), 1, 1, bytes.NewReader(make([]byte, testImageLimits.MaxImageSize+1)))
+	assert.Equal(t, er.ErrImageTooLarge, err)
+
+	_, err = service.SetAlbumCover(context.Background(), 2, 1, bytes.NewReader(pngData(t, 64, 64, color.White)))
+	assert.Equal(t, er.ErrAlbumNotExists, err)
+	assert.Empty(t, album.CoverArtKey)
+}
+
+func TestSetAvatar_KeepsTransparency(t *testing.T) {
+	profile := &model.Profile{UserID: 7}
+	profileRepo := &mocks.MockProfileRepo{
+		GetByUserIDFunc: func(ctx context.Context, userID uint) (*model.Profile, error) {
+			return profile, nil
+		},
+		UpdateFunc: func(ctx context.Context, entity *model.Profile) (*model.Profile, error) {
+			return entity, nil
+		},
+	}
+	service := NewImageService(nil, profileRepo, nil, newTestStorage(t), testImageLimits, zap.NewNop().Sugar())
+
+	data := pngData(t, 64, 64, color.Transparent)
+	_, err := service.SetAvatar(context.Background(), 7, bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(profile.AvatarKey, "avatar/7/"))
+	assert.True(t, strings.HasSuffix(profile.AvatarKey, ".png"))
+
+	// Тот же аватар повторно: миниатюры остаются на месте
+	key := profile.AvatarKey
+	_, err = service.SetAvatar(context.Background(), 7, bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, key, profile.AvatarKey)
+	body, _, err := service.Open(context.Background(), imaging.ThumbnailKey(key, 64))
+	if assert.NoError(t, err) {
+		body.Close()
+	}
+
+	assert.NoError(t, service.DeleteAvatar(context.Background(), 7))
+	assert.Empty(t, profile.AvatarKey)
+	assert.Equal(t, er.ErrImageNotExists, service.DeleteAvatar(context.Background(), 7))
+}
+
+func TestOpenImage_NotFound(t *testing.T) {
+	service := NewImageService(nil, nil, nil, newTestStorage(t), testImageLimits, zap.NewNop().Sugar())
+
+	_, _, err := service.Open(context.Background(), "album/1/missing/64.jpg")
+	assert.Equal(t, er.ErrImageNotExists, err)
+
+	_, _, err = service.Open(context.Background(), "../secret")
+	assert.Equal(t, er.ErrImageNotExists, err)
+}
@@ -1,13 +1,540 @@
 package service
 
-import "music-lib/internal/repository"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/diff"
+	"music-lib/pkg/er"
+	"music-lib/pkg/lrc"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+)
 
 type LyricsService struct {
 	lyricsRepository repository.ILyricsRepository
+	songRepository   repository.ISongRepository
+	audit            auditLog
+
+	logger *zap.SugaredLogger
 }
 
-func NewLyricsService(lyrics repository.ILyricsRepository) *LyricsService {
+func NewLyricsService(
+	lyrics repository.ILyricsRepository,
+	song repository.ISongRepository,
+	audit repository.IAuditRepository,
+	sugar *zap.SugaredLogger,
+) *LyricsService {
 	return &LyricsService{
 		lyricsRepository: lyrics,
+		songRepository:   song,
+		audit:            newAuditLog(audit, sugar),
+		logger:           sugar,
+	}
+}
+
+// GetLyrics возвращает версию текста, лучше всего подходящую под языковые предпочтения.
+// accept - значение ?lang= или заголовка Accept-Language, пустой kind - любой тип.
+// Язык только выбирает среди переводов: оригинал у песни один и возвращается на своем языке.
+func (s *LyricsService) GetLyrics(ctx context.Context, songID uint, accept string, kind model.LyricsKind) (*model.Lyrics, error) {
+	if kind != "" && !kind.IsValid() {
+		return nil, er.ErrLyricsKind
+	}
+
+	versions, err := s.lyricsRepository.GetBySongID(ctx, songID)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	lyrics := s.Select(versions, accept, kind)
+	if lyrics == nil {
+		return nil, er.ErrLyricsNotExists
+	}
+	return lyrics, nil
+}
+
+// Select выбирает версию текста по языковым предпочтениям, по умолчанию - оригинал
+func (s *LyricsService) Select(versions []model.Lyrics, accept string, kind model.LyricsKind) *model.Lyrics {
+	var candidates []model.Lyrics
+	for _, version := range versions {
+		if kind == "" || version.Kind == kind {
+			candidates = append(candidates, version)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// Оригинал первым - он же вариант по умолчанию для матчера
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Kind == model.LyricsOriginal && candidates[j].Kind != model.LyricsOriginal
+	})
+
+	if accept == "" {
+		return &candidates[0]
+	}
+	prefs, _, err := language.ParseAcceptLanguage(accept)
+	if err != nil || len(prefs) == 0 {
+		return &candidates[0]
+	}
+
+	tags := make([]language.Tag, len(candidates))
+	for i, candidate := range candidates {
+		tags[i] = language.Make(candidate.Language)
+	}
+	_, index, confidence := language.NewMatcher(tags).Match(prefs...)
+	if confidence == language.No {
+		return &candidates[0]
+	}
+	return &candidates[index]
+}
+
+// SetLyrics заменяет версию текста песни данными из JSON запроса
+func (s *LyricsService) SetLyrics(ctx context.Context, songID, authorID uint, req request.AddLyrics) (*model.Lyrics, error) {
+	lyrics, err := buildLyrics(songID, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, lyrics, authorID, model.RevisionEdit, nil)
+}
+
+// ImportLRC заменяет версию текста песни содержимым LRC файла.
+// Если язык не указан, используется заголовок [la:] файла.
+func (s *LyricsService) ImportLRC(ctx context.Context, songID, authorID uint, lang, kind, data string) (*model.Lyrics, error) {
+	file, err := lrc.Parse(data)
+	if err != nil {
+		s.logger.Debugw("Can't parse LRC",
+			"song id", songID,
+			"error", err.Error(),
+		)
+		return nil, &er.ValidationError{Message: err.Error()}
+	}
+
+	if lang == "" {
+		lang = file.Tags[lrc.TagLang]
+	}
+	langTag, lyricsKind, err := parseLyricsVersion(lang, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	lyrics := &model.Lyrics{
+		SongID:   songID,
+		Language: langTag,
+		Kind:     lyricsKind,
+		OffsetMs: file.Offset.Milliseconds(),
+	}
+	for number, line := range file.Lines {
+		start := line.Time.Milliseconds()
+		couplet := model.Couplet{
+			Number:  uint(number),
+			Text:    line.Text,
+			StartMs: &start,
+		}
+		for position, word := range line.Words {
+			couplet.Words = append(couplet.Words, model.CoupletWord{
+				Position: uint(position),
+				StartMs:  word.Time.Milliseconds(),
+				Text:     word.Text,
+			})
+		}
+		lyrics.Couplets = append(lyrics.Couplets, couplet)
+	}
+
+	return s.save(ctx, lyrics, authorID, model.RevisionEdit, nil)
+}
+
+// DeleteLyrics удаляет версию текста. Оригинал нельзя удалить, пока есть переводы.
+func (s *LyricsService) DeleteLyrics(ctx context.Context, songID, authorID uint, lang, kind string) error {
+	langTag, lyricsKind, err := parseLyricsVersion(lang, kind)
+	if err != nil {
+		return err
+	}
+
+	versions, err := s.lyricsRepository.GetBySongID(ctx, songID)
+	if err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	// Без ?lang= удаляется оригинал на любом языке, с ним - только на указанном
+	if lang == "" && lyricsKind == model.LyricsOriginal {
+		langTag = ""
+	}
+	target := findVersion(versions, langTag, lyricsKind)
+	if target == nil {
+		return er.ErrLyricsNotExists
+	}
+	if lyricsKind == model.LyricsOriginal && len(versions) > 1 {
+		return &er.ValidationError{Message: "Delete translations before deleting the original lyrics"}
+	}
+
+	revision, err := model.NewLyricsRevision(target, authorID, model.RevisionDelete)
+	if err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	if err := s.lyricsRepository.Delete(ctx, target.ID, revision); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Lyrics version deleted",
+		"song id", songID,
+		"language", target.Language,
+		"kind", target.Kind,
+	)
+	s.audit.record(ctx, authorID, model.SongResource, songID, model.AuditDelete, *target, nil)
+	return nil
+}
+
+// ExportLRC возвращает синхронизированный текст песни в формате LRC
+func (s *LyricsService) ExportLRC(ctx context.Context, songID uint, accept string, kind model.LyricsKind) (string, error) {
+	song, artist, album, err := s.songRepository.GetFullInfo(ctx, songID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", er.ErrSongNotExists
+		}
+		return "", &er.InternalError{Message: err.Error()}
+	}
+
+	lyrics, err := s.GetLyrics(ctx, songID, accept, kind)
+	if err != nil {
+		return "", err
+	}
+	if !lyrics.IsSynced() {
+		return "", er.ErrLyricsNotSynced
+	}
+
+	file := &lrc.File{
+		Tags: map[string]string{
+			lrc.TagTitle:  song.Title,
+			lrc.TagArtist: artist.Name,
+			lrc.TagAlbum:  album.Title,
+			lrc.TagLang:   lyrics.Language,
+		},
+		Offset: time.Duration(lyrics.OffsetMs) * time.Millisecond,
+	}
+	if song.Duration > 0 {
+		file.Tags[lrc.TagLength] = fmt.Sprintf("%02d:%02d", song.Duration/60, song.Duration%60)
+	}
+
+	for _, couplet := range lyrics.Couplets {
+		line := lrc.Line{
+			Time: time.Duration(*couplet.StartMs) * time.Millisecond,
+			Text: strings.Join(strings.Fields(couplet.Text), " "),
+		}
+		for _, word := range couplet.Words {
+			line.Words = append(line.Words, lrc.Word{
+				Time: time.Duration(word.StartMs) * time.Millisecond,
+				Text: word.Text,
+			})
+		}
+		file.Lines = append(file.Lines, line)
+	}
+
+	return lrc.Format(file), nil
+}
+
+// ExportText возвращает текст песни без меток времени
+func (s *LyricsService) ExportText(ctx context.Context, songID uint, accept string, kind model.LyricsKind) (string, error) {
+	lyrics, err := s.GetLyrics(ctx, songID, accept, kind)
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0, len(lyrics.Couplets))
+	for _, couplet := range lyrics.Couplets {
+		lines = append(lines, couplet.Text)
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// GetRevisions возвращает историю изменений текста песни, новые ревизии первыми
+func (s *LyricsService) GetRevisions(ctx context.Context, songID uint, lang, kind string) ([]model.LyricsRevision, error) {
+	if lang != "" {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return nil, &er.ValidationError{Message: fmt.Sprintf("invalid language tag %q", lang)}
+		}
+		lang = tag.String()
+	}
+	if kind != "" && !model.LyricsKind(kind).IsValid() {
+		return nil, er.ErrLyricsKind
 	}
-}
\ No newline at end of file
+
+	revisions, err := s.lyricsRepository.GetRevisions(ctx, songID, lang, model.LyricsKind(kind))
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return revisions, nil
+}
+
+// Изменение куплета между двумя ревизиями
+type CoupletChange struct {
+	Op  diff.Op
+	Old *model.Couplet
+	New *model.Couplet
+}
+
+// DiffRevisions сравнивает куплеты двух ревизий текста одной песни
+func (s *LyricsService) DiffRevisions(ctx context.Context, songID, fromID, toID uint) ([]CoupletChange, error) {
+	from, err := s.getRevision(ctx, songID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getRevision(ctx, songID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldCouplets, err := from.Couplets()
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	newCouplets, err := to.Couplets()
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	var changes []CoupletChange
+	for _, change := range diff.Compare(coupletKeys(oldCouplets), coupletKeys(newCouplets)) {
+		item := CoupletChange{Op: change.Op}
+		if change.OldIndex >= 0 {
+			item.Old = &oldCouplets[change.OldIndex]
+		}
+		if change.NewIndex >= 0 {
+			item.New = &newCouplets[change.NewIndex]
+		}
+		changes = append(changes, item)
+	}
+	return changes, nil
+}
+
+// Rollback восстанавливает версию текста из ревизии, откат сам сохраняется новой ревизией
+func (s *LyricsService) Rollback(ctx context.Context, songID, revisionID, authorID uint) (*model.Lyrics, error) {
+	revision, err := s.getRevision(ctx, songID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Action == model.RevisionDelete {
+		return nil, &er.ValidationError{Message: "Revision records a deletion, nothing to restore"}
+	}
+
+	couplets, err := revision.Couplets()
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	lyrics := &model.Lyrics{
+		SongID:   songID,
+		Language: revision.Language,
+		Kind:     revision.Kind,
+		OffsetMs: revision.OffsetMs,
+		Couplets: couplets,
+	}
+
+	s.logger.Debugw("Rolling back lyrics",
+		"song id", songID,
+		"revision id", revisionID,
+		"author id", authorID,
+	)
+	return s.save(ctx, lyrics, authorID, model.RevisionRollback, &revision.ID)
+}
+
+func (s *LyricsService) getRevision(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error) {
+	revision, err := s.lyricsRepository.GetRevision(ctx, songID, revisionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrLyricsRevisionNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return revision, nil
+}
+
+// Ключ сравнения куплетов: текст и метка времени
+func coupletKeys(couplets []model.Couplet) []string {
+	keys := make([]string, len(couplets))
+	for i, couplet := range couplets {
+		start := "-"
+		if couplet.StartMs != nil {
+			start = strconv.FormatInt(*couplet.StartMs, 10)
+		}
+		keys[i] = start + "|" + couplet.Text
+	}
+	return keys
+}
+
+// Сохранение версии текста: переводы выравниваются по куплетам оригинала,
+// существующая версия с тем же языком и типом заменяется
+func (s *LyricsService) save(
+	ctx context.Context,
+	lyrics *model.Lyrics,
+	authorID uint,
+	action model.RevisionAction,
+	sourceRevisionID *uint,
+) (*model.Lyrics, error) {
+	versions, err := s.lyricsRepository.GetBySongID(ctx, lyrics.SongID)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	if lyrics.Kind != model.LyricsOriginal {
+		if err := alignWithOriginal(lyrics, findVersion(versions, "", model.LyricsOriginal)); err != nil {
+			return nil, err
+		}
+	}
+
+	// У песни один оригинал: новый оригинал заменяет прежний, даже если язык другой
+	match := lyrics.Language
+	if lyrics.Kind == model.LyricsOriginal {
+		match = ""
+	}
+	// Изменения текста пишутся в журнал песни, снимок - сама версия с куплетами
+	var before any
+	auditAction := model.AuditCreate
+	if existing := findVersion(versions, match, lyrics.Kind); existing != nil {
+		lyrics.ID = existing.ID
+		lyrics.CreatedAt = existing.CreatedAt
+		before, auditAction = *existing, model.AuditUpdate
+	}
+
+	revision, err := model.NewLyricsRevision(lyrics, authorID, action)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	revision.SourceRevisionID = sourceRevisionID
+
+	if err := s.lyricsRepository.Upsert(ctx, lyrics, revision); err != nil {
+		s.logger.Errorw("Can't save lyrics",
+			"song id", lyrics.SongID,
+			"language", lyrics.Language,
+			"kind", lyrics.Kind,
+			"error type", "internal",
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Lyrics saved successfully",
+		"song id", lyrics.SongID,
+		"language", lyrics.Language,
+		"kind", lyrics.Kind,
+		"lines", len(lyrics.Couplets),
+		"synced", lyrics.IsSynced(),
+	)
+	s.audit.record(ctx, authorID, model.SongResource, lyrics.SongID, auditAction, before, *lyrics)
+	return lyrics, nil
+}
+
+// Перевод должен совпадать с оригиналом по числу куплетов,
+// недостающие метки времени берутся из оригинала
+func alignWithOriginal(lyrics *model.Lyrics, original *model.Lyrics) error {
+	if original == nil {
+		return er.ErrLyricsOriginalRequired
+	}
+	if original.Language == lyrics.Language && lyrics.Kind == model.LyricsTranslation {
+		return &er.ValidationError{Message: "Translation language must differ from the original"}
+	}
+	if len(lyrics.Couplets) != len(original.Couplets) {
+		return &er.ValidationError{Message: fmt.Sprintf(
+			"%s must have %d couplets aligned with the original, got %d",
+			lyrics.Kind, len(original.Couplets), len(lyrics.Couplets),
+		)}
+	}
+
+	for i := range lyrics.Couplets {
+		lyrics.Couplets[i].Number = original.Couplets[i].Number
+		if lyrics.Couplets[i].StartMs == nil && original.Couplets[i].StartMs != nil {
+			start := *original.Couplets[i].StartMs
+			lyrics.Couplets[i].StartMs = &start
+		}
+	}
+	if lyrics.OffsetMs == 0 {
+		lyrics.OffsetMs = original.OffsetMs
+	}
+	return nil
+}
+
+// Поиск версии текста, пустой lang - любой язык
+func findVersion(versions []model.Lyrics, lang string, kind model.LyricsKind) *model.Lyrics {
+	for i := range versions {
+		if versions[i].Kind == kind && (lang == "" || versions[i].Language == lang) {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// Разбор и нормализация языка (BCP-47) и типа версии текста
+func parseLyricsVersion(lang, kind string) (string, model.LyricsKind, error) {
+	tag := language.Und
+	if lang != "" {
+		var err error
+		tag, err = language.Parse(lang)
+		if err != nil {
+			return "", "", &er.ValidationError{Message: fmt.Sprintf("invalid language tag %q", lang)}
+		}
+	}
+
+	lyricsKind := model.LyricsOriginal
+	if kind != "" {
+		lyricsKind = model.LyricsKind(kind)
+		if !lyricsKind.IsValid() {
+			return "", "", er.ErrLyricsKind
+		}
+	}
+
+	return tag.String(), lyricsKind, nil
+}
+
+// Сборка модели текста из запроса
+func buildLyrics(songID uint, req request.AddLyrics) (*model.Lyrics, error) {
+	langTag, kind, err := parseLyricsVersion(req.Language, req.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	lyrics := &model.Lyrics{
+		SongID:   songID,
+		Language: langTag,
+		Kind:     kind,
+		OffsetMs: req.OffsetMs,
+	}
+
+	for number, couplet := range req.Text {
+		if couplet.TimeMs != nil && *couplet.TimeMs < 0 {
+			return nil, &er.ValidationError{Message: fmt.Sprintf("couplet %d: time_ms must not be negative", number)}
+		}
+		if couplet.TimeMs == nil && len(couplet.Words) > 0 {
+			return nil, &er.ValidationError{Message: fmt.Sprintf("couplet %d: words require time_ms on the couplet", number)}
+		}
+
+		item := model.Couplet{
+			Number:  uint(number),
+			Text:    couplet.Text,
+			StartMs: couplet.TimeMs,
+		}
+		for position, word := range couplet.Words {
+			if word.TimeMs < 0 {
+				return nil, &er.ValidationError{Message: fmt.Sprintf("couplet %d: word time_ms must not be negative", number)}
+			}
+			item.Words = append(item.Words, model.CoupletWord{
+				Position: uint(position),
+				StartMs:  word.TimeMs,
+				Text:     word.Text,
+			})
+		}
+		lyrics.Couplets = append(lyrics.Couplets, item)
+	}
+
+	return lyrics, nil
+}
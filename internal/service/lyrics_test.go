@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/diff"
+	"music-lib/pkg/er"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestGetLyrics_NotExists(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return nil, nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	lyrics, err := service.GetLyrics(context.Background(), 1, "", "")
+
+	assert.Nil(t, lyrics)
+	assert.Equal(t, er.ErrLyricsNotExists, err)
+}
+
+func TestImportLRC_InvalidData(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	service := NewLyricsService(nil, nil, nil, logger)
+
+	lyrics, err := service.ImportLRC(context.Background(), 1, 1, "", "", "no timestamps here")
+
+	assert.Nil(t, lyrics)
+	assert.IsType(t, &er.ValidationError{}, err)
+}
+
+func TestImportLRC_Success(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var saved *model.Lyrics
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return nil, nil
+		},
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			saved = lyrics
+			return nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	lyrics, err := service.ImportLRC(context.Background(), 7, 1, "", "",
+		"[la:ru]\n[offset:300]\n[00:01.00]<00:01.00>Hello <00:01.40>world\n[00:03.50]Second\n")
+
+	assert.NoError(t, err)
+	assert.Same(t, saved, lyrics)
+	assert.Equal(t, uint(7), lyrics.SongID)
+	assert.Equal(t, "ru", lyrics.Language)
+	assert.Equal(t, model.LyricsOriginal, lyrics.Kind)
+	assert.Equal(t, int64(300), lyrics.OffsetMs)
+	assert.True(t, lyrics.IsSynced())
+	assert.Len(t, lyrics.Couplets, 2)
+	assert.Equal(t, int64(1000), *lyrics.Couplets[0].StartMs)
+	assert.Len(t, lyrics.Couplets[0].Words, 2)
+	assert.Equal(t, int64(1400), lyrics.Couplets[0].Words[1].StartMs)
+	assert.Equal(t, uint(1), lyrics.Couplets[1].Number)
+}
+
+func TestImportLRC_UpsertError(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return nil, nil
+		},
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			return errors.New("upsert error")
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	lyrics, err := service.ImportLRC(context.Background(), 1, 1, "", "", "[00:01.00]line\n")
+
+	assert.Nil(t, lyrics)
+	assert.IsType(t, &er.InternalError{}, err)
+}
+
+func TestSetLyrics_NegativeTime(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	service := NewLyricsService(nil, nil, nil, logger)
+
+	req := request.AddLyrics{Text: []request.Couplet{{Text: "line", TimeMs: int64Ptr(-1)}}}
+	lyrics, err := service.SetLyrics(context.Background(), 1, 1, req)
+
+	assert.Nil(t, lyrics)
+	assert.IsType(t, &er.ValidationError{}, err)
+}
+
+func TestExportLRC_NotSynced(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockSongRepo := &mocks.MockSongRepo{
+		GetFullInfoFunc: func(ctx context.Context, id uint) (*model.Song, *model.Artist, *model.Album, error) {
+			return &model.Song{ID: id}, &model.Artist{}, &model.Album{}, nil
+		},
+	}
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return []model.Lyrics{{Kind: model.LyricsOriginal, Couplets: []model.Couplet{{Text: "untimed"}}}}, nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, mockSongRepo, nil, logger)
+
+	data, err := service.ExportLRC(context.Background(), 1, "", "")
+
+	assert.Empty(t, data)
+	assert.Equal(t, er.ErrLyricsNotSynced, err)
+}
+
+func TestExportLRC_Success(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockSongRepo := &mocks.MockSongRepo{
+		GetFullInfoFunc: func(ctx context.Context, id uint) (*model.Song, *model.Artist, *model.Album, error) {
+			return &model.Song{ID: id, Title: "Song", Duration: 125},
+				&model.Artist{Name: "Artist"},
+				&model.Album{Title: "Album"},
+				nil
+		},
+	}
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return []model.Lyrics{{
+				Language: "en",
+				Kind:     model.LyricsOriginal,
+				OffsetMs: 100,
+				Couplets: []model.Couplet{
+					{Text: "First", StartMs: int64Ptr(1000)},
+					{Text: "Second", StartMs: int64Ptr(2500), Words: []model.CoupletWord{
+						{StartMs: 2500, Text: "Second"},
+					}},
+				},
+			}}, nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, mockSongRepo, nil, logger)
+
+	data, err := service.ExportLRC(context.Background(), 1, "", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "[ti:Song]\n[ar:Artist]\n[al:Album]\n[la:en]\n[length:02:05]\n[offset:+100]\n"+
+		"[00:01.00]First\n[00:02.50]<00:02.50>Second\n", data)
+}
+
+func TestSelect_ByLanguage(t *testing.T) {
+	service := NewLyricsService(nil, nil, nil, zap.NewNop().Sugar())
+	versions := []model.Lyrics{
+		{ID: 1, Language: "en", Kind: model.LyricsTranslation},
+		{ID: 2, Language: "ru", Kind: model.LyricsOriginal},
+		{ID: 3, Language: "ru-Latn", Kind: model.LyricsTransliteration},
+	}
+
+	assert.Equal(t, uint(2), service.Select(versions, "", "").ID)
+	assert.Equal(t, uint(1), service.Select(versions, "en-US,en;q=0.9", "").ID)
+	assert.Equal(t, uint(3), service.Select(versions, "ru-Latn", "").ID)
+	assert.Equal(t, uint(2), service.Select(versions, "de", "").ID)
+	assert.Equal(t, uint(1), service.Select(versions, "", model.LyricsTranslation).ID)
+	assert.Nil(t, service.Select(nil, "en", ""))
+}
+
+func TestSetLyrics_TranslationWithoutOriginal(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return nil, nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	req := request.AddLyrics{Language: "en", Kind: "translation", Text: []request.Couplet{{Text: "line"}}}
+	lyrics, err := service.SetLyrics(context.Background(), 1, 1, req)
+
+	assert.Nil(t, lyrics)
+	assert.Equal(t, er.ErrLyricsOriginalRequired, err)
+}
+
+func TestSetLyrics_TranslationMisaligned(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return []model.Lyrics{{
+				ID: 1, Language: "ru", Kind: model.LyricsOriginal,
+				Couplets: []model.Couplet{{Number: 0}, {Number: 1}},
+			}}, nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	req := request.AddLyrics{Language: "en", Kind: "translation", Text: []request.Couplet{{Text: "line"}}}
+	lyrics, err := service.SetLyrics(context.Background(), 1, 1, req)
+
+	assert.Nil(t, lyrics)
+	assert.IsType(t, &er.ValidationError{}, err)
+}
+
+func TestSetLyrics_TranslationReplacesExisting(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var saved *model.Lyrics
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return []model.Lyrics{
+				{
+					ID: 1, Language: "ru", Kind: model.LyricsOriginal,
+					Couplets: []model.Couplet{{Number: 0, StartMs: int64Ptr(1000)}},
+				},
+				{ID: 2, Language: "en", Kind: model.LyricsTranslation},
+			}, nil
+		},
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			saved = lyrics
+			return nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	req := request.AddLyrics{Language: "EN", Kind: "translation", Text: []request.Couplet{{Text: "line"}}}
+	lyrics, err := service.SetLyrics(context.Background(), 1, 1, req)
+
+	assert.NoError(t, err)
+	assert.Same(t, saved, lyrics)
+	assert.Equal(t, uint(2), lyrics.ID)
+	assert.Equal(t, "en", lyrics.Language)
+	assert.Equal(t, int64(1000), *lyrics.Couplets[0].StartMs)
+}
+
+func TestSetLyrics_InvalidLanguage(t *testing.T) {
+	service := NewLyricsService(nil, nil, nil, zap.NewNop().Sugar())
+
+	req := request.AddLyrics{Language: "not a tag", Text: []request.Couplet{{Text: "line"}}}
+	lyrics, err := service.SetLyrics(context.Background(), 1, 1, req)
+
+	assert.Nil(t, lyrics)
+	assert.IsType(t, &er.ValidationError{}, err)
+}
+
+func TestSetLyrics_RecordsRevision(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var saved *model.LyricsRevision
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return nil, nil
+		},
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			saved = revision
+			return nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	req := request.AddLyrics{Language: "ru", Text: []request.Couplet{{Text: "line", TimeMs: int64Ptr(500)}}}
+	_, err := service.SetLyrics(context.Background(), 3, 42, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), saved.SongID)
+	assert.Equal(t, uint(42), saved.AuthorID)
+	assert.Equal(t, model.RevisionEdit, saved.Action)
+	couplets, err := saved.Couplets()
+	assert.NoError(t, err)
+	assert.Equal(t, "line", couplets[0].Text)
+	assert.Equal(t, int64(500), *couplets[0].StartMs)
+}
+
+func revisionOf(t *testing.T, id uint, action model.RevisionAction, texts ...string) *model.LyricsRevision {
+	lyrics := &model.Lyrics{SongID: 1, Language: "ru", Kind: model.LyricsOriginal}
+	for i, text := range texts {
+		lyrics.Couplets = append(lyrics.Couplets, model.Couplet{Number: uint(i), Text: text})
+	}
+	revision, err := model.NewLyricsRevision(lyrics, 1, action)
+	assert.NoError(t, err)
+	revision.ID = id
+	return revision
+}
+
+func TestDiffRevisions_Success(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	revisions := map[uint]*model.LyricsRevision{
+		1: revisionOf(t, 1, model.RevisionEdit, "a", "b", "c"),
+		2: revisionOf(t, 2, model.RevisionEdit, "a", "B", "c", "d"),
+	}
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetRevisionFunc: func(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error) {
+			return revisions[revisionID], nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	changes, err := service.DiffRevisions(context.Background(), 1, 1, 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, changes, 4)
+	assert.Equal(t, diff.OpEqual, changes[0].Op)
+	assert.Equal(t, diff.OpReplace, changes[1].Op)
+	assert.Equal(t, "b", changes[1].Old.Text)
+	assert.Equal(t, "B", changes[1].New.Text)
+	assert.Equal(t, diff.OpInsert, changes[3].Op)
+	assert.Nil(t, changes[3].Old)
+}
+
+func TestDiffRevisions_NotExists(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetRevisionFunc: func(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error) {
+			return nil, gorm.ErrRecordNotFound
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	changes, err := service.DiffRevisions(context.Background(), 1, 1, 2)
+
+	assert.Nil(t, changes)
+	assert.Equal(t, er.ErrLyricsRevisionNotExists, err)
+}
+
+func TestRollback_Success(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var savedRevision *model.LyricsRevision
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetRevisionFunc: func(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error) {
+			return revisionOf(t, revisionID, model.RevisionEdit, "old text"), nil
+		},
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return []model.Lyrics{{ID: 9, SongID: 1, Language: "ru", Kind: model.LyricsOriginal}}, nil
+		},
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			savedRevision = revision
+			return nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	lyrics, err := service.Rollback(context.Background(), 1, 5, 42)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), lyrics.ID)
+	assert.Equal(t, "old text", lyrics.Couplets[0].Text)
+	assert.Equal(t, model.RevisionRollback, savedRevision.Action)
+	assert.Equal(t, uint(5), *savedRevision.SourceRevisionID)
+	assert.Equal(t, uint(42), savedRevision.AuthorID)
+}
+
+func TestRollback_DeleteRevision(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetRevisionFunc: func(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error) {
+			return revisionOf(t, revisionID, model.RevisionDelete), nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, logger)
+
+	lyrics, err := service.Rollback(context.Background(), 1, 5, 42)
+
+	assert.Nil(t, lyrics)
+	assert.IsType(t, &er.ValidationError{}, err)
+}
+
+func TestDeleteLyrics_OriginalLanguageMismatch(t *testing.T) {
+	var deleted []uint
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.Lyrics, error) {
+			return []model.Lyrics{{ID: 3, SongID: songID, Language: "ru", Kind: model.LyricsOriginal}}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id uint, revision *model.LyricsRevision) error {
+			deleted = append(deleted, id)
+			return nil
+		},
+	}
+	service := NewLyricsService(mockLyricsRepo, nil, nil, zap.NewNop().Sugar())
+
+	err := service.DeleteLyrics(context.Background(), 1, 1, "en", "original")
+	assert.Equal(t, er.ErrLyricsNotExists, err)
+	assert.Empty(t, deleted)
+
+	assert.NoError(t, service.DeleteLyrics(context.Background(), 1, 1, "", "original"))
+	assert.NoError(t, service.DeleteLyrics(context.Background(), 1, 1, "ru", "original"))
+	assert.Equal(t, []uint{3, 3}, deleted)
+}
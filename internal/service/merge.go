@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MergeService сливает дубли артистов, альбомов и жанров и ведет перенаправления со слитых идентификаторов
+type MergeService struct {
+	mergeRepo  repository.IMergeRepository
+	artistRepo repository.IArtistRepository
+	albumRepo  repository.IAlbumRepository
+	genreRepo  repository.IGenreRepository
+	audit      auditLog
+
+	logger *zap.SugaredLogger
+}
+
+func NewMergeService(
+	merge repository.IMergeRepository,
+	artist repository.IArtistRepository,
+	album repository.IAlbumRepository,
+	genre repository.IGenreRepository,
+	audit repository.IAuditRepository,
+	logger *zap.SugaredLogger,
+) *MergeService {
+	return &MergeService{
+		mergeRepo:  merge,
+		artistRepo: artist,
+		albumRepo:  album,
+		genreRepo:  genre,
+		audit:      newAuditLog(audit, logger),
+		logger:     logger,
+	}
+}
+
+// Merge переносит на targetID альбомы, песни, избранное, права, метки, оценки и комментарии записи sourceID и удаляет ее
+func (s *MergeService) Merge(ctx context.Context, resource model.Resource, sourceID, targetID, actorID uint) error {
+	if sourceID == targetID {
+		return er.ErrMergeSelf
+	}
+
+	before, err := s.state(ctx, resource, sourceID)
+	if err != nil {
+		return err
+	}
+	after, err := s.state(ctx, resource, targetID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mergeRepo.Merge(ctx, resource, sourceID, targetID, actorID); err != nil {
+		s.logger.Errorw("Failed to merge entries",
+			"resource", resource,
+			"source id", sourceID,
+			"target id", targetID,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Infow("Entries merged",
+		"resource", resource,
+		"source id", sourceID,
+		"target id", targetID,
+		"actor id", actorID,
+	)
+	s.audit.record(ctx, actorID, resource, sourceID, model.AuditMerge, before, after)
+	return nil
+}
+
+// Resolve возвращает запись, в которую влита запись id
+func (s *MergeService) Resolve(ctx context.Context, resource model.Resource, id uint) (uint, bool) {
+	targetID, err := s.mergeRepo.Resolve(ctx, resource, id)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Errorw("Failed to resolve merged entry",
+				"resource", resource,
+				"id", id,
+				"error", err.Error(),
+			)
+		}
+		return 0, false
+	}
+	return targetID, true
+}
+
+// state проверяет, что запись существует, и возвращает ее снимок для журнала
+func (s *MergeService) state(ctx context.Context, resource model.Resource, id uint) (any, error) {
+	var state any
+	var err error
+	switch resource {
+	case model.ArtistResource:
+		var artist *model.Artist
+		if artist, err = s.artistRepo.GetByID(ctx, id); err == nil {
+			state = artistState(artist)
+		}
+	case model.AlbumResource:
+		var album *model.Album
+		if album, err = s.albumRepo.GetByID(ctx, id); err == nil {
+			state = albumState(album)
+		}
+	case model.GenreResource:
+		var genre *model.Genre
+		if genre, err = s.genreRepo.GetById(ctx, id); err == nil {
+			state = genreState(genre)
+		}
+	default:
+		return nil, er.ErrMergeResource
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notExists(resource)
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return state, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+	var merged [][2]uint
+	mergeRepo := &mocks.MockMergeRepo{
+		MergeFunc: func(ctx context.Context, resource model.Resource, sourceID, targetID, actorID uint) error {
+			merged = append(merged, [2]uint{sourceID, targetID})
+			return nil
+		},
+	}
+	var entries []*model.AuditEntry
+	auditRepo := &mocks.MockAuditRepo{
+		CreateFunc: func(ctx context.Context, entry *model.AuditEntry) error {
+			entries = append(entries, entry)
+			return nil
+		},
+	}
+	artists := newArtistRepo(&model.Artist{ID: 1, Name: "The Beatles"}, &model.Artist{ID: 2, Name: "Beatles"})
+	service := NewMergeService(mergeRepo, artists, newAlbumRepo(), nil, auditRepo, zap.NewNop().Sugar())
+
+	assert.Equal(t, er.ErrMergeSelf, service.Merge(ctx, model.ArtistResource, 2, 2, 9))
+	assert.Equal(t, er.ErrArtistNotExists, service.Merge(ctx, model.ArtistResource, 3, 1, 9))
+	assert.Equal(t, er.ErrAlbumNotExists, service.Merge(ctx, model.AlbumResource, 1, 2, 9))
+	assert.Equal(t, er.ErrMergeResource, service.Merge(ctx, model.SongResource, 1, 2, 9))
+	assert.Empty(t, merged)
+
+	assert.NoError(t, service.Merge(ctx, model.ArtistResource, 2, 1, 9))
+	assert.Equal(t, [][2]uint{{2, 1}}, merged)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, model.AuditMerge, entries[0].Action)
+		assert.Equal(t, uint(2), entries[0].EntityID)
+		assert.Equal(t, uint(9), entries[0].ActorID)
+		assert.Contains(t, string(entries[0].Before), `"Name":"Beatles"`)
+		assert.Contains(t, string(entries[0].After), `"Name":"The Beatles"`)
+	}
+}
+
+func TestMergeResolve(t *testing.T) {
+	mergeRepo := &mocks.MockMergeRepo{
+		ResolveFunc: func(ctx context.Context, resource model.Resource, id uint) (uint, error) {
+			if resource == model.AlbumResource && id == 4 {
+				return 7, nil
+			}
+			return 0, gorm.ErrRecordNotFound
+		},
+	}
+	service := NewMergeService(mergeRepo, nil, nil, nil, nil, zap.NewNop().Sugar())
+
+	targetID, ok := service.Resolve(context.Background(), model.AlbumResource, 4)
+	assert.True(t, ok)
+	assert.Equal(t, uint(7), targetID)
+
+	_, ok = service.Resolve(context.Background(), model.ArtistResource, 4)
+	assert.False(t, ok)
+}
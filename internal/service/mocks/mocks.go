@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"music-lib/internal/model"
+	"time"
 )
 
 // MockArtistRepo для IArtistRepository
@@ -10,10 +11,10 @@ type MockArtistRepo struct {
 	CreateFunc                func(ctx context.Context, entity *model.Artist) (*model.Artist, error)
 	UpdateFunc                func(ctx context.Context, entity *model.Artist) (*model.Artist, error)
 	DeleteFunc                func(ctx context.Context, id uint) error
-	SearchFunc                func(ctx context.Context, query string, limit, offset int) ([]model.Artist, int64, error)
+	SearchFunc                func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Artist, int64, error)
 	GetByIDFunc               func(ctx context.Context, id uint) (*model.Artist, error)
 	GetByUserIDFunc           func(ctx context.Context, userID uint) (*model.Artist, error)
-	GetWithAlbumsFunc         func(ctx context.Context, id uint) (*model.Artist, error)
+	GetWithAlbumsFunc         func(ctx context.Context, id, viewerID uint) (*model.Artist, error)
 	IsExistsFunc              func(ctx context.Context, name string) bool
 	GetArtistAlbumByUserIDFunc func(ctx context.Context, userID uint, albumID uint) (*model.Album, int, error)
 }
@@ -30,8 +31,8 @@ func (m *MockArtistRepo) Delete(ctx context.Context, id uint) error {
 	return m.DeleteFunc(ctx, id)
 }
 
-func (m *MockArtistRepo) Search(ctx context.Context, query string, limit, offset int) ([]model.Artist, int64, error) {
-	return m.SearchFunc(ctx, query, limit, offset)
+func (m *MockArtistRepo) Search(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Artist, int64, error) {
+	return m.SearchFunc(ctx, query, tags, viewerID, limit, offset)
 }
 
 func (m *MockArtistRepo) GetByID(ctx context.Context, id uint) (*model.Artist, error) {
@@ -42,8 +43,8 @@ func (m *MockArtistRepo) GetByUserID(ctx context.Context, userID uint) (*model.A
 	return m.GetByUserIDFunc(ctx, userID)
 }
 
-func (m *MockArtistRepo) GetWithAlbums(ctx context.Context, id uint) (*model.Artist, error) {
-	return m.GetWithAlbumsFunc(ctx, id)
+func (m *MockArtistRepo) GetWithAlbums(ctx context.Context, id, viewerID uint) (*model.Artist, error) {
+	return m.GetWithAlbumsFunc(ctx, id, viewerID)
 }
 
 func (m *MockArtistRepo) IsExists(ctx context.Context, name string) bool {
@@ -59,9 +60,13 @@ type MockAlbumRepo struct {
 	CreateFunc        func(ctx context.Context, entity *model.Album) (*model.Album, error)
 	UpdateFunc        func(ctx context.Context, entity *model.Album) (*model.Album, error)
 	DeleteFunc        func(ctx context.Context, id uint) error
-	SearchFunc        func(ctx context.Context, query string, limit, offset int) ([]model.Album, int64, error)
+	SearchFunc        func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Album, int64, error)
 	GetByIDFunc       func(ctx context.Context, id uint) (*model.Album, error)
-	GetWithSongsFunc func(ctx context.Context, id uint) (*model.Album, error)
+	GetWithSongsFunc func(ctx context.Context, id, viewerID uint) (*model.Album, error)
+	ReplaceDiscsFunc func(ctx context.Context, albumID uint, discs []model.AlbumDisc) error
+	GetByUPCFunc     func(ctx context.Context, upc string) (*model.Album, error)
+	GetDueReleasesFunc func(ctx context.Context, now time.Time) ([]model.Album, error)
+	MarkReleasedFunc   func(ctx context.Context, id uint) (bool, error)
 }
 
 func (m *MockAlbumRepo) Create(ctx context.Context, entity *model.Album) (*model.Album, error) {
@@ -76,16 +81,32 @@ func (m *MockAlbumRepo) Delete(ctx context.Context, id uint) error {
 	return m.DeleteFunc(ctx, id)
 }
 
-func (m *MockAlbumRepo) Search(ctx context.Context, query string, limit, offset int) ([]model.Album, int64, error) {
-	return m.SearchFunc(ctx, query, limit, offset)
+func (m *MockAlbumRepo) Search(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Album, int64, error) {
+	return m.SearchFunc(ctx, query, tags, viewerID, limit, offset)
 }
 
 func (m *MockAlbumRepo) GetByID(ctx context.Context, id uint) (*model.Album, error) {
 	return m.GetByIDFunc(ctx, id)
 }
 
-func (m *MockAlbumRepo) GetWithSongs(ctx context.Context, id uint) (*model.Album, error) {
-	return m.GetWithSongsFunc(ctx, id)
+func (m *MockAlbumRepo) GetWithSongs(ctx context.Context, id, viewerID uint) (*model.Album, error) {
+	return m.GetWithSongsFunc(ctx, id, viewerID)
+}
+
+func (m *MockAlbumRepo) GetByUPC(ctx context.Context, upc string) (*model.Album, error) {
+	return m.GetByUPCFunc(ctx, upc)
+}
+
+func (m *MockAlbumRepo) GetDueReleases(ctx context.Context, now time.Time) ([]model.Album, error) {
+	return m.GetDueReleasesFunc(ctx, now)
+}
+
+func (m *MockAlbumRepo) MarkReleased(ctx context.Context, id uint) (bool, error) {
+	return m.MarkReleasedFunc(ctx, id)
+}
+
+func (m *MockAlbumRepo) ReplaceDiscs(ctx context.Context, albumID uint, discs []model.AlbumDisc) error {
+	return m.ReplaceDiscsFunc(ctx, albumID, discs)
 }
 
 // MockSongRepo для ISongRepository
@@ -93,10 +114,15 @@ type MockSongRepo struct {
 	CreateFunc         func(ctx context.Context, entity *model.Song) (*model.Song, error)
 	UpdateFunc         func(ctx context.Context, entity *model.Song) (*model.Song, error)
 	DeleteFunc         func(ctx context.Context, id uint) error
-	SearchFunc         func(ctx context.Context, query string, limit, offset int) ([]model.Song, int64, error)
+	SearchFunc         func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Song, int64, error)
 	ExistsInAlbumFunc  func(ctx context.Context, albumID uint, songName string) bool
+	TrackExistsFunc     func(ctx context.Context, albumID uint, disc, track int, excludeID uint) bool
+	NextTrackNumberFunc func(ctx context.Context, albumID uint, disc int) (int, error)
+	FindDuplicateFunc  func(ctx context.Context, fileHash, audioHash string, excludeID uint) (*model.Song, error)
 	GetByIDFunc        func(ctx context.Context, id uint) (*model.Song, error)
-	GetByArtistIDFunc  func(ctx context.Context, artistID uint, sort string, limit, offset int) ([]model.Song, int64, error)
+	GetByISRCFunc      func(ctx context.Context, isrc string) (*model.Song, error)
+	GetByArtistIDFunc  func(ctx context.Context, artistID, viewerID uint, sort string, limit, offset int) ([]model.Song, int64, error)
+	GetByGenreIDsFunc  func(ctx context.Context, genreIDs []uint, viewerID uint, limit, offset int) ([]model.Song, int64, error)
 	GetByAlbumIDFunc   func(ctx context.Context, albumID uint, sort string, limit, offset int) ([]model.Song, int64, error)
 	GetFullInfoFunc    func(ctx context.Context, id uint) (*model.Song, *model.Artist, *model.Album, error)
 }
@@ -113,20 +139,40 @@ func (m *MockSongRepo) Delete(ctx context.Context, id uint) error {
 	return m.DeleteFunc(ctx, id)
 }
 
-func (m *MockSongRepo) Search(ctx context.Context, query string, limit, offset int) ([]model.Song, int64, error) {
-	return m.SearchFunc(ctx, query, limit, offset)
+func (m *MockSongRepo) Search(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
+	return m.SearchFunc(ctx, query, tags, viewerID, limit, offset)
 }
 
 func (m *MockSongRepo) ExistsInAlbum(ctx context.Context, albumID uint, songName string) bool {
 	return m.ExistsInAlbumFunc(ctx, albumID, songName)
 }
 
+func (m *MockSongRepo) TrackExists(ctx context.Context, albumID uint, disc, track int, excludeID uint) bool {
+	return m.TrackExistsFunc(ctx, albumID, disc, track, excludeID)
+}
+
+func (m *MockSongRepo) NextTrackNumber(ctx context.Context, albumID uint, disc int) (int, error) {
+	return m.NextTrackNumberFunc(ctx, albumID, disc)
+}
+
+func (m *MockSongRepo) FindDuplicate(ctx context.Context, fileHash, audioHash string, excludeID uint) (*model.Song, error) {
+	return m.FindDuplicateFunc(ctx, fileHash, audioHash, excludeID)
+}
+
 func (m *MockSongRepo) GetByID(ctx context.Context, id uint) (*model.Song, error) {
 	return m.GetByIDFunc(ctx, id)
 }
 
-func (m *MockSongRepo) GetByArtistID(ctx context.Context, artistID uint, sort string, limit, offset int) ([]model.Song, int64, error) {
-	return m.GetByArtistIDFunc(ctx, artistID, sort, limit, offset)
+func (m *MockSongRepo) GetByISRC(ctx context.Context, isrc string) (*model.Song, error) {
+	return m.GetByISRCFunc(ctx, isrc)
+}
+
+func (m *MockSongRepo) GetByArtistID(ctx context.Context, artistID, viewerID uint, sort string, limit, offset int) ([]model.Song, int64, error) {
+	return m.GetByArtistIDFunc(ctx, artistID, viewerID, sort, limit, offset)
+}
+
+func (m *MockSongRepo) GetByGenreIDs(ctx context.Context, genreIDs []uint, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
+	return m.GetByGenreIDsFunc(ctx, genreIDs, viewerID, limit, offset)
 }
 
 func (m *MockSongRepo) GetByAlbumID(ctx context.Context, albumID uint, sort string, limit, offset int) ([]model.Song, int64, error) {
@@ -139,21 +185,81 @@ func (m *MockSongRepo) GetFullInfo(ctx context.Context, id uint) (*model.Song, *
 
 // MockLyricsRepo для ILyricsRepository
 type MockLyricsRepo struct {
-	GetBySongIDFunc    func(ctx context.Context, songID uint) (*model.Lyrics, error)
-	UpsertFunc         func(ctx context.Context, lyrics *model.Lyrics) error
-	DeleteBySongIDFunc func(ctx context.Context, songID uint) error
+	GetBySongIDFunc    func(ctx context.Context, songID uint) ([]model.Lyrics, error)
+	UpsertFunc         func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error
+	DeleteFunc         func(ctx context.Context, id uint, revision *model.LyricsRevision) error
+	DeleteBySongIDFunc func(ctx context.Context, songID, authorID uint) error
+	GetRevisionsFunc   func(ctx context.Context, songID uint, language string, kind model.LyricsKind) ([]model.LyricsRevision, error)
+	GetRevisionFunc    func(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error)
 }
 
-func (m *MockLyricsRepo) GetBySongID(ctx context.Context, songID uint) (*model.Lyrics, error) {
+func (m *MockLyricsRepo) GetBySongID(ctx context.Context, songID uint) ([]model.Lyrics, error) {
 	return m.GetBySongIDFunc(ctx, songID)
 }
 
-func (m *MockLyricsRepo) Upsert(ctx context.Context, lyrics *model.Lyrics) error {
-	return m.UpsertFunc(ctx, lyrics)
+func (m *MockLyricsRepo) Upsert(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+	return m.UpsertFunc(ctx, lyrics, revision)
+}
+
+func (m *MockLyricsRepo) Delete(ctx context.Context, id uint, revision *model.LyricsRevision) error {
+	return m.DeleteFunc(ctx, id, revision)
+}
+
+func (m *MockLyricsRepo) DeleteBySongID(ctx context.Context, songID, authorID uint) error {
+	return m.DeleteBySongIDFunc(ctx, songID, authorID)
 }
 
-func (m *MockLyricsRepo) DeleteBySongID(ctx context.Context, songID uint) error {
-	return m.DeleteBySongIDFunc(ctx, songID)
+func (m *MockLyricsRepo) GetRevisions(ctx context.Context, songID uint, language string, kind model.LyricsKind) ([]model.LyricsRevision, error) {
+	return m.GetRevisionsFunc(ctx, songID, language, kind)
+}
+
+func (m *MockLyricsRepo) GetRevision(ctx context.Context, songID, revisionID uint) (*model.LyricsRevision, error) {
+	return m.GetRevisionFunc(ctx, songID, revisionID)
+}
+
+// MockUploadRepo для IUploadRepository
+type MockUploadRepo struct {
+	CreateFunc    func(ctx context.Context, entity *model.Upload) (*model.Upload, error)
+	UpdateFunc    func(ctx context.Context, entity *model.Upload) (*model.Upload, error)
+	GetByIDFunc   func(ctx context.Context, id string) (*model.Upload, error)
+	UsedBytesFunc func(ctx context.Context, userID uint) (int64, error)
+	CompleteFunc  func(ctx context.Context, upload *model.Upload, song *model.Song) error
+
+	AbortStaleFunc func(ctx context.Context, before time.Time) ([]model.Upload, error)
+	MoveOffsetFunc func(ctx context.Context, id string, from, to int64, parts int) (bool, error)
+	CreateSongFunc func(ctx context.Context, upload *model.Upload, song *model.Song) (bool, error)
+}
+
+func (m *MockUploadRepo) Create(ctx context.Context, entity *model.Upload) (*model.Upload, error) {
+	return m.CreateFunc(ctx, entity)
+}
+
+func (m *MockUploadRepo) Update(ctx context.Context, entity *model.Upload) (*model.Upload, error) {
+	return m.UpdateFunc(ctx, entity)
+}
+
+func (m *MockUploadRepo) GetByID(ctx context.Context, id string) (*model.Upload, error) {
+	return m.GetByIDFunc(ctx, id)
+}
+
+func (m *MockUploadRepo) MoveOffset(ctx context.Context, id string, from, to int64, parts int) (bool, error) {
+	return m.MoveOffsetFunc(ctx, id, from, to, parts)
+}
+
+func (m *MockUploadRepo) UsedBytes(ctx context.Context, userID uint) (int64, error) {
+	return m.UsedBytesFunc(ctx, userID)
+}
+
+func (m *MockUploadRepo) Complete(ctx context.Context, upload *model.Upload, song *model.Song) error {
+	return m.CompleteFunc(ctx, upload, song)
+}
+
+func (m *MockUploadRepo) CreateSong(ctx context.Context, upload *model.Upload, song *model.Song) (bool, error) {
+	return m.CreateSongFunc(ctx, upload, song)
+}
+
+func (m *MockUploadRepo) AbortStale(ctx context.Context, before time.Time) ([]model.Upload, error) {
+	return m.AbortStaleFunc(ctx, before)
 }
 
 // MockUserRepo для IUserRepository
@@ -181,6 +287,14 @@ type MockProfileRepo struct {
 	UpdateFunc     func(ctx context.Context, entity *model.Profile) (*model.Profile, error)
 	DeleteFunc     func(ctx context.Context, id uint) error
 	GetByUserIDFunc func(ctx context.Context, userID uint) (*model.Profile, error)
+
+	UpdatePrivacyFunc           func(ctx context.Context, profile *model.Profile) error
+	SetCollectionVisibilityFunc func(ctx context.Context, profileID, collectionID uint, visibility model.Visibility) (bool, error)
+	GetCollectionsFunc          func(ctx context.Context, profileID uint, visible []model.Visibility) ([]model.Collection, error)
+	AddPlayFunc                 func(ctx context.Context, userID, songID uint, since time.Time) (bool, error)
+	RecentFavoritesFunc         func(ctx context.Context, profileID, viewerID uint, limit int) ([]model.Favorite, error)
+	RecentPlaysFunc             func(ctx context.Context, profileID, viewerID uint, limit int) ([]model.History, error)
+	TopArtistsFunc              func(ctx context.Context, profileID, viewerID uint, limit int) ([]model.TopArtist, error)
 }
 
 func (m *MockProfileRepo) Create(ctx context.Context, entity *model.Profile) (*model.Profile, error) {
@@ -199,6 +313,34 @@ func (m *MockProfileRepo) GetByUserID(ctx context.Context, userID uint) (*model.
 	return m.GetByUserIDFunc(ctx, userID)
 }
 
+func (m *MockProfileRepo) UpdatePrivacy(ctx context.Context, profile *model.Profile) error {
+	return m.UpdatePrivacyFunc(ctx, profile)
+}
+
+func (m *MockProfileRepo) SetCollectionVisibility(ctx context.Context, profileID, collectionID uint, visibility model.Visibility) (bool, error) {
+	return m.SetCollectionVisibilityFunc(ctx, profileID, collectionID, visibility)
+}
+
+func (m *MockProfileRepo) GetCollections(ctx context.Context, profileID uint, visible []model.Visibility) ([]model.Collection, error) {
+	return m.GetCollectionsFunc(ctx, profileID, visible)
+}
+
+func (m *MockProfileRepo) AddPlay(ctx context.Context, userID, songID uint, since time.Time) (bool, error) {
+	return m.AddPlayFunc(ctx, userID, songID, since)
+}
+
+func (m *MockProfileRepo) RecentFavorites(ctx context.Context, profileID, viewerID uint, limit int) ([]model.Favorite, error) {
+	return m.RecentFavoritesFunc(ctx, profileID, viewerID, limit)
+}
+
+func (m *MockProfileRepo) RecentPlays(ctx context.Context, profileID, viewerID uint, limit int) ([]model.History, error) {
+	return m.RecentPlaysFunc(ctx, profileID, viewerID, limit)
+}
+
+func (m *MockProfileRepo) TopArtists(ctx context.Context, profileID, viewerID uint, limit int) ([]model.TopArtist, error) {
+	return m.TopArtistsFunc(ctx, profileID, viewerID, limit)
+}
+
 // MockGenreRepo для IGenreRepository
 type MockGenreRepo struct {
 	CreateFunc   func(ctx context.Context, entity *model.Genre) (*model.Genre, error)
@@ -207,6 +349,12 @@ type MockGenreRepo struct {
 	GetByIdFunc  func(ctx context.Context, id uint) (*model.Genre, error)
 	GetByIdsFunc func(ctx context.Context, ids []uint) ([]model.Genre, error)
 	IsExistsFunc func(ctx context.Context, name string) bool
+
+	GetWithChildrenFunc   func(ctx context.Context, id uint) (*model.Genre, error)
+	GetAllFunc            func(ctx context.Context) ([]model.Genre, error)
+	FindByNameFunc        func(ctx context.Context, name string) (*model.Genre, error)
+	GetSubtreeIDsFunc     func(ctx context.Context, id uint) ([]uint, error)
+	DeleteAndReassignFunc func(ctx context.Context, id, replacementID uint) error
 }
 
 func (m *MockGenreRepo) Create(ctx context.Context, entity *model.Genre) (*model.Genre, error) {
@@ -229,6 +377,26 @@ func (m *MockGenreRepo) GetByIds(ctx context.Context, ids []uint) ([]model.Genre
 	return m.GetByIdsFunc(ctx, ids)
 }
 
+func (m *MockGenreRepo) GetWithChildren(ctx context.Context, id uint) (*model.Genre, error) {
+	return m.GetWithChildrenFunc(ctx, id)
+}
+
+func (m *MockGenreRepo) GetAll(ctx context.Context) ([]model.Genre, error) {
+	return m.GetAllFunc(ctx)
+}
+
+func (m *MockGenreRepo) FindByName(ctx context.Context, name string) (*model.Genre, error) {
+	return m.FindByNameFunc(ctx, name)
+}
+
+func (m *MockGenreRepo) GetSubtreeIDs(ctx context.Context, id uint) ([]uint, error) {
+	return m.GetSubtreeIDsFunc(ctx, id)
+}
+
+func (m *MockGenreRepo) DeleteAndReassign(ctx context.Context, id, replacementID uint) error {
+	return m.DeleteAndReassignFunc(ctx, id, replacementID)
+}
+
 func (m *MockGenreRepo) IsExists(ctx context.Context, name string) bool {
 	return m.IsExistsFunc(ctx, name)
 }
@@ -252,12 +420,27 @@ func (m *MockSongGenreRepo) Delete(ctx context.Context, id uint) error {
 	return m.DeleteFunc(ctx, id)
 }
 
+// MockSongCreditRepo для ISongCreditRepository
+type MockSongCreditRepo struct {
+	GetBySongIDFunc func(ctx context.Context, songID uint) ([]model.SongCredit, error)
+	ReplaceFunc     func(ctx context.Context, songID uint, credits []model.SongCredit) error
+}
+
+func (m *MockSongCreditRepo) GetBySongID(ctx context.Context, songID uint) ([]model.SongCredit, error) {
+	return m.GetBySongIDFunc(ctx, songID)
+}
+
+func (m *MockSongCreditRepo) Replace(ctx context.Context, songID uint, credits []model.SongCredit) error {
+	return m.ReplaceFunc(ctx, songID, credits)
+}
+
 // MockPermissionRepo для IPermissionRepository
 type MockPermissionRepo struct {
 	CreateFunc       func(ctx context.Context, entity *model.ResourcePermission) (*model.ResourcePermission, error)
 	UpdateFunc       func(ctx context.Context, entity *model.ResourcePermission) (*model.ResourcePermission, error)
 	DeleteFunc       func(ctx context.Context, id uint) error
 	HasPermissionFunc func(userID, resourceID uint, resourceType model.Resource, permission model.Permission) bool
+	RevokeFunc       func(ctx context.Context, userID, resourceID uint, resourceType model.Resource, permission model.Permission) error
 }
 
 func (m *MockPermissionRepo) Create(ctx context.Context, entity *model.ResourcePermission) (*model.ResourcePermission, error) {
@@ -274,4 +457,279 @@ func (m *MockPermissionRepo) Delete(ctx context.Context, id uint) error {
 
 func (m *MockPermissionRepo) HasPermission(userID, resourceID uint, resourceType model.Resource, permission model.Permission) bool {
 	return m.HasPermissionFunc(userID, resourceID, resourceType, permission)
-}
\ No newline at end of file
+}
+
+func (m *MockPermissionRepo) Revoke(ctx context.Context, userID, resourceID uint, resourceType model.Resource, permission model.Permission) error {
+	return m.RevokeFunc(ctx, userID, resourceID, resourceType, permission)
+}
+// MockTrashRepo для ITrashRepository
+type MockTrashRepo struct {
+	TrashFunc       func(ctx context.Context, resource model.Resource, id uint) error
+	RestoreFunc     func(ctx context.Context, resource model.Resource, id uint) error
+	GetByIDFunc     func(ctx context.Context, resource model.Resource, id uint) (*model.TrashItem, error)
+	GetByUserIDFunc func(ctx context.Context, userID uint) ([]model.TrashItem, error)
+	PurgeFunc       func(ctx context.Context, before time.Time) (*model.PurgedEntries, error)
+}
+
+func (m *MockTrashRepo) Trash(ctx context.Context, resource model.Resource, id uint) error {
+	return m.TrashFunc(ctx, resource, id)
+}
+
+func (m *MockTrashRepo) Restore(ctx context.Context, resource model.Resource, id uint) error {
+	return m.RestoreFunc(ctx, resource, id)
+}
+
+func (m *MockTrashRepo) GetByID(ctx context.Context, resource model.Resource, id uint) (*model.TrashItem, error) {
+	return m.GetByIDFunc(ctx, resource, id)
+}
+
+func (m *MockTrashRepo) GetByUserID(ctx context.Context, userID uint) ([]model.TrashItem, error) {
+	return m.GetByUserIDFunc(ctx, userID)
+}
+
+func (m *MockTrashRepo) Purge(ctx context.Context, before time.Time) (*model.PurgedEntries, error) {
+	return m.PurgeFunc(ctx, before)
+}
+
+// MockAuditRepo для IAuditRepository
+type MockAuditRepo struct {
+	CreateFunc func(ctx context.Context, entry *model.AuditEntry) error
+	FindFunc   func(ctx context.Context, filter model.AuditFilter, limit, offset int) ([]model.AuditEntry, int64, error)
+}
+
+func (m *MockAuditRepo) Create(ctx context.Context, entry *model.AuditEntry) error {
+	return m.CreateFunc(ctx, entry)
+}
+
+func (m *MockAuditRepo) Find(ctx context.Context, filter model.AuditFilter, limit, offset int) ([]model.AuditEntry, int64, error) {
+	return m.FindFunc(ctx, filter, limit, offset)
+}
+
+// MockMergeRepo для IMergeRepository
+type MockMergeRepo struct {
+	MergeFunc   func(ctx context.Context, resource model.Resource, sourceID, targetID, actorID uint) error
+	ResolveFunc func(ctx context.Context, resource model.Resource, id uint) (uint, error)
+}
+
+func (m *MockMergeRepo) Merge(ctx context.Context, resource model.Resource, sourceID, targetID, actorID uint) error {
+	return m.MergeFunc(ctx, resource, sourceID, targetID, actorID)
+}
+
+func (m *MockMergeRepo) Resolve(ctx context.Context, resource model.Resource, id uint) (uint, error) {
+	return m.ResolveFunc(ctx, resource, id)
+}
+
+// MockTagRepo для ITagRepository
+type MockTagRepo struct {
+	VoteFunc       func(ctx context.Context, resource model.Resource, resourceID, userID uint, name string, value int) error
+	UnvoteFunc     func(ctx context.Context, resource model.Resource, resourceID, userID uint, name string) error
+	GetWeightsFunc func(ctx context.Context, resource model.Resource, resourceID, viewerID uint) ([]model.TagWeight, error)
+	GetCloudFunc   func(ctx context.Context, resource model.Resource, limit int) ([]model.TagWeight, error)
+}
+
+func (m *MockTagRepo) Vote(ctx context.Context, resource model.Resource, resourceID, userID uint, name string, value int) error {
+	return m.VoteFunc(ctx, resource, resourceID, userID, name, value)
+}
+
+func (m *MockTagRepo) Unvote(ctx context.Context, resource model.Resource, resourceID, userID uint, name string) error {
+	return m.UnvoteFunc(ctx, resource, resourceID, userID, name)
+}
+
+func (m *MockTagRepo) GetWeights(ctx context.Context, resource model.Resource, resourceID, viewerID uint) ([]model.TagWeight, error) {
+	return m.GetWeightsFunc(ctx, resource, resourceID, viewerID)
+}
+
+func (m *MockTagRepo) GetCloud(ctx context.Context, resource model.Resource, limit int) ([]model.TagWeight, error) {
+	return m.GetCloudFunc(ctx, resource, limit)
+}
+
+// MockReviewRepo для IReviewRepository
+type MockReviewRepo struct {
+	SetRatingFunc      func(ctx context.Context, rating *model.Rating) error
+	DeleteRatingFunc   func(ctx context.Context, resource model.Resource, resourceID, userID uint) error
+	GetSummaryFunc     func(ctx context.Context, resource model.Resource, resourceID, viewerID uint) (*model.RatingSummary, error)
+	CreateReviewFunc   func(ctx context.Context, review *model.Review) error
+	UpdateReviewFunc   func(ctx context.Context, review *model.Review) error
+	DeleteReviewFunc   func(ctx context.Context, id uint) error
+	SetHiddenFunc      func(ctx context.Context, id, moderatorID uint, hidden bool) error
+	GetReviewFunc      func(ctx context.Context, id uint) (*model.Review, error)
+	GetReviewsFunc     func(ctx context.Context, resource model.Resource, resourceID, viewerID uint, limit, offset int) ([]model.Review, int64, error)
+	GetUserReviewsFunc func(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.Review, int64, error)
+}
+
+func (m *MockReviewRepo) SetRating(ctx context.Context, rating *model.Rating) error {
+	return m.SetRatingFunc(ctx, rating)
+}
+
+func (m *MockReviewRepo) DeleteRating(ctx context.Context, resource model.Resource, resourceID, userID uint) error {
+	return m.DeleteRatingFunc(ctx, resource, resourceID, userID)
+}
+
+func (m *MockReviewRepo) GetSummary(ctx context.Context, resource model.Resource, resourceID, viewerID uint) (*model.RatingSummary, error) {
+	return m.GetSummaryFunc(ctx, resource, resourceID, viewerID)
+}
+
+func (m *MockReviewRepo) CreateReview(ctx context.Context, review *model.Review) error {
+	return m.CreateReviewFunc(ctx, review)
+}
+
+func (m *MockReviewRepo) UpdateReview(ctx context.Context, review *model.Review) error {
+	return m.UpdateReviewFunc(ctx, review)
+}
+
+func (m *MockReviewRepo) DeleteReview(ctx context.Context, id uint) error {
+	return m.DeleteReviewFunc(ctx, id)
+}
+
+func (m *MockReviewRepo) SetHidden(ctx context.Context, id, moderatorID uint, hidden bool) error {
+	return m.SetHiddenFunc(ctx, id, moderatorID, hidden)
+}
+
+func (m *MockReviewRepo) GetReview(ctx context.Context, id uint) (*model.Review, error) {
+	return m.GetReviewFunc(ctx, id)
+}
+
+func (m *MockReviewRepo) GetReviews(ctx context.Context, resource model.Resource, resourceID, viewerID uint, limit, offset int) ([]model.Review, int64, error) {
+	return m.GetReviewsFunc(ctx, resource, resourceID, viewerID, limit, offset)
+}
+
+func (m *MockReviewRepo) GetUserReviews(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.Review, int64, error) {
+	return m.GetUserReviewsFunc(ctx, userID, viewerID, limit, offset)
+}
+
+// MockCommentRepo для ICommentRepository
+type MockCommentRepo struct {
+	CreateFunc     func(ctx context.Context, comment *model.Comment) error
+	GetByIDFunc    func(ctx context.Context, id, viewerID uint) (*model.Comment, error)
+	UpdateTextFunc func(ctx context.Context, id uint, text string, editedAt time.Time) error
+	DeleteFunc     func(ctx context.Context, id, deletedBy uint) error
+	GetThreadFunc  func(ctx context.Context, resource model.Resource, resourceID, parentID, viewerID uint, sort model.CommentSort, limit, offset int) ([]model.Comment, int64, error)
+	LikeFunc       func(ctx context.Context, id, userID uint) error
+	UnlikeFunc     func(ctx context.Context, id, userID uint) error
+}
+
+func (m *MockCommentRepo) Create(ctx context.Context, comment *model.Comment) error {
+	return m.CreateFunc(ctx, comment)
+}
+
+func (m *MockCommentRepo) GetByID(ctx context.Context, id, viewerID uint) (*model.Comment, error) {
+	return m.GetByIDFunc(ctx, id, viewerID)
+}
+
+func (m *MockCommentRepo) UpdateText(ctx context.Context, id uint, text string, editedAt time.Time) error {
+	return m.UpdateTextFunc(ctx, id, text, editedAt)
+}
+
+func (m *MockCommentRepo) Delete(ctx context.Context, id, deletedBy uint) error {
+	return m.DeleteFunc(ctx, id, deletedBy)
+}
+
+func (m *MockCommentRepo) GetThread(ctx context.Context, resource model.Resource, resourceID, parentID, viewerID uint, sort model.CommentSort, limit, offset int) ([]model.Comment, int64, error) {
+	return m.GetThreadFunc(ctx, resource, resourceID, parentID, viewerID, sort, limit, offset)
+}
+
+func (m *MockCommentRepo) Like(ctx context.Context, id, userID uint) error {
+	return m.LikeFunc(ctx, id, userID)
+}
+
+func (m *MockCommentRepo) Unlike(ctx context.Context, id, userID uint) error {
+	return m.UnlikeFunc(ctx, id, userID)
+}
+
+// MockReportRepo для IReportRepository
+type MockReportRepo struct {
+	CreateFunc    func(ctx context.Context, report *model.Report) error
+	FindFunc      func(ctx context.Context, filter model.ReportFilter, limit, offset int) ([]model.Report, int64, error)
+	GetByIDsFunc  func(ctx context.Context, ids []uint) ([]model.Report, error)
+	ResolveFunc   func(ctx context.Context, ids []uint, state model.ReportState, moderatorID uint, note string, takenDown bool) error
+	ExistsFunc    func(ctx context.Context, resource model.Resource, id uint) (bool, error)
+	TakeDownFunc  func(ctx context.Context, resource model.Resource, id, moderatorID uint) error
+	GetOwnersFunc func(ctx context.Context, resource model.Resource, id uint) ([]model.User, error)
+}
+
+func (m *MockReportRepo) Create(ctx context.Context, report *model.Report) error {
+	return m.CreateFunc(ctx, report)
+}
+
+func (m *MockReportRepo) Find(ctx context.Context, filter model.ReportFilter, limit, offset int) ([]model.Report, int64, error) {
+	return m.FindFunc(ctx, filter, limit, offset)
+}
+
+func (m *MockReportRepo) GetByIDs(ctx context.Context, ids []uint) ([]model.Report, error) {
+	return m.GetByIDsFunc(ctx, ids)
+}
+
+func (m *MockReportRepo) Resolve(ctx context.Context, ids []uint, state model.ReportState, moderatorID uint, note string, takenDown bool) error {
+	return m.ResolveFunc(ctx, ids, state, moderatorID, note, takenDown)
+}
+
+func (m *MockReportRepo) Exists(ctx context.Context, resource model.Resource, id uint) (bool, error) {
+	return m.ExistsFunc(ctx, resource, id)
+}
+
+func (m *MockReportRepo) TakeDown(ctx context.Context, resource model.Resource, id, moderatorID uint) error {
+	return m.TakeDownFunc(ctx, resource, id, moderatorID)
+}
+
+func (m *MockReportRepo) GetOwners(ctx context.Context, resource model.Resource, id uint) ([]model.User, error) {
+	return m.GetOwnersFunc(ctx, resource, id)
+}
+
+type MockFollowRepo struct {
+	FollowArtistFunc   func(ctx context.Context, userID, artistID uint) error
+	UnfollowArtistFunc func(ctx context.Context, userID, artistID uint) error
+	IsFollowingFunc    func(ctx context.Context, userID, artistID uint) (bool, error)
+	AddToFeedFunc      func(ctx context.Context, item *model.FeedItem) (int64, error)
+	GetFeedFunc        func(ctx context.Context, userID uint, limit, offset int) ([]model.FeedItem, int64, error)
+
+	FollowUserFunc       func(ctx context.Context, followerID, followeeID uint) error
+	UnfollowUserFunc     func(ctx context.Context, followerID, followeeID uint) error
+	IsFollowingUserFunc  func(ctx context.Context, followerID, followeeID uint) (bool, error)
+	CountUserFollowsFunc func(ctx context.Context, userID uint) (int64, int64, error)
+	GetFollowersFunc     func(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error)
+	GetFollowingFunc     func(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error)
+}
+
+func (m *MockFollowRepo) FollowArtist(ctx context.Context, userID, artistID uint) error {
+	return m.FollowArtistFunc(ctx, userID, artistID)
+}
+
+func (m *MockFollowRepo) UnfollowArtist(ctx context.Context, userID, artistID uint) error {
+	return m.UnfollowArtistFunc(ctx, userID, artistID)
+}
+
+func (m *MockFollowRepo) IsFollowing(ctx context.Context, userID, artistID uint) (bool, error) {
+	return m.IsFollowingFunc(ctx, userID, artistID)
+}
+
+func (m *MockFollowRepo) AddToFeed(ctx context.Context, item *model.FeedItem) (int64, error) {
+	return m.AddToFeedFunc(ctx, item)
+}
+
+func (m *MockFollowRepo) GetFeed(ctx context.Context, userID uint, limit, offset int) ([]model.FeedItem, int64, error) {
+	return m.GetFeedFunc(ctx, userID, limit, offset)
+}
+
+func (m *MockFollowRepo) FollowUser(ctx context.Context, followerID, followeeID uint) error {
+	return m.FollowUserFunc(ctx, followerID, followeeID)
+}
+
+func (m *MockFollowRepo) UnfollowUser(ctx context.Context, followerID, followeeID uint) error {
+	return m.UnfollowUserFunc(ctx, followerID, followeeID)
+}
+
+func (m *MockFollowRepo) IsFollowingUser(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	return m.IsFollowingUserFunc(ctx, followerID, followeeID)
+}
+
+func (m *MockFollowRepo) CountUserFollows(ctx context.Context, userID uint) (int64, int64, error) {
+	return m.CountUserFollowsFunc(ctx, userID)
+}
+
+func (m *MockFollowRepo) GetFollowers(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error) {
+	return m.GetFollowersFunc(ctx, userID, viewerID, limit, offset)
+}
+
+func (m *MockFollowRepo) GetFollowing(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.FollowEntry, int64, error) {
+	return m.GetFollowingFunc(ctx, userID, viewerID, limit, offset)
+}
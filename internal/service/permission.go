@@ -1,23 +1,35 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"music-lib/internal/model"
 	"music-lib/internal/repository"
 	"music-lib/pkg/er"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type PermissionService struct {
 	permissionRepo repository.IPermissionRepository
+	userRepo       repository.IUserRepository
+	audit          auditLog
 
 	logger *zap.SugaredLogger
 }
 
-func NewPermissionService(permission repository.IPermissionRepository, log *zap.SugaredLogger) *PermissionService {
+func NewPermissionService(
+	permission repository.IPermissionRepository,
+	user repository.IUserRepository,
+	audit repository.IAuditRepository,
+	log *zap.SugaredLogger,
+) *PermissionService {
 	return &PermissionService{
 		permissionRepo: permission,
+		userRepo:       user,
+		audit:          newAuditLog(audit, log),
 		logger: log,
 	}
 }
@@ -42,7 +54,7 @@ func (s *PermissionService) AddPermission(
 		"resource type", resourceType,
 		"permission", permission,
 	)
-	_, err := s.permissionRepo.Create(ctx, &model.ResourcePermission{
+	granted, err := s.permissionRepo.Create(ctx, &model.ResourcePermission{
 		UserID: userID,
 		ResourceID: resourceID,
 		ResourceType: resourceType,
@@ -60,5 +72,77 @@ func (s *PermissionService) AddPermission(
 		return er.InternalError{Message: err.Error()}
 	}
 	s.logger.Debugw("Permission added successfully")
+	// Право выдается создателю записи, он же автор изменения
+	s.audit.record(ctx, userID, resourceType, resourceID, model.AuditCreate, nil, *granted)
 	return nil
-}
\ No newline at end of file
+}
+
+// GrantView дает пользователю name право на просмотр ресурса, например на прослушивание закрытой песни.
+// Повторная выдача ничего не меняет.
+func (s *PermissionService) GrantView(ctx context.Context, resourceType model.Resource, resourceID uint, name string, actorID uint) error {
+	user, err := s.findUser(name)
+	if err != nil {
+		return err
+	}
+	if s.permissionRepo.HasPermission(user.ID, resourceID, resourceType, model.ViewPermission) {
+		return nil
+	}
+
+	granted, err := s.permissionRepo.Create(ctx, &model.ResourcePermission{
+		UserID:       user.ID,
+		ResourceID:   resourceID,
+		ResourceType: resourceType,
+		Permission:   model.ViewPermission,
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to grant view permission",
+			"user id", user.ID,
+			"resource id", resourceID,
+			"resource type", resourceType,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+	s.audit.record(ctx, actorID, resourceType, resourceID, model.AuditCreate, nil, *granted)
+	return nil
+}
+
+// RevokeView отзывает у пользователя name право на просмотр ресурса
+func (s *PermissionService) RevokeView(ctx context.Context, resourceType model.Resource, resourceID uint, name string, actorID uint) error {
+	user, err := s.findUser(name)
+	if err != nil {
+		return err
+	}
+	if !s.permissionRepo.HasPermission(user.ID, resourceID, resourceType, model.ViewPermission) {
+		return nil
+	}
+
+	if err := s.permissionRepo.Revoke(ctx, user.ID, resourceID, resourceType, model.ViewPermission); err != nil {
+		s.logger.Errorw("Failed to revoke view permission",
+			"user id", user.ID,
+			"resource id", resourceID,
+			"resource type", resourceType,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+	revoked := model.ResourcePermission{
+		UserID:       user.ID,
+		ResourceID:   resourceID,
+		ResourceType: resourceType,
+		Permission:   model.ViewPermission,
+	}
+	s.audit.record(ctx, actorID, resourceType, resourceID, model.AuditDelete, revoked, nil)
+	return nil
+}
+
+func (s *PermissionService) findUser(name string) (*model.User, error) {
+	user, err := s.userRepo.FindByKey(repository.NameKey, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrUserNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return user, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Права в памяти и один пользователь listener с id 8
+func newPermissionService() (*PermissionService, *[]model.ResourcePermission) {
+	var permissions []model.ResourcePermission
+	permissionRepo := &mocks.MockPermissionRepo{
+		CreateFunc: func(ctx context.Context, entity *model.ResourcePermission) (*model.ResourcePermission, error) {
+			permissions = append(permissions, *entity)
+			return entity, nil
+		},
+		HasPermissionFunc: func(userID, resourceID uint, resourceType model.Resource, permission model.Permission) bool {
+			for _, p := range permissions {
+				if p.UserID == userID && p.ResourceID == resourceID && p.ResourceType == resourceType && p.Permission == permission {
+					return true
+				}
+			}
+			return false
+		},
+		RevokeFunc: func(ctx context.Context, userID, resourceID uint, resourceType model.Resource, permission model.Permission) error {
+			kept := permissions[:0]
+			for _, p := range permissions {
+				if p.UserID != userID || p.ResourceID != resourceID || p.ResourceType != resourceType || p.Permission != permission {
+					kept = append(kept, p)
+				}
+			}
+			permissions = kept
+			return nil
+		},
+	}
+	userRepo := &mocks.MockUserRepo{
+		FindByKeyFunc: func(key, data string) (*model.User, error) {
+			if data != "listener" {
+				return nil, gorm.ErrRecordNotFound
+			}
+			user := &model.User{Name: data}
+			user.ID = 8
+			return user, nil
+		},
+	}
+	return NewPermissionService(permissionRepo, userRepo, nil, zap.NewNop().Sugar()), &permissions
+}
+
+func TestGrantView(t *testing.T) {
+	service, permissions := newPermissionService()
+	ctx := context.Background()
+
+	assert.NoError(t, service.GrantView(ctx, model.SongResource, 3, "listener", 1))
+	assert.True(t, service.HasPermission(8, 3, model.SongResource, model.ViewPermission))
+	assert.False(t, service.HasPermission(8, 3, model.SongResource, model.EditPermission))
+
+	// Повторная выдача не создает второе право
+	assert.NoError(t, service.GrantView(ctx, model.SongResource, 3, "listener", 1))
+	assert.Len(t, *permissions, 1)
+
+	err := service.GrantView(ctx, model.SongResource, 3, "nobody", 1)
+	assert.Equal(t, er.ErrUserNotExists, err)
+}
+
+func TestRevokeView(t *testing.T) {
+	service, permissions := newPermissionService()
+	ctx := context.Background()
+
+	assert.NoError(t, service.GrantView(ctx, model.SongResource, 3, "listener", 1))
+	assert.NoError(t, service.GrantView(ctx, model.SongResource, 4, "listener", 1))
+
+	assert.NoError(t, service.RevokeView(ctx, model.SongResource, 3, "listener", 1))
+	assert.False(t, service.HasPermission(8, 3, model.SongResource, model.ViewPermission))
+	assert.True(t, service.HasPermission(8, 4, model.SongResource, model.ViewPermission))
+	assert.Len(t, *permissions, 1)
+
+	err := service.RevokeView(ctx, model.SongResource, 3, "nobody", 1)
+	assert.Equal(t, er.ErrUserNotExists, err)
+}
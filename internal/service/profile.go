@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"music-lib/internal/dto/request"
@@ -13,13 +14,28 @@ import (
 	"gorm.io/gorm"
 )
 
+// Сколько записей избранного, прослушиваний и артистов показывает публичная страница
+const (
+	recentFavorites = 10
+	recentPlays     = 10
+	topArtists      = 5
+)
+
 type ProfileService struct {
 	profileRepository repository.IProfileRepository
+	userRepository    repository.IUserRepository
+	followRepository  repository.IFollowRepository
 }
 
-func NewProfileService(profile repository.IProfileRepository) *ProfileService {
+func NewProfileService(
+	profile repository.IProfileRepository,
+	user repository.IUserRepository,
+	follow repository.IFollowRepository,
+) *ProfileService {
 	return &ProfileService{
 		profileRepository: profile,
+		userRepository:    user,
+		followRepository:  follow,
 	}
 }
 
@@ -48,3 +64,144 @@ func (s *ProfileService) GetProfile(c *gin.Context, userID uint) (*model.Profile
 	}
 	return profile, nil
 }
+
+// GetPublicProfile собирает страницу пользователя name так, как ее видит viewerID.
+// Владелец видит все разделы, остальные - только открытые настройками приватности,
+// подписчики владельца - еще и разделы для подписчиков.
+func (s *ProfileService) GetPublicProfile(ctx context.Context, name string, viewerID uint) (*model.PublicProfile, error) {
+	user, err := s.userRepository.FindByKey(repository.NameKey, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrUserNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	owner := viewerID == user.ID
+	page := &model.PublicProfile{User: user}
+
+	if page.Followers, page.Following, err = s.followRepository.CountUserFollows(ctx, user.ID); err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	if viewerID != 0 && !owner {
+		if page.Followed, err = s.followRepository.IsFollowingUser(ctx, viewerID, user.ID); err != nil {
+			return nil, &er.InternalError{Message: err.Error()}
+		}
+	}
+
+	profile, err := s.profileRepository.GetByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	if profile != nil {
+		if err := s.fillSections(ctx, page, profile, viewerID, owner); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// fillSections добавляет на страницу разделы профиля, которые разрешено видеть
+func (s *ProfileService) fillSections(ctx context.Context, page *model.PublicProfile, profile *model.Profile, viewerID uint, owner bool) error {
+	visible := func(section string, visibility model.Visibility) bool {
+		if owner || visibility.Visible(page.Followed) {
+			return true
+		}
+		page.Hidden = append(page.Hidden, section)
+		return false
+	}
+
+	shown := *profile
+	shown.Collections = nil
+	if !visible("bio", profile.BioVisibility) {
+		shown.Bio = ""
+	}
+	if !visible("avatar", profile.AvatarVisibility) {
+		shown.AvatarURL = ""
+		shown.AvatarKey = ""
+	}
+	page.Profile = &shown
+
+	// Владелец видит все свои коллекции
+	var levels []model.Visibility
+	if !owner {
+		levels = model.VisibleLevels(page.Followed)
+	}
+	var err error
+	if page.Playlists, err = s.profileRepository.GetCollections(ctx, profile.UserID, levels); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	if visible("favorites", profile.FavoritesVisibility) {
+		if page.Favorites, err = s.profileRepository.RecentFavorites(ctx, profile.UserID, viewerID, recentFavorites); err != nil {
+			return &er.InternalError{Message: err.Error()}
+		}
+	}
+	if visible("history", profile.HistoryVisibility) {
+		if page.Plays, err = s.profileRepository.RecentPlays(ctx, profile.UserID, viewerID, recentPlays); err != nil {
+			return &er.InternalError{Message: err.Error()}
+		}
+		if page.TopArtists, err = s.profileRepository.TopArtists(ctx, profile.UserID, viewerID, topArtists); err != nil {
+			return &er.InternalError{Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// UpdatePrivacy меняет настройки приватности и приватную сессию, указанные в запросе
+func (s *ProfileService) UpdatePrivacy(ctx context.Context, userID uint, req request.ProfilePrivacyRequest) (*model.Profile, error) {
+	profile, err := s.profileRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrProfileNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	history := req.History
+	if history == "" {
+		history = req.TopArtists
+	}
+
+	for _, setting := range []struct {
+		value string
+		field *model.Visibility
+	}{
+		{req.Bio, &profile.BioVisibility},
+		{req.Avatar, &profile.AvatarVisibility},
+		{req.Favorites, &profile.FavoritesVisibility},
+		{history, &profile.HistoryVisibility},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		visibility := model.Visibility(setting.value)
+		if !visibility.IsValid() {
+			return nil, er.ErrVisibility
+		}
+		*setting.field = visibility
+	}
+	if req.PrivateSession != nil {
+		profile.PrivateSession = *req.PrivateSession
+	}
+
+	if err := s.profileRepository.UpdatePrivacy(ctx, profile); err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return profile, nil
+}
+
+// SetCollectionVisibility задает, кому видна коллекция на публичной странице
+func (s *ProfileService) SetCollectionVisibility(ctx context.Context, userID, collectionID uint, value string) error {
+	visibility := model.Visibility(value)
+	if !visibility.IsValid() {
+		return er.ErrVisibility
+	}
+
+	found, err := s.profileRepository.SetCollectionVisibility(ctx, userID, collectionID, visibility)
+	if err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	if !found {
+		return er.ErrCollectionNotExists
+	}
+	return nil
+}
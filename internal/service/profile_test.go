@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newPublicProfileService() *ProfileService {
+	userRepo := &mocks.MockUserRepo{
+		FindByKeyFunc: func(key, data string) (*model.User, error) {
+			user := &model.User{Name: data}
+			user.ID = 7
+			return user, nil
+		},
+	}
+	profileRepo := &mocks.MockProfileRepo{
+		GetByUserIDFunc: func(ctx context.Context, userID uint) (*model.Profile, error) {
+			return &model.Profile{
+				UserID:              userID,
+				Bio:                 "bio",
+				AvatarKey:           "avatars/7",
+				BioVisibility:       model.VisibilityPublic,
+				AvatarVisibility:    model.VisibilityPrivate,
+				FavoritesVisibility: model.VisibilityPrivate,
+				HistoryVisibility:   model.VisibilityFollowers,
+			}, nil
+		},
+		GetCollectionsFunc: func(ctx context.Context, profileID uint, visible []model.Visibility) ([]model.Collection, error) {
+			var collections []model.Collection
+			for i, visibility := range []model.Visibility{model.VisibilityPublic, model.VisibilityFollowers, model.VisibilityPrivate} {
+				if len(visible) == 0 || slices.Contains(visible, visibility) {
+					collections = append(collections, model.Collection{ID: uint(i + 1), Visibility: visibility})
+				}
+			}
+			return collections, nil
+		},
+		RecentFavoritesFunc: func(ctx context.Context, profileID, viewerID uint, limit int) ([]model.Favorite, error) {
+			return []model.Favorite{{ObjectType: "song", ObjectID: 3}}, nil
+		},
+		RecentPlaysFunc: func(ctx context.Context, profileID, viewerID uint, limit int) ([]model.History, error) {
+			return []model.History{{SongID: 3}}, nil
+		},
+		TopArtistsFunc: func(ctx context.Context, profileID, viewerID uint, limit int) ([]model.TopArtist, error) {
+			return []model.TopArtist{{ArtistID: 5, Plays: 10}}, nil
+		},
+	}
+	followRepo := &mocks.MockFollowRepo{
+		CountUserFollowsFunc: func(ctx context.Context, userID uint) (int64, int64, error) {
+			return 2, 1, nil
+		},
+		// На владельца страницы подписан только пользователь 8
+		IsFollowingUserFunc: func(ctx context.Context, followerID, followeeID uint) (bool, error) {
+			return followerID == 8, nil
+		},
+	}
+	return NewProfileService(profileRepo, userRepo, followRepo)
+}
+
+func TestGetPublicProfile_Stranger(t *testing.T) {
+	service := newPublicProfileService()
+
+	page, err := service.GetPublicProfile(context.Background(), "listener", 9)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"avatar", "favorites", "history"}, page.Hidden)
+	assert.Equal(t, "bio", page.Profile.Bio)
+	assert.Empty(t, page.Profile.AvatarKey)
+	assert.Empty(t, page.Favorites)
+	assert.Empty(t, page.Plays)
+	assert.Empty(t, page.TopArtists)
+	assert.Len(t, page.Playlists, 1)
+	assert.False(t, page.Followed)
+	assert.Equal(t, int64(2), page.Followers)
+}
+
+func TestGetPublicProfile_Follower(t *testing.T) {
+	service := newPublicProfileService()
+
+	page, err := service.GetPublicProfile(context.Background(), "listener", 8)
+	assert.NoError(t, err)
+	assert.True(t, page.Followed)
+	assert.Equal(t, []string{"avatar", "favorites"}, page.Hidden)
+	assert.Len(t, page.Plays, 1)
+	assert.Len(t, page.TopArtists, 1)
+	assert.Len(t, page.Playlists, 2)
+}
+
+func TestGetPublicProfile_Owner(t *testing.T) {
+	service := newPublicProfileService()
+
+	page, err := service.GetPublicProfile(context.Background(), "listener", 7)
+	assert.NoError(t, err)
+	assert.Empty(t, page.Hidden)
+	assert.Equal(t, "avatars/7", page.Profile.AvatarKey)
+	assert.Len(t, page.Favorites, 1)
+	assert.Len(t, page.Plays, 1)
+	assert.Len(t, page.Playlists, 3)
+	assert.False(t, page.Followed)
+}
+
+func TestUpdatePrivacy(t *testing.T) {
+	var saved *model.Profile
+	profileRepo := &mocks.MockProfileRepo{
+		GetByUserIDFunc: func(ctx context.Context, userID uint) (*model.Profile, error) {
+			return &model.Profile{UserID: userID, BioVisibility: model.VisibilityPublic, FavoritesVisibility: model.VisibilityPrivate}, nil
+		},
+		UpdatePrivacyFunc: func(ctx context.Context, profile *model.Profile) error {
+			saved = profile
+			return nil
+		},
+	}
+	service := NewProfileService(profileRepo, nil, nil)
+
+	_, err := service.UpdatePrivacy(context.Background(), 7, request.ProfilePrivacyRequest{Favorites: "friends"})
+	assert.ErrorIs(t, err, er.ErrVisibility)
+	assert.Nil(t, saved)
+
+	privateSession := true
+	profile, err := service.UpdatePrivacy(context.Background(), 7, request.ProfilePrivacyRequest{
+		Favorites:      "followers",
+		PrivateSession: &privateSession,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, model.VisibilityFollowers, profile.FavoritesVisibility)
+	assert.Equal(t, model.VisibilityPublic, saved.BioVisibility)
+	assert.True(t, saved.PrivateSession)
+
+	// Прежнее имя настройки истории
+	profile, err = service.UpdatePrivacy(context.Background(), 7, request.ProfilePrivacyRequest{TopArtists: "public"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.VisibilityPublic, profile.HistoryVisibility)
+
+	profile, err = service.UpdatePrivacy(context.Background(), 7, request.ProfilePrivacyRequest{History: "followers", TopArtists: "public"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.VisibilityFollowers, profile.HistoryVisibility)
+}
+
+func TestFollowUser_Self(t *testing.T) {
+	userRepo := &mocks.MockUserRepo{
+		FindByKeyFunc: func(key, data string) (*model.User, error) {
+			user := &model.User{Name: data}
+			user.ID = 7
+			return user, nil
+		},
+	}
+	service := NewFollowService(&mocks.MockFollowRepo{}, userRepo, zap.NewNop().Sugar())
+
+	err := service.FollowUser(context.Background(), 7, "listener")
+	assert.ErrorIs(t, err, er.ErrFollowSelf)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"music-lib/pkg/event"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ReleaseService скрывает от всех, кроме редакторов, неопубликованные записи и альбомы до даты релиза,
+// и сообщает о выходе альбома событием event.EventReleased
+type ReleaseService struct {
+	albumRepo  repository.IAlbumRepository
+	visibility visibility
+	event      *event.EventBus
+	now        func() time.Time
+
+	logger *zap.SugaredLogger
+}
+
+func NewReleaseService(
+	album repository.IAlbumRepository,
+	artist repository.IArtistRepository,
+	permission repository.IPermissionRepository,
+	bus *event.EventBus,
+	logger *zap.SugaredLogger,
+) *ReleaseService {
+	return &ReleaseService{
+		albumRepo:  album,
+		visibility: visibility{albums: album, artists: artist, permissions: permission},
+		event:      bus,
+		now:        time.Now,
+		logger:     logger,
+	}
+}
+
+// CanViewAlbum сообщает, видит ли пользователь альбом. userID 0 - анонимный пользователь.
+func (s *ReleaseService) CanViewAlbum(ctx context.Context, album *model.Album, userID uint) (bool, error) {
+	return s.visibility.album(ctx, album, userID, s.now())
+}
+
+// CheckArtist возвращает ErrArtistNotExists для неопубликованного артиста
+func (s *ReleaseService) CheckArtist(artist *model.Artist, userID uint) error {
+	if !s.visibility.artist(artist, userID) {
+		return er.ErrArtistNotExists
+	}
+	return nil
+}
+
+// CheckAlbum возвращает ErrAlbumNotExists для альбома, который пользователь еще не должен видеть
+func (s *ReleaseService) CheckAlbum(ctx context.Context, album *model.Album, userID uint) error {
+	visible, err := s.CanViewAlbum(ctx, album, userID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return er.ErrAlbumNotExists
+	}
+	return nil
+}
+
+// CheckSong возвращает ErrSongNotExists для неопубликованной песни, песни из невышедшего альбома
+// и закрытой песни, которую пользователю не открыли
+func (s *ReleaseService) CheckSong(ctx context.Context, song *model.Song, userID uint) error {
+	return s.visibility.checkSong(ctx, song, userID, s.now())
+}
+
+// UnpublishedStatus проверяет статус записи, снятой с публикации. По умолчанию - черновик.
+func UnpublishedStatus(value string) (model.Status, error) {
+	if value == "" {
+		return model.StatusDraft, nil
+	}
+	status := model.Status(value)
+	if !status.IsValid() || status == model.StatusPublished {
+		return "", er.ErrStatus
+	}
+	return status, nil
+}
+
+// Run публикует вышедшие альбомы каждые interval, пока не отменен ctx
+func (s *ReleaseService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.PublishDue(ctx); err != nil {
+			s.logger.Errorw("Failed to publish releases",
+				"error", err.Error(),
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue отправляет событие о выходе каждого альбома, дата релиза которого наступила.
+// Альбом отмечается до отправки, поэтому при нескольких экземплярах событие уходит один раз.
+func (s *ReleaseService) PublishDue(ctx context.Context) (int, error) {
+	albums, err := s.albumRepo.GetDueReleases(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, album := range albums {
+		marked, err := s.albumRepo.MarkReleased(ctx, album.ID)
+		if err != nil {
+			return published, err
+		}
+		if !marked {
+			continue
+		}
+
+		s.logger.Infow("Album released",
+			"album id", album.ID,
+			"artist id", album.ArtistID,
+			"release date", album.ReleaseDate,
+		)
+		go s.event.Publish(event.Event{
+			Type: event.EventReleased,
+			Data: event.Released{
+				AlbumID:     album.ID,
+				ArtistID:    album.ArtistID,
+				Title:       album.Title,
+				ReleaseDate: album.ReleaseDate,
+			},
+		})
+		published++
+	}
+	return published, nil
+}
+
+// visibility проверяет видимость отдельной записи по тем же правилам, что и выборки репозиториев
+type visibility struct {
+	albums      repository.IAlbumRepository
+	artists     repository.IArtistRepository
+	permissions repository.IPermissionRepository
+}
+
+func (v visibility) canEdit(userID, id uint, resource model.Resource) bool {
+	return userID != 0 && v.permissions.HasPermission(userID, id, resource, model.EditPermission)
+}
+
+// Закрытую песню видят и слушают только пользователи с правом view, право edit его включает
+func (v visibility) canListen(userID, songID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	return v.permissions.HasPermission(userID, songID, model.SongResource, model.ViewPermission) ||
+		v.permissions.HasPermission(userID, songID, model.SongResource, model.EditPermission)
+}
+
+// Черновики, записи на проверке и архивные артисты видны только редакторам
+func (v visibility) artist(artist *model.Artist, userID uint) bool {
+	return artist.Status.Public() || v.canEdit(userID, artist.ID, model.ArtistResource)
+}
+
+// Альбом виден, если он открыт, вышел и его артист открыт, редакторам альбома - всегда
+func (v visibility) album(ctx context.Context, album *model.Album, userID uint, now time.Time) (bool, error) {
+	if v.canEdit(userID, album.ID, model.AlbumResource) {
+		return true, nil
+	}
+	if !album.Status.Public() || album.Embargoed(now) {
+		return false, nil
+	}
+
+	artist, err := v.artists.GetByID(ctx, album.ArtistID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, &er.InternalError{Message: err.Error()}
+	}
+	return artist.Status.Public() || v.canEdit(userID, artist.ID, model.ArtistResource), nil
+}
+
+// Неопубликованную песню и песню невышедшего альбома видят редакторы альбома и самой песни,
+// закрытую песню - только ее редакторы и слушатели, которым ее открыли
+func (v visibility) checkSong(ctx context.Context, song *model.Song, userID uint, now time.Time) error {
+	if v.canEdit(userID, song.ID, model.SongResource) {
+		return nil
+	}
+	if song.Private && !v.canListen(userID, song.ID) {
+		return er.ErrSongNotExists
+	}
+
+	album, err := v.albums.GetByID(ctx, song.AlbumID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if song.Status.Public() {
+				return nil
+			}
+			return er.ErrSongNotExists
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	if v.canEdit(userID, album.ID, model.AlbumResource) {
+		return nil
+	}
+	if !song.Status.Public() {
+		return er.ErrSongNotExists
+	}
+	visible, err := v.album(ctx, album, userID, now)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return er.ErrSongNotExists
+	}
+	return nil
+}
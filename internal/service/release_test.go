@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"music-lib/pkg/event"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newAlbumRepo(albums ...*model.Album) *mocks.MockAlbumRepo {
+	return &mocks.MockAlbumRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Album, error) {
+			for _, album := range albums {
+				if album.ID == id {
+					return album, nil
+				}
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+	}
+}
+
+// Право edit на альбом 1 есть у пользователя 2, на песню 5 - у пользователя 3
+func newEditPermissionRepo() *mocks.MockPermissionRepo {
+	return &mocks.MockPermissionRepo{
+		HasPermissionFunc: func(userID, resourceID uint, resourceType model.Resource, permission model.Permission) bool {
+			if permission != model.EditPermission {
+				return false
+			}
+			return userID == 2 && resourceType == model.AlbumResource && resourceID == 1 ||
+				userID == 3 && resourceType == model.SongResource && resourceID == 5
+		},
+	}
+}
+
+func newArtistRepo(artists ...*model.Artist) *mocks.MockArtistRepo {
+	return &mocks.MockArtistRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Artist, error) {
+			for _, artist := range artists {
+				if artist.ID == id {
+					return artist, nil
+				}
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+	}
+}
+
+func TestRelease_Embargo(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	upcoming := &model.Album{ID: 1, ReleaseDate: now.Add(time.Hour), Status: model.StatusPublished}
+	released := &model.Album{ID: 2, ReleaseDate: now.Add(-time.Hour), Status: model.StatusPublished}
+	service := NewReleaseService(newAlbumRepo(upcoming, released), newArtistRepo(), newEditPermissionRepo(), nil, zap.NewNop().Sugar())
+	service.now = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		album   *model.Album
+		userID  uint
+		visible bool
+	}{
+		{released, 0, true},
+		{upcoming, 0, false},
+		{upcoming, 3, false},
+		{upcoming, 2, true},
+	} {
+		visible, err := service.CanViewAlbum(ctx, tc.album, tc.userID)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.visible, visible, "album %d, user %d", tc.album.ID, tc.userID)
+	}
+	assert.Equal(t, er.ErrAlbumNotExists, service.CheckAlbum(ctx, upcoming, 0))
+
+	song := &model.Song{ID: 5, AlbumID: 1, Status: model.StatusPublished}
+	assert.Equal(t, er.ErrSongNotExists, service.CheckSong(ctx, song, 0))
+	assert.NoError(t, service.CheckSong(ctx, song, 2))
+	assert.NoError(t, service.CheckSong(ctx, song, 3))
+	assert.NoError(t, service.CheckSong(ctx, &model.Song{ID: 6, AlbumID: 2, Status: model.StatusPublished}, 0))
+
+	// В момент релиза альбом становится виден всем
+	service.now = func() time.Time { return upcoming.ReleaseDate }
+	assert.NoError(t, service.CheckSong(ctx, song, 0))
+}
+
+func TestRelease_Status(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	draftArtist := &model.Artist{ID: 7, Status: model.StatusDraft}
+	unlistedArtist := &model.Artist{ID: 8, Status: model.StatusUnlisted}
+	draft := &model.Album{ID: 1, ArtistID: 8, Status: model.StatusDraft, ReleaseDate: now.Add(-time.Hour)}
+	unlisted := &model.Album{ID: 2, ArtistID: 8, Status: model.StatusUnlisted, ReleaseDate: now.Add(-time.Hour)}
+	hidden := &model.Album{ID: 3, ArtistID: 7, Status: model.StatusPublished, ReleaseDate: now.Add(-time.Hour)}
+	service := NewReleaseService(newAlbumRepo(draft, unlisted, hidden), newArtistRepo(draftArtist, unlistedArtist),
+		newEditPermissionRepo(), nil, zap.NewNop().Sugar())
+	service.now = func() time.Time { return now }
+
+	assert.Equal(t, er.ErrArtistNotExists, service.CheckArtist(draftArtist, 0))
+	assert.NoError(t, service.CheckArtist(unlistedArtist, 0))
+
+	// Черновик альбома видит только его редактор
+	assert.Equal(t, er.ErrAlbumNotExists, service.CheckAlbum(ctx, draft, 0))
+	assert.NoError(t, service.CheckAlbum(ctx, draft, 2))
+	// Скрытый из списков альбом открывается по прямой ссылке
+	assert.NoError(t, service.CheckAlbum(ctx, unlisted, 0))
+	// Опубликованный альбом черновика артиста не виден
+	assert.Equal(t, er.ErrAlbumNotExists, service.CheckAlbum(ctx, hidden, 0))
+
+	archived := &model.Song{ID: 5, AlbumID: 2, Status: model.StatusArchived}
+	assert.Equal(t, er.ErrSongNotExists, service.CheckSong(ctx, archived, 0))
+	assert.NoError(t, service.CheckSong(ctx, archived, 3))
+	assert.NoError(t, service.CheckSong(ctx, &model.Song{ID: 6, AlbumID: 2, Status: model.StatusPublished}, 0))
+	// Опубликованная песня черновика альбома не видна
+	assert.Equal(t, er.ErrSongNotExists, service.CheckSong(ctx, &model.Song{ID: 6, AlbumID: 1, Status: model.StatusPublished}, 0))
+}
+
+func TestRelease_PrivateSong(t *testing.T) {
+	ctx := context.Background()
+	album := &model.Album{ID: 2, Status: model.StatusPublished}
+	// Пользователю 4 песню 6 открыли для прослушивания
+	permissions := newEditPermissionRepo()
+	canEdit := permissions.HasPermissionFunc
+	permissions.HasPermissionFunc = func(userID, resourceID uint, resourceType model.Resource, permission model.Permission) bool {
+		if permission == model.ViewPermission {
+			return userID == 4 && resourceType == model.SongResource && resourceID == 6
+		}
+		return canEdit(userID, resourceID, resourceType, permission)
+	}
+	service := NewReleaseService(newAlbumRepo(album), newArtistRepo(), permissions, nil, zap.NewNop().Sugar())
+
+	private := &model.Song{ID: 6, AlbumID: 2, Status: model.StatusPublished, Private: true}
+	assert.Equal(t, er.ErrSongNotExists, service.CheckSong(ctx, private, 0))
+	assert.Equal(t, er.ErrSongNotExists, service.CheckSong(ctx, private, 2))
+	assert.NoError(t, service.CheckSong(ctx, private, 4))
+
+	// Редактор видит свою закрытую песню без отдельного права view
+	assert.NoError(t, service.CheckSong(ctx, &model.Song{ID: 5, AlbumID: 2, Status: model.StatusPublished, Private: true}, 3))
+}
+
+func TestRelease_PublishDue(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	due := []model.Album{
+		{ID: 1, ArtistID: 7, Title: "First", ReleaseDate: now.Add(-time.Minute), ReleasePending: true},
+		{ID: 2, ArtistID: 7, Title: "Second", ReleaseDate: now, ReleasePending: true},
+	}
+	var since time.Time
+	albumRepo := &mocks.MockAlbumRepo{
+		GetDueReleasesFunc: func(ctx context.Context, now time.Time) ([]model.Album, error) {
+			since = now
+			return due, nil
+		},
+		// Второй альбом уже отметил другой экземпляр приложения
+		MarkReleasedFunc: func(ctx context.Context, id uint) (bool, error) {
+			return id == 1, nil
+		},
+	}
+	bus := event.NewEventBus()
+	service := NewReleaseService(albumRepo, nil, nil, bus, zap.NewNop().Sugar())
+	service.now = func() time.Time { return now }
+
+	published, err := service.PublishDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, now, since)
+
+	select {
+	case got := <-bus.Subscribe():
+		assert.Equal(t, event.EventReleased, got.Type)
+		assert.Equal(t, event.Released{AlbumID: 1, ArtistID: 7, Title: "First", ReleaseDate: due[0].ReleaseDate}, got.Data)
+	case <-time.After(time.Second):
+		t.Fatal("released event was not published")
+	}
+}
+
+func TestUnpublishedStatus(t *testing.T) {
+	for value, want := range map[string]model.Status{
+		"":          model.StatusDraft,
+		"in_review": model.StatusInReview,
+		"unlisted":  model.StatusUnlisted,
+		"archived":  model.StatusArchived,
+	} {
+		status, err := UnpublishedStatus(value)
+		assert.NoError(t, err)
+		assert.Equal(t, want, status)
+	}
+
+	for _, value := range []string{"published", "deleted"} {
+		_, err := UnpublishedStatus(value)
+		assert.Equal(t, er.ErrStatus, err)
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/infrastructure/email"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"music-lib/pkg/event"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// Сколько жалоб модератор может закрыть одним запросом
+const maxReportBatch = 100
+
+// ReportService принимает жалобы пользователей и ведет очередь модерации.
+// Снятые записи скрываются от всех, кроме редакторов, а владельцы получают письмо.
+type ReportService struct {
+	reportRepo repository.IReportRepository
+	audit      auditLog
+	event      *event.EventBus
+
+	logger *zap.SugaredLogger
+}
+
+func NewReportService(
+	report repository.IReportRepository,
+	audit repository.IAuditRepository,
+	bus *event.EventBus,
+	logger *zap.SugaredLogger,
+) *ReportService {
+	return &ReportService{
+		reportRepo: report,
+		audit:      newAuditLog(audit, logger),
+		event:      bus,
+		logger:     logger,
+	}
+}
+
+// Report сохраняет жалобу. Пока жалоба открыта, вторую на ту же запись пользователь подать не может.
+func (s *ReportService) Report(ctx context.Context, reporterID uint, req request.ReportRequest) (*model.Report, error) {
+	resource := model.Resource(req.Type)
+	if !reportable(resource) {
+		return nil, er.ErrReportEntity
+	}
+	reason := model.ReportReason(req.Reason)
+	if !reason.IsValid() {
+		return nil, er.ErrReportReason
+	}
+
+	exists, err := s.reportRepo.Exists(ctx, resource, req.ID)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	if !exists {
+		return nil, er.ErrReportTarget
+	}
+
+	report := &model.Report{
+		ReporterID:   reporterID,
+		ResourceType: resource,
+		ResourceID:   req.ID,
+		Reason:       reason,
+		Details:      strings.TrimSpace(req.Details),
+		State:        model.ReportOpen,
+	}
+	if err := s.reportRepo.Create(ctx, report); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, er.ErrReportExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Infow("Report filed",
+		"report id", report.ID,
+		"resource", resource,
+		"id", req.ID,
+		"reason", reason,
+	)
+	return report, nil
+}
+
+// GetReports возвращает очередь модерации, начиная со старых жалоб
+func (s *ReportService) GetReports(ctx context.Context, filter model.ReportFilter, limit, offset int) ([]model.Report, int64, error) {
+	switch filter.State {
+	case "", model.ReportOpen, model.ReportActioned, model.ReportDismissed:
+	default:
+		return nil, 0, er.ErrReportState
+	}
+	if filter.ResourceType != "" && !reportable(filter.ResourceType) {
+		return nil, 0, er.ErrReportEntity
+	}
+
+	reports, total, err := s.reportRepo.Find(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return reports, total, nil
+}
+
+// Resolve закрывает открытые жалобы из запроса. С TakeDown записи, на которые пожаловались,
+// скрываются, а их владельцы получают письмо. Уже закрытые жалобы не меняются.
+func (s *ReportService) Resolve(ctx context.Context, req request.ResolveReportsRequest, moderatorID uint) ([]model.Report, error) {
+	if len(req.IDs) == 0 || len(req.IDs) > maxReportBatch {
+		return nil, er.ErrReportIDs
+	}
+	state := model.ReportState(req.State)
+	if state != model.ReportActioned && state != model.ReportDismissed {
+		return nil, er.ErrReportResolution
+	}
+	if req.TakeDown && state != model.ReportActioned {
+		return nil, er.ErrReportResolution
+	}
+
+	reports, err := s.reportRepo.GetByIDs(ctx, req.IDs)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	var open []model.Report
+	for _, report := range reports {
+		if report.State != model.ReportOpen {
+			continue
+		}
+		if req.TakeDown && report.ResourceType == model.GenreResource {
+			return nil, er.ErrTakedownResource
+		}
+		open = append(open, report)
+	}
+	if len(open) == 0 {
+		return reports, nil
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if req.TakeDown {
+		if err := s.takeDown(ctx, open, note, moderatorID); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]uint, 0, len(open))
+	for _, report := range open {
+		ids = append(ids, report.ID)
+	}
+	if err := s.reportRepo.Resolve(ctx, ids, state, moderatorID, note, req.TakeDown); err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Infow("Reports resolved",
+		"ids", ids,
+		"state", state,
+		"taken down", req.TakeDown,
+		"moderator id", moderatorID,
+	)
+
+	if reports, err = s.reportRepo.GetByIDs(ctx, req.IDs); err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return reports, nil
+}
+
+// takeDown скрывает каждую запись один раз, сколько бы жалоб на нее ни пришло
+func (s *ReportService) takeDown(ctx context.Context, reports []model.Report, note string, moderatorID uint) error {
+	type target struct {
+		resource model.Resource
+		id       uint
+	}
+	done := make(map[target]bool)
+
+	for _, report := range reports {
+		t := target{report.ResourceType, report.ResourceID}
+		if done[t] {
+			continue
+		}
+		done[t] = true
+
+		if err := s.reportRepo.TakeDown(ctx, t.resource, t.id, moderatorID); err != nil {
+			s.logger.Errorw("Failed to take down entry",
+				"resource", t.resource,
+				"id", t.id,
+				"error", err.Error(),
+			)
+			return &er.InternalError{Message: err.Error()}
+		}
+
+		switch t.resource {
+		case model.ArtistResource, model.AlbumResource, model.SongResource:
+			s.audit.record(ctx, moderatorID, t.resource, t.id, model.AuditTakedown, nil, nil)
+		}
+		s.notifyOwners(ctx, report, note)
+	}
+	return nil
+}
+
+// notifyOwners отправляет владельцам снятой записи письмо через шину событий
+func (s *ReportService) notifyOwners(ctx context.Context, report model.Report, note string) {
+	if s.event == nil {
+		return
+	}
+
+	owners, err := s.reportRepo.GetOwners(ctx, report.ResourceType, report.ResourceID)
+	if err != nil {
+		s.logger.Errorw("Failed to find owners of taken down entry",
+			"resource", report.ResourceType,
+			"id", report.ResourceID,
+			"error", err.Error(),
+		)
+		return
+	}
+
+	text := fmt.Sprintf("Ваша запись (%s #%d) скрыта модератором по жалобе: %s.", report.ResourceType, report.ResourceID, report.Reason)
+	if note != "" {
+		text += " Комментарий модератора: " + note
+	}
+	for _, owner := range owners {
+		if owner.Email == "" {
+			continue
+		}
+		go s.event.Publish(event.Event{
+			Type: event.EventSendEmail,
+			Data: email.Addressee{
+				To:      owner.Email,
+				Subject: "Запись скрыта модератором",
+				Text:    text,
+			},
+		})
+	}
+}
+
+func reportable(resource model.Resource) bool {
+	switch resource {
+	case model.ArtistResource, model.AlbumResource, model.SongResource, model.GenreResource,
+		model.ReviewResource, model.CommentResource:
+		return true
+	}
+	return false
+}
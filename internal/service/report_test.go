@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/dto/request"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestReport_Validation(t *testing.T) {
+	var created []model.Report
+	reportRepo := &mocks.MockReportRepo{
+		ExistsFunc: func(ctx context.Context, resource model.Resource, id uint) (bool, error) {
+			return id == 7, nil
+		},
+		CreateFunc: func(ctx context.Context, report *model.Report) error {
+			for _, r := range created {
+				if r.ReporterID == report.ReporterID && r.ResourceID == report.ResourceID {
+					return &pgconn.PgError{Code: "23505"}
+				}
+			}
+			report.ID = uint(len(created) + 1)
+			created = append(created, *report)
+			return nil
+		},
+	}
+	service := NewReportService(reportRepo, nil, nil, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	_, err := service.Report(ctx, 2, request.ReportRequest{Type: "playlist", ID: 7, Reason: "spam"})
+	assert.Equal(t, er.ErrReportEntity, err)
+	_, err = service.Report(ctx, 2, request.ReportRequest{Type: "album", ID: 7, Reason: "boring"})
+	assert.Equal(t, er.ErrReportReason, err)
+	_, err = service.Report(ctx, 2, request.ReportRequest{Type: "album", ID: 8, Reason: "spam"})
+	assert.Equal(t, er.ErrReportTarget, err)
+
+	report, err := service.Report(ctx, 2, request.ReportRequest{Type: "album", ID: 7, Reason: "spam", Details: " ads "})
+	assert.NoError(t, err)
+	assert.Equal(t, model.ReportOpen, report.State)
+	assert.Equal(t, "ads", report.Details)
+
+	_, err = service.Report(ctx, 2, request.ReportRequest{Type: "album", ID: 7, Reason: "abuse"})
+	assert.Equal(t, er.ErrReportExists, err)
+}
+
+func TestResolveReports(t *testing.T) {
+	reports := map[uint]*model.Report{
+		1: {ID: 1, ResourceType: model.SongResource, ResourceID: 7, Reason: model.ReasonCopyright, State: model.ReportOpen},
+		2: {ID: 2, ResourceType: model.SongResource, ResourceID: 7, Reason: model.ReasonSpam, State: model.ReportOpen},
+		3: {ID: 3, ResourceType: model.CommentResource, ResourceID: 4, Reason: model.ReasonAbuse, State: model.ReportDismissed},
+		4: {ID: 4, ResourceType: model.GenreResource, ResourceID: 1, Reason: model.ReasonMisleading, State: model.ReportOpen},
+	}
+	var takenDown []uint
+	var resolved []uint
+	reportRepo := &mocks.MockReportRepo{
+		GetByIDsFunc: func(ctx context.Context, ids []uint) ([]model.Report, error) {
+			var found []model.Report
+			for _, id := range ids {
+				if report, ok := reports[id]; ok {
+					found = append(found, *report)
+				}
+			}
+			return found, nil
+		},
+		TakeDownFunc: func(ctx context.Context, resource model.Resource, id, moderatorID uint) error {
+			takenDown = append(takenDown, id)
+			return nil
+		},
+		ResolveFunc: func(ctx context.Context, ids []uint, state model.ReportState, moderatorID uint, note string, takenDown bool) error {
+			resolved = append(resolved, ids...)
+			for _, id := range ids {
+				reports[id].State = state
+				reports[id].TakenDown = takenDown
+			}
+			return nil
+		},
+	}
+	service := NewReportService(reportRepo, nil, nil, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	_, err := service.Resolve(ctx, request.ResolveReportsRequest{State: "actioned"}, 1)
+	assert.Equal(t, er.ErrReportIDs, err)
+	_, err = service.Resolve(ctx, request.ResolveReportsRequest{IDs: []uint{1}, State: "open"}, 1)
+	assert.Equal(t, er.ErrReportResolution, err)
+	_, err = service.Resolve(ctx, request.ResolveReportsRequest{IDs: []uint{1}, State: "dismissed", TakeDown: true}, 1)
+	assert.Equal(t, er.ErrReportResolution, err)
+	_, err = service.Resolve(ctx, request.ResolveReportsRequest{IDs: []uint{1, 4}, State: "actioned", TakeDown: true}, 1)
+	assert.Equal(t, er.ErrTakedownResource, err)
+	assert.Empty(t, takenDown)
+
+	// Две жалобы на одну песню скрывают ее один раз, закрытая жалоба не меняется
+	result, err := service.Resolve(ctx, request.ResolveReportsRequest{IDs: []uint{1, 2, 3}, State: "actioned", TakeDown: true}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{7}, takenDown)
+	assert.Equal(t, []uint{1, 2}, resolved)
+	assert.Len(t, result, 3)
+	assert.Equal(t, model.ReportActioned, result[0].State)
+	assert.True(t, result[1].TakenDown)
+	assert.Equal(t, model.ReportDismissed, result[2].State)
+}
+
+func TestSetStatus_TakenDown(t *testing.T) {
+	songRepo := &mocks.MockSongRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Song, error) {
+			return &model.Song{ID: id, Status: model.StatusTakenDown}, nil
+		},
+	}
+	service := NewSongService(songRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop().Sugar())
+
+	_, err := service.SetStatus(context.Background(), 7, model.StatusPublished, 2)
+	assert.Equal(t, er.ErrTakenDown, err)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Наибольшая длина рецензии в символах
+const maxReviewLength = 5000
+
+// ReviewService ведет оценки и рецензии песен и альбомов.
+// Видимость самой записи проверяет вызывающий.
+type ReviewService struct {
+	reviewRepo repository.IReviewRepository
+	userRepo   repository.IUserRepository
+
+	logger *zap.SugaredLogger
+}
+
+func NewReviewService(review repository.IReviewRepository, user repository.IUserRepository, logger *zap.SugaredLogger) *ReviewService {
+	return &ReviewService{
+		reviewRepo: review,
+		userRepo:   user,
+		logger:     logger,
+	}
+}
+
+// Rate ставит записи оценку от 1 до 5 звезд или меняет прежнюю
+func (s *ReviewService) Rate(ctx context.Context, resource model.Resource, id, userID uint, stars int) error {
+	if stars < 1 || stars > 5 {
+		return er.ErrRatingStars
+	}
+
+	if err := s.reviewRepo.SetRating(ctx, &model.Rating{
+		UserID:       userID,
+		ResourceType: resource,
+		ResourceID:   id,
+		Stars:        stars,
+	}); err != nil {
+		s.logger.Errorw("Failed to save rating",
+			"resource", resource,
+			"id", id,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// Unrate отзывает оценку пользователя
+func (s *ReviewService) Unrate(ctx context.Context, resource model.Resource, id, userID uint) error {
+	if err := s.reviewRepo.DeleteRating(ctx, resource, id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return er.ErrRatingNotExists
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// GetSummary возвращает среднюю оценку записи, распределение оценок и оценку viewerID
+func (s *ReviewService) GetSummary(ctx context.Context, resource model.Resource, id, viewerID uint) (*model.RatingSummary, error) {
+	summary, err := s.reviewRepo.GetSummary(ctx, resource, id, viewerID)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return summary, nil
+}
+
+// AddReview сохраняет рецензию пользователя, на одну запись пишется одна рецензия
+func (s *ReviewService) AddReview(ctx context.Context, resource model.Resource, id, userID uint, text string) (*model.Review, error) {
+	text, err := reviewText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &model.Review{
+		UserID:       userID,
+		ResourceType: resource,
+		ResourceID:   id,
+		Text:         text,
+	}
+	if err := s.reviewRepo.CreateReview(ctx, review); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, er.ErrReviewExists
+		}
+		s.logger.Errorw("Failed to create review",
+			"resource", resource,
+			"id", id,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Review created",
+		"review id", review.ID,
+		"resource", resource,
+		"id", id,
+		"user id", userID,
+	)
+	return s.GetReview(ctx, review.ID)
+}
+
+// UpdateReview меняет текст рецензии, доступно только автору
+func (s *ReviewService) UpdateReview(ctx context.Context, id, userID uint, text string) (*model.Review, error) {
+	review, err := s.ownReview(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Text, err = reviewText(text); err != nil {
+		return nil, err
+	}
+
+	if err := s.reviewRepo.UpdateReview(ctx, review); err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return s.GetReview(ctx, id)
+}
+
+// DeleteReview удаляет рецензию, доступно только автору
+func (s *ReviewService) DeleteReview(ctx context.Context, id, userID uint) error {
+	if _, err := s.ownReview(ctx, id, userID); err != nil {
+		return err
+	}
+
+	if err := s.reviewRepo.DeleteReview(ctx, id); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// HideReview скрывает рецензию от всех, кроме автора, или возвращает ее. Точка входа для модерации.
+func (s *ReviewService) HideReview(ctx context.Context, id, moderatorID uint, hidden bool) error {
+	if _, err := s.GetReview(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.reviewRepo.SetHidden(ctx, id, moderatorID, hidden); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Infow("Review moderated",
+		"review id", id,
+		"moderator id", moderatorID,
+		"hidden", hidden,
+	)
+	return nil
+}
+
+func (s *ReviewService) GetReview(ctx context.Context, id uint) (*model.Review, error) {
+	review, err := s.reviewRepo.GetReview(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrReviewNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return review, nil
+}
+
+// GetReviews возвращает рецензии записи, начиная с новых
+func (s *ReviewService) GetReviews(ctx context.Context, resource model.Resource, id, viewerID uint, limit, offset int) ([]model.Review, int64, error) {
+	reviews, total, err := s.reviewRepo.GetReviews(ctx, resource, id, viewerID, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return reviews, total, nil
+}
+
+// GetUserReviews возвращает рецензии пользователя с именем name на записи, которые видит viewerID
+func (s *ReviewService) GetUserReviews(ctx context.Context, name string, viewerID uint, limit, offset int) ([]model.Review, int64, error) {
+	user, err := s.userRepo.FindByKey(repository.NameKey, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, er.ErrUserNotExists
+		}
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+
+	reviews, total, err := s.reviewRepo.GetUserReviews(ctx, user.ID, viewerID, limit, offset)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return reviews, total, nil
+}
+
+func (s *ReviewService) ownReview(ctx context.Context, id, userID uint) (*model.Review, error) {
+	review, err := s.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, er.ErrReviewOwner
+	}
+	return review, nil
+}
+
+func reviewText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxReviewLength {
+		return "", er.ErrReviewText
+	}
+	return text, nil
+}
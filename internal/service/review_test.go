@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newReviewRepo() *mocks.MockReviewRepo {
+	reviews := map[uint]*model.Review{
+		1: {ID: 1, UserID: 2, ResourceType: model.AlbumResource, ResourceID: 5, Text: "Great"},
+	}
+	return &mocks.MockReviewRepo{
+		CreateReviewFunc: func(ctx context.Context, review *model.Review) error {
+			for _, existing := range reviews {
+				if existing.UserID == review.UserID && existing.ResourceType == review.ResourceType && existing.ResourceID == review.ResourceID {
+					return &pgconn.PgError{Code: "23505"}
+				}
+			}
+			review.ID = uint(len(reviews) + 1)
+			reviews[review.ID] = review
+			return nil
+		},
+		UpdateReviewFunc: func(ctx context.Context, review *model.Review) error {
+			reviews[review.ID] = review
+			return nil
+		},
+		DeleteReviewFunc: func(ctx context.Context, id uint) error {
+			delete(reviews, id)
+			return nil
+		},
+		GetReviewFunc: func(ctx context.Context, id uint) (*model.Review, error) {
+			review, ok := reviews[id]
+			if !ok {
+				return nil, gorm.ErrRecordNotFound
+			}
+			copied := *review
+			return &copied, nil
+		},
+	}
+}
+
+func TestRate(t *testing.T) {
+	var saved []int
+	reviewRepo := &mocks.MockReviewRepo{
+		SetRatingFunc: func(ctx context.Context, rating *model.Rating) error {
+			saved = append(saved, rating.Stars)
+			return nil
+		},
+		DeleteRatingFunc: func(ctx context.Context, resource model.Resource, resourceID, userID uint) error {
+			return gorm.ErrRecordNotFound
+		},
+	}
+	service := NewReviewService(reviewRepo, nil, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	assert.Equal(t, er.ErrRatingStars, service.Rate(ctx, model.SongResource, 1, 2, 0))
+	assert.Equal(t, er.ErrRatingStars, service.Rate(ctx, model.SongResource, 1, 2, 6))
+	assert.NoError(t, service.Rate(ctx, model.SongResource, 1, 2, 4))
+	assert.Equal(t, []int{4}, saved)
+
+	assert.Equal(t, er.ErrRatingNotExists, service.Unrate(ctx, model.SongResource, 1, 2))
+}
+
+func TestRatingSummaryAdd(t *testing.T) {
+	var summary model.RatingSummary
+	summary.Add(5, 3)
+	summary.Add(2, 1)
+	summary.Add(7, 1)
+
+	assert.Equal(t, int64(4), summary.Count)
+	assert.InDelta(t, 4.25, summary.Average, 1e-9)
+	assert.Equal(t, [5]int64{0, 1, 0, 0, 3}, summary.Histogram)
+}
+
+func TestAddReview(t *testing.T) {
+	service := NewReviewService(newReviewRepo(), nil, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	_, err := service.AddReview(ctx, model.AlbumResource, 5, 3, "   ")
+	assert.Equal(t, er.ErrReviewText, err)
+	_, err = service.AddReview(ctx, model.AlbumResource, 5, 3, strings.Repeat("a", maxReviewLength+1))
+	assert.Equal(t, er.ErrReviewText, err)
+	_, err = service.AddReview(ctx, model.AlbumResource, 5, 2, "Again")
+	assert.Equal(t, er.ErrReviewExists, err)
+
+	review, err := service.AddReview(ctx, model.AlbumResource, 5, 3, " Solid record ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Solid record", review.Text)
+}
+
+func TestReviewOwner(t *testing.T) {
+	service := NewReviewService(newReviewRepo(), nil, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	_, err := service.UpdateReview(ctx, 1, 3, "Mine now")
+	assert.Equal(t, er.ErrReviewOwner, err)
+	assert.Equal(t, er.ErrReviewOwner, service.DeleteReview(ctx, 1, 3))
+	_, err = service.UpdateReview(ctx, 9, 2, "Missing")
+	assert.Equal(t, er.ErrReviewNotExists, err)
+
+	review, err := service.UpdateReview(ctx, 1, 2, "Great, on second listen")
+	assert.NoError(t, err)
+	assert.Equal(t, "Great, on second listen", review.Text)
+
+	assert.NoError(t, service.DeleteReview(ctx, 1, 2))
+	_, err = service.GetReview(ctx, 1)
+	assert.Equal(t, er.ErrReviewNotExists, err)
+}
+
+func TestGetUserReviews(t *testing.T) {
+	userRepo := &mocks.MockUserRepo{
+		FindByKeyFunc: func(key, data string) (*model.User, error) {
+			if key == repository.NameKey && data == "alice" {
+				user := &model.User{Name: "alice"}
+				user.ID = 2
+				return user, nil
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+	}
+	var requested uint
+	reviewRepo := &mocks.MockReviewRepo{
+		GetUserReviewsFunc: func(ctx context.Context, userID, viewerID uint, limit, offset int) ([]model.Review, int64, error) {
+			requested = userID
+			return []model.Review{{ID: 1, UserID: userID}}, 1, nil
+		},
+	}
+	service := NewReviewService(reviewRepo, userRepo, zap.NewNop().Sugar())
+
+	_, _, err := service.GetUserReviews(context.Background(), "bob", 0, 10, 0)
+	assert.Equal(t, er.ErrUserNotExists, err)
+
+	reviews, total, err := service.GetUserReviews(context.Background(), "alice", 0, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, uint(2), requested)
+}
@@ -34,6 +34,8 @@ func (s *SearchService) Search(
 	c *gin.Context, 
 	types []string,
 	query string,
+	tags []string,
+	viewerID uint,
 	limit int,
 	offset int,
 ) any {
@@ -53,11 +55,11 @@ func (s *SearchService) Search(
 
 			switch t {
 			case "artist":
-				data, total, err = s.artistRepo.Search(c, query, limit, offset)
+				data, total, err = s.artistRepo.Search(c, query, tags, viewerID, limit, offset)
 			case "album":
-				data, total, err = s.albumRepo.Search(c, query, limit, offset)
+				data, total, err = s.albumRepo.Search(c, query, tags, viewerID, limit, offset)
 			case "song":
-				data, total, err = s.songRepo.Search(c, query, limit, offset)
+				data, total, err = s.songRepo.Search(c, query, tags, viewerID, limit, offset)
 			}
 
 			if err != nil {
@@ -93,8 +95,10 @@ func convertToDTO(t string, data any) any {
 			dtos = append(dtos, response.ArtistDTO{
 				ID:            artist.ID,
 				Name:          artist.Name,
+				Status:        string(artist.Status),
 				Description:   artist.Description,
 				FormationYear: artist.FormationYear,
+				Followers:     artist.Followers,
 			})
 		}
         return dtos
@@ -111,8 +115,12 @@ func convertToDTO(t string, data any) any {
 			dtos = append(dtos, response.AlbumDTO{
 				ID: album.ID,
 				Title: album.Title,
+				Status: string(album.Status),
 				ReleaseDate: album.ReleaseDate,
 				CoverArtURL: album.CoverArtURL,
+				CoverArt: response.NewImageDTO(album.CoverArtKey),
+				Type: string(album.Type),
+				UPC: album.UPC,
 			})
 		}
 		return dtos
@@ -129,9 +137,12 @@ func convertToDTO(t string, data any) any {
 			dtos = append(dtos, response.SongDTO{
 				ID: song.ID,
 				Title: song.Title,
+				Status: string(song.Status),
+				ISRC: song.ISRC,
 				AlbumID: song.AlbumID,
 				Duration: song.Duration,
-				FilePath: song.FilePath,
+				StreamURL: response.SongStreamURL(song.ID, song.FilePath),
+				Private: song.Private,
 			})
 		}
 		return dtos
@@ -18,7 +18,7 @@ import (
 func TestSearchService_Search_Success(t *testing.T) {
 	// Создаем мок-репозитории
 	mockArtistRepo := &mocks.MockArtistRepo{
-		SearchFunc: func(ctx context.Context, query string, limit, offset int) ([]model.Artist, int64, error) {
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Artist, int64, error) {
 			return []model.Artist{
 				{ID: 1, Name: "Artist 1"},
 				{ID: 2, Name: "Artist 2"},
@@ -26,14 +26,14 @@ func TestSearchService_Search_Success(t *testing.T) {
 		},
 	}
 	mockAlbumRepo := &mocks.MockAlbumRepo{
-		SearchFunc: func(ctx context.Context, query string, limit, offset int) ([]model.Album, int64, error) {
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Album, int64, error) {
 			return []model.Album{
 				{ID: 1, Title: "Album 1"},
 			}, 1, nil
 		},
 	}
 	mockSongRepo := &mocks.MockSongRepo{
-		SearchFunc: func(ctx context.Context, query string, limit, offset int) ([]model.Song, int64, error) {
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
 			return []model.Song{
 				{ID: 1, Title: "Song 1"},
 			}, 1, nil
@@ -48,7 +48,7 @@ func TestSearchService_Search_Success(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 
 	// Выполняем поиск
-	result := service.Search(ctx, []string{"artist", "album", "song"}, "test", 10, 0)
+	result := service.Search(ctx, []string{"artist", "album", "song"}, "test", nil, 0, 10, 0)
 
 	// Проверяем результат
 	searchResult, ok := result.(response.SearchResult)
@@ -96,7 +96,7 @@ func TestSearchService_Search_EmptyTypes(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 
 	// Выполняем поиск с пустым списком типов
-	result := service.Search(ctx, []string{}, "test", 10, 0)
+	result := service.Search(ctx, []string{}, "test", nil, 0, 10, 0)
 
 	// Проверяем результат
 	searchResult, ok := result.(response.SearchResult)
@@ -108,7 +108,7 @@ func TestSearchService_Search_EmptyTypes(t *testing.T) {
 func TestSearchService_Search_UnknownType(t *testing.T) {
 	// Создаем мок-репозиторий для artist
 	mockArtistRepo := &mocks.MockArtistRepo{
-		SearchFunc: func(ctx context.Context, query string, limit, offset int) ([]model.Artist, int64, error) {
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Artist, int64, error) {
 			return []model.Artist{{ID: 1, Name: "Artist 1"}}, 1, nil
 		},
 	}
@@ -119,7 +119,7 @@ func TestSearchService_Search_UnknownType(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 
 	// Выполняем поиск с известным и неизвестным типом
-	result := service.Search(ctx, []string{"artist", "unknown"}, "test", 10, 0)
+	result := service.Search(ctx, []string{"artist", "unknown"}, "test", nil, 0, 10, 0)
 
 	// Проверяем результат
 	searchResult, ok := result.(response.SearchResult)
@@ -145,17 +145,17 @@ func TestSearchService_Search_UnknownType(t *testing.T) {
 func TestSearchService_Search_RepoError(t *testing.T) {
 	// Создаем мок-репозитории с ошибкой для artist
 	mockArtistRepo := &mocks.MockArtistRepo{
-		SearchFunc: func(ctx context.Context, query string, limit, offset int) ([]model.Artist, int64, error) {
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Artist, int64, error) {
 			return nil, 0, errors.New("search error")
 		},
 	}
 	mockAlbumRepo := &mocks.MockAlbumRepo{
-		SearchFunc: func(ctx context.Context, query string, limit, offset int) ([]model.Album, int64, error) {
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Album, int64, error) {
 			return []model.Album{{ID: 1, Title: "Album 1"}}, 1, nil
 		},
 	}
 	mockSongRepo := &mocks.MockSongRepo{
-		SearchFunc: func(ctx context.Context, query string, limit, offset int) ([]model.Song, int64, error) {
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
 			return []model.Song{{ID: 1, Title: "Song 1"}}, 1, nil
 		},
 	}
@@ -167,7 +167,7 @@ func TestSearchService_Search_RepoError(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 
 	// Выполняем поиск
-	result := service.Search(ctx, []string{"artist", "album", "song"}, "test", 10, 0)
+	result := service.Search(ctx, []string{"artist", "album", "song"}, "test", nil, 0, 10, 0)
 
 	// Проверяем результат
 	searchResult, ok := result.(response.SearchResult)
@@ -200,4 +200,24 @@ func TestConvertToDTO(t *testing.T) {
 	errorResp, ok = dto.(response.SearchErrorResponse)
 	assert.True(t, ok, "Данные должны быть SearchErrorResponse")
 	assert.Equal(t, "unknown search type", errorResp.Error.Error())
-}
\ No newline at end of file
+}
+
+// TestSearchService_Search_Tags проверяет, что фильтр по меткам доходит до репозитория
+func TestSearchService_Search_Tags(t *testing.T) {
+	var got []string
+	mockSongRepo := &mocks.MockSongRepo{
+		SearchFunc: func(ctx context.Context, query string, tags []string, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
+			got = tags
+			return []model.Song{{ID: 1, Title: "Song 1"}}, 1, nil
+		},
+	}
+	service := NewSearchService(mockSongRepo, nil, nil)
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	result := service.Search(ctx, []string{"song"}, "", []string{"chill", "lo fi"}, 0, 10, 0)
+
+	searchResult, ok := result.(response.SearchResult)
+	assert.True(t, ok)
+	assert.Contains(t, searchResult, "song")
+	assert.Equal(t, []string{"chill", "lo fi"}, got)
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
 	"music-lib/internal/repository"
 	"music-lib/pkg/event"
 
@@ -10,6 +12,11 @@ import (
 type Deps struct {
 	Event        *event.EventBus
 	Repositories *repository.Repositories
+	Storage      storage.Storage
+	Upload       config.UploadConfig
+	Stream       config.StreamConfig
+	Trash        config.TrashConfig
+	Comment      config.CommentConfig
 	Logger       *zap.SugaredLogger
 }
 
@@ -18,27 +25,109 @@ type Services struct {
 	Album      *AlbumService
 	Artist     *ArtistService
 	Song       *SongService
+	Lyrics     *LyricsService
+	Upload     *UploadService
+	Stream     *StreamService
+	Waveform   *WaveformService
+	Image      *ImageService
 	Genre      *GenreService
 	Search     *SearchService
 	Profile    *ProfileService
 	Permission *PermissionService
+	Release    *ReleaseService
+	Trash      *TrashService
+	Audit      *AuditService
+	Merge      *MergeService
+	Tag        *TagService
+	Review     *ReviewService
+	Comment    *CommentService
+	Report     *ReportService
+	Follow     *FollowService
 }
 
 func NewServices(deps *Deps) *Services {
 	return &Services{
 		Auth:   NewAuthService(deps.Repositories.User, deps.Event),
-		Album:  NewAlbumService(deps.Repositories.Album, deps.Repositories.Artist),
-		Artist: NewArtistService(deps.Repositories.Artist, deps.Logger),
+		Album:  NewAlbumService(deps.Repositories.Album, deps.Repositories.Artist, deps.Repositories.Audit, deps.Logger),
+		Artist: NewArtistService(deps.Repositories.Artist, deps.Repositories.Audit, deps.Logger),
 		Song: NewSongService(deps.Repositories.Song,
 			deps.Repositories.Album,
 			deps.Repositories.SongGenre,
 			deps.Repositories.Genre,
 			deps.Repositories.Lyrics,
+			deps.Repositories.Upload,
+			deps.Repositories.Artist,
+			deps.Repositories.SongCredit,
+			deps.Repositories.Audit,
+			deps.Event,
 			deps.Logger,
 		),
-		Genre:   NewGenreService(deps.Repositories.Genre, deps.Logger),
+		Lyrics:  NewLyricsService(deps.Repositories.Lyrics, deps.Repositories.Song, deps.Repositories.Audit, deps.Logger),
+		Upload: NewUploadService(deps.Repositories.Upload,
+			deps.Repositories.Song,
+			deps.Storage,
+			deps.Upload,
+			deps.Logger,
+		),
+		Stream: NewStreamService(deps.Repositories.Song,
+			deps.Repositories.Album,
+			deps.Repositories.Artist,
+			deps.Repositories.Permission,
+			deps.Repositories.Profile,
+			deps.Storage,
+			deps.Stream,
+			deps.Logger,
+		),
+		Waveform: NewWaveformService(deps.Storage, deps.Logger),
+		Image: NewImageService(deps.Repositories.Album,
+			deps.Repositories.Profile,
+			deps.Repositories.Audit,
+			deps.Storage,
+			deps.Upload,
+			deps.Logger,
+		),
+		Genre:   NewGenreService(deps.Repositories.Genre, deps.Repositories.Audit, deps.Logger),
 		Search:  NewSearchService(deps.Repositories.Song, deps.Repositories.Album, deps.Repositories.Artist),
-		Profile: NewProfileService(deps.Repositories.Profile),
-		Permission: NewPermissionService(deps.Repositories.Permission, deps.Logger),
+		Profile: NewProfileService(deps.Repositories.Profile,
+			deps.Repositories.User,
+			deps.Repositories.Follow,
+		),
+		Permission: NewPermissionService(deps.Repositories.Permission, deps.Repositories.User, deps.Repositories.Audit, deps.Logger),
+		Release: NewReleaseService(deps.Repositories.Album,
+			deps.Repositories.Artist,
+			deps.Repositories.Permission,
+			deps.Event,
+			deps.Logger,
+		),
+		Trash: NewTrashService(deps.Repositories.Trash,
+			deps.Repositories.Album,
+			deps.Repositories.Artist,
+			deps.Repositories.Genre,
+			deps.Repositories.Audit,
+			deps.Storage,
+			deps.Trash,
+			deps.Logger,
+		),
+		Audit: NewAuditService(deps.Repositories.Audit),
+		Merge: NewMergeService(deps.Repositories.Merge,
+			deps.Repositories.Artist,
+			deps.Repositories.Album,
+			deps.Repositories.Genre,
+			deps.Repositories.Audit,
+			deps.Logger,
+		),
+		Tag:    NewTagService(deps.Repositories.Tag, deps.Logger),
+		Review: NewReviewService(deps.Repositories.Review, deps.Repositories.User, deps.Logger),
+		Comment: NewCommentService(deps.Repositories.Comment,
+			deps.Repositories.Song,
+			deps.Comment,
+			deps.Logger,
+		),
+		Report: NewReportService(deps.Repositories.Report,
+			deps.Repositories.Audit,
+			deps.Event,
+			deps.Logger,
+		),
+		Follow: NewFollowService(deps.Repositories.Follow, deps.Repositories.User, deps.Logger),
 	}
 }
@@ -3,11 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"music-lib/internal/dto/request"
 	"music-lib/internal/model"
 	"music-lib/internal/repository"
 	"music-lib/pkg/er"
+	"music-lib/pkg/event"
+	"music-lib/pkg/identifier"
+	"strings"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -18,6 +23,11 @@ type SongService struct {
 	songGenreRepo  repository.ISongGenreRepository
 	genreRepo      repository.IGenreRepository
 	lyricsRepo     repository.ILyricsRepository
+	uploadRepo     repository.IUploadRepository
+	artistRepo     repository.IArtistRepository
+	creditRepo     repository.ISongCreditRepository
+	audit          auditLog
+	event          *event.EventBus
 
 	logger *zap.SugaredLogger
 }
@@ -28,6 +38,11 @@ func NewSongService(
 	songGenre repository.ISongGenreRepository,
 	genre repository.IGenreRepository,
 	lyrics repository.ILyricsRepository,
+	upload repository.IUploadRepository,
+	artist repository.IArtistRepository,
+	credit repository.ISongCreditRepository,
+	audit repository.IAuditRepository,
+	bus *event.EventBus,
 	sugar *zap.SugaredLogger,
 ) *SongService {
 	return &SongService{
@@ -36,11 +51,16 @@ func NewSongService(
 		songGenreRepo: songGenre,
 		genreRepo:     genre,
 		lyricsRepo:    lyrics,
+		uploadRepo:    upload,
+		artistRepo:    artist,
+		creditRepo:    credit,
+		audit:         newAuditLog(audit, sugar),
+		event:         bus,
 		logger:        sugar,
 	}
 }
 
-func (s *SongService) AddSong(ctx context.Context, album *model.Album, songReq request.NewSongRequest) (*model.Song, error) {
+func (s *SongService) AddSong(ctx context.Context, album *model.Album, songReq request.NewSongRequest, userID uint) (*model.Song, error) {
 	s.logger.Debugw("Attempting to add song",
 		"album_id", album.ID,
 		"artist_id", album.ArtistID,
@@ -56,18 +76,58 @@ func (s *SongService) AddSong(ctx context.Context, album *model.Album, songReq r
 		return nil,  er.ErrSongExists
 	}
 
+	credits, err := s.buildCredits(ctx, songReq.Credits)
+	if err != nil {
+		return nil, err
+	}
+
+	disc, track, err := s.trackPosition(ctx, album.ID, songReq.DiscNumber, songReq.TrackNumber, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	isrc, err := s.checkISRC(ctx, songReq.ISRC, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Файл, загруженный заранее, после того как пользователь подтвердил извлеченные теги
+	var upload *model.Upload
+	if songReq.UploadID != "" {
+		upload, err = s.stagedUpload(ctx, songReq.UploadID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	s.logger.Debug("Attempting to create song")
 
-	song, err := s.songRepo.Create(ctx, &model.Song{
-		Title:      songReq.Title,
-		AlbumID:    album.ID,
-		ArtistID:   album.ArtistID,
-		SongGenres: nil,
-		Duration:   songReq.Duration,
-		FilePath:   songReq.FilePath,
-	})
+	newSong := &model.Song{
+		Title:       songReq.Title,
+		ISRC:        isrc,
+		AlbumID:     album.ID,
+		ArtistID:    album.ArtistID,
+		Status:      model.StatusDraft,
+		DiscNumber:  disc,
+		TrackNumber: track,
+		SongGenres:  nil,
+		Duration:    songReq.Duration,
+		Private:     songReq.Private,
+		// Участники пишутся вместе с песней в транзакции ее создания
+		Credits: credits,
+	}
+
+	var song *model.Song
+	if upload != nil {
+		song, err = s.createFromUpload(ctx, newSong, upload)
+	} else {
+		song, err = s.songRepo.Create(ctx, newSong)
+	}
 
 	if err != nil {
+		if errors.Is(err, er.ErrUploadNotPending) {
+			return nil, err
+		}
 		s.logger.Errorw("Failed to create song",
 			"album_id", album.ID,
 			"song_title", songReq.Title,
@@ -88,7 +148,7 @@ func (s *SongService) AddSong(ctx context.Context, album *model.Album, songReq r
 	}
 
 	s.logger.Debug("Attempting to add lyrics")
-	err = s.addLyrics(ctx, song.ID, songReq.Lyrics)
+	err = s.addLyrics(ctx, song.ID, userID, songReq.Lyrics)
 	if err != nil {
 		s.logger.Errorw("Failed to add lyrics",
 			"album_id", album.ID,
@@ -99,10 +159,54 @@ func (s *SongService) AddSong(ctx context.Context, album *model.Album, songReq r
 	}
 
 	s.logger.Debug("Song created successfully")
+	s.audit.record(ctx, userID, model.SongResource, song.ID, model.AuditCreate, nil, songState(song))
 
 	return song, nil
 }
 
+func (s *SongService) stagedUpload(ctx context.Context, uploadID string, userID uint) (*model.Upload, error) {
+	if err := uuid.Validate(uploadID); err != nil {
+		return nil, er.ErrUploadNotExists
+	}
+
+	upload, err := s.uploadRepo.GetByID(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrUploadNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	if upload.UserID != userID {
+		return nil, er.ErrUploadNotExists
+	}
+	if upload.Status != model.UploadStaged {
+		return nil, er.ErrUploadNotPending
+	}
+	return upload, nil
+}
+
+// createFromUpload создает песню с файлом загрузки. Загрузка забирается в транзакции создания песни,
+// поэтому один файл не достанется двум песням и не будет удален очисткой после проверки.
+func (s *SongService) createFromUpload(ctx context.Context, song *model.Song, upload *model.Upload) (*model.Song, error) {
+	song.FilePath = upload.StorageKey
+	song.FileSize = upload.Size
+	song.FileHash = upload.Hash
+	song.AudioHash = upload.AudioHash
+	song.MimeType = upload.MimeType
+
+	claimed, err := s.uploadRepo.CreateSong(ctx, upload, song)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		s.logger.Debugw("Upload was taken by another request",
+			"upload_id", upload.ID,
+		)
+		return nil, er.ErrUploadNotPending
+	}
+	return song, nil
+}
+
 func (s *SongService) addGenres(ctx context.Context, songID uint, req []request.Genres) error {
 	var genreIds []uint
 	for _, genre := range req {
@@ -125,19 +229,20 @@ func (s *SongService) addGenres(ctx context.Context, songID uint, req []request.
 	return nil
 }
 
-func (s *SongService) addLyrics(ctx context.Context, songID uint, req request.AddLyrics) error {
-	var lyrics model.Lyrics
-	lyrics.SongID = songID
+func (s *SongService) addLyrics(ctx context.Context, songID, authorID uint, req request.AddLyrics) error {
+	lyrics, err := buildLyrics(songID, req)
+	if err != nil {
+		return err
+	}
+	// У новой песни может быть только оригинал, переводы добавляются отдельно
+	lyrics.Kind = model.LyricsOriginal
 
-	for number, couplet := range req.Text {
-		lyrics.Couplets = append(lyrics.Couplets, model.Couplet{
-			LyricsID: songID,
-			Number:   uint(number),
-			Text:     couplet.Text,
-		})
+	revision, err := model.NewLyricsRevision(lyrics, authorID, model.RevisionEdit)
+	if err != nil {
+		return err
 	}
 
-	return s.lyricsRepo.Upsert(ctx, &lyrics)
+	return s.lyricsRepo.Upsert(ctx, lyrics, revision)
 }
 
 
@@ -152,3 +257,313 @@ func (s *SongService) GetSong(ctx context.Context, songID uint) (*model.Song, er
 
 	return song, nil
 }
+
+// GetSongByISRC ищет песню по коду ISRC
+func (s *SongService) GetSongByISRC(ctx context.Context, value string) (*model.Song, error) {
+	isrc, err := identifier.NormalizeISRC(value)
+	if err != nil {
+		return nil, er.ErrISRCFormat
+	}
+
+	song, err := s.songRepo.GetByISRC(ctx, isrc)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrSongNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	return s.GetSong(ctx, song.ID)
+}
+
+// UpdateSong меняет ISRC песни
+func (s *SongService) UpdateSong(ctx context.Context, songID uint, req request.UpdateSongRequest, userID uint) (*model.Song, error) {
+	song, err := s.GetSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ISRC == nil {
+		return song, nil
+	}
+
+	before := songState(song)
+	if song.ISRC, err = s.checkISRC(ctx, *req.ISRC, song.ID); err != nil {
+		return nil, err
+	}
+	if _, err := s.songRepo.Update(ctx, song); err != nil {
+		s.logger.Errorw("Failed to update song",
+			"song_id", songID,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	s.audit.record(ctx, userID, model.SongResource, songID, model.AuditUpdate, before, songState(song))
+	return song, nil
+}
+
+// checkISRC нормализует код и проверяет, что он не занят другой песней
+func (s *SongService) checkISRC(ctx context.Context, value string, songID uint) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	isrc, err := identifier.NormalizeISRC(value)
+	if err != nil {
+		return "", er.ErrISRCFormat
+	}
+
+	song, err := s.songRepo.GetByISRC(ctx, isrc)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return isrc, nil
+	case err != nil:
+		return "", &er.InternalError{Message: err.Error()}
+	case song.ID != songID:
+		s.logger.Debugw("ISRC is already taken",
+			"isrc", isrc,
+			"song_id", song.ID,
+		)
+		return "", er.ErrISRCTaken
+	}
+	return isrc, nil
+}
+
+// SetStatus публикует песню или снимает ее с публикации
+func (s *SongService) SetStatus(ctx context.Context, songID uint, status model.Status, userID uint) (*model.Song, error) {
+	song, err := s.GetSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	if song.Status == model.StatusTakenDown {
+		return nil, er.ErrTakenDown
+	}
+
+	before := songState(song)
+	song.Status = status
+	if _, err := s.songRepo.Update(ctx, song); err != nil {
+		s.logger.Errorw("Failed to update song status",
+			"song_id", songID,
+			"status", status,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	s.audit.record(ctx, userID, model.SongResource, songID, model.AuditStatus, before, songState(song))
+	if status == model.StatusPublished && before.Status != model.StatusPublished {
+		s.announce(ctx, song)
+	}
+	return song, nil
+}
+
+// announce сообщает о песне, опубликованной в уже вышедшем альбоме. О песнях альбома,
+// который еще ждет релиза, сообщит событие о выходе самого альбома.
+func (s *SongService) announce(ctx context.Context, song *model.Song) {
+	if s.event == nil {
+		return
+	}
+
+	album, err := s.albumRepo.GetByID(ctx, song.AlbumID)
+	if err != nil {
+		s.logger.Errorw("Failed to get album of published song",
+			"song_id", song.ID,
+			"album_id", song.AlbumID,
+			"error", err.Error(),
+		)
+		return
+	}
+	if album.ReleasePending || album.Status != model.StatusPublished {
+		return
+	}
+	artistID := song.ArtistID
+	if artistID == 0 {
+		artistID = album.ArtistID
+	}
+
+	go s.event.Publish(event.Event{
+		Type: event.EventSongReleased,
+		Data: event.SongReleased{
+			SongID:      song.ID,
+			AlbumID:     album.ID,
+			ArtistID:    artistID,
+			Title:       song.Title,
+			ReleaseDate: album.ReleaseDate,
+		},
+	})
+}
+
+// SetTrack переносит песню на другую позицию в альбоме
+func (s *SongService) SetTrack(ctx context.Context, songID uint, req request.SetTrackRequest, userID uint) (*model.Song, error) {
+	song, err := s.GetSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if req.TrackNumber <= 0 {
+		return nil, er.ErrTrackNumber
+	}
+
+	disc, track, err := s.trackPosition(ctx, song.AlbumID, req.DiscNumber, req.TrackNumber, song.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := songState(song)
+	song.DiscNumber, song.TrackNumber = disc, track
+	if _, err := s.songRepo.Update(ctx, song); err != nil {
+		s.logger.Errorw("Failed to update track number",
+			"song_id", songID,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	s.audit.record(ctx, userID, model.SongResource, songID, model.AuditUpdate, before, songState(song))
+	return song, nil
+}
+
+// trackPosition проверяет номер диска и дорожки, без номера дорожки песня встает в конец диска
+func (s *SongService) trackPosition(ctx context.Context, albumID uint, disc, track int, songID uint) (int, int, error) {
+	if disc < 0 || track < 0 {
+		return 0, 0, er.ErrTrackNumber
+	}
+	if disc == 0 {
+		disc = 1
+	}
+
+	if track == 0 {
+		next, err := s.songRepo.NextTrackNumber(ctx, albumID, disc)
+		if err != nil {
+			return 0, 0, &er.InternalError{Message: err.Error()}
+		}
+		return disc, next, nil
+	}
+
+	if s.songRepo.TrackExists(ctx, albumID, disc, track, songID) {
+		s.logger.Debugw("Track number is already taken",
+			"album_id", albumID,
+			"disc", disc,
+			"track", track,
+		)
+		return 0, 0, er.ErrTrackTaken
+	}
+	return disc, track, nil
+}
+
+// SetCredits заменяет список участников песни
+func (s *SongService) SetCredits(ctx context.Context, songID uint, req []request.Credit, userID uint) ([]model.SongCredit, error) {
+	song, err := s.GetSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	before := songState(song)
+
+	credits, err := s.buildCredits(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.creditRepo.Replace(ctx, songID, credits); err != nil {
+		s.logger.Errorw("Failed to replace credits",
+			"song_id", songID,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	credits, err = s.creditRepo.GetBySongID(ctx, songID)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	after := before
+	after.Credits = credits
+	s.audit.record(ctx, userID, model.SongResource, songID, model.AuditUpdate, before, after)
+	return credits, nil
+}
+
+// maxCredits ограничивает длину списка участников одной песни
+const maxCredits = 50
+
+// buildCredits проверяет участников из запроса: роль, ссылку на артиста или имя, повторы
+func (s *SongService) buildCredits(ctx context.Context, req []request.Credit) ([]model.SongCredit, error) {
+	if len(req) > maxCredits {
+		return nil, &er.ValidationError{Message: fmt.Sprintf("a song can have at most %d credits", maxCredits)}
+	}
+
+	type key struct {
+		artistID uint
+		name     string
+		role     model.CreditRole
+	}
+	seen := make(map[key]bool)
+	credits := make([]model.SongCredit, 0, len(req))
+	for i, c := range req {
+		role := model.CreditRole(c.Role)
+		if !role.IsValid() {
+			return nil, er.ErrCreditRole
+		}
+		name := strings.TrimSpace(c.Name)
+		if (c.ArtistID == 0) == (name == "") {
+			return nil, er.ErrCreditTarget
+		}
+
+		k := key{artistID: c.ArtistID, name: strings.ToLower(name), role: role}
+		if seen[k] {
+			return nil, er.ErrCreditDuplicate
+		}
+		seen[k] = true
+
+		credit := model.SongCredit{Name: name, Role: role, Position: i}
+		if c.ArtistID != 0 {
+			if _, err := s.artistRepo.GetByID(ctx, c.ArtistID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, er.ErrArtistNotExists
+				}
+				return nil, &er.InternalError{Message: err.Error()}
+			}
+			artistID := c.ArtistID
+			credit.ArtistID = &artistID
+		}
+		credits = append(credits, credit)
+	}
+	return credits, nil
+}
+
+// GetGenreSongs возвращает песни жанра и всех его поджанров
+func (s *SongService) GetGenreSongs(ctx context.Context, genreID, viewerID uint, limit, offset int) ([]model.Song, int64, error) {
+	genreIDs, err := s.genreRepo.GetSubtreeIDs(ctx, genreID)
+	if err != nil {
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	if len(genreIDs) == 0 {
+		return nil, 0, er.ErrGenreNotExists
+	}
+
+	songs, total, err := s.songRepo.GetByGenreIDs(ctx, genreIDs, viewerID, limit, offset)
+	if err != nil {
+		s.logger.Errorw("Failed to get genre songs",
+			"genre_id", genreID,
+			"error", err.Error(),
+		)
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return songs, total, nil
+}
+
+// GetArtistSongs возвращает дискографию артиста вместе с песнями, где он указан участником.
+// Песни невышедших альбомов видят только их редакторы.
+func (s *SongService) GetArtistSongs(ctx context.Context, artistID, viewerID uint, sort string, limit, offset int) ([]model.Song, int64, error) {
+	if _, err := s.artistRepo.GetByID(ctx, artistID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, er.ErrArtistNotExists
+		}
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+
+	songs, total, err := s.songRepo.GetByArtistID(ctx, artistID, viewerID, sort, limit, offset)
+	if err != nil {
+		s.logger.Errorw("Failed to get artist songs",
+			"artist_id", artistID,
+			"error", err.Error(),
+		)
+		return nil, 0, &er.InternalError{Message: err.Error()}
+	}
+	return songs, total, nil
+}
@@ -21,11 +21,11 @@ func TestAddSong_SongExists(t *testing.T) {
 			return true
 		},
 	}
-	service := NewSongService(mockSongRepo, nil, nil, nil, nil, logger)
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
 	album := &model.Album{ID: 1}
 	req := request.NewSongRequest{Title: "Test Song"}
 
-	song, err := service.AddSong(context.Background(), album, req)
+	song, err := service.AddSong(context.Background(), album, req, 1)
 
 	assert.Nil(t, song)
 	assert.Equal(t, er.ErrSongExists, err)
@@ -37,15 +37,18 @@ func TestAddSong_CreateError(t *testing.T) {
 		ExistsInAlbumFunc: func(ctx context.Context, albumID uint, songName string) bool {
 			return false
 		},
+		NextTrackNumberFunc: func(ctx context.Context, albumID uint, disc int) (int, error) {
+			return 1, nil
+		},
 		CreateFunc: func(ctx context.Context, entity *model.Song) (*model.Song, error) {
 			return nil, errors.New("create error")
 		},
 	}
-	service := NewSongService(mockSongRepo, nil, nil, nil, nil, logger)
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
 	album := &model.Album{ID: 1}
 	req := request.NewSongRequest{Title: "Test Song"}
 
-	song, err := service.AddSong(context.Background(), album, req)
+	song, err := service.AddSong(context.Background(), album, req, 1)
 
 	assert.Nil(t, song)
 	assert.IsType(t, &er.InternalError{}, err)
@@ -58,6 +61,9 @@ func TestAddSong_Success(t *testing.T) {
 		ExistsInAlbumFunc: func(ctx context.Context, albumID uint, songName string) bool {
 			return false
 		},
+		NextTrackNumberFunc: func(ctx context.Context, albumID uint, disc int) (int, error) {
+			return 1, nil
+		},
 		CreateFunc: func(ctx context.Context, entity *model.Song) (*model.Song, error) {
 			return &model.Song{ID: 1}, nil
 		},
@@ -73,11 +79,11 @@ func TestAddSong_Success(t *testing.T) {
 		},
 	}
 	mockLyricsRepo := &mocks.MockLyricsRepo{
-		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics) error {
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
 			return nil
 		},
 	}
-	service := NewSongService(mockSongRepo, nil, mockSongGenreRepo, mockGenreRepo, mockLyricsRepo, logger)
+	service := NewSongService(mockSongRepo, nil, mockSongGenreRepo, mockGenreRepo, mockLyricsRepo, nil, nil, nil, nil, nil, logger)
 	album := &model.Album{ID: 1, ArtistID: 1}
 	req := request.NewSongRequest{
 		Title:  "Test Song",
@@ -85,7 +91,7 @@ func TestAddSong_Success(t *testing.T) {
 		Lyrics: request.AddLyrics{Text: []request.Couplet{{Text: "Lyrics"}}},
 	}
 
-	song, err := service.AddSong(context.Background(), album, req)
+	song, err := service.AddSong(context.Background(), album, req, 1)
 
 	assert.NotNil(t, song)
 	assert.NoError(t, err)
@@ -99,7 +105,7 @@ func TestAddGenres_GetGenresError(t *testing.T) {
 			return nil, errors.New("get genres error")
 		},
 	}
-	service := NewSongService(nil, nil, nil, mockGenreRepo, nil, logger)
+	service := NewSongService(nil, nil, nil, mockGenreRepo, nil, nil, nil, nil, nil, nil, logger)
 
 	err := service.addGenres(context.Background(), 1, []request.Genres{{GenreID: 1}})
 
@@ -119,7 +125,7 @@ func TestAddGenres_Success(t *testing.T) {
 			return &model.SongGenre{}, nil
 		},
 	}
-	service := NewSongService(nil, nil, mockSongGenreRepo, mockGenreRepo, nil, logger)
+	service := NewSongService(nil, nil, mockSongGenreRepo, mockGenreRepo, nil, nil, nil, nil, nil, nil, logger)
 
 	err := service.addGenres(context.Background(), 1, []request.Genres{{GenreID: 1}})
 
@@ -129,14 +135,14 @@ func TestAddGenres_Success(t *testing.T) {
 func TestAddLyrics_UpsertError(t *testing.T) {
 	logger := zap.NewNop().Sugar()
 	mockLyricsRepo := &mocks.MockLyricsRepo{
-		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics) error {
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
 			return errors.New("upsert error")
 		},
 	}
-	service := NewSongService(nil, nil, nil, nil, mockLyricsRepo, logger)
+	service := NewSongService(nil, nil, nil, nil, mockLyricsRepo, nil, nil, nil, nil, nil, logger)
 
 	req := request.AddLyrics{Text: []request.Couplet{{Text: "Lyrics"}}}
-	err := service.addLyrics(context.Background(), 1, req)
+	err := service.addLyrics(context.Background(), 1, 1, req)
 
 	assert.Equal(t, errors.New("upsert error"), err)
 }
@@ -144,14 +150,14 @@ func TestAddLyrics_UpsertError(t *testing.T) {
 func TestAddLyrics_Success(t *testing.T) {
 	logger := zap.NewNop().Sugar()
 	mockLyricsRepo := &mocks.MockLyricsRepo{
-		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics) error {
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
 			return nil
 		},
 	}
-	service := NewSongService(nil, nil, nil, nil, mockLyricsRepo, logger)
+	service := NewSongService(nil, nil, nil, nil, mockLyricsRepo, nil, nil, nil, nil, nil, logger)
 
 	req := request.AddLyrics{Text: []request.Couplet{{Text: "Lyrics"}}}
-	err := service.addLyrics(context.Background(), 1, req)
+	err := service.addLyrics(context.Background(), 1, 1, req)
 
 	assert.NoError(t, err)
 }
@@ -163,7 +169,7 @@ func TestGetSong_NotFound(t *testing.T) {
 			return nil, gorm.ErrRecordNotFound
 		},
 	}
-	service := NewSongService(mockSongRepo, nil, nil, nil, nil, logger)
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
 
 	song, err := service.GetSong(context.Background(), 1)
 
@@ -178,11 +184,390 @@ func TestGetSong_Success(t *testing.T) {
 			return &model.Song{ID: 1}, nil
 		},
 	}
-	service := NewSongService(mockSongRepo, nil, nil, nil, nil, logger)
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
 
 	song, err := service.GetSong(context.Background(), 1)
 
 	assert.NotNil(t, song)
 	assert.NoError(t, err)
 	assert.Equal(t, uint(1), song.ID)
-}
\ No newline at end of file
+}
+
+func TestAddSong_WithStagedUpload(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	const uploadID = "7f9c2ba4-e88f-4a1d-9d3b-2c5f1e0a6b7d"
+	var created *model.Song
+	mockSongRepo := &mocks.MockSongRepo{
+		ExistsInAlbumFunc: func(ctx context.Context, albumID uint, songName string) bool {
+			return false
+		},
+		NextTrackNumberFunc: func(ctx context.Context, albumID uint, disc int) (int, error) {
+			return 1, nil
+		},
+	}
+	var claimed *model.Upload
+	mockUploadRepo := &mocks.MockUploadRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*model.Upload, error) {
+			return &model.Upload{
+				ID:         id,
+				UserID:     1,
+				Size:       2048,
+				StorageKey: "uploads/" + id + "/audio.flac",
+				MimeType:   "audio/flac",
+				Hash:       "abc",
+				Status:     model.UploadStaged,
+			}, nil
+		},
+		CreateSongFunc: func(ctx context.Context, upload *model.Upload, song *model.Song) (bool, error) {
+			song.ID = 5
+			created = song
+			upload.SongID, upload.Status = song.ID, model.UploadComplete
+			claimed = upload
+			return true, nil
+		},
+	}
+	mockGenreRepo := &mocks.MockGenreRepo{
+		GetByIdsFunc: func(ctx context.Context, ids []uint) ([]model.Genre, error) {
+			return nil, nil
+		},
+	}
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			return nil
+		},
+	}
+	service := NewSongService(mockSongRepo, nil, nil, mockGenreRepo, mockLyricsRepo, mockUploadRepo, nil, nil, nil, nil, logger)
+	req := request.NewSongRequest{
+		Title:    "Tagged Song",
+		Duration: 215,
+		UploadID: uploadID,
+		Lyrics:   request.AddLyrics{Text: []request.Couplet{{Text: "Lyrics"}}},
+	}
+
+	song, err := service.AddSong(context.Background(), &model.Album{ID: 1, ArtistID: 1}, req, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), song.ID)
+	assert.Equal(t, "uploads/"+uploadID+"/audio.flac", created.FilePath)
+	assert.Equal(t, "abc", created.FileHash)
+	assert.Equal(t, int64(2048), created.FileSize)
+	assert.Equal(t, uint(5), claimed.SongID)
+	assert.Equal(t, model.UploadComplete, claimed.Status)
+}
+
+func TestAddSong_StagedUploadTaken(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockSongRepo := &mocks.MockSongRepo{
+		ExistsInAlbumFunc: func(ctx context.Context, albumID uint, songName string) bool {
+			return false
+		},
+		NextTrackNumberFunc: func(ctx context.Context, albumID uint, disc int) (int, error) {
+			return 1, nil
+		},
+	}
+	// Проверку прошли оба запроса, но забрать загрузку успел только другой
+	mockUploadRepo := &mocks.MockUploadRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*model.Upload, error) {
+			return &model.Upload{ID: id, UserID: 1, Status: model.UploadStaged}, nil
+		},
+		CreateSongFunc: func(ctx context.Context, upload *model.Upload, song *model.Song) (bool, error) {
+			return false, nil
+		},
+	}
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, mockUploadRepo, nil, nil, nil, nil, logger)
+	req := request.NewSongRequest{
+		Title:    "Tagged Song",
+		UploadID: "7f9c2ba4-e88f-4a1d-9d3b-2c5f1e0a6b7d",
+	}
+
+	song, err := service.AddSong(context.Background(), &model.Album{ID: 1, ArtistID: 1}, req, 1)
+
+	assert.Nil(t, song)
+	assert.Equal(t, er.ErrUploadNotPending, err)
+}
+
+func TestAddSong_WithCredits(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var created *model.Song
+	mockSongRepo := &mocks.MockSongRepo{
+		ExistsInAlbumFunc: func(ctx context.Context, albumID uint, songName string) bool {
+			return false
+		},
+		NextTrackNumberFunc: func(ctx context.Context, albumID uint, disc int) (int, error) {
+			return 1, nil
+		},
+		CreateFunc: func(ctx context.Context, entity *model.Song) (*model.Song, error) {
+			entity.ID = 5
+			created = entity
+			return entity, nil
+		},
+	}
+	mockArtistRepo := &mocks.MockArtistRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Artist, error) {
+			return &model.Artist{ID: id}, nil
+		},
+	}
+	// Участники создаются вместе с песней, отдельной записи после создания нет
+	mockCreditRepo := &mocks.MockSongCreditRepo{
+		ReplaceFunc: func(ctx context.Context, songID uint, credits []model.SongCredit) error {
+			t.Error("credits must be created with the song")
+			return nil
+		},
+	}
+	mockGenreRepo := &mocks.MockGenreRepo{
+		GetByIdsFunc: func(ctx context.Context, ids []uint) ([]model.Genre, error) {
+			return nil, nil
+		},
+	}
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			return nil
+		},
+	}
+	service := NewSongService(mockSongRepo, nil, nil, mockGenreRepo, mockLyricsRepo, nil, mockArtistRepo, mockCreditRepo, nil, nil, logger)
+	req := request.NewSongRequest{
+		Title:   "Duet",
+		Credits: []request.Credit{{ArtistID: 3, Role: "featured"}, {Name: "Jane Doe", Role: "producer"}},
+		Lyrics:  request.AddLyrics{Text: []request.Couplet{{Text: "Lyrics"}}},
+	}
+
+	_, err := service.AddSong(context.Background(), &model.Album{ID: 1, ArtistID: 1}, req, 1)
+
+	assert.NoError(t, err)
+	if assert.Len(t, created.Credits, 2) {
+		assert.Equal(t, uint(3), *created.Credits[0].ArtistID)
+		assert.Equal(t, "Jane Doe", created.Credits[1].Name)
+		assert.Equal(t, 1, created.Credits[1].Position)
+	}
+}
+
+func TestAddSong_ForeignUpload(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	mockSongRepo := &mocks.MockSongRepo{
+		ExistsInAlbumFunc: func(ctx context.Context, albumID uint, songName string) bool {
+			return false
+		},
+		NextTrackNumberFunc: func(ctx context.Context, albumID uint, disc int) (int, error) {
+			return 1, nil
+		},
+	}
+	mockUploadRepo := &mocks.MockUploadRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*model.Upload, error) {
+			return &model.Upload{ID: id, UserID: 2, Status: model.UploadStaged}, nil
+		},
+	}
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, mockUploadRepo, nil, nil, nil, nil, logger)
+	req := request.NewSongRequest{Title: "Song", UploadID: "7f9c2ba4-e88f-4a1d-9d3b-2c5f1e0a6b7d"}
+
+	song, err := service.AddSong(context.Background(), &model.Album{ID: 1}, req, 1)
+
+	assert.Nil(t, song)
+	assert.Equal(t, er.ErrUploadNotExists, err)
+}
+
+func newCreditService(saved *[]model.SongCredit) *SongService {
+	songRepo := &mocks.MockSongRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Song, error) {
+			if id != 1 {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return &model.Song{ID: 1}, nil
+		},
+	}
+	artistRepo := &mocks.MockArtistRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Artist, error) {
+			if id != 3 {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return &model.Artist{ID: 3, Name: "Guest"}, nil
+		},
+	}
+	creditRepo := &mocks.MockSongCreditRepo{
+		ReplaceFunc: func(ctx context.Context, songID uint, credits []model.SongCredit) error {
+			*saved = credits
+			return nil
+		},
+		GetBySongIDFunc: func(ctx context.Context, songID uint) ([]model.SongCredit, error) {
+			return *saved, nil
+		},
+	}
+	return NewSongService(songRepo, nil, nil, nil, nil, nil, artistRepo, creditRepo, nil, nil, zap.NewNop().Sugar())
+}
+
+func TestSetCredits_Success(t *testing.T) {
+	var saved []model.SongCredit
+	service := newCreditService(&saved)
+
+	credits, err := service.SetCredits(context.Background(), 1, []request.Credit{
+		{ArtistID: 3, Role: "featured"},
+		{Name: "  Jane Doe ", Role: "composer"},
+		{Name: "Jane Doe", Role: "lyricist"},
+	}, 2)
+
+	assert.NoError(t, err)
+	if assert.Len(t, credits, 3) {
+		assert.Equal(t, uint(3), *credits[0].ArtistID)
+		assert.Equal(t, model.CreditFeatured, credits[0].Role)
+		assert.Nil(t, credits[1].ArtistID)
+		assert.Equal(t, "Jane Doe", credits[1].Name)
+		assert.Equal(t, 2, credits[2].Position)
+	}
+}
+
+func TestSetCredits_Validation(t *testing.T) {
+	var saved []model.SongCredit
+	service := newCreditService(&saved)
+	ctx := context.Background()
+
+	_, err := service.SetCredits(ctx, 1, []request.Credit{{Name: "Jane", Role: "drummer"}}, 2)
+	assert.Equal(t, er.ErrCreditRole, err)
+
+	_, err = service.SetCredits(ctx, 1, []request.Credit{{ArtistID: 3, Name: "Jane", Role: "producer"}}, 2)
+	assert.Equal(t, er.ErrCreditTarget, err)
+
+	_, err = service.SetCredits(ctx, 1, []request.Credit{{Role: "producer"}}, 2)
+	assert.Equal(t, er.ErrCreditTarget, err)
+
+	_, err = service.SetCredits(ctx, 1, []request.Credit{{Name: "Jane", Role: "producer"}, {Name: "jane", Role: "producer"}}, 2)
+	assert.Equal(t, er.ErrCreditDuplicate, err)
+
+	_, err = service.SetCredits(ctx, 1, []request.Credit{{ArtistID: 4, Role: "remixer"}}, 2)
+	assert.Equal(t, er.ErrArtistNotExists, err)
+
+	_, err = service.SetCredits(ctx, 2, nil, 2)
+	assert.Equal(t, er.ErrSongNotExists, err)
+
+	assert.Nil(t, saved)
+}
+
+func TestAddSong_TrackNumber(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var created *model.Song
+	mockSongRepo := &mocks.MockSongRepo{
+		ExistsInAlbumFunc: func(ctx context.Context, albumID uint, songName string) bool {
+			return false
+		},
+		TrackExistsFunc: func(ctx context.Context, albumID uint, disc, track int, excludeID uint) bool {
+			return disc == 1 && track == 3
+		},
+		NextTrackNumberFunc: func(ctx context.Context, albumID uint, disc int) (int, error) {
+			return 4, nil
+		},
+		CreateFunc: func(ctx context.Context, entity *model.Song) (*model.Song, error) {
+			created = entity
+			return entity, nil
+		},
+	}
+	mockGenreRepo := &mocks.MockGenreRepo{
+		GetByIdsFunc: func(ctx context.Context, ids []uint) ([]model.Genre, error) {
+			return nil, nil
+		},
+	}
+	mockLyricsRepo := &mocks.MockLyricsRepo{
+		UpsertFunc: func(ctx context.Context, lyrics *model.Lyrics, revision *model.LyricsRevision) error {
+			return nil
+		},
+	}
+	service := NewSongService(mockSongRepo, nil, nil, mockGenreRepo, mockLyricsRepo, nil, nil, nil, nil, nil, logger)
+	album := &model.Album{ID: 1, ArtistID: 1}
+	req := request.NewSongRequest{
+		Title:  "Test Song",
+		Lyrics: request.AddLyrics{Text: []request.Couplet{{Text: "Lyrics"}}},
+	}
+
+	// Без номера песня встает в конец первого диска
+	_, err := service.AddSong(context.Background(), album, req, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created.DiscNumber)
+	assert.Equal(t, 4, created.TrackNumber)
+
+	req.TrackNumber = 3
+	_, err = service.AddSong(context.Background(), album, req, 1)
+	assert.Equal(t, er.ErrTrackTaken, err)
+
+	req.DiscNumber = 2
+	_, err = service.AddSong(context.Background(), album, req, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, created.DiscNumber)
+	assert.Equal(t, 3, created.TrackNumber)
+
+	req.TrackNumber = -1
+	_, err = service.AddSong(context.Background(), album, req, 1)
+	assert.Equal(t, er.ErrTrackNumber, err)
+}
+
+func TestSetTrack(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var excluded uint
+	mockSongRepo := &mocks.MockSongRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Song, error) {
+			return &model.Song{ID: id, AlbumID: 1, DiscNumber: 1, TrackNumber: 2}, nil
+		},
+		TrackExistsFunc: func(ctx context.Context, albumID uint, disc, track int, excludeID uint) bool {
+			excluded = excludeID
+			return disc == 1 && track == 1
+		},
+		UpdateFunc: func(ctx context.Context, entity *model.Song) (*model.Song, error) {
+			return entity, nil
+		},
+	}
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
+
+	_, err := service.SetTrack(context.Background(), 5, request.SetTrackRequest{TrackNumber: 1}, 2)
+	assert.Equal(t, er.ErrTrackTaken, err)
+	assert.Equal(t, uint(5), excluded)
+
+	_, err = service.SetTrack(context.Background(), 5, request.SetTrackRequest{TrackNumber: 0}, 2)
+	assert.Equal(t, er.ErrTrackNumber, err)
+
+	song, err := service.SetTrack(context.Background(), 5, request.SetTrackRequest{DiscNumber: 2, TrackNumber: 1}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, song.DiscNumber)
+	assert.Equal(t, 1, song.TrackNumber)
+}
+
+func TestUpdateSong_ISRC(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	var updated *model.Song
+	mockSongRepo := &mocks.MockSongRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Song, error) {
+			return &model.Song{ID: id, ISRC: "USS1Z9900001"}, nil
+		},
+		GetByISRCFunc: func(ctx context.Context, isrc string) (*model.Song, error) {
+			if isrc == "GBAYE1501234" {
+				return &model.Song{ID: 7}, nil
+			}
+			if isrc == "USS1Z9900001" {
+				return &model.Song{ID: 5}, nil
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+		UpdateFunc: func(ctx context.Context, entity *model.Song) (*model.Song, error) {
+			updated = entity
+			return entity, nil
+		},
+	}
+	service := NewSongService(mockSongRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
+	isrc := func(value string) request.UpdateSongRequest {
+		return request.UpdateSongRequest{ISRC: &value}
+	}
+
+	_, err := service.UpdateSong(context.Background(), 5, isrc("US-S1Z-99"), 2)
+	assert.Equal(t, er.ErrISRCFormat, err)
+
+	_, err = service.UpdateSong(context.Background(), 5, isrc("GB-AYE-15-01234"), 2)
+	assert.Equal(t, er.ErrISRCTaken, err)
+
+	// Свой же код не считается занятым
+	song, err := service.UpdateSong(context.Background(), 5, isrc("us-s1z-99-00001"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "USS1Z9900001", song.ISRC)
+
+	_, err = service.UpdateSong(context.Background(), 5, isrc("FR-Z03-14-00123"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "FRZ031400123", updated.ISRC)
+
+	_, err = service.UpdateSong(context.Background(), 5, isrc(""), 2)
+	assert.NoError(t, err)
+	assert.Empty(t, updated.ISRC)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Параметры подписанной ссылки на прослушивание
+const (
+	streamUserParam      = "uid"
+	streamExpiresParam   = "expires"
+	streamSignatureParam = "signature"
+)
+
+const (
+	// Меньшее начало файла запрашивают для проверки, например Safari шлет Range: bytes=0-1
+	minPlayBytes = 64 << 10
+	// Окно повторов для песни без длительности
+	defaultPlayWindow = 10 * time.Minute
+)
+
+type StreamService struct {
+	songRepo    repository.ISongRepository
+	profileRepo repository.IProfileRepository
+	visibility  visibility
+	storage     storage.Storage
+	conf        config.StreamConfig
+	now         func() time.Time
+
+	logger *zap.SugaredLogger
+}
+
+func NewStreamService(
+	song repository.ISongRepository,
+	album repository.IAlbumRepository,
+	artist repository.IArtistRepository,
+	permission repository.IPermissionRepository,
+	profile repository.IProfileRepository,
+	store storage.Storage,
+	conf config.StreamConfig,
+	logger *zap.SugaredLogger,
+) *StreamService {
+	return &StreamService{
+		songRepo:    song,
+		profileRepo: profile,
+		visibility:  visibility{albums: album, artists: artist, permissions: permission},
+		storage:     store,
+		conf:        conf,
+		now:         time.Now,
+		logger:      logger,
+	}
+}
+
+// Open возвращает песню, если у пользователя есть доступ к ее прослушиванию.
+// userID 0 - анонимный слушатель, ему доступны только публичные вышедшие песни.
+func (s *StreamService) Open(ctx context.Context, songID, userID uint) (*model.Song, error) {
+	song, err := s.songRepo.GetByID(ctx, songID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrSongNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	if song.Private && !s.visibility.canListen(userID, song.ID) {
+		s.logger.Debugw("Stream access denied",
+			"song id", song.ID,
+			"user id", userID,
+		)
+		if userID == 0 {
+			return nil, er.ErrNotAuthorized
+		}
+		// Не раскрываем существование приватной песни
+		return nil, er.ErrSongNotExists
+	}
+
+	if err := s.visibility.checkSong(ctx, song, userID, s.now()); err != nil {
+		return nil, err
+	}
+
+	if song.FilePath == "" {
+		return nil, er.ErrSongFileNotExists
+	}
+
+	// Размер и тип файлов, загруженных до появления этих колонок, берем из хранилища
+	if song.FileSize <= 0 {
+		info, err := s.storage.Stat(ctx, song.FilePath)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, er.ErrSongFileNotExists
+			}
+			return nil, &er.InternalError{Message: err.Error()}
+		}
+		song.FileSize = info.Size
+		if song.MimeType == "" {
+			song.MimeType = info.ContentType
+		}
+	}
+	return song, nil
+}
+
+// Read открывает length байт аудиофайла начиная с offset
+func (s *StreamService) Read(ctx context.Context, song *model.Song, offset, length int64) (io.ReadCloser, error) {
+	r, err := s.storage.GetRange(ctx, song.FilePath, offset, length)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.logger.Errorw("Song file is missing in storage",
+				"song id", song.ID,
+				"key", song.FilePath,
+			)
+			return nil, er.ErrSongFileNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return r, nil
+}
+
+// RecordPlay записывает прослушивание в историю пользователя, если у него не включена приватная сессия.
+// offset и length - отданная часть файла: прослушиванием считается только начало файла не меньше
+// minPlayBytes. Повторный запрос раньше, чем песня могла бы доиграть, то же прослушивание.
+// Ошибка записи не мешает прослушиванию и только логируется.
+func (s *StreamService) RecordPlay(ctx context.Context, song *model.Song, userID uint, offset, length int64) {
+	if userID == 0 || offset != 0 || length < min(song.FileSize, minPlayBytes) {
+		return
+	}
+
+	window := time.Duration(song.Duration) * time.Second
+	if window <= 0 {
+		window = defaultPlayWindow
+	}
+	recorded, err := s.profileRepo.AddPlay(ctx, userID, song.ID, s.now().Add(-window))
+	if err != nil {
+		s.logger.Errorw("Failed to record play",
+			"song id", song.ID,
+			"user id", userID,
+			"error", err.Error(),
+		)
+		return
+	}
+	if !recorded {
+		s.logger.Debugw("Play not recorded: no profile, private session or repeated request",
+			"song id", song.ID,
+			"user id", userID,
+		)
+	}
+}
+
+// SignURL возвращает параметры ссылки, по которой песню можно слушать без заголовка Authorization.
+// Доступ пользователя проверяется заново при каждом запросе, поэтому отзыв права действует сразу.
+func (s *StreamService) SignURL(songID, userID uint) (url.Values, time.Time) {
+	expires := s.now().Add(s.conf.URLTTL).Truncate(time.Second)
+
+	query := url.Values{}
+	query.Set(streamUserParam, strconv.FormatUint(uint64(userID), 10))
+	query.Set(streamExpiresParam, strconv.FormatInt(expires.Unix(), 10))
+	query.Set(streamSignatureParam, s.signature(songID, userID, expires.Unix()))
+	return query, expires
+}
+
+// VerifyURL проверяет подпись ссылки и возвращает пользователя, для которого она выдана.
+// ok false, если ссылка не подписана.
+func (s *StreamService) VerifyURL(songID uint, query url.Values) (userID uint, ok bool, err error) {
+	signature := query.Get(streamSignatureParam)
+	if signature == "" {
+		return 0, false, nil
+	}
+
+	uid, err := strconv.ParseUint(query.Get(streamUserParam), 10, 64)
+	if err != nil {
+		return 0, true, er.ErrStreamURLInvalid
+	}
+	expires, err := strconv.ParseInt(query.Get(streamExpiresParam), 10, 64)
+	if err != nil || s.now().Unix() > expires {
+		return 0, true, er.ErrStreamURLInvalid
+	}
+
+	expected := s.signature(songID, uint(uid), expires)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return 0, true, er.ErrStreamURLInvalid
+	}
+	return uint(uid), true, nil
+}
+
+func (s *StreamService) signature(songID, userID uint, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.conf.Secret))
+	fmt.Fprintf(mac, "stream\n%d\n%d\n%d", songID, userID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
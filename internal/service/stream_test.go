@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"music-lib/internal/config"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+var testStreamConfig = config.StreamConfig{Secret: "stream-secret", URLTTL: time.Minute}
+
+// Права view выданы только пользователю 2
+func newViewPermissionRepo() *mocks.MockPermissionRepo {
+	return &mocks.MockPermissionRepo{
+		HasPermissionFunc: func(userID, resourceID uint, resourceType model.Resource, permission model.Permission) bool {
+			return userID == 2 && resourceType == model.SongResource && permission == model.ViewPermission
+		},
+	}
+}
+
+func TestStreamOpen_PrivateSong(t *testing.T) {
+	song := &model.Song{ID: 1, FilePath: "songs/1/a.mp3", FileSize: 10, Private: true, Status: model.StatusPublished}
+	service := NewStreamService(newSongRepo(song), newAlbumRepo(), newArtistRepo(), newViewPermissionRepo(), nil, newTestStorage(t), testStreamConfig, zap.NewNop().Sugar())
+
+	_, err := service.Open(context.Background(), 1, 0)
+	assert.Equal(t, er.ErrNotAuthorized, err)
+
+	_, err = service.Open(context.Background(), 1, 3)
+	assert.Equal(t, er.ErrSongNotExists, err)
+
+	got, err := service.Open(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, song, got)
+}
+
+func TestStreamOpen_WithoutFile(t *testing.T) {
+	song := &model.Song{ID: 1, Status: model.StatusPublished}
+	service := NewStreamService(newSongRepo(song), newAlbumRepo(), newArtistRepo(), newViewPermissionRepo(), nil, newTestStorage(t), testStreamConfig, zap.NewNop().Sugar())
+
+	_, err := service.Open(context.Background(), 1, 0)
+	assert.Equal(t, er.ErrSongFileNotExists, err)
+}
+
+func TestStreamRead_Range(t *testing.T) {
+	store := newTestStorage(t)
+	data := flacData(100)
+	assert.NoError(t, store.Put(context.Background(), "songs/1/a.flac", bytes.NewReader(data), 100, "audio/flac"))
+
+	// Размер старых записей берется из хранилища
+	song := &model.Song{ID: 1, FilePath: "songs/1/a.flac", Status: model.StatusPublished}
+	service := NewStreamService(newSongRepo(song), newAlbumRepo(), newArtistRepo(), newViewPermissionRepo(), nil, store, testStreamConfig, zap.NewNop().Sugar())
+
+	opened, err := service.Open(context.Background(), 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), opened.FileSize)
+	assert.Equal(t, "audio/flac", opened.MimeType)
+
+	r, err := service.Read(context.Background(), opened, 10, 20)
+	assert.NoError(t, err)
+	got, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, data[10:30], got)
+}
+
+func TestStreamSignedURL(t *testing.T) {
+	service := NewStreamService(nil, nil, nil, nil, nil, nil, testStreamConfig, zap.NewNop().Sugar())
+	now := time.Unix(1_700_000_000, 0)
+	service.now = func() time.Time { return now }
+
+	query, expires := service.SignURL(1, 2)
+	assert.Equal(t, now.Add(time.Minute), expires)
+
+	userID, signed, err := service.VerifyURL(1, query)
+	assert.NoError(t, err)
+	assert.True(t, signed)
+	assert.Equal(t, uint(2), userID)
+
+	// Ссылка не подходит для другой песни и другого пользователя
+	_, _, err = service.VerifyURL(3, query)
+	assert.Equal(t, er.ErrStreamURLInvalid, err)
+
+	query.Set(streamUserParam, "5")
+	_, _, err = service.VerifyURL(1, query)
+	assert.Equal(t, er.ErrStreamURLInvalid, err)
+
+	query, _ = service.SignURL(1, 2)
+	now = now.Add(2 * time.Minute)
+	_, _, err = service.VerifyURL(1, query)
+	assert.Equal(t, er.ErrStreamURLInvalid, err)
+
+	// Без подписи проверка не выполняется
+	_, signed, err = service.VerifyURL(1, nil)
+	assert.NoError(t, err)
+	assert.False(t, signed)
+}
+
+func TestStreamOpen_Embargoed(t *testing.T) {
+	song := &model.Song{ID: 5, AlbumID: 1, FilePath: "songs/1/a.mp3", FileSize: 10, Status: model.StatusPublished}
+	album := &model.Album{ID: 1, ReleaseDate: time.Now().Add(time.Hour), Status: model.StatusPublished}
+	service := NewStreamService(newSongRepo(song), newAlbumRepo(album), newArtistRepo(), newEditPermissionRepo(), nil, newTestStorage(t), testStreamConfig, zap.NewNop().Sugar())
+
+	_, err := service.Open(context.Background(), 5, 0)
+	assert.Equal(t, er.ErrSongNotExists, err)
+
+	got, err := service.Open(context.Background(), 5, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, song, got)
+}
+
+func TestStreamRecordPlay(t *testing.T) {
+	var plays []uint
+	var since time.Time
+	profileRepo := &mocks.MockProfileRepo{
+		AddPlayFunc: func(ctx context.Context, userID, songID uint, after time.Time) (bool, error) {
+			plays = append(plays, userID)
+			since = after
+			return userID != 3, nil // У пользователя 3 приватная сессия
+		},
+	}
+	service := NewStreamService(nil, nil, nil, nil, profileRepo, nil, testStreamConfig, zap.NewNop().Sugar())
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	song := &model.Song{ID: 5, FileSize: 1 << 20, Duration: 200}
+
+	service.RecordPlay(context.Background(), song, 0, 0, song.FileSize)
+	service.RecordPlay(context.Background(), song, 2, 0, song.FileSize)
+	service.RecordPlay(context.Background(), song, 3, 0, song.FileSize)
+	assert.Equal(t, []uint{2, 3}, plays)
+	// Повтор раньше, чем песня могла доиграть, отсеивает репозиторий
+	assert.Equal(t, now.Add(-200*time.Second), since)
+
+	// Проверка плеера и перемотка не считаются прослушиванием
+	service.RecordPlay(context.Background(), song, 2, 0, 2)
+	service.RecordPlay(context.Background(), song, 2, 4096, song.FileSize-4096)
+	assert.Len(t, plays, 2)
+
+	// Файл меньше порога отдан целиком
+	small := &model.Song{ID: 6, FileSize: 100}
+	service.RecordPlay(context.Background(), small, 2, 0, 100)
+	assert.Len(t, plays, 3)
+	assert.Equal(t, now.Add(-defaultPlayWindow), since)
+}
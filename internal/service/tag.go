@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"music-lib/pkg/tagname"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TagService ведет пользовательские метки песен и альбомов.
+// Видимость самой записи проверяет вызывающий, сервис работает только с голосами.
+type TagService struct {
+	tagRepo repository.ITagRepository
+
+	logger *zap.SugaredLogger
+}
+
+func NewTagService(tag repository.ITagRepository, logger *zap.SugaredLogger) *TagService {
+	return &TagService{
+		tagRepo: tag,
+		logger:  logger,
+	}
+}
+
+// Vote ставит метку записи или меняет голос пользователя за нее: 1 - метка подходит, -1 - нет
+func (s *TagService) Vote(ctx context.Context, resource model.Resource, id, userID uint, tag string, value int) error {
+	if err := checkTagResource(resource); err != nil {
+		return err
+	}
+	if value != 1 && value != -1 {
+		return er.ErrTagVote
+	}
+	name, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tagRepo.Vote(ctx, resource, id, userID, name, value); err != nil {
+		s.logger.Errorw("Failed to vote for tag",
+			"resource", resource,
+			"id", id,
+			"tag", name,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Tag vote saved",
+		"resource", resource,
+		"id", id,
+		"user id", userID,
+		"tag", name,
+		"vote", value,
+	)
+	return nil
+}
+
+// Unvote отзывает голос пользователя за метку
+func (s *TagService) Unvote(ctx context.Context, resource model.Resource, id, userID uint, tag string) error {
+	if err := checkTagResource(resource); err != nil {
+		return err
+	}
+	name, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tagRepo.Unvote(ctx, resource, id, userID, name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return er.ErrTagVoteNotExists
+		}
+		s.logger.Errorw("Failed to remove tag vote",
+			"resource", resource,
+			"id", id,
+			"tag", name,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// GetTags возвращает метки записи с весами и голосом viewerID, 0 - анонимный
+func (s *TagService) GetTags(ctx context.Context, resource model.Resource, id, viewerID uint) ([]model.TagWeight, error) {
+	if err := checkTagResource(resource); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.GetWeights(ctx, resource, id, viewerID)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return tags, nil
+}
+
+// GetCloud возвращает самые распространенные метки песен или альбомов
+func (s *TagService) GetCloud(ctx context.Context, resource model.Resource, limit int) ([]model.TagWeight, error) {
+	if err := checkTagResource(resource); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.GetCloud(ctx, resource, limit)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return tags, nil
+}
+
+// NormalizeTag приводит метку к каноническому написанию
+func NormalizeTag(tag string) (string, error) {
+	name, err := tagname.Normalize(tag)
+	if err != nil {
+		return "", er.ErrTagName
+	}
+	return name, nil
+}
+
+func checkTagResource(resource model.Resource) error {
+	if resource != model.SongResource && resource != model.AlbumResource {
+		return er.ErrTagResource
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestTagVote(t *testing.T) {
+	type vote struct {
+		name  string
+		value int
+	}
+	var votes []vote
+	tagRepo := &mocks.MockTagRepo{
+		VoteFunc: func(ctx context.Context, resource model.Resource, resourceID, userID uint, name string, value int) error {
+			votes = append(votes, vote{name, value})
+			return nil
+		},
+	}
+	service := NewTagService(tagRepo, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	assert.Equal(t, er.ErrTagResource, service.Vote(ctx, model.ArtistResource, 1, 2, "chill", 1))
+	assert.Equal(t, er.ErrTagVote, service.Vote(ctx, model.SongResource, 1, 2, "chill", 2))
+	assert.Equal(t, er.ErrTagName, service.Vote(ctx, model.SongResource, 1, 2, "#!", 1))
+	assert.Empty(t, votes)
+
+	// Разные написания сводятся к одной метке
+	assert.NoError(t, service.Vote(ctx, model.SongResource, 1, 2, "#Rainy-Day", 1))
+	assert.NoError(t, service.Vote(ctx, model.AlbumResource, 1, 2, " rainy   day ", -1))
+	assert.Equal(t, []vote{{"rainy day", 1}, {"rainy day", -1}}, votes)
+}
+
+func TestTagUnvote(t *testing.T) {
+	tagRepo := &mocks.MockTagRepo{
+		UnvoteFunc: func(ctx context.Context, resource model.Resource, resourceID, userID uint, name string) error {
+			if name != "chill" {
+				return gorm.ErrRecordNotFound
+			}
+			return nil
+		},
+	}
+	service := NewTagService(tagRepo, zap.NewNop().Sugar())
+
+	assert.NoError(t, service.Unvote(context.Background(), model.SongResource, 1, 2, "Chill"))
+	assert.Equal(t, er.ErrTagVoteNotExists, service.Unvote(context.Background(), model.SongResource, 1, 2, "lo-fi"))
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/er"
+	"music-lib/pkg/imaging"
+	"music-lib/pkg/waveform"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TrashService переносит артистов, альбомы и песни в корзину, восстанавливает их и удаленные жанры
+// и окончательно удаляет по истечении срока хранения
+type TrashService struct {
+	trashRepo  repository.ITrashRepository
+	albumRepo  repository.IAlbumRepository
+	artistRepo repository.IArtistRepository
+	genreRepo  repository.IGenreRepository
+	storage    storage.Storage
+	conf       config.TrashConfig
+	audit      auditLog
+	now        func() time.Time
+
+	logger *zap.SugaredLogger
+}
+
+func NewTrashService(
+	trash repository.ITrashRepository,
+	album repository.IAlbumRepository,
+	artist repository.IArtistRepository,
+	genre repository.IGenreRepository,
+	audit repository.IAuditRepository,
+	store storage.Storage,
+	conf config.TrashConfig,
+	logger *zap.SugaredLogger,
+) *TrashService {
+	return &TrashService{
+		trashRepo:  trash,
+		albumRepo:  album,
+		artistRepo: artist,
+		genreRepo:  genre,
+		storage:    store,
+		conf:       conf,
+		audit:      newAuditLog(audit, logger),
+		now:        time.Now,
+		logger:     logger,
+	}
+}
+
+// Trash переносит запись в корзину вместе с ее альбомами и песнями
+func (s *TrashService) Trash(ctx context.Context, resource model.Resource, id, userID uint) error {
+	if err := s.trashRepo.Trash(ctx, resource, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notExists(resource)
+		}
+		s.logger.Errorw("Failed to move entry to trash",
+			"resource", resource,
+			"id", id,
+			"error", err.Error(),
+		)
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Infow("Entry moved to trash",
+		"resource", resource,
+		"id", id,
+	)
+	s.audit.record(ctx, userID, resource, id, model.AuditDelete, nil, nil)
+	return nil
+}
+
+// Restore возвращает запись из корзины. Песню нельзя восстановить раньше ее альбома, альбом - раньше артиста,
+// поджанр - раньше родителя.
+func (s *TrashService) Restore(ctx context.Context, resource model.Resource, id, userID uint) error {
+	item, err := s.trashRepo.GetByID(ctx, resource, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return er.ErrNotInTrash
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	switch resource {
+	case model.ArtistResource:
+		// У владельца может быть только один артист, восстанавливать может и другой редактор
+		if _, err := s.artistRepo.GetByUserID(ctx, item.UserID); err == nil {
+			return er.ErrArtistLinked
+		}
+	case model.AlbumResource:
+		_, err = s.artistRepo.GetByID(ctx, item.ParentID)
+	case model.SongResource:
+		_, err = s.albumRepo.GetByID(ctx, item.ParentID)
+	case model.GenreResource:
+		if item.ParentID != 0 {
+			_, err = s.genreRepo.GetById(ctx, item.ParentID)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return er.ErrTrashParent
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	if err := s.trashRepo.Restore(ctx, resource, id); err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return er.ErrNotInTrash
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return er.ErrRestoreConflict
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Infow("Entry restored from trash",
+		"resource", resource,
+		"id", id,
+		"user id", userID,
+	)
+	s.audit.record(ctx, userID, resource, id, model.AuditRestore, nil, nil)
+	return nil
+}
+
+// GetTrash возвращает корзину пользователя со сроком окончательного удаления каждой записи
+func (s *TrashService) GetTrash(ctx context.Context, userID uint) ([]model.TrashItem, error) {
+	items, err := s.trashRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.conf.Retention)
+	}
+	return items, nil
+}
+
+// Run очищает корзину каждые interval, пока не отменен ctx
+func (s *TrashService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Purge(ctx); err != nil {
+			s.logger.Errorw("Failed to purge trash",
+				"error", err.Error(),
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge окончательно удаляет записи с истекшим сроком хранения и их файлы
+func (s *TrashService) Purge(ctx context.Context) (*model.PurgedEntries, error) {
+	purged, err := s.trashRepo.Purge(ctx, s.now().Add(-s.conf.Retention))
+	if err != nil {
+		return nil, err
+	}
+
+	// Файлы удаляются после записей: объект без записи лучше, чем запись без файла
+	for _, song := range purged.Songs {
+		if song.FilePath == "" {
+			continue
+		}
+		s.deleteObject(ctx, song.FilePath)
+		for _, spp := range waveform.Resolutions {
+			s.deleteObject(ctx, waveformKey(song.FilePath, spp))
+		}
+	}
+	for _, album := range purged.Albums {
+		if album.CoverArtKey == "" {
+			continue
+		}
+		for _, size := range imaging.ThumbnailSizes {
+			s.deleteObject(ctx, imageStorageKey(imaging.ThumbnailKey(album.CoverArtKey, size)))
+		}
+	}
+
+	if purged.Artists > 0 || len(purged.Albums) > 0 || len(purged.Songs) > 0 {
+		s.logger.Infow("Trash purged",
+			"artists", purged.Artists,
+			"albums", len(purged.Albums),
+			"songs", len(purged.Songs),
+		)
+	}
+	return purged, nil
+}
+
+// Ошибка удаления не прерывает очистку, объект останется в хранилище
+func (s *TrashService) deleteObject(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		s.logger.Errorw("Failed to delete object from storage",
+			"key", key,
+			"error", err.Error(),
+		)
+	}
+}
+
+func notExists(resource model.Resource) error {
+	switch resource {
+	case model.ArtistResource:
+		return er.ErrArtistNotExists
+	case model.AlbumResource:
+		return er.ErrAlbumNotExists
+	case model.GenreResource:
+		return er.ErrGenreNotExists
+	}
+	return er.ErrSongNotExists
+}
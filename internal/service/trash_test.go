@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/er"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var testTrashConfig = config.TrashConfig{Retention: 30 * 24 * time.Hour, PurgeInterval: time.Hour}
+
+func newTrashRepo(items ...*model.TrashItem) *mocks.MockTrashRepo {
+	return &mocks.MockTrashRepo{
+		GetByIDFunc: func(ctx context.Context, resource model.Resource, id uint) (*model.TrashItem, error) {
+			for _, item := range items {
+				if item.Resource == resource && item.ID == id {
+					return item, nil
+				}
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+		RestoreFunc: func(ctx context.Context, resource model.Resource, id uint) error {
+			return nil
+		},
+	}
+}
+
+func TestTrashRestore(t *testing.T) {
+	ctx := context.Background()
+	song := &model.TrashItem{Resource: model.SongResource, ID: 5, ParentID: 1}
+	orphan := &model.TrashItem{Resource: model.SongResource, ID: 6, ParentID: 2}
+	// Артист 7 принадлежит пользователю 3, у которого уже есть другой артист, артист 9 - пользователю 4
+	artist := &model.TrashItem{Resource: model.ArtistResource, ID: 7, UserID: 3}
+	other := &model.TrashItem{Resource: model.ArtistResource, ID: 9, UserID: 4}
+	trashRepo := newTrashRepo(song, orphan, artist, other)
+	artistRepo := newArtistRepo()
+	artistRepo.GetByUserIDFunc = func(ctx context.Context, userID uint) (*model.Artist, error) {
+		if userID == 3 {
+			return &model.Artist{ID: 8, UserID: 3}, nil
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+	service := NewTrashService(trashRepo, newAlbumRepo(&model.Album{ID: 1}), artistRepo, newGenreRepo(), nil, nil, testTrashConfig, zap.NewNop().Sugar())
+
+	assert.NoError(t, service.Restore(ctx, model.SongResource, 5, 2))
+	// Альбом песни сам лежит в корзине
+	assert.Equal(t, er.ErrTrashParent, service.Restore(ctx, model.SongResource, 6, 2))
+	assert.Equal(t, er.ErrNotInTrash, service.Restore(ctx, model.AlbumResource, 1, 2))
+	// Проверяется владелец артиста, а не тот, кто восстанавливает
+	assert.Equal(t, er.ErrArtistLinked, service.Restore(ctx, model.ArtistResource, 7, 2))
+	assert.NoError(t, service.Restore(ctx, model.ArtistResource, 9, 3))
+
+	// Пока песня была в корзине, ее ISRC или номер дорожки заняли
+	trashRepo.RestoreFunc = func(ctx context.Context, resource model.Resource, id uint) error {
+		return &pgconn.PgError{Code: "23505"}
+	}
+	assert.Equal(t, er.ErrRestoreConflict, service.Restore(ctx, model.SongResource, 5, 2))
+}
+
+func TestTrashRestore_Genre(t *testing.T) {
+	ctx := context.Background()
+	subgenre := &model.TrashItem{Resource: model.GenreResource, ID: 6, ParentID: 1}
+	orphan := &model.TrashItem{Resource: model.GenreResource, ID: 7, ParentID: 9}
+	root := &model.TrashItem{Resource: model.GenreResource, ID: 8}
+	service := NewTrashService(newTrashRepo(subgenre, orphan, root), nil, nil, newGenreRepo(), nil, nil, testTrashConfig, zap.NewNop().Sugar())
+
+	assert.NoError(t, service.Restore(ctx, model.GenreResource, 6, 1))
+	assert.NoError(t, service.Restore(ctx, model.GenreResource, 8, 1))
+	// Родитель жанра удален после него
+	assert.Equal(t, er.ErrTrashParent, service.Restore(ctx, model.GenreResource, 7, 1))
+}
+
+func TestTrashGetTrash(t *testing.T) {
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	trashRepo := &mocks.MockTrashRepo{
+		GetByUserIDFunc: func(ctx context.Context, userID uint) ([]model.TrashItem, error) {
+			return []model.TrashItem{{Resource: model.AlbumResource, ID: 1, DeletedAt: deletedAt}}, nil
+		},
+	}
+	service := NewTrashService(trashRepo, nil, nil, nil, nil, nil, testTrashConfig, zap.NewNop().Sugar())
+
+	items, err := service.GetTrash(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, deletedAt.Add(testTrashConfig.Retention), items[0].PurgeAt)
+}
+
+func TestTrashPurge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStorage(t)
+	for _, key := range []string{"songs/1/a.mp3", waveformKey("songs/1/a.mp3", 256), "songs/2/b.mp3"} {
+		assert.NoError(t, store.Put(ctx, key, strings.NewReader("data"), 4, "audio/mpeg"))
+	}
+
+	var before time.Time
+	trashRepo := &mocks.MockTrashRepo{
+		PurgeFunc: func(ctx context.Context, cutoff time.Time) (*model.PurgedEntries, error) {
+			before = cutoff
+			return &model.PurgedEntries{Songs: []model.Song{{ID: 1, FilePath: "songs/1/a.mp3"}, {ID: 3}}}, nil
+		},
+	}
+	service := NewTrashService(trashRepo, nil, nil, nil, nil, store, testTrashConfig, zap.NewNop().Sugar())
+	service.now = func() time.Time { return now }
+
+	purged, err := service.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, purged.Songs, 2)
+	assert.Equal(t, now.Add(-testTrashConfig.Retention), before)
+
+	// Файл и пики удаленной песни убраны, чужой файл остался
+	_, err = store.Stat(ctx, "songs/1/a.mp3")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.Stat(ctx, waveformKey("songs/1/a.mp3", 256))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.Stat(ctx, "songs/2/b.mp3")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,667 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/internal/repository"
+	"music-lib/pkg/audio"
+	"music-lib/pkg/er"
+	"music-lib/pkg/waveform"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type UploadService struct {
+	uploadRepo repository.IUploadRepository
+	songRepo   repository.ISongRepository
+	storage    storage.Storage
+	limits     config.UploadConfig
+	now        func() time.Time
+
+	logger *zap.SugaredLogger
+}
+
+func NewUploadService(
+	upload repository.IUploadRepository,
+	song repository.ISongRepository,
+	store storage.Storage,
+	limits config.UploadConfig,
+	logger *zap.SugaredLogger,
+) *UploadService {
+	return &UploadService{
+		uploadRepo: upload,
+		songRepo:   song,
+		storage:    store,
+		limits:     limits,
+		now:        time.Now,
+		logger:     logger,
+	}
+}
+
+// UploadFile загружает аудиофайл песни одним запросом.
+// Вместе с песней возвращается загрузка, в ней отмечен найденный дубликат.
+func (s *UploadService) UploadFile(ctx context.Context, songID, userID uint, r io.Reader, size int64) (*model.Song, *model.Upload, error) {
+	song, err := s.getSong(ctx, songID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upload, err := s.createUpload(ctx, songID, userID, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	upload.Offset = size
+
+	if _, err := s.finish(ctx, upload, song, r); err != nil {
+		return nil, nil, err
+	}
+	return song, upload, nil
+}
+
+// StageFile загружает файл до создания песни. Извлеченные теги показываются пользователю,
+// а файл привязывается к песне при ее создании по upload_id.
+func (s *UploadService) StageFile(ctx context.Context, userID uint, r io.Reader, size int64) (*model.Upload, *audio.Metadata, error) {
+	upload, err := s.createUpload(ctx, 0, userID, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	upload.Offset = size
+
+	meta, err := s.finish(ctx, upload, nil, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return upload, meta, nil
+}
+
+// CreateUpload начинает возобновляемую загрузку, место под файл резервируется в квоте сразу.
+// Без songID файл после сборки ждет создания песни, как при StageFile.
+func (s *UploadService) CreateUpload(ctx context.Context, songID, userID uint, size int64) (*model.Upload, error) {
+	if songID != 0 {
+		if _, err := s.getSong(ctx, songID); err != nil {
+			return nil, err
+		}
+	}
+	return s.createUpload(ctx, songID, userID, size)
+}
+
+func (s *UploadService) createUpload(ctx context.Context, songID, userID uint, size int64) (*model.Upload, error) {
+	if err := s.checkLimits(ctx, userID, size); err != nil {
+		return nil, err
+	}
+
+	upload, err := s.uploadRepo.Create(ctx, &model.Upload{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		SongID: songID,
+		Size:   size,
+		Status: model.UploadPending,
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to create upload",
+			"song id", songID,
+			"user id", userID,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	return upload, nil
+}
+
+func (s *UploadService) GetUpload(ctx context.Context, uploadID string, userID uint) (*model.Upload, error) {
+	if err := uuid.Validate(uploadID); err != nil {
+		return nil, er.ErrUploadNotExists
+	}
+
+	upload, err := s.uploadRepo.GetByID(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrUploadNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	// Чужие загрузки не раскрываются
+	if upload.UserID != userID {
+		return nil, er.ErrUploadNotExists
+	}
+	return upload, nil
+}
+
+// AppendChunk дописывает часть файла с позиции offset.
+// После получения последнего байта части собираются в итоговый файл песни.
+func (s *UploadService) AppendChunk(ctx context.Context, uploadID string, userID uint, offset int64, r io.Reader, length int64) (*model.Upload, error) {
+	upload, err := s.GetUpload(ctx, uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status != model.UploadPending {
+		return nil, er.ErrUploadNotPending
+	}
+	if offset != upload.Offset {
+		return nil, er.ErrUploadOffset
+	}
+	if length <= 0 {
+		return nil, &er.ValidationError{Message: "chunk must not be empty"}
+	}
+	if offset+length > upload.Size {
+		return nil, er.ErrFileTooLarge
+	}
+
+	// Смещение занимается до записи части: параллельный запрос с тем же смещением
+	// получает 409 и не перезаписывает часть
+	moved, err := s.uploadRepo.MoveOffset(ctx, upload.ID, offset, offset+length, upload.Parts+1)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	if !moved {
+		return nil, er.ErrUploadOffset
+	}
+
+	err = s.storage.Put(ctx, partKey(upload.ID, upload.Parts), r, length, "application/octet-stream")
+	if err != nil {
+		s.logger.Errorw("Failed to store upload chunk",
+			"upload id", upload.ID,
+			"part", upload.Parts,
+			"error", err.Error(),
+		)
+		// Смещение возвращается, чтобы часть можно было отправить повторно
+		if _, moveErr := s.uploadRepo.MoveOffset(ctx, upload.ID, offset+length, offset, upload.Parts); moveErr != nil {
+			s.logger.Errorw("Failed to release upload offset",
+				"upload id", upload.ID,
+				"error", moveErr.Error(),
+			)
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	upload.Offset += length
+	upload.Parts++
+	if upload.Offset < upload.Size {
+		return upload, nil
+	}
+
+	var song *model.Song
+	if upload.SongID != 0 {
+		song, err = s.getSong(ctx, upload.SongID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parts := &partsReader{ctx: ctx, storage: s.storage, uploadID: upload.ID, count: upload.Parts}
+	defer parts.Close()
+
+	_, err = s.finish(ctx, upload, song, parts)
+	s.deleteParts(ctx, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// AbortUpload отменяет незавершенную загрузку и освобождает квоту
+func (s *UploadService) AbortUpload(ctx context.Context, uploadID string, userID uint) error {
+	upload, err := s.GetUpload(ctx, uploadID, userID)
+	if err != nil {
+		return err
+	}
+	if upload.Status != model.UploadPending {
+		return er.ErrUploadNotPending
+	}
+
+	upload.Status = model.UploadAborted
+	if _, err := s.uploadRepo.Update(ctx, upload); err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	s.deleteParts(ctx, upload)
+
+	return nil
+}
+
+// OpenCover открывает обложку из тегов файла, загруженного до создания песни
+func (s *UploadService) OpenCover(ctx context.Context, uploadID string, userID uint) (io.ReadCloser, *storage.ObjectInfo, error) {
+	upload, err := s.GetUpload(ctx, uploadID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if upload.Metadata == nil || !upload.Metadata.HasCover {
+		return nil, nil, er.ErrImageNotExists
+	}
+
+	info, err := s.storage.Stat(ctx, coverKey(upload.ID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, er.ErrImageNotExists
+		}
+		return nil, nil, &er.InternalError{Message: err.Error()}
+	}
+	body, err := s.storage.Get(ctx, coverKey(upload.ID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, er.ErrImageNotExists
+		}
+		return nil, nil, &er.InternalError{Message: err.Error()}
+	}
+
+	if info.ContentType == "" {
+		info.ContentType = upload.Metadata.CoverMimeType
+	}
+	return body, info, nil
+}
+
+// TakeCover читает обложку из тегов загрузки и удаляет ее из хранилища.
+// Вызывается после создания песни из загрузки, без обложки возвращает nil.
+func (s *UploadService) TakeCover(ctx context.Context, uploadID string, userID uint) ([]byte, error) {
+	body, _, err := s.OpenCover(ctx, uploadID, userID)
+	if errors.Is(err, er.ErrImageNotExists) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	s.deleteObject(ctx, coverKey(uploadID))
+	return data, nil
+}
+
+// Run отменяет просроченные загрузки каждые interval, пока не отменен ctx
+func (s *UploadService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Purge(ctx); err != nil {
+			s.logger.Errorw("Failed to purge stale uploads",
+				"error", err.Error(),
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge отменяет загрузки, которые не менялись дольше TTL, освобождает их квоту и удаляет файлы.
+// Возвращает число отмененных загрузок.
+func (s *UploadService) Purge(ctx context.Context) (int, error) {
+	uploads, err := s.uploadRepo.AbortStale(ctx, s.now().Add(-s.limits.TTL))
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range uploads {
+		s.deleteParts(ctx, &uploads[i])
+		s.deleteCover(ctx, &uploads[i])
+		if uploads[i].StorageKey != "" {
+			s.deleteAudio(ctx, uploads[i].StorageKey)
+		}
+	}
+
+	if len(uploads) > 0 {
+		s.logger.Infow("Stale uploads aborted",
+			"uploads", len(uploads),
+		)
+	}
+	return len(uploads), nil
+}
+
+func (s *UploadService) getSong(ctx context.Context, songID uint) (*model.Song, error) {
+	song, err := s.songRepo.GetByID(ctx, songID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, er.ErrSongNotExists
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return song, nil
+}
+
+func (s *UploadService) checkLimits(ctx context.Context, userID uint, size int64) error {
+	if size <= 0 {
+		return &er.ValidationError{Message: "file size must be positive"}
+	}
+	if size > s.limits.MaxFileSize {
+		return er.ErrFileTooLarge
+	}
+
+	used, err := s.uploadRepo.UsedBytes(ctx, userID)
+	if err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+	if used+size > s.limits.UserQuota {
+		s.logger.Debugw("Upload quota exceeded",
+			"user id", userID,
+			"used", used,
+			"size", size,
+		)
+		return er.ErrUploadQuotaExceeded
+	}
+	return nil
+}
+
+// finish сохраняет полученный файл и привязывает его к песне, а без песни оставляет ожидать ее создания.
+// При ошибке загрузка отменяется.
+func (s *UploadService) finish(ctx context.Context, upload *model.Upload, song *model.Song, r io.Reader) (*audio.Metadata, error) {
+	meta, err := s.store(ctx, upload, r)
+	if err == nil {
+		if song != nil {
+			err = s.attach(ctx, upload, song)
+		} else {
+			upload.Status = model.UploadStaged
+			if _, updateErr := s.uploadRepo.Update(ctx, upload); updateErr != nil {
+				s.deleteAudio(ctx, upload.StorageKey)
+				err = &er.InternalError{Message: updateErr.Error()}
+			}
+		}
+	}
+	if err != nil {
+		s.abort(ctx, upload)
+		return nil, err
+	}
+	return meta, nil
+}
+
+// store определяет тип файла по содержимому и пишет его в хранилище, по пути считая SHA-256.
+// Копия во временном файле нужна для чтения тегов: MP4 и Ogg требуют произвольного доступа.
+func (s *UploadService) store(ctx context.Context, upload *model.Upload, r io.Reader) (*audio.Metadata, error) {
+	br := bufio.NewReaderSize(r, audio.SniffLen)
+	header, err := br.Peek(audio.SniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	mime, ok := audio.Sniff(header)
+	if !ok {
+		s.logger.Debugw("Unsupported audio format",
+			"song id", upload.SongID,
+			"upload id", upload.ID,
+		)
+		return nil, er.ErrUnsupportedAudio
+	}
+
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	key := fmt.Sprintf("uploads/%s/audio%s", upload.ID, audio.Extension(mime))
+	if upload.SongID != 0 {
+		key = fmt.Sprintf("songs/%d/%s%s", upload.SongID, upload.ID, audio.Extension(mime))
+	}
+
+	hash := sha256.New()
+	err = s.storage.Put(ctx, key, io.TeeReader(br, io.MultiWriter(hash, tmp)), upload.Size, mime)
+	if err != nil {
+		s.logger.Errorw("Failed to store audio file",
+			"song id", upload.SongID,
+			"key", key,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	upload.StorageKey = key
+	upload.MimeType = mime
+	upload.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	// Без пиков и хеша звука файл остается рабочим, дубликаты тогда ищутся только по хешу файла
+	var waveforms []*waveform.Waveform
+	if audio.CanDecode(mime) {
+		waveforms, upload.AudioHash, err = analyzeAudio(io.NewSectionReader(tmp, 0, upload.Size), mime)
+		if err != nil {
+			s.logger.Debugw("Failed to decode audio",
+				"upload id", upload.ID,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	if err := s.checkDuplicate(ctx, upload); err != nil {
+		s.deleteObject(ctx, key)
+		return nil, err
+	}
+
+	if waveforms != nil {
+		if err := storeWaveforms(ctx, s.storage, key, waveforms); err != nil {
+			s.logger.Errorw("Failed to store waveform",
+				"upload id", upload.ID,
+				"key", key,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	// Файл без читаемых тегов все равно принимается
+	meta, err := audio.ReadMetadata(tmp, upload.Size)
+	if err != nil {
+		s.logger.Debugw("Failed to read audio metadata",
+			"upload id", upload.ID,
+			"error", err.Error(),
+		)
+		return nil, nil
+	}
+	upload.Metadata = toAudioMetadata(meta)
+
+	if meta.Cover != nil && upload.SongID == 0 {
+		s.storeCover(ctx, upload, meta.Cover.Data)
+	}
+
+	return meta, nil
+}
+
+// storeCover сохраняет обложку из тегов файла без песни: после создания песни она может стать
+// обложкой альбома. Тип определяется по содержимому, тип из тегов ничем не проверен.
+func (s *UploadService) storeCover(ctx context.Context, upload *model.Upload, cover []byte) {
+	mimeType := http.DetectContentType(cover)
+	if !strings.HasPrefix(mimeType, "image/") {
+		upload.Metadata.HasCover = false
+		return
+	}
+
+	err := s.storage.Put(ctx, coverKey(upload.ID), bytes.NewReader(cover), int64(len(cover)), mimeType)
+	if err != nil {
+		s.logger.Errorw("Failed to store embedded cover",
+			"upload id", upload.ID,
+			"error", err.Error(),
+		)
+		upload.Metadata.HasCover = false
+		return
+	}
+	upload.Metadata.CoverMimeType = mimeType
+}
+
+// checkDuplicate ищет в каталоге другую песню с тем же файлом или звуком. В режиме warn
+// загрузка продолжается, а найденная песня запоминается в DuplicateOf.
+func (s *UploadService) checkDuplicate(ctx context.Context, upload *model.Upload) error {
+	duplicate, err := s.songRepo.FindDuplicate(ctx, upload.Hash, upload.AudioHash, upload.SongID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	s.logger.Debugw("Duplicate audio uploaded",
+		"upload id", upload.ID,
+		"song id", upload.SongID,
+		"duplicate of", duplicate.ID,
+	)
+	upload.DuplicateOf = duplicate.ID
+	if s.limits.Duplicates == config.DuplicatesWarn {
+		return nil
+	}
+	return &er.DuplicateSongError{SongID: duplicate.ID}
+}
+
+// attach привязывает сохраненный файл к песне. Прежний файл песни удаляется.
+func (s *UploadService) attach(ctx context.Context, upload *model.Upload, song *model.Song) error {
+	previousKey := song.FilePath
+
+	upload.SongID = song.ID
+	upload.Status = model.UploadComplete
+
+	song.FilePath = upload.StorageKey
+	song.FileSize = upload.Size
+	song.FileHash = upload.Hash
+	song.AudioHash = upload.AudioHash
+	song.MimeType = upload.MimeType
+
+	if err := s.uploadRepo.Complete(ctx, upload, song); err != nil {
+		s.logger.Errorw("Failed to attach audio file to song",
+			"song id", song.ID,
+			"upload id", upload.ID,
+			"error", err.Error(),
+		)
+		s.deleteAudio(ctx, upload.StorageKey)
+		return &er.InternalError{Message: err.Error()}
+	}
+
+	if previousKey != "" && previousKey != upload.StorageKey {
+		s.deleteAudio(ctx, previousKey)
+	}
+	return nil
+}
+
+func toAudioMetadata(meta *audio.Metadata) *model.AudioMetadata {
+	return &model.AudioMetadata{
+		Title:          meta.Title,
+		Artist:         meta.Artist,
+		Album:          meta.Album,
+		AlbumArtist:    meta.AlbumArtist,
+		Genre:          meta.Genre,
+		Year:           meta.Year,
+		TrackNumber:    meta.TrackNumber,
+		TrackTotal:     meta.TrackTotal,
+		DiscNumber:     meta.DiscNumber,
+		DiscTotal:      meta.DiscTotal,
+		DurationMs:     meta.Duration.Milliseconds(),
+		SampleRate:     meta.SampleRate,
+		Channels:       meta.Channels,
+		Bitrate:        meta.Bitrate,
+		Lyrics:         meta.Lyrics,
+		LyricsLanguage: meta.LyricsLanguage,
+		HasCover:       meta.Cover != nil,
+	}
+}
+
+// Отмена загрузки после ошибки, квота освобождается
+func (s *UploadService) abort(ctx context.Context, upload *model.Upload) {
+	s.deleteCover(ctx, upload)
+	upload.Status = model.UploadAborted
+	if _, err := s.uploadRepo.Update(ctx, upload); err != nil {
+		s.logger.Errorw("Failed to abort upload",
+			"upload id", upload.ID,
+			"error", err.Error(),
+		)
+	}
+}
+
+func (s *UploadService) deleteCover(ctx context.Context, upload *model.Upload) {
+	if upload.Metadata != nil && upload.Metadata.HasCover {
+		s.deleteObject(ctx, coverKey(upload.ID))
+	}
+}
+
+func (s *UploadService) deleteParts(ctx context.Context, upload *model.Upload) {
+	for i := 0; i < upload.Parts; i++ {
+		s.deleteObject(ctx, partKey(upload.ID, i))
+	}
+}
+
+// Ошибка удаления не прерывает операцию, объект останется в хранилище
+func (s *UploadService) deleteObject(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		s.logger.Errorw("Failed to delete object from storage",
+			"key", key,
+			"error", err.Error(),
+		)
+	}
+}
+
+// Вместе с аудиофайлом удаляются его пики
+func (s *UploadService) deleteAudio(ctx context.Context, key string) {
+	s.deleteObject(ctx, key)
+	for _, spp := range waveform.Resolutions {
+		s.deleteObject(ctx, waveformKey(key, spp))
+	}
+}
+
+func partKey(uploadID string, part int) string {
+	return fmt.Sprintf("uploads/%s/part-%d", uploadID, part)
+}
+
+func coverKey(uploadID string) string {
+	return fmt.Sprintf("uploads/%s/cover", uploadID)
+}
+
+// Последовательное чтение частей загрузки, каждая часть открывается по мере чтения
+type partsReader struct {
+	ctx      context.Context
+	storage  storage.Storage
+	uploadID string
+	count    int
+
+	next    int
+	current io.ReadCloser
+}
+
+func (p *partsReader) Read(b []byte) (int, error) {
+	for {
+		if p.current == nil {
+			if p.next >= p.count {
+				return 0, io.EOF
+			}
+			part, err := p.storage.Get(p.ctx, partKey(p.uploadID, p.next))
+			if err != nil {
+				return 0, err
+			}
+			p.current = part
+			p.next++
+		}
+
+		n, err := p.current.Read(b)
+		if errors.Is(err, io.EOF) {
+			p.current.Close()
+			p.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (p *partsReader) Close() error {
+	if p.current != nil {
+		return p.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"image/color"
+	"io"
+	"music-lib/internal/config"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/internal/service/mocks"
+	"music-lib/pkg/audio"
+	"music-lib/pkg/er"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var testUploadLimits = config.UploadConfig{MaxFileSize: 1 << 20, UserQuota: 2 << 20}
+
+func flacData(size int) []byte {
+	data := make([]byte, size)
+	copy(data, "fLaC")
+	for i := 4; i < size; i++ {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+// Репозиторий загрузок в памяти
+func newUploadRepo(used int64) (*mocks.MockUploadRepo, map[string]*model.Upload) {
+	uploads := map[string]*model.Upload{}
+	return &mocks.MockUploadRepo{
+		CreateFunc: func(ctx context.Context, entity *model.Upload) (*model.Upload, error) {
+			uploads[entity.ID] = entity
+			return entity, nil
+		},
+		UpdateFunc: func(ctx context.Context, entity *model.Upload) (*model.Upload, error) {
+			uploads[entity.ID] = entity
+			return entity, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id string) (*model.Upload, error) {
+			upload, ok := uploads[id]
+			if !ok {
+				return nil, gorm.ErrRecordNotFound
+			}
+			copied := *upload
+			return &copied, nil
+		},
+		MoveOffsetFunc: func(ctx context.Context, id string, from, to int64, parts int) (bool, error) {
+			upload, ok := uploads[id]
+			if !ok || upload.Offset != from || upload.Status != model.UploadPending {
+				return false, nil
+			}
+			upload.Offset, upload.Parts = to, parts
+			return true, nil
+		},
+		UsedBytesFunc: func(ctx context.Context, userID uint) (int64, error) {
+			return used, nil
+		},
+		CompleteFunc: func(ctx context.Context, upload *model.Upload, song *model.Song) error {
+			uploads[upload.ID] = upload
+			return nil
+		},
+		AbortStaleFunc: func(ctx context.Context, before time.Time) ([]model.Upload, error) {
+			var aborted []model.Upload
+			for _, upload := range uploads {
+				active := upload.Status == model.UploadPending || upload.Status == model.UploadStaged
+				if active && upload.UpdatedAt.Before(before) {
+					upload.Status = model.UploadAborted
+					aborted = append(aborted, *upload)
+				}
+			}
+			return aborted, nil
+		},
+	}, uploads
+}
+
+// Репозиторий с одной песней для загрузки; catalogue - другие песни каталога для поиска дубликатов
+func newSongRepo(song *model.Song, catalogue ...*model.Song) *mocks.MockSongRepo {
+	return &mocks.MockSongRepo{
+		GetByIDFunc: func(ctx context.Context, id uint) (*model.Song, error) {
+			if song == nil || id != song.ID {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return song, nil
+		},
+		FindDuplicateFunc: func(ctx context.Context, fileHash, audioHash string, excludeID uint) (*model.Song, error) {
+			for _, other := range catalogue {
+				if other.ID != excludeID && (other.FileHash == fileHash || audioHash != "" && other.AudioHash == audioHash) {
+					return other, nil
+				}
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+	}
+}
+
+func newTestStorage(t *testing.T) *storage.LocalStorage {
+	store, err := storage.NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+	return store
+}
+
+func TestUploadFile_Success(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, uploads := newUploadRepo(0)
+	song := &model.Song{ID: 1}
+	service := NewUploadService(uploadRepo, newSongRepo(song), store, testUploadLimits, logger)
+
+	data := flacData(4096)
+	result, _, err := service.UploadFile(context.Background(), 1, 7, bytes.NewReader(data), int64(len(data)))
+
+	assert.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), result.FileHash)
+	assert.Equal(t, int64(len(data)), result.FileSize)
+	assert.Equal(t, audio.MimeFLAC, result.MimeType)
+	assert.Regexp(t, `^songs/1/[0-9a-f-]{36}\.flac$`, result.FilePath)
+
+	r, err := store.Get(context.Background(), result.FilePath)
+	assert.NoError(t, err)
+	stored, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, data, stored)
+
+	assert.Len(t, uploads, 1)
+	for _, upload := range uploads {
+		assert.Equal(t, model.UploadComplete, upload.Status)
+		assert.Equal(t, uint(7), upload.UserID)
+	}
+}
+
+func TestUploadFile_ReplacesPreviousFile(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, _ := newUploadRepo(0)
+	song := &model.Song{ID: 1}
+	service := NewUploadService(uploadRepo, newSongRepo(song), store, testUploadLimits, logger)
+	ctx := context.Background()
+
+	first, _, err := service.UploadFile(ctx, 1, 7, bytes.NewReader(flacData(100)), 100)
+	assert.NoError(t, err)
+	oldKey := first.FilePath
+
+	second, _, err := service.UploadFile(ctx, 1, 7, bytes.NewReader(flacData(200)), 200)
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldKey, second.FilePath)
+
+	_, err = store.Stat(ctx, oldKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestUploadFile_UnsupportedFormat(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	uploadRepo, uploads := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(&model.Song{ID: 1}), newTestStorage(t), testUploadLimits, logger)
+
+	data := []byte("<html>not audio</html>")
+	song, _, err := service.UploadFile(context.Background(), 1, 7, bytes.NewReader(data), int64(len(data)))
+
+	assert.Nil(t, song)
+	assert.Equal(t, er.ErrUnsupportedAudio, err)
+	for _, upload := range uploads {
+		assert.Equal(t, model.UploadAborted, upload.Status)
+	}
+}
+
+func TestUploadFile_Limits(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	song := &model.Song{ID: 1}
+
+	uploadRepo, _ := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(song), newTestStorage(t), testUploadLimits, logger)
+	_, _, err := service.UploadFile(context.Background(), 1, 7, bytes.NewReader(nil), testUploadLimits.MaxFileSize+1)
+	assert.Equal(t, er.ErrFileTooLarge, err)
+
+	uploadRepo, _ = newUploadRepo(testUploadLimits.UserQuota - 10)
+	service = NewUploadService(uploadRepo, newSongRepo(song), newTestStorage(t), testUploadLimits, logger)
+	_, _, err = service.UploadFile(context.Background(), 1, 7, bytes.NewReader(flacData(100)), 100)
+	assert.Equal(t, er.ErrUploadQuotaExceeded, err)
+}
+
+func TestUploadFile_SongNotExists(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	uploadRepo, _ := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(&model.Song{ID: 1}), newTestStorage(t), testUploadLimits, logger)
+
+	_, _, err := service.UploadFile(context.Background(), 2, 7, bytes.NewReader(flacData(100)), 100)
+
+	assert.Equal(t, er.ErrSongNotExists, err)
+}
+
+func TestResumableUpload(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, _ := newUploadRepo(0)
+	song := &model.Song{ID: 1}
+	service := NewUploadService(uploadRepo, newSongRepo(song), store, testUploadLimits, logger)
+	ctx := context.Background()
+
+	data := flacData(1000)
+	upload, err := service.CreateUpload(ctx, 1, 7, int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, model.UploadPending, upload.Status)
+
+	// Часть с неверным смещением отклоняется
+	_, err = service.AppendChunk(ctx, upload.ID, 7, 10, bytes.NewReader(data[:300]), 300)
+	assert.Equal(t, er.ErrUploadOffset, err)
+
+	upload, err = service.AppendChunk(ctx, upload.ID, 7, 0, bytes.NewReader(data[:300]), 300)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), upload.Offset)
+	assert.Equal(t, model.UploadPending, upload.Status)
+
+	// Чужая загрузка не видна
+	_, err = service.GetUpload(ctx, upload.ID, 8)
+	assert.Equal(t, er.ErrUploadNotExists, err)
+
+	upload, err = service.AppendChunk(ctx, upload.ID, 7, 300, bytes.NewReader(data[300:]), 700)
+	assert.NoError(t, err)
+	assert.Equal(t, model.UploadComplete, upload.Status)
+	assert.Equal(t, song.FilePath, upload.StorageKey)
+
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), song.FileHash)
+
+	r, err := store.Get(ctx, song.FilePath)
+	assert.NoError(t, err)
+	stored, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, data, stored)
+
+	// Части удалены после сборки
+	_, err = store.Stat(ctx, partKey(upload.ID, 0))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = service.AppendChunk(ctx, upload.ID, 7, 1000, bytes.NewReader([]byte{0}), 1)
+	assert.Equal(t, er.ErrUploadNotPending, err)
+}
+
+func TestResumableUpload_ChunkExceedsSize(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	uploadRepo, _ := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(&model.Song{ID: 1}), newTestStorage(t), testUploadLimits, logger)
+	ctx := context.Background()
+
+	upload, err := service.CreateUpload(ctx, 1, 7, 100)
+	assert.NoError(t, err)
+
+	_, err = service.AppendChunk(ctx, upload.ID, 7, 0, bytes.NewReader(flacData(200)), 200)
+	assert.Equal(t, er.ErrFileTooLarge, err)
+}
+
+func TestResumableUpload_ConcurrentChunk(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, uploads := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(&model.Song{ID: 1}), store, testUploadLimits, logger)
+	ctx := context.Background()
+
+	upload, err := service.CreateUpload(ctx, 1, 7, 1000)
+	assert.NoError(t, err)
+
+	// Другой запрос дописывает часть после того, как этот прочитал смещение
+	getByID := uploadRepo.GetByIDFunc
+	uploadRepo.GetByIDFunc = func(ctx context.Context, id string) (*model.Upload, error) {
+		read, err := getByID(ctx, id)
+		uploads[id].Offset, uploads[id].Parts = 300, 1
+		return read, err
+	}
+
+	_, err = service.AppendChunk(ctx, upload.ID, 7, 0, bytes.NewReader(flacData(200)), 200)
+	assert.Equal(t, er.ErrUploadOffset, err)
+	assert.Equal(t, int64(300), uploads[upload.ID].Offset)
+	_, err = store.Stat(ctx, partKey(upload.ID, 0))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestAbortUpload(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, uploads := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(&model.Song{ID: 1}), store, testUploadLimits, logger)
+	ctx := context.Background()
+
+	upload, err := service.CreateUpload(ctx, 1, 7, 100)
+	assert.NoError(t, err)
+	_, err = service.AppendChunk(ctx, upload.ID, 7, 0, bytes.NewReader(flacData(50)), 50)
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.AbortUpload(ctx, upload.ID, 7))
+	assert.Equal(t, model.UploadAborted, uploads[upload.ID].Status)
+
+	_, err = store.Stat(ctx, partKey(upload.ID, 0))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestStageFile_ExtractsMetadata(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, uploads := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(nil), store, testUploadLimits, logger)
+
+	// FLAC: STREAMINFO на 10 секунд и Vorbis comment с названием
+	comment := "TITLE=Staged"
+	var data []byte
+	data = append(data, "fLaC"...)
+	data = append(data, 0, 0, 0, 34)
+	streamInfo := make([]byte, 34)
+	copy(streamInfo[10:], []byte{0x0A, 0xC4, 0x42, 0xF0, 0x00, 0x06, 0xBA, 0xA8})
+	data = append(data, streamInfo...)
+	block := []byte{0, 0, 0, 0, 1, 0, 0, 0, byte(len(comment)), 0, 0, 0}
+	block = append(block, comment...)
+	data = append(data, 0x84, 0, 0, byte(len(block)))
+	data = append(data, block...)
+
+	upload, meta, err := service.StageFile(context.Background(), 7, bytes.NewReader(data), int64(len(data)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.UploadStaged, uploads[upload.ID].Status)
+	assert.Equal(t, uint(0), upload.SongID)
+	assert.Equal(t, "uploads/"+upload.ID+"/audio.flac", upload.StorageKey)
+	assert.Equal(t, "Staged", meta.Title)
+	if assert.NotNil(t, upload.Metadata) {
+		assert.Equal(t, "Staged", upload.Metadata.Title)
+		assert.Equal(t, int64(10000), upload.Metadata.DurationMs)
+	}
+}
+
+func TestStageFile_KeepsCover(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, _ := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(nil), store, testUploadLimits, logger)
+	ctx := context.Background()
+
+	// FLAC: STREAMINFO и блок PICTURE с передней обложкой
+	cover := pngData(t, 64, 64, color.White)
+	picture := binary.BigEndian.AppendUint32(nil, 3)
+	picture = binary.BigEndian.AppendUint32(picture, uint32(len("image/png")))
+	picture = append(picture, "image/png"...)
+	picture = append(picture, make([]byte, 20)...)
+	picture = binary.BigEndian.AppendUint32(picture, uint32(len(cover)))
+	picture = append(picture, cover...)
+	var data []byte
+	data = append(data, "fLaC"...)
+	data = append(data, 0, 0, 0, 34)
+	data = append(data, make([]byte, 34)...)
+	data = append(data, 0x86, 0, byte(len(picture)>>8), byte(len(picture)))
+	data = append(data, picture...)
+
+	upload, _, err := service.StageFile(ctx, 7, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	if assert.NotNil(t, upload.Metadata) {
+		assert.True(t, upload.Metadata.HasCover)
+	}
+
+	body, info, err := service.OpenCover(ctx, upload.ID, 7)
+	if assert.NoError(t, err) {
+		stored, _ := io.ReadAll(body)
+		body.Close()
+		assert.Equal(t, cover, stored)
+		assert.Equal(t, "image/png", info.ContentType)
+	}
+	_, _, err = service.OpenCover(ctx, upload.ID, 8)
+	assert.Equal(t, er.ErrUploadNotExists, err)
+
+	// После создания песни обложка забирается один раз
+	taken, err := service.TakeCover(ctx, upload.ID, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, cover, taken)
+	taken, err = service.TakeCover(ctx, upload.ID, 7)
+	assert.NoError(t, err)
+	assert.Nil(t, taken)
+}
+
+func TestUploadFile_Duplicate(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	ctx := context.Background()
+
+	// Тот же звук с другими тегами: файлы разные, хеш звука один
+	original := wavData(1000, 4096)
+	tagged := append(wavData(1000, 4096), "LIST\x04\x00\x00\x00INFO"...)
+	_, hash, err := analyzeAudio(bytes.NewReader(original), audio.MimeWAV)
+	assert.NoError(t, err)
+	existing := &model.Song{ID: 5, FileHash: "other", AudioHash: hash}
+
+	store := newTestStorage(t)
+	uploadRepo, uploads := newUploadRepo(0)
+	service := NewUploadService(uploadRepo, newSongRepo(&model.Song{ID: 1}, existing), store, testUploadLimits, logger)
+
+	_, _, err = service.UploadFile(ctx, 1, 7, bytes.NewReader(tagged), int64(len(tagged)))
+	var duplicate *er.DuplicateSongError
+	if assert.ErrorAs(t, err, &duplicate) {
+		assert.Equal(t, uint(5), duplicate.SongID)
+	}
+	for _, upload := range uploads {
+		assert.Equal(t, model.UploadAborted, upload.Status)
+		_, err := store.Stat(ctx, upload.StorageKey)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	}
+
+	// В режиме warn файл принимается, дубликат отмечается в загрузке
+	limits := testUploadLimits
+	limits.Duplicates = config.DuplicatesWarn
+	song := &model.Song{ID: 1}
+	uploadRepo, _ = newUploadRepo(0)
+	service = NewUploadService(uploadRepo, newSongRepo(song, existing), store, limits, logger)
+
+	result, upload, err := service.UploadFile(ctx, 1, 7, bytes.NewReader(tagged), int64(len(tagged)))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), upload.DuplicateOf)
+	assert.Equal(t, hash, result.AudioHash)
+
+	// Файл без совпадений принимается без предупреждения
+	_, upload, err = service.UploadFile(ctx, 1, 7, bytes.NewReader(flacData(100)), 100)
+	assert.NoError(t, err)
+	assert.Zero(t, upload.DuplicateOf)
+}
+
+func TestPurgeUploads(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, uploads := newUploadRepo(0)
+	limits := testUploadLimits
+	limits.TTL = time.Hour
+	service := NewUploadService(uploadRepo, newSongRepo(nil), store, limits, logger)
+	ctx := context.Background()
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	pending, err := service.CreateUpload(ctx, 0, 7, 100)
+	assert.NoError(t, err)
+	_, err = service.AppendChunk(ctx, pending.ID, 7, 0, bytes.NewReader(flacData(50)), 50)
+	assert.NoError(t, err)
+	data := flacData(200)
+	staged, _, err := service.StageFile(ctx, 7, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	fresh, err := service.CreateUpload(ctx, 0, 7, 100)
+	assert.NoError(t, err)
+
+	uploads[pending.ID].UpdatedAt = now.Add(-2 * time.Hour)
+	uploads[staged.ID].UpdatedAt = now.Add(-2 * time.Hour)
+	uploads[fresh.ID].UpdatedAt = now
+
+	purged, err := service.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, model.UploadAborted, uploads[pending.ID].Status)
+	assert.Equal(t, model.UploadAborted, uploads[staged.ID].Status)
+	assert.Equal(t, model.UploadPending, uploads[fresh.ID].Status)
+
+	_, err = store.Stat(ctx, partKey(pending.ID, 0))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.Stat(ctx, staged.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/pkg/audio"
+	"music-lib/pkg/er"
+	"music-lib/pkg/waveform"
+	"path"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+type WaveformService struct {
+	storage storage.Storage
+	// Одновременные запросы к файлу без пиков ждут одно декодирование
+	generating singleflight.Group
+
+	logger *zap.SugaredLogger
+}
+
+func NewWaveformService(store storage.Storage, logger *zap.SugaredLogger) *WaveformService {
+	return &WaveformService{
+		storage: store,
+		logger:  logger,
+	}
+}
+
+// Get возвращает пики файла песни для масштаба samplesPerPixel.
+// Для файлов, загруженных до появления волновых форм, пики строятся при первом запросе.
+func (s *WaveformService) Get(ctx context.Context, song *model.Song, samplesPerPixel int) (*waveform.Waveform, error) {
+	if !slices.Contains(waveform.Resolutions, samplesPerPixel) {
+		return nil, er.ErrWaveformResolution
+	}
+	if song.FilePath == "" {
+		return nil, er.ErrSongFileNotExists
+	}
+
+	key := waveformKey(song.FilePath, samplesPerPixel)
+	w, err := s.load(ctx, key)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return w, err
+	}
+
+	_, err, _ = s.generating.Do(song.FilePath, func() (any, error) {
+		// Пики могли сохраниться, пока запрос дожидался своей очереди
+		if _, err := s.storage.Stat(ctx, key); err == nil {
+			return nil, nil
+		}
+		return nil, s.generate(ctx, song)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.load(ctx, key)
+}
+
+func (s *WaveformService) load(ctx context.Context, key string) (*waveform.Waveform, error) {
+	body, err := s.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+
+	var w waveform.Waveform
+	if err := w.UnmarshalBinary(data); err != nil {
+		s.logger.Errorw("Stored waveform is corrupted",
+			"key", key,
+			"error", err.Error(),
+		)
+		return nil, &er.InternalError{Message: err.Error()}
+	}
+	return &w, nil
+}
+
+func (s *WaveformService) generate(ctx context.Context, song *model.Song) error {
+	body, err := s.storage.Get(ctx, song.FilePath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return er.ErrSongFileNotExists
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+	defer body.Close()
+
+	// Тип старых файлов мог не сохраниться, тогда он определяется по содержимому
+	br := bufio.NewReaderSize(body, audio.SniffLen)
+	mime := song.MimeType
+	if mime == "" {
+		header, _ := br.Peek(audio.SniffLen)
+		mime, _ = audio.Sniff(header)
+	}
+	if !audio.CanDecode(mime) {
+		return er.ErrWaveformNotExists
+	}
+
+	waveforms, _, err := analyzeAudio(br, mime)
+	if err == nil {
+		err = storeWaveforms(ctx, s.storage, song.FilePath, waveforms)
+	}
+	if err != nil {
+		s.logger.Errorw("Failed to generate waveform",
+			"song id", song.ID,
+			"key", song.FilePath,
+			"error", err.Error(),
+		)
+		if errors.Is(err, audio.ErrMalformed) || errors.Is(err, audio.ErrUnsupportedFormat) {
+			return er.ErrWaveformNotExists
+		}
+		return &er.InternalError{Message: err.Error()}
+	}
+	return nil
+}
+
+// analyzeAudio декодирует файл один раз: строит пики всех масштабов и считает хеш звука
+func analyzeAudio(r io.Reader, mime string) ([]*waveform.Waveform, string, error) {
+	dec, err := audio.NewDecoder(r, mime)
+	if err != nil {
+		return nil, "", err
+	}
+	hasher := audio.NewPCMHasher(dec)
+	waveforms, err := waveform.Generate(hasher, waveform.Resolutions)
+	if err != nil {
+		return nil, "", err
+	}
+	return waveforms, hasher.Sum(), nil
+}
+
+// storeWaveforms сохраняет пики рядом с аудиофайлом
+func storeWaveforms(ctx context.Context, store storage.Storage, audioKey string, waveforms []*waveform.Waveform) error {
+	for _, w := range waveforms {
+		data, err := w.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		key := waveformKey(audioKey, w.SamplesPerPixel)
+		if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "application/octet-stream"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Пики хранятся рядом с файлом: "songs/1/abc.mp3" -> "songs/1/abc.waveform/1024.dat"
+func waveformKey(audioKey string, samplesPerPixel int) string {
+	return fmt.Sprintf("%s.waveform/%d.dat", strings.TrimSuffix(audioKey, path.Ext(audioKey)), samplesPerPixel)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"music-lib/internal/infrastructure/storage"
+	"music-lib/internal/model"
+	"music-lib/pkg/audio"
+	"music-lib/pkg/er"
+	"music-lib/pkg/waveform"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// 16-битный WAV, моно: samples сэмплов, половина из которых на уровне level
+func wavData(samples int, level int16) []byte {
+	var pcm []byte
+	for i := 0; i < samples; i++ {
+		v := level
+		if i%2 == 1 {
+			v = -level
+		}
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(8000), uint32(16000), uint16(2), uint16(16)} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
+
+func TestWaveform_GeneratedOnUpload(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	store := newTestStorage(t)
+	uploadRepo, _ := newUploadRepo(0)
+	song := &model.Song{ID: 1}
+	uploads := NewUploadService(uploadRepo, newSongRepo(song), store, testUploadLimits, logger)
+	ctx := context.Background()
+
+	data := wavData(3000, 16384)
+	first, _, err := uploads.UploadFile(ctx, 1, 7, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	oldKey := first.FilePath
+	for _, spp := range waveform.Resolutions {
+		_, err := store.Stat(ctx, waveformKey(first.FilePath, spp))
+		assert.NoError(t, err)
+	}
+
+	w, err := NewWaveformService(store, logger).Get(ctx, first, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, 8000, w.SampleRate)
+	assert.Equal(t, 3, w.Length())
+	assert.Equal(t, []int16{-64, 64, -64, 64, -64, 64}, w.Data)
+
+	// Пики прежнего файла удаляются вместе с ним
+	_, _, err = uploads.UploadFile(ctx, 1, 7, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	_, err = store.Stat(ctx, waveformKey(oldKey, 1024))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestWaveform_GeneratedLazily(t *testing.T) {
+	store := newTestStorage(t)
+	service := NewWaveformService(store, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	// Файл загружен до появления пиков, тип не сохранен
+	data := wavData(600, 8192)
+	assert.NoError(t, store.Put(ctx, "songs/2/old.wav", bytes.NewReader(data), int64(len(data)), audio.MimeWAV))
+	song := &model.Song{ID: 2, FilePath: "songs/2/old.wav"}
+
+	w, err := service.Get(ctx, song, 256)
+	assert.NoError(t, err)
+	assert.Equal(t, 256, w.SamplesPerPixel)
+	assert.Equal(t, 3, w.Length())
+
+	_, err = store.Stat(ctx, "songs/2/old.waveform/4096.dat")
+	assert.NoError(t, err)
+}
+
+// countingStorage считает чтения файла песни
+type countingStorage struct {
+	*storage.LocalStorage
+	key   string
+	reads atomic.Int32
+}
+
+func (s *countingStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if key == s.key {
+		s.reads.Add(1)
+	}
+	return s.LocalStorage.Get(ctx, key)
+}
+
+func TestWaveform_GeneratedOnce(t *testing.T) {
+	store := &countingStorage{LocalStorage: newTestStorage(t), key: "songs/3/old.wav"}
+	service := NewWaveformService(store, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	data := wavData(600, 8192)
+	assert.NoError(t, store.Put(ctx, store.key, bytes.NewReader(data), int64(len(data)), audio.MimeWAV))
+	song := &model.Song{ID: 3, FilePath: store.key, MimeType: audio.MimeWAV}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Get(ctx, song, 256)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), store.reads.Load())
+}
+
+func TestWaveform_Errors(t *testing.T) {
+	store := newTestStorage(t)
+	service := NewWaveformService(store, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	_, err := service.Get(ctx, &model.Song{ID: 1, FilePath: "songs/1/a.wav"}, 512)
+	assert.ErrorIs(t, err, er.ErrWaveformResolution)
+
+	_, err = service.Get(ctx, &model.Song{ID: 1}, 1024)
+	assert.ErrorIs(t, err, er.ErrSongFileNotExists)
+
+	_, err = service.Get(ctx, &model.Song{ID: 1, FilePath: "songs/1/missing.wav"}, 1024)
+	assert.ErrorIs(t, err, er.ErrSongFileNotExists)
+
+	// Ogg не декодируется, пиков для него нет
+	data := []byte("OggS\x00\x02 not really vorbis")
+	assert.NoError(t, store.Put(ctx, "songs/1/a.ogg", bytes.NewReader(data), int64(len(data)), audio.MimeOgg))
+	_, err = service.Get(ctx, &model.Song{ID: 1, FilePath: "songs/1/a.ogg", MimeType: audio.MimeOgg}, 1024)
+	assert.ErrorIs(t, err, er.ErrWaveformNotExists)
+}
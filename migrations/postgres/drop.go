@@ -7,19 +7,37 @@ import (
 func DropTables(db *gorm.DB) error {
     tables := []string{
         "song_genres",
+        "tag_votes",
+        "tags",
+        "song_credits",
+        "genre_aliases",
         "genres",
+        "couplet_words",
         "couplets",
         "lyrics",
+        "lyrics_revisions",
+        "uploads",
         "songs",
+        "album_discs",
         "albums",
         "artists",
         "histories",
+        "reviews",
+        "ratings",
+        "comment_likes",
+        "comments",
+        "artist_follows",
+        "user_follows",
+        "feed_items",
         "collection_items",
         "collections",
         "favorites",
         "profiles",
         "users",
         "resource_permission",
+        "audit_entries",
+        "merge_aliases",
+        "reports",
     }
 
     for _, table := range tables {
@@ -6,25 +6,121 @@ import (
 	"gorm.io/gorm"
 )
 
+// Уникальные индексы, которые учитывали записи в корзине. AutoMigrate не меняет условие
+// существующего индекса, поэтому они заменены индексами с суффиксом _active.
+var legacyIndexes = []struct {
+	model any
+	name  string
+}{
+	{&model.Album{}, "idx_album_upc"},
+	{&model.Song{}, "idx_song_isrc"},
+	{&model.Song{}, "idx_song_track"},
+	{&model.Genre{}, "idx_genres_name"},
+}
+
+// Колонки, переименованные после выхода. Переименование до AutoMigrate сохраняет данные.
+var renamedColumns = []struct {
+	model    any
+	old, new string
+}{
+	{&model.Profile{}, "top_artists_visibility", "history_visibility"},
+}
+
 func MigrateTables(db *gorm.DB) error {
+	if err := migrateLyricsKey(db); err != nil {
+		return err
+	}
+
+	for _, column := range renamedColumns {
+		if !db.Migrator().HasColumn(column.model, column.old) || db.Migrator().HasColumn(column.model, column.new) {
+			continue
+		}
+		if err := db.Migrator().RenameColumn(column.model, column.old, column.new); err != nil {
+			return err
+		}
+	}
+
+	if err := autoMigrate(db); err != nil {
+		return err
+	}
+
+	for _, index := range legacyIndexes {
+		if !db.Migrator().HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func autoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		// User
 		&model.User{},
 		// Music
 		&model.Artist{},
 		&model.Album{},
+		&model.AlbumDisc{},
 		&model.Song{},
 		&model.Lyrics{},
 		&model.Couplet{},
+		&model.CoupletWord{},
+		&model.LyricsRevision{},
 		&model.Genre{},
+		&model.GenreAlias{},
 		&model.SongGenre{},
+		&model.Tag{},
+		&model.TagVote{},
+		&model.SongCredit{},
+		&model.Upload{},
 		// Profile
 		&model.Profile{},
 		&model.Favorite{},
 		&model.Collection{},
 		&model.CollectionItem{},
 		&model.History{},
+		&model.Rating{},
+		&model.Review{},
+		&model.Comment{},
+		&model.CommentLike{},
+		&model.ArtistFollow{},
+		&model.UserFollow{},
+		&model.FeedItem{},
 		// Permission
 		&model.ResourcePermission{},
+		// Audit
+		&model.AuditEntry{},
+		// Merge
+		&model.MergeAlias{},
+		// Moderation
+		&model.Report{},
 	)
 }
+
+// migrateLyricsKey переводит lyrics с ключа song_id на собственный id. Раньше у песни была одна
+// версия текста и couplets.lyrics_id хранил id песни. AutoMigrate не меняет первичный ключ,
+// поэтому id заполняется и куплеты перепривязываются здесь, до него.
+func migrateLyricsKey(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Lyrics{}) || db.Migrator().HasColumn(&model.Lyrics{}, "id") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range []string{
+			// bigserial заполняет id существующих строк
+			"ALTER TABLE lyrics ADD COLUMN id bigserial",
+			// Внешний ключ ссылается на старый первичный ключ, AutoMigrate создаст его заново
+			"ALTER TABLE couplets DROP CONSTRAINT IF EXISTS fk_lyrics_couplets",
+			"UPDATE couplets SET lyrics_id = lyrics.id FROM lyrics WHERE couplets.lyrics_id = lyrics.song_id",
+			"ALTER TABLE lyrics DROP CONSTRAINT lyrics_pkey",
+			"ALTER TABLE lyrics ADD PRIMARY KEY (id)",
+		} {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testDB открывает базу из TEST_DSN в отдельной схеме, которая удаляется после теста
+func testDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// search_path задается соединению, поэтому соединение одно
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	require.NoError(t, db.Exec("CREATE SCHEMA "+schema).Error)
+	require.NoError(t, db.Exec("SET search_path TO "+schema).Error)
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+	return db
+}
+
+func TestMigrateTables_LyricsKeyedBySong(t *testing.T) {
+	db := testDB(t)
+
+	// Схема до версий текста: ключ lyrics - song_id, куплеты ссылаются на id песни
+	for _, statement := range []string{
+		"CREATE TABLE lyrics (song_id bigint PRIMARY KEY, offset_ms bigint, created_at timestamptz, updated_at timestamptz)",
+		"CREATE TABLE couplets (id bigserial PRIMARY KEY, lyrics_id bigint, number bigint, text text, start_ms bigint, " +
+			"CONSTRAINT fk_lyrics_couplets FOREIGN KEY (lyrics_id) REFERENCES lyrics(song_id) ON DELETE CASCADE)",
+		"CREATE INDEX idx_couplets_lyrics_id ON couplets (lyrics_id)",
+		"INSERT INTO lyrics (song_id, offset_ms) VALUES (40, 0), (7, 250)",
+		"INSERT INTO couplets (lyrics_id, number, text) VALUES (40, 1, 'forty'), (7, 1, 'seven'), (7, 2, 'seven again')",
+	} {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+
+	require.NoError(t, MigrateTables(db))
+
+	var rows []struct {
+		SongID   uint
+		Language string
+		Kind     string
+		Text     string
+	}
+	require.NoError(t, db.Raw("SELECT lyrics.song_id, lyrics.language, lyrics.kind, couplets.text FROM couplets "+
+		"JOIN lyrics ON lyrics.id = couplets.lyrics_id ORDER BY couplets.id").Scan(&rows).Error)
+	require.Len(t, rows, 3)
+	assert.Equal(t, uint(40), rows[0].SongID)
+	assert.Equal(t, "forty", rows[0].Text)
+	assert.Equal(t, uint(7), rows[1].SongID)
+	assert.Equal(t, uint(7), rows[2].SongID)
+	assert.Equal(t, "und", rows[0].Language)
+	assert.Equal(t, "original", rows[0].Kind)
+
+	// Новая версия текста той же песни получает свой id, удаление версии удаляет ее куплеты
+	require.NoError(t, db.Exec("INSERT INTO lyrics (song_id, language, kind) VALUES (7, 'en', 'translation')").Error)
+	require.NoError(t, db.Exec("DELETE FROM lyrics WHERE song_id = 40").Error)
+	var count int64
+	require.NoError(t, db.Table("couplets").Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	// Повторный запуск ничего не меняет
+	require.NoError(t, MigrateTables(db))
+}
+
+func TestMigrateTables_RenamedColumns(t *testing.T) {
+	db := testDB(t)
+
+	// Профиль с настройкой видимости под прежним именем
+	for _, statement := range []string{
+		"CREATE TABLE profiles (user_id bigserial PRIMARY KEY, " +
+			"top_artists_visibility varchar(20) NOT NULL DEFAULT 'private')",
+		"INSERT INTO profiles (user_id, top_artists_visibility) VALUES (3, 'followers')",
+	} {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+
+	require.NoError(t, MigrateTables(db))
+
+	var visibility string
+	require.NoError(t, db.Raw("SELECT history_visibility FROM profiles WHERE user_id = 3").Scan(&visibility).Error)
+	assert.Equal(t, "followers", visibility)
+	assert.False(t, db.Migrator().HasColumn("profiles", "top_artists_visibility"))
+}
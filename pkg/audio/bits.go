@@ -0,0 +1,55 @@
+package audio
+
+import "io"
+
+// Чтение потока по битам, старший бит байта первый.
+// Ошибка чтения запоминается, дальше читаются нули.
+type bitReader struct {
+	r     io.ByteReader
+	cache uint64
+	n     uint // Число непрочитанных бит в cache
+	err   error
+}
+
+// read возвращает следующие n бит, n не больше 56
+func (b *bitReader) read(n uint) uint64 {
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			if b.err == nil {
+				b.err = err
+			}
+			c = 0
+		}
+		b.cache = b.cache<<8 | uint64(c)
+		b.n += 8
+	}
+	b.n -= n
+	return b.cache >> b.n & (1<<n - 1)
+}
+
+// readSigned читает число в дополнительном коде из n бит
+func (b *bitReader) readSigned(n uint) int64 {
+	if n == 0 {
+		return 0
+	}
+	v := b.read(n)
+	return int64(v<<(64-n)) >> (64 - n)
+}
+
+// unary считает нулевые биты до первой единицы
+func (b *bitReader) unary() uint64 {
+	var count uint64
+	for b.read(1) == 0 {
+		if b.err != nil {
+			return count
+		}
+		count++
+	}
+	return count
+}
+
+// align пропускает биты до границы байта
+func (b *bitReader) align() {
+	b.n -= b.n % 8
+}
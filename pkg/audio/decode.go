@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"bufio"
+	"io"
+)
+
+// Decoder выдает декодированный звук, сведенный в моно
+type Decoder interface {
+	SampleRate() int
+	// Read заполняет buf сэмплами в диапазоне [-1, 1], в конце потока возвращает io.EOF
+	Read(buf []float32) (int, error)
+}
+
+// CanDecode сообщает, умеет ли NewDecoder декодировать данный тип
+func CanDecode(mime string) bool {
+	switch mime {
+	case MimeWAV, MimeFLAC, MimeMP3:
+		return true
+	}
+	return false
+}
+
+// NewDecoder начинает декодирование потока WAV, FLAC или MP3 (Layer III).
+// Декодирование последовательное, произвольный доступ к файлу не нужен.
+func NewDecoder(r io.Reader, mime string) (Decoder, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	switch mime {
+	case MimeWAV:
+		return newWAVDecoder(br)
+	case MimeFLAC:
+		return newFLACDecoder(br)
+	case MimeMP3:
+		return newMP3Decoder(br)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// Буфер декодированных, но еще не прочитанных сэмплов
+type pcmBuffer struct {
+	samples []float32
+	pos     int
+}
+
+// drain копирует накопленные сэмплы в buf
+func (b *pcmBuffer) drain(buf []float32) int {
+	n := copy(buf, b.samples[b.pos:])
+	b.pos += n
+	return n
+}
+
+func (b *pcmBuffer) empty() bool {
+	return b.pos >= len(b.samples)
+}
+
+func (b *pcmBuffer) reset() {
+	b.samples = b.samples[:0]
+	b.pos = 0
+}
+
+// readSamples выдает сэмплы блоками, которые decode декодирует по одному
+func readSamples(b *pcmBuffer, buf []float32, decode func() error) (int, error) {
+	n := 0
+	for n < len(buf) {
+		if b.empty() {
+			b.reset()
+			if err := decode(); err != nil {
+				if n > 0 && err == io.EOF {
+					return n, nil
+				}
+				return n, err
+			}
+			continue
+		}
+		n += b.drain(buf[n:])
+	}
+	return n, nil
+}
@@ -0,0 +1,383 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Назначение каналов в заголовке кадра FLAC
+const (
+	flacLeftSide  = 8
+	flacSideRight = 9
+	flacMidSide   = 10
+)
+
+type flacDecoder struct {
+	r          *bufio.Reader
+	bits       bitReader
+	sampleRate int
+	channels   int
+	bps        int
+
+	block [][]int64 // Сэмплы последнего кадра по каналам
+	pcm   pcmBuffer
+}
+
+func newFLACDecoder(r *bufio.Reader) (*flacDecoder, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != "fLaC" {
+		return nil, ErrMalformed
+	}
+
+	d := &flacDecoder{r: r, bits: bitReader{r: r}}
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, ErrMalformed
+		}
+		last := header[0]&0x80 != 0
+		length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		if header[0]&0x7F == flacStreamInfo {
+			block := make([]byte, length)
+			if _, err := io.ReadFull(r, block); err != nil || length < 18 {
+				return nil, ErrMalformed
+			}
+			d.sampleRate = int(block[10])<<12 | int(block[11])<<4 | int(block[12])>>4
+			d.channels = int(block[12]>>1&0x7) + 1
+			d.bps = int(binary.BigEndian.Uint16(block[12:])>>4&0x1F) + 1
+		} else if _, err := r.Discard(length); err != nil {
+			return nil, ErrMalformed
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if d.sampleRate == 0 {
+		return nil, ErrMalformed
+	}
+	return d, nil
+}
+
+func (d *flacDecoder) SampleRate() int {
+	return d.sampleRate
+}
+
+func (d *flacDecoder) Read(buf []float32) (int, error) {
+	return readSamples(&d.pcm, buf, d.decodeFrame)
+}
+
+// Декодирует очередной кадр и сводит его каналы в моно
+func (d *flacDecoder) decodeFrame() error {
+	if err := d.readFrame(); err != nil {
+		// Обрезанный последний кадр отбрасывается
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF
+		}
+		return err
+	}
+
+	scale := 1 / float64(int64(len(d.block))<<(d.bps-1))
+	for i := range d.block[0] {
+		var sum int64
+		for ch := range d.block {
+			sum += d.block[ch][i]
+		}
+		d.pcm.samples = append(d.pcm.samples, float32(float64(sum)*scale))
+	}
+	return nil
+}
+
+// readFrame декодирует кадр в d.block
+func (d *flacDecoder) readFrame() error {
+	if err := d.sync(); err != nil {
+		return err
+	}
+	b := &d.bits
+
+	blockSizeCode := b.read(4)
+	sampleRateCode := b.read(4)
+	assignment := int(b.read(4))
+	sampleSizeCode := b.read(3)
+	b.read(1)
+
+	// Номер кадра или сэмпла в кодировке, похожей на UTF-8
+	first := b.read(8)
+	for mask := uint64(0x40); first&0x80 != 0 && first&mask != 0; mask >>= 1 {
+		b.read(8)
+	}
+
+	var blockSize int
+	switch {
+	case blockSizeCode == 1:
+		blockSize = 192
+	case blockSizeCode >= 2 && blockSizeCode <= 5:
+		blockSize = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		blockSize = int(b.read(8)) + 1
+	case blockSizeCode == 7:
+		blockSize = int(b.read(16)) + 1
+	case blockSizeCode >= 8:
+		blockSize = 256 << (blockSizeCode - 8)
+	default:
+		return ErrMalformed
+	}
+
+	switch sampleRateCode {
+	case 12:
+		b.read(8)
+	case 13, 14:
+		b.read(16)
+	}
+
+	bps := d.bps
+	switch sampleSizeCode {
+	case 1:
+		bps = 8
+	case 2:
+		bps = 12
+	case 4:
+		bps = 16
+	case 5:
+		bps = 20
+	case 6:
+		bps = 24
+	case 7:
+		bps = 32
+	}
+	d.bps = bps
+
+	channels := assignment + 1
+	if assignment >= flacLeftSide {
+		channels = 2
+	}
+	if assignment > flacMidSide {
+		return ErrMalformed
+	}
+	b.read(8) // CRC-8 заголовка
+
+	if cap(d.block) < channels {
+		d.block = make([][]int64, channels)
+	}
+	d.block = d.block[:channels]
+	for ch := range d.block {
+		if cap(d.block[ch]) < blockSize {
+			d.block[ch] = make([]int64, blockSize)
+		}
+		d.block[ch] = d.block[ch][:blockSize]
+
+		// Канал разности хранится с дополнительным битом
+		channelBPS := bps
+		if assignment == flacLeftSide && ch == 1 || assignment == flacSideRight && ch == 0 ||
+			assignment == flacMidSide && ch == 1 {
+			channelBPS++
+		}
+		if err := d.readSubframe(d.block[ch], channelBPS); err != nil {
+			return err
+		}
+	}
+
+	b.align()
+	b.read(16) // CRC-16 кадра
+	if b.err != nil {
+		if errors.Is(b.err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return b.err
+	}
+
+	left, right := d.block[0], d.block[len(d.block)-1]
+	for i := 0; i < blockSize && channels == 2; i++ {
+		switch assignment {
+		case flacLeftSide:
+			right[i] = left[i] - right[i]
+		case flacSideRight:
+			left[i] += right[i]
+		case flacMidSide:
+			mid := left[i]<<1 | right[i]&1
+			side := right[i]
+			left[i] = (mid + side) >> 1
+			right[i] = (mid - side) >> 1
+		}
+	}
+	return nil
+}
+
+// sync ищет код синхронизации кадра 0xFFF8 или 0xFFF9
+func (d *flacDecoder) sync() error {
+	d.bits.n = 0
+	d.bits.err = nil
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if c != 0xFF {
+			continue
+		}
+		next, err := d.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if next[0]&0xFE == 0xF8 {
+			d.r.ReadByte()
+			return nil
+		}
+	}
+}
+
+func (d *flacDecoder) readSubframe(out []int64, bps int) error {
+	b := &d.bits
+	if b.read(1) != 0 {
+		return ErrMalformed
+	}
+	kind := b.read(6)
+
+	// Младшие нулевые биты, общие для всех сэмплов
+	var wasted uint
+	if b.read(1) == 1 {
+		wasted = uint(b.unary()) + 1
+		bps -= int(wasted)
+	}
+	if bps <= 0 || bps > 33 {
+		return ErrMalformed
+	}
+
+	switch {
+	case kind == 0:
+		value := b.readSigned(uint(bps))
+		for i := range out {
+			out[i] = value
+		}
+	case kind == 1:
+		for i := range out {
+			out[i] = b.readSigned(uint(bps))
+		}
+	case kind >= 8 && kind <= 12:
+		if err := d.readFixed(out, int(kind-8), bps); err != nil {
+			return err
+		}
+	case kind >= 32:
+		if err := d.readLPC(out, int(kind-31), bps); err != nil {
+			return err
+		}
+	default:
+		return ErrMalformed
+	}
+
+	if wasted > 0 {
+		for i := range out {
+			out[i] <<= wasted
+		}
+	}
+	return nil
+}
+
+// Фиксированные предсказатели порядка 0-4
+func (d *flacDecoder) readFixed(out []int64, order, bps int) error {
+	if order > len(out) {
+		return ErrMalformed
+	}
+	for i := 0; i < order; i++ {
+		out[i] = d.bits.readSigned(uint(bps))
+	}
+	if err := d.readResidual(out, order); err != nil {
+		return err
+	}
+
+	for i := order; i < len(out); i++ {
+		switch order {
+		case 1:
+			out[i] += out[i-1]
+		case 2:
+			out[i] += 2*out[i-1] - out[i-2]
+		case 3:
+			out[i] += 3*out[i-1] - 3*out[i-2] + out[i-3]
+		case 4:
+			out[i] += 4*out[i-1] - 6*out[i-2] + 4*out[i-3] - out[i-4]
+		}
+	}
+	return nil
+}
+
+func (d *flacDecoder) readLPC(out []int64, order, bps int) error {
+	b := &d.bits
+	if order > len(out) {
+		return ErrMalformed
+	}
+	for i := 0; i < order; i++ {
+		out[i] = b.readSigned(uint(bps))
+	}
+
+	precision := uint(b.read(4)) + 1
+	shift := b.readSigned(5)
+	if precision == 16 || shift < 0 {
+		return ErrMalformed
+	}
+	coefs := make([]int64, order)
+	for i := range coefs {
+		coefs[i] = b.readSigned(precision)
+	}
+
+	if err := d.readResidual(out, order); err != nil {
+		return err
+	}
+
+	for i := order; i < len(out); i++ {
+		var sum int64
+		for j, c := range coefs {
+			sum += c * out[i-1-j]
+		}
+		out[i] += sum >> shift
+	}
+	return nil
+}
+
+// readResidual читает остатки предсказания в кодах Райса в out[order:]
+func (d *flacDecoder) readResidual(out []int64, order int) error {
+	b := &d.bits
+	paramBits, escape := uint(4), uint64(15)
+	switch b.read(2) {
+	case 0:
+	case 1:
+		paramBits, escape = 5, 31
+	default:
+		return ErrMalformed
+	}
+
+	partitionOrder := uint(b.read(4))
+	partitionSize := len(out) >> partitionOrder
+	if partitionSize < order {
+		return ErrMalformed
+	}
+
+	i := order
+	for p := 0; p < 1<<partitionOrder; p++ {
+		end := (p + 1) * partitionSize
+		// Некоторые кодировщики делят последний неполный блок на неравные разделы
+		if p == 1<<partitionOrder-1 {
+			end = len(out)
+		}
+		param := b.read(paramBits)
+		if param == escape {
+			n := uint(b.read(5))
+			for ; i < end; i++ {
+				out[i] = b.readSigned(n)
+			}
+			continue
+		}
+
+		for ; i < end; i++ {
+			v := b.unary()<<param | b.read(uint(param))
+			out[i] = int64(v>>1) ^ -int64(v&1)
+			if b.err != nil {
+				return io.ErrUnexpectedEOF
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,611 @@
+package audio
+
+import (
+	"bufio"
+	"io"
+	"math"
+)
+
+const (
+	mp3GranuleSize = 576
+	// main_data_begin ссылается не дальше чем на 511 байт назад
+	mp3MaxReservoir = 511
+)
+
+// Тип блока гранулы
+const (
+	mp3BlockNormal = 0
+	mp3BlockStart  = 1
+	mp3BlockShort  = 2
+	mp3BlockStop   = 3
+)
+
+// Параметры гранулы одного канала из side information
+type mp3Granule struct {
+	part23Length     int
+	bigValues        int
+	globalGain       int
+	scalefacCompress int
+	blockType        int
+	mixed            bool
+	tableSelect      [3]int
+	subblockGain     [3]int
+	region0Count     int
+	region1Count     int
+	preflag          bool
+	scalefacScale    bool
+	count1Table      int
+}
+
+type mp3SideInfo struct {
+	mainDataBegin int
+	scfsi         [2][4]bool
+	granules      [2][2]mp3Granule // [гранула][канал]
+}
+
+// Масштабные множители канала. В MPEG-1 часть значений переходит во вторую гранулу (scfsi).
+type mp3Scalefactors struct {
+	long  [22]int
+	short [13][3]int
+	// Для intensity stereo в MPEG-2: значение, означающее недопустимую позицию
+	longMax  [22]int
+	shortMax [13][3]int
+}
+
+// mp3Decoder декодирует MPEG-1/2/2.5 Layer III
+type mp3Decoder struct {
+	r      *bufio.Reader
+	header mp3Frame // Первый кадр: версия и частота остальных должны совпадать
+	frames int
+
+	frame     []byte
+	main      []byte
+	reservoir []byte
+
+	scalefac [2]mp3Scalefactors
+	values   [2][mp3GranuleSize]int
+	xr       [2][mp3GranuleSize]float32
+	overlap  [2][32][18]float32
+	subbands [18][32]float32
+	synth    mp3Synth
+
+	pcm pcmBuffer
+}
+
+func newMP3Decoder(r *bufio.Reader) (*mp3Decoder, error) {
+	d := &mp3Decoder{r: r}
+	d.skipID3()
+
+	// Первый кадр, за которым следует еще один корректный кадр
+	for skipped := 0; skipped < mp3SyncWindow; skipped++ {
+		header, err := r.Peek(4)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		frame, ok := parseMP3Frame(header)
+		if ok {
+			data, err := r.Peek(frame.length + 4)
+			next, nextOK := parseMP3Frame(data[min(frame.length, len(data)):])
+			if err != nil || nextOK && next.sampleRate == frame.sampleRate && next.layer == frame.layer {
+				if frame.layer != 3 {
+					return nil, ErrUnsupportedFormat
+				}
+				d.header = frame
+				return d, nil
+			}
+		}
+		r.Discard(1)
+	}
+	return nil, ErrMalformed
+}
+
+func (d *mp3Decoder) SampleRate() int {
+	return d.header.sampleRate
+}
+
+func (d *mp3Decoder) Read(buf []float32) (int, error) {
+	return readSamples(&d.pcm, buf, d.decodeFrame)
+}
+
+// Пропуск тега ID3v2
+func (d *mp3Decoder) skipID3() {
+	header, _ := d.r.Peek(id3HeaderSize)
+	if size := id3v2Size(header); size > 0 {
+		d.r.Discard(int(size))
+	}
+}
+
+// nextFrame читает следующий кадр в d.frame, пропуская мусор и теги между кадрами
+func (d *mp3Decoder) nextFrame() (mp3Frame, error) {
+	for {
+		header, err := d.r.Peek(4)
+		if err != nil {
+			return mp3Frame{}, io.EOF
+		}
+
+		frame, ok := parseMP3Frame(header)
+		if ok && frame.layer == 3 && frame.mpeg1 == d.header.mpeg1 && frame.sampleRate == d.header.sampleRate {
+			if cap(d.frame) < frame.length {
+				d.frame = make([]byte, frame.length)
+			}
+			d.frame = d.frame[:frame.length]
+			// Обрезанный последний кадр отбрасывается
+			if _, err := io.ReadFull(d.r, d.frame); err != nil {
+				return mp3Frame{}, io.EOF
+			}
+			return frame, nil
+		}
+
+		if string(header[:3]) == "ID3" {
+			d.skipID3()
+			continue
+		}
+		d.r.Discard(1)
+	}
+}
+
+// decodeFrame декодирует кадр в 1152 (MPEG-1) или 576 моно сэмплов
+func (d *mp3Decoder) decodeFrame() error {
+	frame, err := d.nextFrame()
+	if err != nil {
+		return err
+	}
+	d.frames++
+
+	offset := 4
+	if frame.crc {
+		offset += 2
+	}
+	side := mp3Bits{data: d.frame[offset:]}
+	info := d.readSideInfo(&side, frame)
+	mainData := d.frame[min(frame.xingOffset()+frame.crcLen(), len(d.frame)):]
+
+	// Кадр Xing/Info в начале файла содержит только заголовок VBR
+	if offset := frame.xingOffset(); d.frames == 1 && len(d.frame) >= offset+4 {
+		if tag := string(d.frame[offset : offset+4]); tag == "Xing" || tag == "Info" {
+			return nil
+		}
+	}
+
+	granules := 1
+	if frame.mpeg1 {
+		granules = 2
+	}
+
+	// Данных предыдущих кадров нет (начало потока или потерянный кадр): звучит тишина
+	if info.mainDataBegin > len(d.reservoir) {
+		d.fillReservoir(mainData)
+		d.pcm.samples = append(d.pcm.samples, make([]float32, granules*mp3GranuleSize)...)
+		return nil
+	}
+	d.main = append(d.main[:0], d.reservoir[len(d.reservoir)-info.mainDataBegin:]...)
+	d.main = append(d.main, mainData...)
+	d.fillReservoir(mainData)
+
+	bits := mp3Bits{data: d.main}
+	for gr := 0; gr < granules; gr++ {
+		for ch := 0; ch < frame.channels; ch++ {
+			g := &info.granules[gr][ch]
+			sf := &d.scalefac[ch]
+			start := bits.pos
+			if frame.mpeg1 {
+				readMP3Scalefactors(&bits, g, sf, gr, info.scfsi[ch])
+			} else {
+				intensity := frame.jointMode && frame.modeExt&1 != 0 && ch == 1
+				readLSFScalefactors(&bits, g, sf, intensity)
+			}
+
+			bands := mp3BandsFor(frame.sampleRate, g)
+			readMP3Huffman(&bits, g, start+g.part23Length, bands, &d.values[ch])
+			bits.pos = start + g.part23Length
+
+			requantize(g, sf, bands, &d.values[ch], &d.xr[ch])
+		}
+
+		if frame.channels == 2 {
+			d.stereo(frame, &info.granules[gr][1])
+		}
+
+		d.subbands = [18][32]float32{}
+		weight := 1 / float32(frame.channels)
+		for ch := 0; ch < frame.channels; ch++ {
+			g := &info.granules[gr][ch]
+			bands := mp3BandsFor(frame.sampleRate, g)
+			reorderShort(&d.xr[ch], bands)
+			antialias(&d.xr[ch], g)
+			hybridSynthesis(&d.xr[ch], g, &d.overlap[ch], &d.subbands, weight)
+		}
+
+		for t := range d.subbands {
+			d.pcm.samples = d.synth.filter(&d.subbands[t], d.pcm.samples)
+		}
+	}
+	return nil
+}
+
+func (f mp3Frame) crcLen() int {
+	if f.crc {
+		return 2
+	}
+	return 0
+}
+
+// Запоминает конец основных данных кадра для следующих кадров
+func (d *mp3Decoder) fillReservoir(mainData []byte) {
+	d.reservoir = append(d.reservoir, mainData...)
+	if extra := len(d.reservoir) - mp3MaxReservoir; extra > 0 {
+		d.reservoir = append(d.reservoir[:0], d.reservoir[extra:]...)
+	}
+}
+
+func (d *mp3Decoder) readSideInfo(b *mp3Bits, f mp3Frame) *mp3SideInfo {
+	info := &mp3SideInfo{}
+	granules := 1
+	if f.mpeg1 {
+		granules = 2
+		info.mainDataBegin = b.read(9)
+		if f.channels == 1 {
+			b.read(5)
+		} else {
+			b.read(3)
+		}
+		for ch := 0; ch < f.channels; ch++ {
+			for i := range info.scfsi[ch] {
+				info.scfsi[ch][i] = b.bit() == 1
+			}
+		}
+	} else {
+		info.mainDataBegin = b.read(8)
+		b.read(f.channels)
+	}
+
+	for gr := 0; gr < granules; gr++ {
+		for ch := 0; ch < f.channels; ch++ {
+			g := &info.granules[gr][ch]
+			g.part23Length = b.read(12)
+			g.bigValues = b.read(9)
+			g.globalGain = b.read(8)
+			if f.mpeg1 {
+				g.scalefacCompress = b.read(4)
+			} else {
+				g.scalefacCompress = b.read(9)
+			}
+
+			if b.bit() == 1 {
+				g.blockType = b.read(2)
+				g.mixed = b.bit() == 1
+				for i := 0; i < 2; i++ {
+					g.tableSelect[i] = b.read(5)
+				}
+				for i := range g.subblockGain {
+					g.subblockGain[i] = b.read(3)
+				}
+				g.region0Count = 7
+				if g.blockType == mp3BlockShort && !g.mixed {
+					g.region0Count = 8
+				}
+				// Третьего региона нет
+				g.region1Count = 36
+			} else {
+				for i := range g.tableSelect {
+					g.tableSelect[i] = b.read(5)
+				}
+				g.region0Count = b.read(4)
+				g.region1Count = b.read(3)
+			}
+
+			if f.mpeg1 {
+				g.preflag = b.bit() == 1
+			}
+			g.scalefacScale = b.bit() == 1
+			g.count1Table = b.bit()
+		}
+	}
+	return info
+}
+
+func readMP3Scalefactors(b *mp3Bits, g *mp3Granule, sf *mp3Scalefactors, gr int, scfsi [4]bool) {
+	slen := mp3Slen[g.scalefacCompress]
+	if g.blockType == mp3BlockShort {
+		sfb := 0
+		if g.mixed {
+			for ; sfb < 8; sfb++ {
+				sf.long[sfb] = b.read(slen[0])
+			}
+			sfb = 3
+		}
+		for ; sfb < 12; sfb++ {
+			n := slen[0]
+			if sfb >= 6 {
+				n = slen[1]
+			}
+			for w := 0; w < 3; w++ {
+				sf.short[sfb][w] = b.read(n)
+			}
+		}
+		return
+	}
+
+	// Группы полос, для которых scfsi разрешает взять множители первой гранулы
+	groups := [4][2]int{{0, 6}, {6, 11}, {11, 16}, {16, 21}}
+	for i, group := range groups {
+		if gr == 1 && scfsi[i] {
+			continue
+		}
+		n := slen[0]
+		if i >= 2 {
+			n = slen[1]
+		}
+		for sfb := group[0]; sfb < group[1]; sfb++ {
+			sf.long[sfb] = b.read(n)
+		}
+	}
+}
+
+// Масштабные множители MPEG-2 (13818-3, 2.4.3.2). У правого канала при intensity stereo своя кодировка.
+func readLSFScalefactors(b *mp3Bits, g *mp3Granule, sf *mp3Scalefactors, intensity bool) {
+	var slen [4]int
+	var table int
+	sfc := g.scalefacCompress
+	g.preflag = false
+
+	switch {
+	case !intensity && sfc < 400:
+		slen = [4]int{(sfc >> 4) / 5, (sfc >> 4) % 5, (sfc & 15) >> 2, sfc & 3}
+	case !intensity && sfc < 500:
+		sfc -= 400
+		slen = [4]int{(sfc >> 2) / 5, (sfc >> 2) % 5, sfc & 3, 0}
+		table = 1
+	case !intensity:
+		sfc -= 500
+		slen = [4]int{sfc / 3, sfc % 3, 0, 0}
+		g.preflag = true
+		table = 2
+	case sfc>>1 < 180:
+		sfc >>= 1
+		slen = [4]int{sfc / 36, sfc % 36 / 6, sfc % 36 % 6, 0}
+		table = 3
+	case sfc>>1 < 244:
+		sfc = sfc>>1 - 180
+		slen = [4]int{(sfc & 63) >> 4, (sfc & 15) >> 2, sfc & 3, 0}
+		table = 4
+	default:
+		sfc = sfc>>1 - 244
+		slen = [4]int{sfc / 3, sfc % 3, 0, 0}
+		table = 5
+	}
+
+	kind := 0
+	if g.blockType == mp3BlockShort {
+		kind = 1
+		if g.mixed {
+			kind = 2
+		}
+	}
+
+	*sf = mp3Scalefactors{}
+	k := 0
+	for i, count := range mp3LSFBandCounts[table][kind] {
+		for j := 0; j < count; j++ {
+			value, limit := b.read(slen[i]), 1<<slen[i]-1
+			switch {
+			case kind == 0:
+				sf.long[k], sf.longMax[k] = value, limit
+			case kind == 2 && k < 6:
+				sf.long[k], sf.longMax[k] = value, limit
+			default:
+				index := k
+				if kind == 2 {
+					index = k - 6 + 9
+				}
+				sf.short[index/3][index%3], sf.shortMax[index/3][index%3] = value, limit
+			}
+			k++
+		}
+	}
+}
+
+// readMP3Huffman декодирует квантованные значения спектра гранулы до бита end
+func readMP3Huffman(b *mp3Bits, g *mp3Granule, end int, bands []mp3Band, out *[mp3GranuleSize]int) {
+	region1, region2 := mp3GranuleSize, mp3GranuleSize
+	if g.region0Count < len(bands) {
+		region1 = bands[g.region0Count].end
+	}
+	if i := g.region0Count + g.region1Count + 1; i < len(bands) {
+		region2 = bands[i].end
+	}
+
+	bigValues := min(g.bigValues*2, mp3GranuleSize)
+	i := 0
+	for ; i < bigValues; i += 2 {
+		table := g.tableSelect[0]
+		switch {
+		case i >= region2:
+			table = g.tableSelect[2]
+		case i >= region1:
+			table = g.tableSelect[1]
+		}
+		out[i], out[i+1] = decodeMP3Pair(b, table)
+	}
+
+	// Область count1: четверки значений 0 и ±1
+	tree := mp3HuffmanTrees[32+g.count1Table]
+	for i+4 <= mp3GranuleSize && b.pos < end {
+		v := tree.decode(b)
+		quad := [4]int{v >> 3 & 1, v >> 2 & 1, v >> 1 & 1, v & 1}
+		for k := range quad {
+			if quad[k] != 0 && b.bit() == 1 {
+				quad[k] = -1
+			}
+		}
+		// Четверка, вышедшая за конец данных гранулы, отбрасывается
+		if b.pos > end {
+			break
+		}
+		copy(out[i:], quad[:])
+		i += 4
+	}
+	clear(out[i:])
+}
+
+func decodeMP3Pair(b *mp3Bits, table int) (int, int) {
+	tree := table
+	switch {
+	case table >= 24:
+		tree = 24
+	case table >= 16:
+		tree = 16
+	}
+	t, ok := mp3HuffmanTrees[tree]
+	if !ok {
+		return 0, 0
+	}
+
+	v := t.decode(b)
+	x, y := v/t.width, v%t.width
+	linbits := mp3Linbits[table]
+	if linbits > 0 && x == 15 {
+		x += b.read(linbits)
+	}
+	if x != 0 && b.bit() == 1 {
+		x = -x
+	}
+	if linbits > 0 && y == 15 {
+		y += b.read(linbits)
+	}
+	if y != 0 && b.bit() == 1 {
+		y = -y
+	}
+	return x, y
+}
+
+// requantize восстанавливает значения спектра: sign(v) * |v|^(4/3) * 2^(показатель полосы)
+func requantize(g *mp3Granule, sf *mp3Scalefactors, bands []mp3Band, values *[mp3GranuleSize]int, xr *[mp3GranuleSize]float32) {
+	gain := 0.25 * float64(g.globalGain-210)
+	multiplier := 0.5
+	if g.scalefacScale {
+		multiplier = 1
+	}
+
+	for _, band := range bands {
+		var exponent float64
+		if band.window < 0 {
+			scalefac := sf.long[band.sfb]
+			if g.preflag {
+				scalefac += mp3Pretab[band.sfb]
+			}
+			exponent = gain - multiplier*float64(scalefac)
+		} else {
+			exponent = gain - 2*float64(g.subblockGain[band.window]) - multiplier*float64(sf.short[band.sfb][band.window])
+		}
+		scale := math.Exp2(exponent)
+
+		for i := band.start; i < band.end; i++ {
+			v := values[i]
+			switch {
+			case v == 0:
+				xr[i] = 0
+			case v > 0:
+				xr[i] = float32(mp3Pow43(v) * scale)
+			default:
+				xr[i] = float32(-mp3Pow43(-v) * scale)
+			}
+		}
+	}
+}
+
+// Наибольшее значение: 15 плюс 13 бит linbits
+var mp3Pow43Table = func() []float64 {
+	table := make([]float64, 8207)
+	for i := range table {
+		table[i] = math.Pow(float64(i), 4.0/3)
+	}
+	return table
+}()
+
+func mp3Pow43(v int) float64 {
+	if v < len(mp3Pow43Table) {
+		return mp3Pow43Table[v]
+	}
+	return math.Pow(float64(v), 4.0/3)
+}
+
+// Чтение из основных данных кадра, за концом данных читаются нули
+type mp3Bits struct {
+	data []byte
+	pos  int
+}
+
+func (b *mp3Bits) bit() int {
+	i := b.pos >> 3
+	b.pos++
+	if i >= len(b.data) {
+		return 0
+	}
+	return int(b.data[i]>>(7-uint(b.pos-1)&7)) & 1
+}
+
+func (b *mp3Bits) read(n int) int {
+	v := 0
+	for ; n > 0; n-- {
+		v = v<<1 | b.bit()
+	}
+	return v
+}
+
+// Код Хаффмана в виде дерева: узел хранит потомков для бита 0 и 1.
+// Отрицательный потомок - лист со значением -child-1, нулевой - отсутствующая ветвь.
+type mp3HuffmanTree struct {
+	width int
+	nodes [][2]int16
+}
+
+type mp3HuffmanCode struct {
+	width   int
+	codes   []uint16
+	lengths []uint8
+}
+
+var mp3HuffmanTrees = func() map[int]*mp3HuffmanTree {
+	trees := make(map[int]*mp3HuffmanTree, len(mp3HuffmanCodes))
+	for table, code := range mp3HuffmanCodes {
+		trees[table] = code.tree()
+	}
+	return trees
+}()
+
+func (c mp3HuffmanCode) tree() *mp3HuffmanTree {
+	t := &mp3HuffmanTree{width: c.width, nodes: make([][2]int16, 1, 2*len(c.codes))}
+	for value, code := range c.codes {
+		node := 0
+		for bit := int(c.lengths[value]) - 1; bit >= 0; bit-- {
+			branch := code >> bit & 1
+			if bit == 0 {
+				t.nodes[node][branch] = int16(-value - 1)
+				break
+			}
+			next := t.nodes[node][branch]
+			if next == 0 {
+				t.nodes = append(t.nodes, [2]int16{})
+				next = int16(len(t.nodes) - 1)
+				t.nodes[node][branch] = next
+			}
+			node = int(next)
+		}
+	}
+	return t
+}
+
+func (t *mp3HuffmanTree) decode(b *mp3Bits) int {
+	node := 0
+	for {
+		next := t.nodes[node][b.bit()]
+		switch {
+		case next < 0:
+			return int(-next - 1)
+		case next == 0:
+			return 0
+		}
+		node = int(next)
+	}
+}
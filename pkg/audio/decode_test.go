@@ -0,0 +1,297 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeAll(t *testing.T, data []byte, mime string) (Decoder, []float32) {
+	t.Helper()
+	dec, err := NewDecoder(bytes.NewReader(data), mime)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var out []float32
+	buf := make([]float32, 3)
+	for {
+		n, err := dec.Read(buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return dec, out
+		}
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+}
+
+func wavFile(format, channels, sampleRate, bits uint16, data []byte) []byte {
+	fmtChunk := binary.LittleEndian.AppendUint16(nil, format)
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, channels)
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate)*uint32(channels*bits/8))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, channels*bits/8)
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, bits)
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF\x00\x00\x00\x00WAVE")
+	// Посторонний чанк перед fmt пропускается
+	buf.WriteString("JUNK\x03\x00\x00\x00abc\x00")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(fmtChunk)))
+	buf.Write(fmtChunk)
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	var pcm []byte
+	for _, v := range []int16{16384, 0, -32768, -16384, 8192, 8192} {
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+	}
+	dec, samples := decodeAll(t, wavFile(wavFormatPCM, 2, 22050, 16, pcm), MimeWAV)
+	assert.Equal(t, 22050, dec.SampleRate())
+	assert.Equal(t, []float32{0.25, -0.75, 0.25}, samples)
+
+	// 24 бита, моно
+	pcm = []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xC0}
+	_, samples = decodeAll(t, wavFile(wavFormatPCM, 1, 8000, 24, pcm), MimeWAV)
+	assert.Equal(t, []float32{0.5, -0.5}, samples)
+
+	// Float32, обрезанный последний сэмпл отбрасывается
+	pcm = binary.LittleEndian.AppendUint32(nil, math.Float32bits(-0.125))
+	pcm = append(pcm, 0x00, 0x00)
+	_, samples = decodeAll(t, wavFile(wavFormatFloat, 1, 8000, 32, pcm), MimeWAV)
+	assert.Equal(t, []float32{-0.125}, samples)
+
+	_, err := NewDecoder(bytes.NewReader(wavFile(2, 1, 8000, 4, nil)), MimeWAV)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+// Запись потока по битам, старший бит первый
+type bitWriter struct {
+	buf []byte
+	n   uint
+}
+
+func (w *bitWriter) write(v uint64, n uint) {
+	for i := n; i > 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(v>>(i-1)&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+func (w *bitWriter) writeSigned(v int64, n uint) {
+	w.write(uint64(v)&(1<<n-1), n)
+}
+
+func (w *bitWriter) align() {
+	w.n += (8 - w.n%8) % 8
+}
+
+// Остатки одним разделом кода Райса с параметром param
+func (w *bitWriter) writeResidual(residual []int64, param uint) {
+	w.write(0, 2)
+	w.write(0, 4)
+	w.write(uint64(param), 4)
+	for _, r := range residual {
+		v := uint64(r<<1) ^ uint64(r>>63)
+		for q := v >> param; q > 0; q-- {
+			w.write(0, 1)
+		}
+		w.write(1, 1)
+		w.write(v, param)
+	}
+}
+
+func flacHeader(sampleRate, channels, bps int) *bitWriter {
+	w := &bitWriter{}
+	for _, c := range []byte("fLaC") {
+		w.write(uint64(c), 8)
+	}
+	w.write(0x80, 8) // Последний блок, STREAMINFO
+	w.write(34, 24)
+	w.write(4096, 16)
+	w.write(4096, 16)
+	w.write(0, 24)
+	w.write(0, 24)
+	w.write(uint64(sampleRate), 20)
+	w.write(uint64(channels-1), 3)
+	w.write(uint64(bps-1), 5)
+	w.write(0, 36)
+	w.write(0, 64)
+	w.write(0, 64)
+	return w
+}
+
+// Заголовок кадра: размер блока 8 битами, 16 бит на сэмпл
+func flacFrameHeader(w *bitWriter, number, blockSize, assignment int) {
+	w.write(0xFFF8, 16)
+	w.write(6, 4)
+	w.write(0, 4)
+	w.write(uint64(assignment), 4)
+	w.write(4, 3)
+	w.write(0, 1)
+	w.write(uint64(number), 8)
+	w.write(uint64(blockSize-1), 8)
+	w.write(0, 8) // CRC-8 не проверяется
+}
+
+func TestDecodeFLAC(t *testing.T) {
+	w := flacHeader(44100, 2, 16)
+
+	// Mid/side: mid дословно, side фиксированным предсказателем первого порядка
+	flacFrameHeader(w, 0, 4, flacMidSide)
+	w.write(0x01<<1, 8)
+	for _, v := range []int64{500, 1500, -2000, 350} {
+		w.writeSigned(v, 16)
+	}
+	w.write(0x09<<1, 8)
+	w.writeSigned(1000, 17)
+	w.writeResidual([]int64{0, -3000, 2100}, 11)
+	w.align()
+	w.write(0, 16)
+
+	// Left/side: left LPC первого порядка, side - константа
+	flacFrameHeader(w, 1, 3, flacLeftSide)
+	w.write(0x20<<1, 8)
+	w.writeSigned(-100, 16)
+	w.write(3, 4) // Точность коэффициентов 4 бита
+	w.write(0, 5) // Сдвиг
+	w.writeSigned(1, 4)
+	w.writeResidual([]int64{200, -50}, 4)
+	w.write(0, 8)
+	w.writeSigned(-100, 17)
+	w.align()
+	w.write(0, 16)
+
+	dec, samples := decodeAll(t, w.buf, MimeFLAC)
+	assert.Equal(t, 44100, dec.SampleRate())
+
+	// Левый канал: 1000, 2000, -3000, 400, -100, 100, 50; правый: 0, 1000, -1000, 300, 0, 200, 150
+	want := []float32{500, 1500, -2000, 350, -50, 150, 100}
+	for i := range want {
+		want[i] /= 1 << 15
+	}
+	assert.Equal(t, want, samples)
+
+	_, err := NewDecoder(bytes.NewReader([]byte("fLaX")), MimeFLAC)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestMP3HuffmanTrees(t *testing.T) {
+	for table, code := range mp3HuffmanCodes {
+		tree := mp3HuffmanTrees[table]
+		for value, c := range code.codes {
+			w := &bitWriter{}
+			w.write(uint64(c), uint(code.lengths[value]))
+			b := &mp3Bits{data: w.buf}
+			assert.Equal(t, value, tree.decode(b), "table %d", table)
+			assert.Equal(t, int(code.lengths[value]), b.pos, "table %d", table)
+		}
+	}
+}
+
+func TestDecodeMP3_Silence(t *testing.T) {
+	data := append(id3Tag(id3Frame("TIT2", []byte("\x00Song"))), mp3Frames(3)...)
+	// Мусор между кадрами пропускается
+	data = append(data[:len(data)-mp3FrameLength], append([]byte{0x00, 0xFF}, data[len(data)-mp3FrameLength:]...)...)
+
+	dec, samples := decodeAll(t, data, MimeMP3)
+	assert.Equal(t, 44100, dec.SampleRate())
+	assert.Len(t, samples, 3*1152)
+	for _, v := range samples {
+		assert.Zero(t, v)
+	}
+
+	_, err := NewDecoder(bytes.NewReader([]byte("not an mp3 file")), MimeMP3)
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	_, err = NewDecoder(bytes.NewReader(nil), MimeOgg)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestMP3SynthesisReconstructs(t *testing.T) {
+	// Анализ банком фильтров кодера (окно C = D/32) и синтез восстанавливают сигнал с задержкой 481 сэмпл
+	const delay = 481
+	input := make([]float64, 32*100)
+	for i := range input {
+		input[i] = math.Sin(float64(i)*0.05) + 0.3*math.Sin(float64(i)*1.3)
+	}
+
+	var x [512]float64
+	var s mp3Synth
+	var out []float32
+	for frame := 0; frame < len(input)/32; frame++ {
+		copy(x[32:], x[:480])
+		for i := 0; i < 32; i++ {
+			x[31-i] = input[frame*32+i]
+		}
+		var y [64]float64
+		for i := range y {
+			for j := 0; j < 8; j++ {
+				y[i] += float64(mp3SynthWindow[i+64*j]) / 32 * x[i+64*j]
+			}
+		}
+		var subbands [32]float32
+		for k := range subbands {
+			var sum float64
+			for i := range y {
+				sum += math.Cos(float64((2*k+1)*(i-16))*math.Pi/64) * y[i]
+			}
+			subbands[k] = float32(sum)
+		}
+		out = s.filter(&subbands, out)
+	}
+
+	for i := 1000; i < len(out); i++ {
+		assert.InDelta(t, input[i-delay], out[i], 1e-3)
+	}
+}
+
+func TestPCMHasher(t *testing.T) {
+	hashOf := func(data []byte, mime string) string {
+		dec, err := NewDecoder(bytes.NewReader(data), mime)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		hasher := NewPCMHasher(dec)
+		buf := make([]float32, 5)
+		for {
+			if _, err := hasher.Read(buf); err != nil {
+				assert.ErrorIs(t, err, io.EOF)
+				return hasher.Sum()
+			}
+		}
+	}
+
+	// Тот же звук во FLAC: один кадр моно, сэмплы дословно
+	w := flacHeader(44100, 1, 16)
+	flacFrameHeader(w, 0, 4, 0)
+	w.write(0x01<<1, 8)
+	samples := []int16{500, 1500, -2000, 350}
+	var pcm []byte
+	for _, v := range samples {
+		w.writeSigned(int64(v), 16)
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+	}
+	w.align()
+	w.write(0, 16)
+
+	wav := hashOf(wavFile(wavFormatPCM, 1, 44100, 16, pcm), MimeWAV)
+	assert.Equal(t, wav, hashOf(w.buf, MimeFLAC))
+
+	// Другая частота дискретизации меняет хеш
+	assert.NotEqual(t, wav, hashOf(wavFile(wavFormatPCM, 1, 22050, 16, pcm), MimeWAV))
+}
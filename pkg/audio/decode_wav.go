@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// Коды формата в чанке fmt
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+type wavDecoder struct {
+	r          *bufio.Reader
+	sampleRate int
+	channels   int
+	width      int // Байт на сэмпл одного канала
+	float      bool
+	remaining  int64 // Непрочитанные байты чанка data, -1 - до конца файла
+	frame      []byte
+}
+
+func newWAVDecoder(r *bufio.Reader) (*wavDecoder, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:4]) != "RIFF" || string(header[8:]) != "WAVE" {
+		return nil, ErrMalformed
+	}
+
+	d := &wavDecoder{r: r}
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, ErrMalformed
+		}
+		id := string(chunk[:4])
+		length := int64(binary.LittleEndian.Uint32(chunk[4:]))
+
+		switch id {
+		case "fmt ":
+			if length < 16 || length > 1024 {
+				return nil, ErrMalformed
+			}
+			format := make([]byte, length+length%2)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return nil, ErrMalformed
+			}
+			if err := d.readFormat(format); err != nil {
+				return nil, err
+			}
+			continue
+		case "data":
+			if d.channels == 0 {
+				return nil, ErrMalformed
+			}
+			// Размер может быть не записан при потоковой записи
+			d.remaining = length
+			if length == 0 || length == 0xFFFFFFFF {
+				d.remaining = -1
+			}
+			d.frame = make([]byte, d.channels*d.width)
+			return d, nil
+		}
+
+		// Чанки выровнены по двум байтам
+		if _, err := r.Discard(int(length + length%2)); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+}
+
+func (d *wavDecoder) readFormat(chunk []byte) error {
+	format := binary.LittleEndian.Uint16(chunk)
+	d.channels = int(binary.LittleEndian.Uint16(chunk[2:]))
+	d.sampleRate = int(binary.LittleEndian.Uint32(chunk[4:]))
+	bits := int(binary.LittleEndian.Uint16(chunk[14:]))
+
+	// В WAVE_FORMAT_EXTENSIBLE настоящий формат - первые два байта GUID подтипа
+	if format == wavFormatExtensible && len(chunk) >= 26 {
+		format = binary.LittleEndian.Uint16(chunk[24:])
+	}
+
+	d.width = (bits + 7) / 8
+	switch {
+	case d.channels == 0 || d.sampleRate == 0:
+		return ErrMalformed
+	case format == wavFormatPCM && d.width >= 1 && d.width <= 4:
+	case format == wavFormatFloat && (d.width == 4 || d.width == 8):
+		d.float = true
+	default:
+		return ErrUnsupportedFormat
+	}
+	return nil
+}
+
+func (d *wavDecoder) SampleRate() int {
+	return d.sampleRate
+}
+
+func (d *wavDecoder) Read(buf []float32) (int, error) {
+	n := 0
+	for n < len(buf) {
+		if d.remaining >= 0 && d.remaining < int64(len(d.frame)) {
+			break
+		}
+		if _, err := io.ReadFull(d.r, d.frame); err != nil {
+			// Обрезанный последний кадр отбрасывается
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return n, err
+		}
+		if d.remaining > 0 {
+			d.remaining -= int64(len(d.frame))
+		}
+
+		var sum float64
+		for ch := 0; ch < d.channels; ch++ {
+			sum += d.sample(d.frame[ch*d.width:])
+		}
+		buf[n] = float32(sum / float64(d.channels))
+		n++
+	}
+
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Сэмпл одного канала, приведенный к [-1, 1]
+func (d *wavDecoder) sample(b []byte) float64 {
+	if d.float {
+		if d.width == 8 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+
+	switch d.width {
+	case 1:
+		// 8-битный PCM беззнаковый
+		return float64(int(b[0])-128) / 128
+	case 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case 3:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
@@ -0,0 +1,177 @@
+package audio
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// Типы блоков метаданных FLAC
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+func readFLAC(r io.ReaderAt, size int64, meta *Metadata) error {
+	offset := int64(4) // "fLaC"
+	for {
+		header, err := readAt(r, offset, 4, size)
+		if err != nil {
+			return err
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		offset += 4
+
+		switch blockType {
+		case flacStreamInfo, flacVorbisComment, flacPicture:
+			block, err := readAt(r, offset, length, size)
+			if err != nil {
+				return err
+			}
+			switch blockType {
+			case flacStreamInfo:
+				readStreamInfo(block, meta)
+			case flacVorbisComment:
+				readVorbisComments(block, meta)
+			case flacPicture:
+				if picture, pictureType := parseFLACPicture(block); picture != nil &&
+					(meta.Cover == nil || pictureType == pictureFrontCover) {
+					meta.Cover = picture
+				}
+			}
+		}
+
+		offset += length
+		if last {
+			return nil
+		}
+	}
+}
+
+// STREAMINFO: 20 бит частоты, 3 бита каналов, 5 бит разрядности, 36 бит числа сэмплов
+func readStreamInfo(block []byte, meta *Metadata) {
+	if len(block) < 18 {
+		return
+	}
+	sampleRate := int(block[10])<<12 | int(block[11])<<4 | int(block[12])>>4
+	channels := int(block[12]>>1&0x7) + 1
+	samples := uint64(block[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(block[14:18]))
+
+	meta.SampleRate = sampleRate
+	meta.Channels = channels
+	meta.Duration = durationOf(samples, sampleRate)
+}
+
+// Блок PICTURE (тот же формат используется в METADATA_BLOCK_PICTURE у Ogg)
+func parseFLACPicture(block []byte) (*Picture, byte) {
+	read := func() ([]byte, bool) {
+		if len(block) < 4 {
+			return nil, false
+		}
+		n := binary.BigEndian.Uint32(block)
+		if uint64(n) > uint64(len(block)-4) {
+			return nil, false
+		}
+		value := block[4 : 4+n]
+		block = block[4+n:]
+		return value, true
+	}
+
+	if len(block) < 4 {
+		return nil, 0
+	}
+	pictureType := binary.BigEndian.Uint32(block)
+	block = block[4:]
+
+	mime, ok := read()
+	if !ok {
+		return nil, 0
+	}
+	description, ok := read()
+	if !ok {
+		return nil, 0
+	}
+	// Ширина, высота, глубина цвета, размер палитры
+	if len(block) < 16 {
+		return nil, 0
+	}
+	block = block[16:]
+	data, ok := read()
+	if !ok || len(data) == 0 {
+		return nil, 0
+	}
+
+	return &Picture{
+		MimeType:    strings.ToLower(string(mime)),
+		Description: string(description),
+		Data:        data,
+	}, byte(pictureType)
+}
+
+// Vorbis comments: длины little-endian, строки вида KEY=value
+func readVorbisComments(block []byte, meta *Metadata) {
+	read := func() (string, bool) {
+		if len(block) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(block)
+		if uint64(n) > uint64(len(block)-4) {
+			return "", false
+		}
+		value := string(block[4 : 4+n])
+		block = block[4+n:]
+		return value, true
+	}
+
+	// Строка вендора
+	if _, ok := read(); !ok {
+		return
+	}
+	if len(block) < 4 {
+		return
+	}
+	count := binary.LittleEndian.Uint32(block)
+	block = block[4:]
+
+	var coverMime string
+	var legacyCover []byte
+	for i := uint32(0); i < count; i++ {
+		comment, ok := read()
+		if !ok {
+			return
+		}
+		key, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+
+		switch strings.ToUpper(key) {
+		case "METADATA_BLOCK_PICTURE":
+			data, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				continue
+			}
+			if picture, pictureType := parseFLACPicture(data); picture != nil &&
+				(meta.Cover == nil || pictureType == pictureFrontCover) {
+				meta.Cover = picture
+			}
+		case "COVERART":
+			legacyCover, _ = base64.StdEncoding.DecodeString(value)
+		case "COVERARTMIME":
+			coverMime = value
+		default:
+			meta.setTag(key, value)
+		}
+	}
+
+	if meta.Cover == nil && len(legacyCover) > 0 {
+		if coverMime == "" {
+			coverMime = "image/jpeg"
+		}
+		meta.Cover = &Picture{MimeType: coverMime, Data: legacyCover}
+	}
+}
@@ -0,0 +1,387 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	id3HeaderSize = 10
+	id3v1Size     = 128
+)
+
+// Тип изображения APIC "обложка"
+const pictureFrontCover = 3
+
+// Жанры ID3v1 (0-79 из спецификации, 80-125 - расширения Winamp)
+var id3v1Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychadelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+	"Folk", "Folk-Rock", "National Folk", "Swing", "Fast Fusion", "Bebob", "Latin", "Revival",
+	"Celtic", "Bluegrass", "Avantgarde", "Gothic Rock", "Progressive Rock", "Psychedelic Rock", "Symphonic Rock", "Slow Rock",
+	"Big Band", "Chorus", "Easy Listening", "Acoustic", "Humour", "Speech", "Chanson", "Opera",
+	"Chamber Music", "Sonata", "Symphony", "Booty Bass", "Primus", "Porn Groove", "Satire", "Slow Jam",
+	"Club", "Tango", "Samba", "Folklore", "Ballad", "Power Ballad", "Rhythmic Soul", "Freestyle",
+	"Duet", "Punk Rock", "Drum Solo", "A capella", "Euro-House", "Dance Hall",
+}
+
+func id3v1Genre(index int) string {
+	if index >= 0 && index < len(id3v1Genres) {
+		return id3v1Genres[index]
+	}
+	return ""
+}
+
+// Размер тега ID3v2 вместе с заголовком и футером, 0 если тега нет
+func id3v2Size(header []byte) int64 {
+	if len(header) < id3HeaderSize || !bytes.HasPrefix(header, []byte("ID3")) {
+		return 0
+	}
+	size := int64(syncsafe(header[6:10])) + id3HeaderSize
+	if header[5]&0x10 != 0 {
+		size += id3HeaderSize
+	}
+	return size
+}
+
+func syncsafe(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<7 | uint32(c&0x7F)
+	}
+	return v
+}
+
+// Удаление unsynchronisation: после 0xFF вставлен лишний 0x00
+func removeUnsync(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0x00 {
+			i++
+		}
+	}
+	return out
+}
+
+// readID3v2 разбирает тег ID3v2.2-2.4 и заполняет метаданные.
+// tlen - длительность из фрейма TLEN, если он есть.
+func readID3v2(tag []byte, meta *Metadata) (tlen time.Duration) {
+	if len(tag) < id3HeaderSize {
+		return 0
+	}
+	version := tag[3]
+	flags := tag[5]
+	body := tag[id3HeaderSize:]
+	if size := int(syncsafe(tag[6:10])); size < len(body) {
+		body = body[:size]
+	}
+	if version < 2 || version > 4 {
+		return 0
+	}
+
+	if flags&0x80 != 0 && version < 4 {
+		body = removeUnsync(body)
+	}
+
+	// Расширенный заголовок
+	if flags&0x40 != 0 && version >= 3 && len(body) >= 4 {
+		var skip int
+		if version == 3 {
+			skip = int(binary.BigEndian.Uint32(body)) + 4
+		} else {
+			skip = int(syncsafe(body[:4]))
+		}
+		if skip > len(body) {
+			return 0
+		}
+		body = body[skip:]
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	var cover *Picture
+	for len(body) >= headerLen {
+		// Начались байты заполнения
+		if body[0] == 0 {
+			break
+		}
+
+		id := string(body[:idLen])
+		var size int
+		var frameFlags uint16
+		switch version {
+		case 2:
+			size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		case 4:
+			size = int(syncsafe(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		}
+		if size < 0 || headerLen+size > len(body) {
+			break
+		}
+		data := body[headerLen : headerLen+size]
+		body = body[headerLen+size:]
+
+		data, ok := frameData(version, frameFlags, data)
+		if !ok {
+			continue
+		}
+
+		switch id {
+		case "TIT2", "TT2":
+			meta.Title = id3Text(data)
+		case "TPE1", "TP1":
+			meta.Artist = id3Text(data)
+		case "TALB", "TAL":
+			meta.Album = id3Text(data)
+		case "TPE2", "TP2":
+			meta.AlbumArtist = id3Text(data)
+		case "TCON", "TCO":
+			meta.Genre = id3Genre(id3Text(data))
+		case "TRCK", "TRK":
+			meta.TrackNumber, meta.TrackTotal = parsePosition(id3Text(data), meta.TrackTotal)
+		case "TPOS", "TPA":
+			meta.DiscNumber, meta.DiscTotal = parsePosition(id3Text(data), meta.DiscTotal)
+		case "TDRC", "TYER", "TYE":
+			if year := parseYear(id3Text(data)); year != 0 {
+				meta.Year = year
+			}
+		case "TLEN", "TLE":
+			if ms, err := strconv.Atoi(id3Text(data)); err == nil && ms > 0 {
+				tlen = time.Duration(ms) * time.Millisecond
+			}
+		case "USLT", "ULT":
+			if meta.Lyrics == "" {
+				meta.Lyrics, meta.LyricsLanguage = id3Lyrics(data)
+			}
+		case "APIC", "PIC":
+			picture, pictureType := id3Picture(data, version == 2)
+			if picture != nil && (cover == nil || pictureType == pictureFrontCover) {
+				cover = picture
+			}
+		}
+	}
+	if cover != nil {
+		meta.Cover = cover
+	}
+	return tlen
+}
+
+// Данные фрейма с учетом флагов формата. Сжатые и зашифрованные фреймы пропускаются.
+func frameData(version byte, flags uint16, data []byte) ([]byte, bool) {
+	switch version {
+	case 3:
+		if flags&0x00C0 != 0 {
+			return nil, false
+		}
+		if flags&0x0020 != 0 && len(data) > 0 {
+			data = data[1:]
+		}
+	case 4:
+		if flags&0x000C != 0 {
+			return nil, false
+		}
+		if flags&0x0040 != 0 && len(data) > 0 {
+			data = data[1:]
+		}
+		if flags&0x0001 != 0 {
+			if len(data) < 4 {
+				return nil, false
+			}
+			data = data[4:]
+		}
+		if flags&0x0002 != 0 {
+			data = removeUnsync(data)
+		}
+	}
+	return data, true
+}
+
+// Текстовый фрейм: байт кодировки и строка. В ID3v2.4 значений может быть несколько, берется первое.
+func id3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	text, _ := splitString(data[0], data[1:])
+	return strings.TrimSpace(text)
+}
+
+// Жанр вида "Rock", "17", "(17)" или "(17)Rock"
+func id3Genre(value string) string {
+	if strings.HasPrefix(value, "(") {
+		if end := strings.Index(value, ")"); end > 0 {
+			if rest := strings.TrimSpace(value[end+1:]); rest != "" {
+				return rest
+			}
+			if index, err := strconv.Atoi(value[1:end]); err == nil {
+				return id3v1Genre(index)
+			}
+		}
+	}
+	if index, err := strconv.Atoi(value); err == nil {
+		return id3v1Genre(index)
+	}
+	return value
+}
+
+// USLT: кодировка, язык (3 байта), описание, текст
+func id3Lyrics(data []byte) (string, string) {
+	if len(data) < 4 {
+		return "", ""
+	}
+	encoding := data[0]
+	language := strings.TrimSpace(strings.Trim(string(data[1:4]), "\x00"))
+	_, rest := splitString(encoding, data[4:])
+	text := decodeText(encoding, rest)
+	return strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), strings.ToLower(language)
+}
+
+// APIC: кодировка, MIME (в v2.2 - формат из 3 символов), тип, описание, данные
+func id3Picture(data []byte, v22 bool) (*Picture, byte) {
+	if len(data) < 2 {
+		return nil, 0
+	}
+	encoding := data[0]
+	data = data[1:]
+
+	var mime string
+	if v22 {
+		if len(data) < 3 {
+			return nil, 0
+		}
+		switch strings.ToUpper(string(data[:3])) {
+		case "PNG":
+			mime = "image/png"
+		default:
+			mime = "image/jpeg"
+		}
+		data = data[3:]
+	} else {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			return nil, 0
+		}
+		mime = strings.ToLower(string(data[:end]))
+		data = data[end+1:]
+		// Встречается укороченная форма "jpg"/"png"
+		if !strings.Contains(mime, "/") {
+			mime = "image/" + strings.Replace(mime, "jpg", "jpeg", 1)
+		}
+	}
+
+	if len(data) < 1 {
+		return nil, 0
+	}
+	pictureType := data[0]
+	description, image := splitString(encoding, data[1:])
+	if len(image) == 0 {
+		return nil, 0
+	}
+	return &Picture{MimeType: mime, Description: description, Data: image}, pictureType
+}
+
+// Строка до терминатора в заданной кодировке и остаток данных
+func splitString(encoding byte, data []byte) (string, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return decodeText(encoding, data[:i]), data[i+2:]
+			}
+		}
+		return decodeText(encoding, data), nil
+	}
+
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		return decodeText(encoding, data[:end]), data[end+1:]
+	}
+	return decodeText(encoding, data), nil
+}
+
+// Кодировки ID3v2: 0 - ISO-8859-1, 1 - UTF-16 с BOM, 2 - UTF-16BE, 3 - UTF-8
+func decodeText(encoding byte, data []byte) string {
+	switch encoding {
+	case 1, 2:
+		bigEndian := encoding == 2
+		if len(data) >= 2 {
+			switch {
+			case data[0] == 0xFF && data[1] == 0xFE:
+				bigEndian, data = false, data[2:]
+			case data[0] == 0xFE && data[1] == 0xFF:
+				bigEndian, data = true, data[2:]
+			}
+		}
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			if bigEndian {
+				units = append(units, binary.BigEndian.Uint16(data[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(data[i:]))
+			}
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	case 3:
+		return strings.TrimRight(string(data), "\x00")
+	default:
+		return latin1(data)
+	}
+}
+
+func latin1(data []byte) string {
+	runes := make([]rune, 0, len(data))
+	for _, b := range data {
+		if b == 0 {
+			break
+		}
+		runes = append(runes, rune(b))
+	}
+	return string(runes)
+}
+
+// readID3v1 читает тег из последних 128 байт и дополняет пустые поля
+func readID3v1(tag []byte, meta *Metadata) {
+	if len(tag) != id3v1Size || !bytes.HasPrefix(tag, []byte("TAG")) {
+		return
+	}
+	field := func(b []byte) string {
+		return strings.TrimSpace(latin1(b))
+	}
+
+	if meta.Title == "" {
+		meta.Title = field(tag[3:33])
+	}
+	if meta.Artist == "" {
+		meta.Artist = field(tag[33:63])
+	}
+	if meta.Album == "" {
+		meta.Album = field(tag[63:93])
+	}
+	if meta.Year == 0 {
+		meta.Year = parseYear(field(tag[93:97]))
+	}
+	// ID3v1.1: номер трека в последнем байте комментария
+	if meta.TrackNumber == 0 && tag[125] == 0 && tag[126] != 0 {
+		meta.TrackNumber = int(tag[126])
+	}
+	if meta.Genre == "" {
+		meta.Genre = id3v1Genre(int(tag[127]))
+	}
+}
@@ -0,0 +1,168 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("audio: unsupported format")
+	ErrMalformed         = errors.New("audio: malformed file")
+)
+
+// Ограничение на размер блока тегов, читаемого в память целиком
+const maxTagSize = 32 << 20
+
+// Встроенное изображение (обложка)
+type Picture struct {
+	MimeType    string
+	Description string
+	Data        []byte
+}
+
+// Теги и параметры аудиопотока
+type Metadata struct {
+	Format      string // MIME тип файла
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Genre       string
+	Year        int
+	TrackNumber int
+	TrackTotal  int
+	DiscNumber  int
+	DiscTotal   int
+
+	Duration   time.Duration
+	SampleRate int
+	Channels   int
+	Bitrate    int // кбит/с
+
+	Lyrics         string // Несинхронизированный текст (USLT, LYRICS, ©lyr)
+	LyricsLanguage string // Код ISO 639-2 из USLT
+	Cover          *Picture
+}
+
+// ReadMetadata определяет формат файла и читает его теги и длительность.
+// Поддерживаются MP3 (ID3v1/v2), FLAC, Ogg Vorbis/Opus, MP4/M4A и WAV.
+func ReadMetadata(r io.ReaderAt, size int64) (*Metadata, error) {
+	header := make([]byte, SniffLen)
+	n, err := r.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	mime, ok := Sniff(header[:n])
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	meta := &Metadata{Format: mime}
+	switch mime {
+	case MimeMP3:
+		err = readMP3(r, size, meta)
+	case MimeFLAC:
+		err = readFLAC(r, size, meta)
+	case MimeOgg:
+		err = readOgg(r, size, meta)
+	case MimeMP4:
+		err = readMP4(r, size, meta)
+	case MimeWAV:
+		err = readWAV(r, size, meta)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Bitrate == 0 && meta.Duration > 0 {
+		meta.Bitrate = int(float64(size*8) / meta.Duration.Seconds() / 1000)
+	}
+	return meta, nil
+}
+
+// Заполнение полей тегами вида ключ-значение (Vorbis comments, RIFF INFO)
+func (m *Metadata) setTag(key, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	switch strings.ToUpper(key) {
+	case "TITLE":
+		m.Title = value
+	case "ARTIST":
+		m.Artist = value
+	case "ALBUM":
+		m.Album = value
+	case "ALBUMARTIST", "ALBUM ARTIST":
+		m.AlbumArtist = value
+	case "GENRE":
+		m.Genre = value
+	case "DATE", "YEAR":
+		m.Year = parseYear(value)
+	case "TRACKNUMBER":
+		m.TrackNumber, m.TrackTotal = parsePosition(value, m.TrackTotal)
+	case "TRACKTOTAL", "TOTALTRACKS":
+		m.TrackTotal, _ = strconv.Atoi(value)
+	case "DISCNUMBER":
+		m.DiscNumber, m.DiscTotal = parsePosition(value, m.DiscTotal)
+	case "DISCTOTAL", "TOTALDISCS":
+		m.DiscTotal, _ = strconv.Atoi(value)
+	case "LYRICS", "UNSYNCEDLYRICS":
+		m.Lyrics = value
+	}
+}
+
+// Номер вида "3" или "3/12"
+func parsePosition(value string, total int) (int, int) {
+	number, rest, found := strings.Cut(strings.TrimSpace(value), "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(number))
+	if found {
+		if t, err := strconv.Atoi(strings.TrimSpace(rest)); err == nil {
+			total = t
+		}
+	}
+	return n, total
+}
+
+// Год из даты в форматах "2019", "2019-05-01", "2019-05-01T10:00"
+func parseYear(value string) int {
+	if len(value) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(value[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+func durationOf(samples uint64, sampleRate int) time.Duration {
+	if sampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+}
+
+// Чтение size байт с позиции offset с проверкой границ файла
+func readAt(r io.ReaderAt, offset, size, fileSize int64) ([]byte, error) {
+	if offset < 0 || size < 0 || offset+size > fileSize {
+		return nil, ErrMalformed
+	}
+	if size > maxTagSize {
+		return nil, ErrMalformed
+	}
+	buf := make([]byte, size)
+	n, err := r.ReadAt(buf, offset)
+	if int64(n) == size {
+		return buf, nil
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil, ErrMalformed
+	}
+	return nil, err
+}
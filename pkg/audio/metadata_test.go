@@ -0,0 +1,342 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Кадр MPEG-1 Layer III, 128 кбит/с, 44100 Гц, joint stereo: 417 байт
+var mp3FrameHeader = []byte{0xFF, 0xFB, 0x90, 0x64}
+
+const mp3FrameLength = 417
+
+func mp3Frames(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		frame := make([]byte, mp3FrameLength)
+		copy(frame, mp3FrameHeader)
+		buf.Write(frame)
+	}
+	return buf.Bytes()
+}
+
+func id3Frame(id string, data []byte) []byte {
+	frame := make([]byte, 10, 10+len(data))
+	copy(frame, id)
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+func id3Tag(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, body...)
+}
+
+func read(t *testing.T, data []byte) *Metadata {
+	t.Helper()
+	meta, err := ReadMetadata(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	if meta == nil {
+		t.FailNow()
+	}
+	return meta
+}
+
+func TestReadMetadata_ID3v2(t *testing.T) {
+	// UTF-16 с BOM
+	utf16Title := []byte{1, 0xFF, 0xFE, 'S', 0, 'o', 0, 'n', 0, 'g', 0}
+	lyrics := append([]byte{0, 'e', 'n', 'g', 0}, "First line\r\nSecond line"...)
+	picture := append([]byte{0}, "image/png\x00\x03cover\x00"...)
+	picture = append(picture, 0x89, 'P', 'N', 'G')
+
+	tag := id3Tag(
+		id3Frame("TIT2", utf16Title),
+		id3Frame("TPE1", append([]byte{3}, "Артист"...)),
+		id3Frame("TALB", append([]byte{0}, "Album"...)),
+		id3Frame("TRCK", append([]byte{0}, "3/12"...)),
+		id3Frame("TCON", append([]byte{0}, "(17)"...)),
+		id3Frame("TYER", append([]byte{0}, "2019"...)),
+		id3Frame("USLT", lyrics),
+		id3Frame("APIC", picture),
+	)
+	// Байты заполнения после фреймов
+	tag = append(tag, make([]byte, 32)...)
+	tag[9] += 32
+
+	meta := read(t, append(tag, mp3Frames(100)...))
+
+	assert.Equal(t, MimeMP3, meta.Format)
+	assert.Equal(t, "Song", meta.Title)
+	assert.Equal(t, "Артист", meta.Artist)
+	assert.Equal(t, "Album", meta.Album)
+	assert.Equal(t, 3, meta.TrackNumber)
+	assert.Equal(t, 12, meta.TrackTotal)
+	assert.Equal(t, "Rock", meta.Genre)
+	assert.Equal(t, 2019, meta.Year)
+	assert.Equal(t, "First line\nSecond line", meta.Lyrics)
+	assert.Equal(t, "eng", meta.LyricsLanguage)
+	if assert.NotNil(t, meta.Cover) {
+		assert.Equal(t, "image/png", meta.Cover.MimeType)
+		assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, meta.Cover.Data)
+	}
+
+	// CBR: 100 кадров по 417 байт при 128 кбит/с
+	expected := time.Duration(float64(100*mp3FrameLength*8) / 128000 * float64(time.Second))
+	assert.Equal(t, expected, meta.Duration)
+	assert.Equal(t, 44100, meta.SampleRate)
+	assert.Equal(t, 2, meta.Channels)
+}
+
+func TestReadMetadata_ID3v1(t *testing.T) {
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+	copy(tag[3:], "Old Title")
+	copy(tag[33:], "Old Artist")
+	copy(tag[93:], "1999")
+	tag[126] = 7
+	tag[127] = 13
+
+	meta := read(t, append(mp3Frames(10), tag...))
+
+	assert.Equal(t, "Old Title", meta.Title)
+	assert.Equal(t, "Old Artist", meta.Artist)
+	assert.Equal(t, 1999, meta.Year)
+	assert.Equal(t, 7, meta.TrackNumber)
+	assert.Equal(t, "Pop", meta.Genre)
+	// ID3v1 не входит в аудиоданные
+	assert.Equal(t, time.Duration(float64(10*mp3FrameLength*8)/128000*float64(time.Second)), meta.Duration)
+}
+
+func TestReadMetadata_XingVBR(t *testing.T) {
+	data := mp3Frames(5)
+	copy(data[4+32:], "Xing")
+	binary.BigEndian.PutUint32(data[4+32+4:], 1)
+	binary.BigEndian.PutUint32(data[4+32+8:], 1000)
+
+	meta := read(t, data)
+
+	assert.Equal(t, durationOf(1000*1152, 44100), meta.Duration)
+}
+
+func vorbisComments(comments ...string) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(6))
+	buf.WriteString("vendor")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(c)))
+		buf.WriteString(c)
+	}
+	return buf.Bytes()
+}
+
+func TestReadMetadata_FLAC(t *testing.T) {
+	streamInfo := make([]byte, 34)
+	// 44100 Гц, 2 канала, 16 бит, 441000 сэмплов
+	streamInfo[10] = 0x0A
+	streamInfo[11] = 0xC4
+	streamInfo[12] = 0x42
+	streamInfo[13] = 0xF0
+	binary.BigEndian.PutUint32(streamInfo[14:], 441000)
+
+	comments := vorbisComments("TITLE=Flac Song", "ARTIST=Band", "TRACKNUMBER=2", "TRACKTOTAL=9", "GENRE=Jazz", "DATE=2021-03-04", "LYRICS=la la la")
+
+	var file bytes.Buffer
+	file.WriteString("fLaC")
+	file.Write([]byte{flacStreamInfo, 0, 0, 34})
+	file.Write(streamInfo)
+	file.Write([]byte{0x80 | flacVorbisComment, 0, byte(len(comments) >> 8), byte(len(comments))})
+	file.Write(comments)
+
+	meta := read(t, file.Bytes())
+
+	assert.Equal(t, MimeFLAC, meta.Format)
+	assert.Equal(t, "Flac Song", meta.Title)
+	assert.Equal(t, "Band", meta.Artist)
+	assert.Equal(t, 2, meta.TrackNumber)
+	assert.Equal(t, 9, meta.TrackTotal)
+	assert.Equal(t, "Jazz", meta.Genre)
+	assert.Equal(t, 2021, meta.Year)
+	assert.Equal(t, "la la la", meta.Lyrics)
+	assert.Equal(t, 44100, meta.SampleRate)
+	assert.Equal(t, 2, meta.Channels)
+	assert.Equal(t, 10*time.Second, meta.Duration)
+}
+
+func oggPageBytes(granule int64, serial uint32, packets ...[]byte) []byte {
+	var segments, data []byte
+	for _, packet := range packets {
+		n := len(packet)
+		for n >= 255 {
+			segments = append(segments, 255)
+			n -= 255
+		}
+		segments = append(segments, byte(n))
+		data = append(data, packet...)
+	}
+
+	header := make([]byte, oggHeaderSize)
+	copy(header, "OggS")
+	binary.LittleEndian.PutUint64(header[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(header[14:], serial)
+	header[26] = byte(len(segments))
+
+	page := append(header, segments...)
+	return append(page, data...)
+}
+
+func TestReadMetadata_OggVorbis(t *testing.T) {
+	ident := make([]byte, 30)
+	copy(ident, "\x01vorbis")
+	ident[11] = 2
+	binary.LittleEndian.PutUint32(ident[12:], 48000)
+
+	comments := append([]byte("\x03vorbis"), vorbisComments("TITLE=Ogg Song", "ALBUM=Ogg Album")...)
+	// Длинный пакет комментариев занимает несколько сегментов
+	comments = append(comments, make([]byte, 600)...)
+
+	var file bytes.Buffer
+	file.Write(oggPageBytes(0, 1, ident))
+	file.Write(oggPageBytes(0, 1, comments))
+	file.Write(oggPageBytes(48000*3, 1, make([]byte, 100)))
+
+	meta := read(t, file.Bytes())
+
+	assert.Equal(t, MimeOgg, meta.Format)
+	assert.Equal(t, "Ogg Song", meta.Title)
+	assert.Equal(t, "Ogg Album", meta.Album)
+	assert.Equal(t, 48000, meta.SampleRate)
+	assert.Equal(t, 3*time.Second, meta.Duration)
+}
+
+func TestReadMetadata_Opus(t *testing.T) {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[9] = 2
+	binary.LittleEndian.PutUint16(head[10:], 312)
+	binary.LittleEndian.PutUint32(head[12:], 44100)
+
+	tags := append([]byte("OpusTags"), vorbisComments("TITLE=Opus Song")...)
+
+	var file bytes.Buffer
+	file.Write(oggPageBytes(0, 7, head))
+	file.Write(oggPageBytes(0, 7, tags))
+	file.Write(oggPageBytes(48000*2+312, 7, make([]byte, 50)))
+
+	meta := read(t, file.Bytes())
+
+	assert.Equal(t, "Opus Song", meta.Title)
+	assert.Equal(t, 2*time.Second, meta.Duration)
+}
+
+func atom(kind string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(body)))
+	copy(header[4:], kind)
+	return append(header, body...)
+}
+
+func dataAtom(valueType uint32, value []byte) []byte {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, valueType)
+	return atom("data", header, value)
+}
+
+func TestReadMetadata_MP4(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 185500)
+
+	trkn := []byte{0, 0, 0, 4, 0, 10, 0, 0}
+	ilst := atom("ilst",
+		atom("\xa9nam", dataAtom(mp4DataUTF8, []byte("M4A Song"))),
+		atom("\xa9ART", dataAtom(mp4DataUTF8, []byte("M4A Artist"))),
+		atom("gnre", dataAtom(0, []byte{0, 19})),
+		atom("trkn", dataAtom(0, trkn)),
+		atom("\xa9lyr", dataAtom(mp4DataUTF8, []byte("line one\rline two"))),
+		atom("covr", dataAtom(mp4DataJPEG, []byte{0xFF, 0xD8, 0xFF})),
+	)
+	hdlr := atom("hdlr", make([]byte, 25))
+	meta := atom("meta", []byte{0, 0, 0, 0}, hdlr, ilst)
+
+	file := bytes.Join([][]byte{
+		atom("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom")),
+		atom("moov", atom("mvhd", mvhd), atom("udta", meta)),
+		atom("mdat", make([]byte, 64)),
+	}, nil)
+
+	result := read(t, file)
+
+	assert.Equal(t, MimeMP4, result.Format)
+	assert.Equal(t, "M4A Song", result.Title)
+	assert.Equal(t, "M4A Artist", result.Artist)
+	assert.Equal(t, "Techno", result.Genre)
+	assert.Equal(t, 4, result.TrackNumber)
+	assert.Equal(t, 10, result.TrackTotal)
+	assert.Equal(t, "line one\nline two", result.Lyrics)
+	assert.Equal(t, 185500*time.Millisecond, result.Duration)
+	if assert.NotNil(t, result.Cover) {
+		assert.Equal(t, "image/jpeg", result.Cover.MimeType)
+	}
+}
+
+func TestReadMetadata_WAV(t *testing.T) {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format, 1)
+	binary.LittleEndian.PutUint16(format[2:], 2)
+	binary.LittleEndian.PutUint32(format[4:], 44100)
+	binary.LittleEndian.PutUint32(format[8:], 176400)
+
+	chunk := func(id string, data []byte) []byte {
+		header := make([]byte, 8)
+		copy(header, id)
+		binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+		if len(data)%2 == 1 {
+			data = append(data, 0)
+		}
+		return append(header, data...)
+	}
+	info := append([]byte("INFO"), chunk("INAM", []byte("Wave Song\x00"))...)
+
+	var file bytes.Buffer
+	file.WriteString("RIFF\x00\x00\x00\x00WAVE")
+	file.Write(chunk("fmt ", format))
+	file.Write(chunk("LIST", info))
+	file.Write(chunk("data", make([]byte, 176400*2)))
+
+	meta := read(t, file.Bytes())
+
+	assert.Equal(t, MimeWAV, meta.Format)
+	assert.Equal(t, "Wave Song", meta.Title)
+	assert.Equal(t, 2*time.Second, meta.Duration)
+	assert.Equal(t, 1411, meta.Bitrate)
+}
+
+func TestReadMetadata_Unsupported(t *testing.T) {
+	data := []byte("plain text, not audio")
+	_, err := ReadMetadata(bytes.NewReader(data), int64(len(data)))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestReadMetadata_Truncated(t *testing.T) {
+	tag := id3Tag(id3Frame("TIT2", append([]byte{0}, "Title"...)))
+	// Размер тега больше файла
+	tag[8] = 0x7F
+	_, err := ReadMetadata(bytes.NewReader(tag), int64(len(tag)))
+	assert.Error(t, err)
+}
+
+func TestID3Genre(t *testing.T) {
+	assert.Equal(t, "Rock", id3Genre("(17)"))
+	assert.Equal(t, "Synthpop", id3Genre("(52)Synthpop"))
+	assert.Equal(t, "Pop", id3Genre("13"))
+	assert.Equal(t, "Indie", id3Genre("Indie"))
+}
@@ -0,0 +1,207 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Сколько байт после тега просматривается в поисках первого кадра
+const mp3SyncWindow = 64 << 10
+
+var mp3Bitrates = [2][3][16]int{
+	// MPEG-1: Layer I, II, III
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	// MPEG-2 и 2.5: Layer I, II, III
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+var mp3SampleRates = map[uint32][3]int{
+	3: {44100, 48000, 32000}, // MPEG-1
+	2: {22050, 24000, 16000}, // MPEG-2
+	0: {11025, 12000, 8000},  // MPEG-2.5
+}
+
+// Заголовок кадра MPEG audio
+type mp3Frame struct {
+	mpeg1      bool
+	layer      int // 1, 2 или 3
+	bitrate    int // кбит/с
+	sampleRate int
+	channels   int
+	length     int // Длина кадра в байтах
+
+	crc       bool // После заголовка идут 16 бит CRC
+	jointMode bool
+	modeExt   int // Режим joint stereo: бит 1 - M/S, бит 0 - intensity
+}
+
+func parseMP3Frame(b []byte) (mp3Frame, bool) {
+	if !isMPEGFrame(b) {
+		return mp3Frame{}, false
+	}
+	h := binary.BigEndian.Uint32(b)
+	version := (h >> 19) & 0x3
+	layer := 4 - int((h>>17)&0x3)
+	bitrateIndex := (h >> 12) & 0xF
+	sampleRateIndex := (h >> 10) & 0x3
+	padding := int((h >> 9) & 0x1)
+	mode := (h >> 6) & 0x3
+	modeExt := int((h >> 4) & 0x3)
+
+	frame := mp3Frame{
+		mpeg1:      version == 3,
+		layer:      layer,
+		sampleRate: mp3SampleRates[version][sampleRateIndex],
+		channels:   2,
+		crc:        (h>>16)&0x1 == 0,
+		jointMode:  mode == 1,
+		modeExt:    modeExt,
+	}
+	if mode == 3 {
+		frame.channels = 1
+	}
+
+	table := 1
+	if frame.mpeg1 {
+		table = 0
+	}
+	frame.bitrate = mp3Bitrates[table][layer-1][bitrateIndex]
+	// Свободный битрейт не поддерживается
+	if frame.bitrate == 0 {
+		return mp3Frame{}, false
+	}
+
+	bps := frame.bitrate * 1000
+	switch {
+	case layer == 1:
+		frame.length = (12*bps/frame.sampleRate + padding) * 4
+	case layer == 3 && !frame.mpeg1:
+		frame.length = 72*bps/frame.sampleRate + padding
+	default:
+		frame.length = 144*bps/frame.sampleRate + padding
+	}
+	return frame, true
+}
+
+func (f mp3Frame) samplesPerFrame() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && !f.mpeg1:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// Смещение заголовка Xing/Info: после 4 байт заголовка и side information
+func (f mp3Frame) xingOffset() int {
+	switch {
+	case f.mpeg1 && f.channels == 2:
+		return 4 + 32
+	case f.mpeg1, f.channels == 2:
+		return 4 + 17
+	default:
+		return 4 + 9
+	}
+}
+
+func readMP3(r io.ReaderAt, size int64, meta *Metadata) error {
+	header, err := readAt(r, 0, min(id3HeaderSize, size), size)
+	if err != nil {
+		return err
+	}
+
+	var tlen time.Duration
+	audioStart := id3v2Size(header)
+	if audioStart > 0 {
+		tag, err := readAt(r, 0, min(audioStart, size), size)
+		if err != nil {
+			return err
+		}
+		tlen = readID3v2(tag, meta)
+	}
+
+	audioEnd := size
+	if size-audioStart >= id3v1Size {
+		tag, err := readAt(r, size-id3v1Size, id3v1Size, size)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(tag, []byte("TAG")) {
+			readID3v1(tag, meta)
+			audioEnd -= id3v1Size
+		}
+	}
+
+	window, err := readAt(r, audioStart, min(mp3SyncWindow, audioEnd-audioStart), size)
+	if err != nil {
+		return err
+	}
+
+	// Первый кадр, за которым следует еще один корректный кадр
+	for i := 0; i+4 <= len(window); i++ {
+		frame, ok := parseMP3Frame(window[i:])
+		if !ok {
+			continue
+		}
+		next := i + frame.length
+		if next+4 <= len(window) {
+			if _, ok := parseMP3Frame(window[next:]); !ok {
+				continue
+			}
+		}
+
+		meta.SampleRate = frame.sampleRate
+		meta.Channels = frame.channels
+		meta.Duration = mp3Duration(window[i:], frame, audioEnd-audioStart-int64(i))
+		if meta.Duration == 0 {
+			meta.Duration = tlen
+		}
+		return nil
+	}
+
+	meta.Duration = tlen
+	return nil
+}
+
+// Длительность по заголовку Xing/Info или VBRI для VBR, иначе по битрейту первого кадра
+func mp3Duration(data []byte, frame mp3Frame, audioSize int64) time.Duration {
+	if frames := vbrFrames(data, frame); frames > 0 {
+		return durationOf(uint64(frames)*uint64(frame.samplesPerFrame()), frame.sampleRate)
+	}
+	if frame.bitrate == 0 {
+		return 0
+	}
+	return time.Duration(float64(audioSize*8) / float64(frame.bitrate*1000) * float64(time.Second))
+}
+
+func vbrFrames(data []byte, frame mp3Frame) uint32 {
+	offset := frame.xingOffset()
+	if len(data) >= offset+12 {
+		tag := string(data[offset : offset+4])
+		if tag == "Xing" || tag == "Info" {
+			flags := binary.BigEndian.Uint32(data[offset+4:])
+			if flags&0x1 != 0 {
+				return binary.BigEndian.Uint32(data[offset+8:])
+			}
+			return 0
+		}
+	}
+
+	// VBRI всегда находится через 32 байта после заголовка кадра
+	if len(data) >= 4+32+18 && string(data[36:40]) == "VBRI" {
+		return binary.BigEndian.Uint32(data[36+14:])
+	}
+	return 0
+}
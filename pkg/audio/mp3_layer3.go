@@ -0,0 +1,363 @@
+package audio
+
+import "math"
+
+// Полоса масштабного множителя в порядке значений после декодирования Хаффмана.
+// Короткие блоки хранят полосу трижды, по окну подряд.
+type mp3Band struct {
+	start, end int
+	sfb        int
+	window     int // Окно короткого блока, -1 для длинных полос
+}
+
+type mp3BandKey struct {
+	sampleRate int
+	blockKind  int // 0 - длинные, 1 - короткие, 2 - смешанные блоки
+}
+
+var mp3BandTables = func() map[mp3BandKey][]mp3Band {
+	tables := make(map[mp3BandKey][]mp3Band)
+	for rate, long := range mp3LongBands {
+		short := mp3ShortBands[rate]
+
+		var longBands []mp3Band
+		for sfb := 0; sfb < 22; sfb++ {
+			longBands = append(longBands, mp3Band{start: long[sfb], end: long[sfb+1], sfb: sfb, window: -1})
+		}
+		shortBands := func(first int) []mp3Band {
+			var bands []mp3Band
+			for sfb := first; sfb < 13; sfb++ {
+				width := short[sfb+1] - short[sfb]
+				base := short[sfb] * 3
+				for w := 0; w < 3; w++ {
+					bands = append(bands, mp3Band{start: base + w*width, end: base + (w+1)*width, sfb: sfb, window: w})
+				}
+			}
+			return bands
+		}
+
+		// Смешанный блок: длинные полосы до начала третьей короткой полосы
+		var mixed []mp3Band
+		for _, band := range longBands {
+			if band.end > short[3]*3 {
+				break
+			}
+			mixed = append(mixed, band)
+		}
+
+		tables[mp3BandKey{rate, 0}] = longBands
+		tables[mp3BandKey{rate, 1}] = shortBands(0)
+		tables[mp3BandKey{rate, 2}] = append(mixed, shortBands(3)...)
+	}
+	return tables
+}()
+
+func mp3BandsFor(sampleRate int, g *mp3Granule) []mp3Band {
+	kind := 0
+	if g.blockType == mp3BlockShort {
+		kind = 1
+		if g.mixed {
+			kind = 2
+		}
+	}
+	return mp3BandTables[mp3BandKey{sampleRate, kind}]
+}
+
+// stereo восстанавливает левый и правый каналы из M/S и intensity stereo
+func (d *mp3Decoder) stereo(f mp3Frame, g *mp3Granule) {
+	ms := f.jointMode && f.modeExt&2 != 0
+	intensity := f.jointMode && f.modeExt&1 != 0
+	if !ms && !intensity {
+		return
+	}
+	left, right := &d.xr[0], &d.xr[1]
+	bands := mp3BandsFor(f.sampleRate, g)
+
+	// В intensity stereo передаются полосы правого канала выше последней ненулевой
+	var intensityBands [39]bool
+	if intensity {
+		lastLong, lastShort := -1, [3]int{-1, -1, -1}
+		for _, band := range bands {
+			if allZero(right[band.start:band.end]) {
+				continue
+			}
+			if band.window < 0 {
+				lastLong = band.sfb
+			} else {
+				lastShort[band.window] = band.sfb
+			}
+		}
+		for i, band := range bands {
+			if band.window < 0 {
+				intensityBands[i] = band.sfb > lastLong && lastShort == [3]int{-1, -1, -1}
+			} else {
+				intensityBands[i] = band.sfb > lastShort[band.window]
+			}
+		}
+	}
+
+	for i, band := range bands {
+		if intensityBands[i] {
+			if kl, kr, ok := intensityRatio(f, g, &d.scalefac[1], band); ok {
+				for j := band.start; j < band.end; j++ {
+					v := left[j]
+					left[j], right[j] = v*kl, v*kr
+				}
+				continue
+			}
+		}
+		if ms {
+			for j := band.start; j < band.end; j++ {
+				m, s := left[j], right[j]
+				left[j], right[j] = (m+s)*math.Sqrt2/2, (m-s)*math.Sqrt2/2
+			}
+		}
+	}
+}
+
+// Коэффициенты каналов полосы intensity stereo, false для недопустимой позиции
+func intensityRatio(f mp3Frame, g *mp3Granule, sf *mp3Scalefactors, band mp3Band) (float32, float32, bool) {
+	// Последняя полоса не имеет своего множителя и берет его у предыдущей
+	var pos, limit int
+	if band.window < 0 {
+		sfb := min(band.sfb, 20)
+		pos, limit = sf.long[sfb], sf.longMax[sfb]
+	} else {
+		sfb := min(band.sfb, 11)
+		pos, limit = sf.short[sfb][band.window], sf.shortMax[sfb][band.window]
+	}
+
+	if f.mpeg1 {
+		switch {
+		case pos >= 7:
+			return 0, 0, false
+		case pos == 6:
+			return 1, 0, true
+		}
+		ratio := math.Tan(float64(pos) * math.Pi / 12)
+		return float32(ratio / (1 + ratio)), float32(1 / (1 + ratio)), true
+	}
+
+	if pos == limit {
+		return 0, 0, false
+	}
+	base := math.Pow(2, -0.25)
+	if g.scalefacCompress&1 == 1 {
+		base = math.Sqrt2 / 2
+	}
+	switch {
+	case pos == 0:
+		return 1, 1, true
+	case pos%2 == 1:
+		return float32(math.Pow(base, float64(pos+1)/2)), 1, true
+	default:
+		return 1, float32(math.Pow(base, float64(pos)/2)), true
+	}
+}
+
+// reorderShort переставляет значения коротких блоков из порядка "полоса, окно, частота"
+// в порядок "частота, окно", удобный для обратного MDCT по подполосам
+func reorderShort(xr *[mp3GranuleSize]float32, bands []mp3Band) {
+	var tmp [mp3GranuleSize]float32
+	first := -1
+	for _, band := range bands {
+		if band.window < 0 {
+			continue
+		}
+		if first < 0 {
+			first = band.start
+		}
+		width := band.end - band.start
+		base := band.start - band.window*width
+		for j := 0; j < width; j++ {
+			tmp[base+3*j+band.window] = xr[band.start+j]
+		}
+	}
+	if first >= 0 {
+		copy(xr[first:], tmp[first:])
+	}
+}
+
+var mp3AliasCS, mp3AliasCA = func() ([8]float32, [8]float32) {
+	var cs, ca [8]float32
+	for i, c := range mp3AliasCoefs {
+		norm := math.Sqrt(1 + c*c)
+		cs[i], ca[i] = float32(1/norm), float32(c/norm)
+	}
+	return cs, ca
+}()
+
+// antialias подавляет алиасинг между соседними подполосами длинных блоков
+func antialias(xr *[mp3GranuleSize]float32, g *mp3Granule) {
+	limit := 32
+	if g.blockType == mp3BlockShort {
+		if !g.mixed {
+			return
+		}
+		limit = 2
+	}
+	for sb := 1; sb < limit; sb++ {
+		for i := 0; i < 8; i++ {
+			lo, hi := xr[18*sb-1-i], xr[18*sb+i]
+			xr[18*sb-1-i] = lo*mp3AliasCS[i] - hi*mp3AliasCA[i]
+			xr[18*sb+i] = hi*mp3AliasCS[i] + lo*mp3AliasCA[i]
+		}
+	}
+}
+
+var (
+	mp3IMDCTLong  [18][36]float32
+	mp3IMDCTShort [6][12]float32
+	// Окна длинных блоков по типу блока и окно короткого блока
+	mp3Windows     [4][36]float32
+	mp3ShortWindow [12]float32
+)
+
+func init() {
+	for k := 0; k < 18; k++ {
+		for i := 0; i < 36; i++ {
+			mp3IMDCTLong[k][i] = float32(math.Cos(math.Pi / 72 * float64((2*i+1+18)*(2*k+1))))
+		}
+	}
+	for k := 0; k < 6; k++ {
+		for i := 0; i < 12; i++ {
+			mp3IMDCTShort[k][i] = float32(math.Cos(math.Pi / 24 * float64((2*i+1+6)*(2*k+1))))
+		}
+	}
+
+	for i := 0; i < 36; i++ {
+		long := float32(math.Sin(math.Pi / 36 * (float64(i) + 0.5)))
+		mp3Windows[mp3BlockNormal][i] = long
+		mp3Windows[mp3BlockShort][i] = long
+	}
+	for i := 0; i < 12; i++ {
+		mp3ShortWindow[i] = float32(math.Sin(math.Pi / 12 * (float64(i) + 0.5)))
+	}
+
+	start, stop := &mp3Windows[mp3BlockStart], &mp3Windows[mp3BlockStop]
+	for i := 0; i < 36; i++ {
+		switch {
+		case i < 18:
+			start[i] = mp3Windows[mp3BlockNormal][i]
+		case i < 24:
+			start[i] = 1
+		case i < 30:
+			start[i] = mp3ShortWindow[i-18]
+		}
+		switch {
+		case i >= 18:
+			stop[i] = mp3Windows[mp3BlockNormal][i]
+		case i >= 12:
+			stop[i] = 1
+		case i >= 6:
+			stop[i] = mp3ShortWindow[i-6]
+		}
+	}
+}
+
+// hybridSynthesis выполняет обратный MDCT с наложением соседних гранул и добавляет
+// полученные сэмплы подполос в out с весом weight
+func hybridSynthesis(xr *[mp3GranuleSize]float32, g *mp3Granule, overlap *[32][18]float32, out *[18][32]float32, weight float32) {
+	for sb := 0; sb < 32; sb++ {
+		in := xr[sb*18 : sb*18+18]
+		blockType := g.blockType
+		// В смешанном блоке две нижние подполосы длинные
+		if g.mixed && sb < 2 {
+			blockType = mp3BlockNormal
+		}
+
+		var raw [36]float32
+		switch {
+		case allZero(in):
+		case blockType == mp3BlockShort:
+			for w := 0; w < 3; w++ {
+				for i := 0; i < 12; i++ {
+					var sum float32
+					for k := 0; k < 6; k++ {
+						sum += in[3*k+w] * mp3IMDCTShort[k][i]
+					}
+					raw[6+6*w+i] += sum * mp3ShortWindow[i]
+				}
+			}
+		default:
+			window := &mp3Windows[blockType]
+			for i := 0; i < 36; i++ {
+				var sum float32
+				for k := 0; k < 18; k++ {
+					sum += in[k] * mp3IMDCTLong[k][i]
+				}
+				raw[i] = sum * window[i]
+			}
+		}
+
+		prev := &overlap[sb]
+		for i := 0; i < 18; i++ {
+			v := raw[i] + prev[i]
+			prev[i] = raw[18+i]
+			// Инверсия частоты нечетных подполос
+			if sb%2 == 1 && i%2 == 1 {
+				v = -v
+			}
+			out[i][sb] += v * weight
+		}
+	}
+}
+
+func allZero(values []float32) bool {
+	for _, v := range values {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Полифазный банк фильтров синтеза: 32 сэмпла подполос дают 32 сэмпла PCM
+type mp3Synth struct {
+	v [1024]float32
+}
+
+var mp3SynthMatrix = func() (m [64][32]float32) {
+	for i := 0; i < 64; i++ {
+		for k := 0; k < 32; k++ {
+			m[i][k] = float32(math.Cos(float64((16+i)*(2*k+1)) * math.Pi / 64))
+		}
+	}
+	return m
+}()
+
+// Полное окно D[0..511]: D[512-i] = ±D[i], знак меняется в каждом нечетном блоке из 64 отсчетов
+var mp3SynthWindow = func() (w [512]float32) {
+	sign := func(i int) int32 {
+		return 1 - 2*int32(i/64%2)
+	}
+	for i := range w {
+		v := mp3SynthWindowHalf[min(i, 256)]
+		if i > 256 {
+			v = mp3SynthWindowHalf[512-i] * sign(512-i) * sign(i)
+		}
+		w[i] = float32(v) / 65536
+	}
+	return w
+}()
+
+func (s *mp3Synth) filter(in *[32]float32, out []float32) []float32 {
+	copy(s.v[64:], s.v[:960])
+	for i := 0; i < 64; i++ {
+		var sum float32
+		for k, x := range in {
+			sum += mp3SynthMatrix[i][k] * x
+		}
+		s.v[i] = sum
+	}
+
+	for j := 0; j < 32; j++ {
+		var sum float32
+		for i := 0; i < 8; i++ {
+			sum += s.v[128*i+j] * mp3SynthWindow[64*i+j]
+			sum += s.v[128*i+96+j] * mp3SynthWindow[64*i+32+j]
+		}
+		out = append(out, sum)
+	}
+	return out
+}
@@ -0,0 +1,254 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+)
+
+// Типы значений атома data в iTunes metadata
+const (
+	mp4DataUTF8 = 1
+	mp4DataJPEG = 13
+	mp4DataPNG  = 14
+)
+
+type mp4Atom struct {
+	kind   string
+	offset int64 // Смещение содержимого
+	size   int64 // Размер содержимого
+}
+
+// Атомы верхнего уровня в пределах [offset, end) файла
+func mp4Atoms(r io.ReaderAt, offset, end, fileSize int64) ([]mp4Atom, error) {
+	var atoms []mp4Atom
+	for offset+8 <= end {
+		header, err := readAt(r, offset, 8, fileSize)
+		if err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			large, err := readAt(r, offset+8, 8, fileSize)
+			if err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(large))
+			headerSize = 16
+		}
+		if size < headerSize || offset+size > end {
+			return nil, ErrMalformed
+		}
+
+		atoms = append(atoms, mp4Atom{kind: kind, offset: offset + headerSize, size: size - headerSize})
+		offset += size
+	}
+	return atoms, nil
+}
+
+// Дочерние атомы из уже прочитанного в память содержимого
+func mp4Children(data []byte) []mp4Atom {
+	var atoms []mp4Atom
+	offset := int64(0)
+	for offset+8 <= int64(len(data)) {
+		size := int64(binary.BigEndian.Uint32(data[offset:]))
+		kind := string(data[offset+4 : offset+8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			size = int64(len(data)) - offset
+		case 1:
+			if offset+16 > int64(len(data)) {
+				return atoms
+			}
+			size = int64(binary.BigEndian.Uint64(data[offset+8:]))
+			headerSize = 16
+		}
+		if size < headerSize || offset+size > int64(len(data)) {
+			return atoms
+		}
+		atoms = append(atoms, mp4Atom{kind: kind, offset: offset + headerSize, size: size - headerSize})
+		offset += size
+	}
+	return atoms
+}
+
+func (a mp4Atom) data(parent []byte) []byte {
+	return parent[a.offset : a.offset+a.size]
+}
+
+func readMP4(r io.ReaderAt, size int64, meta *Metadata) error {
+	atoms, err := mp4Atoms(r, 0, size, size)
+	if err != nil {
+		return err
+	}
+
+	for _, atom := range atoms {
+		if atom.kind != "moov" {
+			continue
+		}
+		// moov содержит только таблицы и теги, медиаданные лежат в mdat
+		moov, err := readAt(r, atom.offset, atom.size, size)
+		if err != nil {
+			return err
+		}
+		readMoov(moov, meta)
+		return nil
+	}
+	return ErrMalformed
+}
+
+func readMoov(moov []byte, meta *Metadata) {
+	for _, child := range mp4Children(moov) {
+		data := child.data(moov)
+		switch child.kind {
+		case "mvhd":
+			readMvhd(data, meta)
+		case "trak":
+			readTrak(data, meta)
+		case "udta":
+			for _, udta := range mp4Children(data) {
+				if udta.kind == "meta" {
+					readMP4Meta(udta.data(data), meta)
+				}
+			}
+		}
+	}
+}
+
+// mvhd: длительность в единицах timescale
+func readMvhd(data []byte, meta *Metadata) {
+	if len(data) < 1 {
+		return
+	}
+	var timescale uint32
+	var duration uint64
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return
+		}
+		timescale = binary.BigEndian.Uint32(data[20:])
+		duration = binary.BigEndian.Uint64(data[24:])
+	} else {
+		if len(data) < 20 {
+			return
+		}
+		timescale = binary.BigEndian.Uint32(data[12:])
+		duration = uint64(binary.BigEndian.Uint32(data[16:]))
+	}
+	if timescale > 0 {
+		meta.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	}
+}
+
+// Параметры звуковой дорожки из описания сэмплов: trak/mdia/minf/stbl/stsd
+func readTrak(data []byte, meta *Metadata) {
+	path := []string{"mdia", "minf", "stbl", "stsd"}
+	for _, name := range path {
+		found := false
+		for _, child := range mp4Children(data) {
+			if child.kind == name {
+				data, found = child.data(data), true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+
+	// stsd: версия и флаги, число записей, затем запись вида mp4a
+	if len(data) < 8+8+28 {
+		return
+	}
+	entry := data[8:]
+	if kind := string(entry[4:8]); kind != "mp4a" && kind != "alac" {
+		return
+	}
+	// Общий заголовок записи (8) + резерв (8), далее каналы, разрядность, резерв и частота 16.16
+	meta.Channels = int(binary.BigEndian.Uint16(entry[8+16:]))
+	meta.SampleRate = int(binary.BigEndian.Uint32(entry[8+24:]) >> 16)
+}
+
+// meta - полный атом с версией и флагами, но в файлах QuickTime они отсутствуют
+func readMP4Meta(data []byte, meta *Metadata) {
+	if len(data) >= 12 && string(data[4:8]) != "hdlr" {
+		data = data[4:]
+	}
+	for _, child := range mp4Children(data) {
+		if child.kind != "ilst" {
+			continue
+		}
+		ilst := child.data(data)
+		for _, item := range mp4Children(ilst) {
+			readMP4Item(item.kind, item.data(ilst), meta)
+		}
+	}
+}
+
+func readMP4Item(kind string, item []byte, meta *Metadata) {
+	for _, child := range mp4Children(item) {
+		if child.kind != "data" {
+			continue
+		}
+		data := child.data(item)
+		// Тип (4 байта) и локаль (4 байта)
+		if len(data) < 8 {
+			return
+		}
+		valueType := binary.BigEndian.Uint32(data) & 0x00FFFFFF
+		value := data[8:]
+		text := strings.TrimSpace(string(value))
+
+		switch kind {
+		case "\xa9nam":
+			meta.Title = text
+		case "\xa9ART":
+			meta.Artist = text
+		case "\xa9alb":
+			meta.Album = text
+		case "aART":
+			meta.AlbumArtist = text
+		case "\xa9gen":
+			meta.Genre = text
+		case "gnre":
+			// Номер жанра ID3v1, увеличенный на единицу
+			if len(value) >= 2 {
+				meta.Genre = id3v1Genre(int(binary.BigEndian.Uint16(value)) - 1)
+			}
+		case "\xa9day":
+			meta.Year = parseYear(text)
+		case "trkn":
+			if len(value) >= 6 {
+				meta.TrackNumber = int(binary.BigEndian.Uint16(value[2:]))
+				meta.TrackTotal = int(binary.BigEndian.Uint16(value[4:]))
+			}
+		case "disk":
+			if len(value) >= 6 {
+				meta.DiscNumber = int(binary.BigEndian.Uint16(value[2:]))
+				meta.DiscTotal = int(binary.BigEndian.Uint16(value[4:]))
+			}
+		case "\xa9lyr":
+			if valueType == mp4DataUTF8 {
+				meta.Lyrics = strings.ReplaceAll(text, "\r", "\n")
+			}
+		case "covr":
+			if meta.Cover != nil || len(value) == 0 {
+				return
+			}
+			mime := "image/jpeg"
+			if valueType == mp4DataPNG {
+				mime = "image/png"
+			}
+			meta.Cover = &Picture{MimeType: mime, Data: value}
+		}
+		return
+	}
+}
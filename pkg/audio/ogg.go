@@ -0,0 +1,152 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	oggHeaderSize  = 27
+	oggMaxPageSize = oggHeaderSize + 255 + 255*255
+	// Частота гранул Opus не зависит от исходной частоты дискретизации
+	opusGranuleRate = 48000
+)
+
+type oggPage struct {
+	granule  int64
+	serial   uint32
+	segments []byte
+	offset   int64 // Смещение данных страницы в файле
+	size     int64 // Размер данных страницы
+	length   int64 // Длина страницы вместе с заголовком
+}
+
+func readOggPage(r io.ReaderAt, offset, size int64) (*oggPage, error) {
+	header, err := readAt(r, offset, oggHeaderSize, size)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, []byte("OggS")) {
+		return nil, ErrMalformed
+	}
+
+	count := int64(header[26])
+	segments, err := readAt(r, offset+oggHeaderSize, count, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataLength int64
+	for _, s := range segments {
+		dataLength += int64(s)
+	}
+
+	return &oggPage{
+		granule:  int64(binary.LittleEndian.Uint64(header[6:14])),
+		serial:   binary.LittleEndian.Uint32(header[14:18]),
+		segments: segments,
+		offset:   offset + oggHeaderSize + count,
+		size:     dataLength,
+		length:   oggHeaderSize + count + dataLength,
+	}, nil
+}
+
+// Первые n пакетов логического потока. Пакет может занимать несколько страниц.
+func readOggPackets(r io.ReaderAt, size int64, n int) ([][]byte, uint32, error) {
+	var packets [][]byte
+	var current []byte
+	var serial uint32
+
+	offset := int64(0)
+	for first := true; len(packets) < n; first = false {
+		page, err := readOggPage(r, offset, size)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += page.length
+
+		if first {
+			serial = page.serial
+		} else if page.serial != serial {
+			// Страницы других потоков мультиплексированного файла
+			continue
+		}
+
+		data, err := readAt(r, page.offset, page.size, size)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, s := range page.segments {
+			current = append(current, data[:s]...)
+			data = data[s:]
+			if len(current) > maxTagSize {
+				return nil, 0, ErrMalformed
+			}
+			// Сегмент короче 255 байт завершает пакет
+			if s < 255 {
+				packets = append(packets, current)
+				current = nil
+				if len(packets) == n {
+					break
+				}
+			}
+		}
+	}
+	return packets, serial, nil
+}
+
+func readOgg(r io.ReaderAt, size int64, meta *Metadata) error {
+	packets, serial, err := readOggPackets(r, size, 2)
+	if err != nil {
+		return err
+	}
+	ident, comments := packets[0], packets[1]
+
+	var granuleRate int
+	var preSkip int64
+	switch {
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")) && len(ident) >= 16:
+		meta.Channels = int(ident[11])
+		meta.SampleRate = int(binary.LittleEndian.Uint32(ident[12:16]))
+		granuleRate = meta.SampleRate
+		if bytes.HasPrefix(comments, []byte("\x03vorbis")) {
+			readVorbisComments(comments[7:], meta)
+		}
+	case bytes.HasPrefix(ident, []byte("OpusHead")) && len(ident) >= 16:
+		meta.Channels = int(ident[9])
+		preSkip = int64(binary.LittleEndian.Uint16(ident[10:12]))
+		meta.SampleRate = int(binary.LittleEndian.Uint32(ident[12:16]))
+		granuleRate = opusGranuleRate
+		if bytes.HasPrefix(comments, []byte("OpusTags")) {
+			readVorbisComments(comments[8:], meta)
+		}
+	default:
+		return ErrUnsupportedFormat
+	}
+
+	if granule := lastOggGranule(r, size, serial); granule > preSkip {
+		meta.Duration = durationOf(uint64(granule-preSkip), granuleRate)
+	}
+	return nil
+}
+
+// Позиция последней страницы потока - число сэмплов от начала
+func lastOggGranule(r io.ReaderAt, size int64, serial uint32) int64 {
+	start := max(size-oggMaxPageSize, 0)
+	tail, err := readAt(r, start, size-start, size)
+	if err != nil {
+		return 0
+	}
+
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if i+oggHeaderSize > len(tail) {
+			continue
+		}
+		granule := int64(binary.LittleEndian.Uint64(tail[i+6 : i+14]))
+		if binary.LittleEndian.Uint32(tail[i+14:i+18]) == serial && granule >= 0 {
+			return granule
+		}
+	}
+	return 0
+}
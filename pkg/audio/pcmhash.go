@@ -0,0 +1,41 @@
+package audio
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"math"
+)
+
+// PCMHasher считает SHA-256 декодированного звука по мере чтения из Decoder.
+// Хеш не зависит от контейнера и тегов: WAV и FLAC с одинаковым звуком дают один хеш.
+// Сэмплы перед хешированием приводятся к 16 битам.
+type PCMHasher struct {
+	Decoder
+	hash hash.Hash
+	buf  []byte
+}
+
+func NewPCMHasher(dec Decoder) *PCMHasher {
+	h := &PCMHasher{Decoder: dec, hash: sha256.New()}
+	h.hash.Write(binary.LittleEndian.AppendUint32(nil, uint32(dec.SampleRate())))
+	return h
+}
+
+func (h *PCMHasher) Read(buf []float32) (int, error) {
+	n, err := h.Decoder.Read(buf)
+	h.buf = h.buf[:0]
+	for _, sample := range buf[:n] {
+		v := math.Round(float64(sample) * (1 << 15))
+		v = max(math.MinInt16, min(math.MaxInt16, v))
+		h.buf = binary.LittleEndian.AppendUint16(h.buf, uint16(int16(v)))
+	}
+	h.hash.Write(h.buf)
+	return n, err
+}
+
+// Sum возвращает хеш прочитанных сэмплов в hex
+func (h *PCMHasher) Sum() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
@@ -0,0 +1,75 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Поддерживаемые типы аудио
+const (
+	MimeMP3  = "audio/mpeg"
+	MimeFLAC = "audio/flac"
+	MimeOgg  = "audio/ogg"
+	MimeWAV  = "audio/wav"
+	MimeMP4  = "audio/mp4"
+)
+
+// Размер заголовка, достаточный для определения формата
+const SniffLen = 512
+
+var extensions = map[string]string{
+	MimeMP3:  ".mp3",
+	MimeFLAC: ".flac",
+	MimeOgg:  ".ogg",
+	MimeWAV:  ".wav",
+	MimeMP4:  ".m4a",
+}
+
+// Sniff определяет тип аудио по сигнатуре в начале файла.
+// http.DetectContentType не распознает FLAC и MP4, поэтому проверки свои.
+func Sniff(header []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return MimeFLAC, true
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return MimeOgg, true
+	case len(header) >= 12 && bytes.HasPrefix(header, []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return MimeWAV, true
+	case len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")) && isAudioBrand(header[8:12]):
+		return MimeMP4, true
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return MimeMP3, true
+	case isMPEGFrame(header):
+		return MimeMP3, true
+	}
+	return "", false
+}
+
+// Extension возвращает расширение файла для типа аудио
+func Extension(mime string) string {
+	return extensions[mime]
+}
+
+func isAudioBrand(brand []byte) bool {
+	switch string(brand) {
+	case "M4A ", "M4B ", "M4P ", "mp42", "isom", "iso2", "dash", "mp41":
+		return true
+	}
+	return false
+}
+
+// Заголовок кадра MPEG audio: 11 бит синхронизации, layer != 0, допустимые битрейт и частота
+func isMPEGFrame(header []byte) bool {
+	if len(header) < 4 {
+		return false
+	}
+	h := binary.BigEndian.Uint32(header)
+	if h&0xFFE00000 != 0xFFE00000 {
+		return false
+	}
+	version := (h >> 19) & 0x3
+	layer := (h >> 17) & 0x3
+	bitrate := (h >> 12) & 0xF
+	sampleRate := (h >> 10) & 0x3
+	return version != 1 && layer != 0 && bitrate != 0xF && sampleRate != 0x3
+}
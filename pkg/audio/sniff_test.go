@@ -0,0 +1,48 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		mime   string
+	}{
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), MimeFLAC},
+		{"ogg", []byte("OggS\x00\x02"), MimeOgg},
+		{"wav", []byte("RIFF\x24\x08\x00\x00WAVEfmt "), MimeWAV},
+		{"m4a", []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"), MimeMP4},
+		{"mp3 id3", []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), MimeMP3},
+		{"mp3 frame", []byte{0xFF, 0xFB, 0x90, 0x64}, MimeMP3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mime, ok := Sniff(tt.header)
+			assert.True(t, ok)
+			assert.Equal(t, tt.mime, mime)
+		})
+	}
+}
+
+func TestSniffRejects(t *testing.T) {
+	for _, header := range [][]byte{
+		nil,
+		[]byte("<html><body>"),
+		[]byte("\x89PNG\r\n\x1a\n"),
+		[]byte("\x00\x00\x00\x20ftypqt  "), // видео QuickTime
+		{0xFF, 0xFB, 0xF0, 0x64},           // недопустимый битрейт
+	} {
+		_, ok := Sniff(header)
+		assert.False(t, ok, "%q", header)
+	}
+}
+
+func TestExtension(t *testing.T) {
+	assert.Equal(t, ".flac", Extension(MimeFLAC))
+	assert.Equal(t, ".m4a", Extension(MimeMP4))
+	assert.Equal(t, "", Extension("video/mp4"))
+}
@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+)
+
+// Теги RIFF INFO
+var riffInfoTags = map[string]string{
+	"INAM": "TITLE",
+	"IART": "ARTIST",
+	"IPRD": "ALBUM",
+	"IGNR": "GENRE",
+	"ICRD": "DATE",
+	"ITRK": "TRACKNUMBER",
+}
+
+func readWAV(r io.ReaderAt, size int64, meta *Metadata) error {
+	var byteRate uint32
+	var dataSize int64
+
+	offset := int64(12) // "RIFF", размер, "WAVE"
+	for offset+8 <= size {
+		header, err := readAt(r, offset, 8, size)
+		if err != nil {
+			return err
+		}
+		id := string(header[:4])
+		length := int64(binary.LittleEndian.Uint32(header[4:]))
+		offset += 8
+
+		switch id {
+		case "fmt ":
+			chunk, err := readAt(r, offset, min(length, 16), size)
+			if err != nil {
+				return err
+			}
+			if len(chunk) < 16 {
+				return ErrMalformed
+			}
+			meta.Channels = int(binary.LittleEndian.Uint16(chunk[2:]))
+			meta.SampleRate = int(binary.LittleEndian.Uint32(chunk[4:]))
+			byteRate = binary.LittleEndian.Uint32(chunk[8:])
+		case "data":
+			// Размер может быть не записан при потоковой записи
+			dataSize = min(length, size-offset)
+		case "LIST":
+			chunk, err := readAt(r, offset, min(length, size-offset), size)
+			if err != nil {
+				return err
+			}
+			readRIFFInfo(chunk, meta)
+		}
+
+		// Чанки выровнены по двум байтам
+		offset += length + length%2
+	}
+
+	if byteRate > 0 {
+		meta.Duration = time.Duration(float64(dataSize) / float64(byteRate) * float64(time.Second))
+		meta.Bitrate = int(byteRate * 8 / 1000)
+	}
+	return nil
+}
+
+func readRIFFInfo(chunk []byte, meta *Metadata) {
+	if len(chunk) < 4 || string(chunk[:4]) != "INFO" {
+		return
+	}
+	chunk = chunk[4:]
+	for len(chunk) >= 8 {
+		id := string(chunk[:4])
+		length := int(binary.LittleEndian.Uint32(chunk[4:]))
+		if length > len(chunk)-8 {
+			return
+		}
+		if key, ok := riffInfoTags[id]; ok {
+			meta.setTag(key, strings.TrimRight(string(chunk[8:8+length]), "\x00"))
+		}
+		next := 8 + length + length%2
+		if next > len(chunk) {
+			return
+		}
+		chunk = chunk[next:]
+	}
+}
@@ -0,0 +1,74 @@
+package diff
+
+type Op string
+
+const (
+	OpEqual   Op = "equal"
+	OpInsert  Op = "insert"
+	OpDelete  Op = "delete"
+	OpReplace Op = "replace"
+)
+
+// Изменение между последовательностями. Индексы равны -1, если элемента нет с этой стороны.
+type Change struct {
+	Op       Op
+	OldIndex int
+	NewIndex int
+}
+
+// Compare сравнивает две последовательности ключей через наибольшую общую подпоследовательность.
+// Подряд идущие удаления и вставки объединяются в замены.
+func Compare(old, new []string) []Change {
+	n, m := len(old), len(new)
+
+	// lcs[i][j] - длина НОП для old[i:] и new[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []Change
+	var deleted, inserted []int
+	flush := func() {
+		for len(deleted) > 0 && len(inserted) > 0 {
+			changes = append(changes, Change{Op: OpReplace, OldIndex: deleted[0], NewIndex: inserted[0]})
+			deleted, inserted = deleted[1:], inserted[1:]
+		}
+		for _, i := range deleted {
+			changes = append(changes, Change{Op: OpDelete, OldIndex: i, NewIndex: -1})
+		}
+		for _, j := range inserted {
+			changes = append(changes, Change{Op: OpInsert, OldIndex: -1, NewIndex: j})
+		}
+		deleted, inserted = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && old[i] == new[j]:
+			flush()
+			changes = append(changes, Change{Op: OpEqual, OldIndex: i, NewIndex: j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			inserted = append(inserted, j)
+			j++
+		default:
+			deleted = append(deleted, i)
+			i++
+		}
+	}
+	flush()
+
+	return changes
+}
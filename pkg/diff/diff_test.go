@@ -0,0 +1,34 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare_Equal(t *testing.T) {
+	changes := Compare([]string{"a", "b"}, []string{"a", "b"})
+
+	assert.Equal(t, []Change{
+		{Op: OpEqual, OldIndex: 0, NewIndex: 0},
+		{Op: OpEqual, OldIndex: 1, NewIndex: 1},
+	}, changes)
+}
+
+func TestCompare_InsertDeleteReplace(t *testing.T) {
+	changes := Compare([]string{"a", "b", "c", "d"}, []string{"a", "x", "c", "d", "e"})
+
+	assert.Equal(t, []Change{
+		{Op: OpEqual, OldIndex: 0, NewIndex: 0},
+		{Op: OpReplace, OldIndex: 1, NewIndex: 1},
+		{Op: OpEqual, OldIndex: 2, NewIndex: 2},
+		{Op: OpEqual, OldIndex: 3, NewIndex: 3},
+		{Op: OpInsert, OldIndex: -1, NewIndex: 4},
+	}, changes)
+}
+
+func TestCompare_Empty(t *testing.T) {
+	assert.Equal(t, []Change{{Op: OpDelete, OldIndex: 0, NewIndex: -1}}, Compare([]string{"a"}, nil))
+	assert.Equal(t, []Change{{Op: OpInsert, OldIndex: -1, NewIndex: 0}}, Compare(nil, []string{"a"}))
+	assert.Nil(t, Compare(nil, nil))
+}
@@ -2,6 +2,7 @@ package er
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -20,19 +21,42 @@ type (
 	UnauthorizedError struct {
 		Message string
 	}
+	ForbiddenError struct {
+		Message string
+	}
 	InternalError struct {
 		Message string
 	}
 	ConflictError struct {
 		ResourceType string
 	}
+	PayloadTooLargeError struct {
+		Message string
+	}
+	UnsupportedMediaTypeError struct {
+		Message string
+	}
+	RangeNotSatisfiableError struct {
+		Message string
+	}
+	// Загруженный файл совпадает с файлом или звуком уже существующей песни
+	DuplicateSongError struct {
+		SongID uint
+	}
 )
 
-func (e ValidationError) Error() string   { return e.Message }
-func (e NotFoundError) Error() string     { return e.Message }
-func (e UnauthorizedError) Error() string { return e.Message }
-func (e InternalError) Error() string     { return e.Message }
-func (e *ConflictError) Error() string    { return e.ResourceType }
+func (e ValidationError) Error() string           { return e.Message }
+func (e NotFoundError) Error() string             { return e.Message }
+func (e UnauthorizedError) Error() string         { return e.Message }
+func (e ForbiddenError) Error() string            { return e.Message }
+func (e InternalError) Error() string             { return e.Message }
+func (e *ConflictError) Error() string            { return e.ResourceType }
+func (e PayloadTooLargeError) Error() string      { return e.Message }
+func (e UnsupportedMediaTypeError) Error() string { return e.Message }
+func (e RangeNotSatisfiableError) Error() string  { return e.Message }
+func (e *DuplicateSongError) Error() string {
+	return fmt.Sprintf("The same audio is already uploaded for song %d", e.SongID)
+}
 
 // ErrorResponse - унифицированный формат ответа об ошибке
 type ErrorResponse struct {
@@ -66,6 +90,10 @@ var (
 		Message: "Wrong user credentials",
 	}
 
+	ErrAdminOnly = &ForbiddenError{
+		Message: "Only administrators can access this resource",
+	}
+
 	ErrUserExists = &ConflictError{
 		ResourceType: "User already exists",
 	}
@@ -113,7 +141,303 @@ var (
 	ErrGenreNotExists = &NotFoundError{
 		Message: "This genre does not exists",
 	}
-	
+
+	ErrLyricsNotExists = &NotFoundError{
+		Message: "Lyrics for this song do not exist",
+	}
+
+	ErrLyricsNotSynced = &ValidationError{
+		Message: "Lyrics are not synchronized: every line needs a timestamp",
+	}
+
+	ErrLyricsFormat = &ValidationError{
+		Message: "Unknown lyrics format: expected lrc, json or txt",
+	}
+
+	ErrLyricsKind = &ValidationError{
+		Message: "Unknown lyrics kind: expected original, translation or transliteration",
+	}
+
+	ErrLyricsOriginalRequired = &ValidationError{
+		Message: "Original lyrics must be added before translations",
+	}
+
+	ErrLyricsRevisionNotExists = &NotFoundError{
+		Message: "Lyrics revision does not exist",
+	}
+
+	ErrUploadNotExists = &NotFoundError{
+		Message: "Upload does not exist",
+	}
+
+	ErrUploadOffset = &ConflictError{
+		ResourceType: "Upload offset does not match the received size",
+	}
+
+	ErrUploadNotPending = &ConflictError{
+		ResourceType: "Upload is already completed or aborted",
+	}
+
+	ErrFileTooLarge = &PayloadTooLargeError{
+		Message: "File exceeds the maximum upload size",
+	}
+
+	ErrUploadQuotaExceeded = &PayloadTooLargeError{
+		Message: "Upload quota exceeded",
+	}
+
+	ErrUnsupportedAudio = &UnsupportedMediaTypeError{
+		Message: "Unsupported audio format: expected mp3, flac, ogg, wav or m4a",
+	}
+
+	ErrUnsupportedImage = &UnsupportedMediaTypeError{
+		Message: "Unsupported image format: expected jpeg, png or webp",
+	}
+
+	ErrImageTooLarge = &PayloadTooLargeError{
+		Message: "Image exceeds the maximum upload size",
+	}
+
+	ErrImageDimensions = &ValidationError{
+		Message: "Image must be at least 64x64 and at most 50 megapixels",
+	}
+
+	ErrImageNotExists = &NotFoundError{
+		Message: "Image does not exist",
+	}
+
+	ErrProfileNotExists = &NotFoundError{
+		Message: "Profile does not exist",
+	}
+
+	ErrSongFileNotExists = &NotFoundError{
+		Message: "Audio file for this song is not uploaded",
+	}
+
+	ErrStreamURLInvalid = &UnauthorizedError{
+		Message: "Stream URL is invalid or expired",
+	}
+
+	ErrRangeNotSatisfiable = &RangeNotSatisfiableError{
+		Message: "Requested range is outside of the file",
+	}
+
+	ErrWaveformNotExists = &NotFoundError{
+		Message: "Waveform is not available for this song",
+	}
+
+	ErrWaveformResolution = &ValidationError{
+		Message: "samples_per_pixel must be one of 256, 1024, 4096",
+	}
+
+	ErrAlbumType = &ValidationError{
+		Message: "Unknown album type: expected single, ep, lp, compilation or live",
+	}
+
+	ErrAlbumDiscs = &ValidationError{
+		Message: "Disc numbers must be positive and unique, and every disc needs a title",
+	}
+
+	ErrTrackNumber = &ValidationError{
+		Message: "Track and disc numbers must be positive",
+	}
+
+	ErrTrackTaken = &ConflictError{
+		ResourceType: "Track number is already taken on this disc",
+	}
+
+	ErrISRCFormat = &ValidationError{
+		Message: "ISRC must have the form CC-XXX-YY-NNNNN",
+	}
+
+	ErrUPCFormat = &ValidationError{
+		Message: "UPC must have 12 digits and EAN 13 digits with a valid check digit",
+	}
+
+	ErrISRCTaken = &ConflictError{
+		ResourceType: "ISRC is already assigned to another song",
+	}
+
+	ErrUPCTaken = &ConflictError{
+		ResourceType: "UPC is already assigned to another album",
+	}
+
+	ErrNotInTrash = &NotFoundError{
+		Message: "Entry is not in the trash",
+	}
+
+	ErrTrashParent = &ConflictError{
+		ResourceType: "Restore the album or artist of this entry first",
+	}
+
+	ErrRestoreConflict = &ConflictError{
+		ResourceType: "Entry can't be restored: its ISRC, UPC or track number is taken",
+	}
+
+	ErrGenreAliasTaken = &ConflictError{
+		ResourceType: "Alias is already used by another genre",
+	}
+
+	ErrGenreParent = &ValidationError{
+		Message: "Parent genre does not exist",
+	}
+
+	ErrGenreCycle = &ValidationError{
+		Message: "A genre can't be a sub-genre of itself or of its sub-genres",
+	}
+
+	ErrGenreReplacement = &ValidationError{
+		Message: "Replacement genre does not exist or is the genre being deleted",
+	}
+
+	ErrTagName = &ValidationError{
+		Message: "Invalid tag: expected 2-40 letters or digits",
+	}
+
+	ErrTagVote = &ValidationError{
+		Message: "Invalid vote: expected 1 or -1",
+	}
+
+	ErrTagResource = &ValidationError{
+		Message: "Unknown entity: expected song or album",
+	}
+
+	ErrTagVoteNotExists = &NotFoundError{
+		Message: "You have not voted for this tag",
+	}
+
+	ErrRatingStars = &ValidationError{
+		Message: "Invalid rating: expected 1 to 5 stars",
+	}
+
+	ErrRatingNotExists = &NotFoundError{
+		Message: "You have not rated this entry",
+	}
+
+	ErrReviewText = &ValidationError{
+		Message: "Review text must be 1-5000 characters",
+	}
+
+	ErrReviewExists = &ConflictError{
+		ResourceType: "You have already reviewed this entry, edit your review instead",
+	}
+
+	ErrReviewNotExists = &NotFoundError{
+		Message: "Review does not exist",
+	}
+
+	ErrReviewOwner = &ForbiddenError{
+		Message: "Only the author can change this review",
+	}
+
+	ErrCommentText = &ValidationError{
+		Message: "Comment text must be 1-2000 characters",
+	}
+
+	ErrCommentNotExists = &NotFoundError{
+		Message: "Comment does not exist",
+	}
+
+	ErrCommentParent = &ValidationError{
+		Message: "Reply must answer a comment on the same entry",
+	}
+
+	ErrCommentAnchor = &ValidationError{
+		Message: "Invalid timestamp: expected m:ss within the song duration, only songs have timestamps",
+	}
+
+	ErrCommentSort = &ValidationError{
+		Message: "Unknown sort: expected newest, oldest or top",
+	}
+
+	ErrCommentAuthor = &ForbiddenError{
+		Message: "Only the author can edit this comment",
+	}
+
+	ErrCommentEditWindow = &ForbiddenError{
+		Message: "The time to edit this comment has passed",
+	}
+
+	ErrCommentOwner = &ForbiddenError{
+		Message: "Only the author or an administrator can delete this comment",
+	}
+
+	ErrReportReason = &ValidationError{
+		Message: "Unknown reason: expected spam, abuse, copyright, inappropriate, misleading or other",
+	}
+
+	ErrReportEntity = &ValidationError{
+		Message: "Unknown entity: expected artist, album, song, genre, review or comment",
+	}
+
+	ErrReportTarget = &NotFoundError{
+		Message: "Reported entry does not exist",
+	}
+
+	ErrReportExists = &ConflictError{
+		ResourceType: "You have already reported this entry, it is waiting for a moderator",
+	}
+
+	ErrReportState = &ValidationError{
+		Message: "Unknown state: expected open, actioned or dismissed",
+	}
+
+	ErrReportResolution = &ValidationError{
+		Message: "Reports can only be actioned or dismissed, and only actioned reports take content down",
+	}
+
+	ErrReportIDs = &ValidationError{
+		Message: "Expected 1-100 report ids",
+	}
+
+	ErrTakedownResource = &ValidationError{
+		Message: "Genres can't be taken down, merge or delete them instead",
+	}
+
+	ErrTakenDown = &ForbiddenError{
+		Message: "Entry was taken down by moderators",
+	}
+
+	ErrFollowSelf = &ValidationError{
+		Message: "You can't follow yourself",
+	}
+
+	ErrVisibility = &ValidationError{
+		Message: "Unknown visibility: expected public, followers or private",
+	}
+
+	ErrCollectionNotExists = &NotFoundError{
+		Message: "Collection does not exist",
+	}
+
+	ErrMergeSelf = &ValidationError{
+		Message: "Entry can't be merged into itself",
+	}
+
+	ErrMergeResource = &ValidationError{
+		Message: "Unknown entity: expected artist, album or genre",
+	}
+
+	ErrAuditEntity = &ValidationError{
+		Message: "Unknown entity: expected artist, album, song or genre",
+	}
+
+	ErrStatus = &ValidationError{
+		Message: "Unknown status: expected draft, in_review, unlisted or archived",
+	}
+
+	ErrCreditRole = &ValidationError{
+		Message: "Unknown credit role: expected primary, featured, composer, lyricist, producer or remixer",
+	}
+
+	ErrCreditTarget = &ValidationError{
+		Message: "Credit needs either artist_id or name",
+	}
+
+	ErrCreditDuplicate = &ValidationError{
+		Message: "The same person is credited twice in one role",
+	}
+
 	ErrUserNotVerified = &ValidationError{
 		Message: "User is not verified",
 	}
@@ -160,12 +484,42 @@ func (h *ErrorHandler) Handle(ctx context.Context, err error) (int, ErrorRespons
 				Tip:       "Please login and try again",
 				Reference: errorID,
 			}
+		case *ForbiddenError:
+			return http.StatusForbidden, ErrorResponse{
+				Error:     e.Error(),
+				Tip:       "Ask an administrator for access",
+				Reference: errorID,
+			}
 		case *ConflictError:
 			return http.StatusConflict, ErrorResponse{
 				Error:     e.Error(),
 				Tip:       "Please check if the " + e.ResourceType,
 				Reference: errorID,
 			}
+		case *PayloadTooLargeError:
+			return http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error:     e.Error(),
+				Tip:       "Reduce the file size or free up space",
+				Reference: errorID,
+			}
+		case *UnsupportedMediaTypeError:
+			return http.StatusUnsupportedMediaType, ErrorResponse{
+				Error:     e.Error(),
+				Tip:       "Upload a file in a supported format",
+				Reference: errorID,
+			}
+		case *DuplicateSongError:
+			return http.StatusConflict, ErrorResponse{
+				Error:     e.Error(),
+				Tip:       fmt.Sprintf("Use the existing song /api/v1/song/%d instead of uploading it again", e.SongID),
+				Reference: errorID,
+			}
+		case *RangeNotSatisfiableError:
+			return http.StatusRequestedRangeNotSatisfiable, ErrorResponse{
+				Error:     e.Error(),
+				Tip:       "Check the Range header against the file size",
+				Reference: errorID,
+			}
 		default:
 			status := http.StatusInternalServerError
 			msg := "Internal server error"
@@ -1,7 +1,16 @@
 package event
 
+import (
+	"sync"
+	"time"
+)
+
 const (
 	EventSendEmail = "send.email"
+	// Наступила дата релиза альбома, данные - Released
+	EventReleased = "album.released"
+	// Песню опубликовали в уже вышедшем альбоме, данные - SongReleased
+	EventSongReleased = "song.released"
 )
 
 type Event struct {
@@ -9,20 +18,52 @@ type Event struct {
 	Data any
 }
 
+type Released struct {
+	AlbumID     uint
+	ArtistID    uint
+	Title       string
+	ReleaseDate time.Time
+}
+
+type SongReleased struct {
+	SongID      uint
+	AlbumID     uint
+	ArtistID    uint
+	Title       string
+	ReleaseDate time.Time
+}
+
+// Сколько событий может накопиться у подписчика, прежде чем Publish начнет его ждать
+const subscriberBuffer = 64
+
+// EventBus доставляет каждое событие всем подписчикам
 type EventBus struct {
-	bus chan Event
+	mu          sync.RWMutex
+	subscribers []chan Event
 }
 
 func NewEventBus() *EventBus {
-	return &EventBus{
-		bus: make(chan Event),
-	}
+	return &EventBus{}
 }
 
+// Publish кладет событие в буфер каждого подписчика. Ждать приходится, только если буфер
+// подписчика заполнен, поэтому издатели все равно вызывают Publish в отдельной горутине.
 func (e *EventBus) Publish(event Event) {
-	e.bus <- event
+	e.mu.RLock()
+	subscribers := e.subscribers
+	e.mu.RUnlock()
+
+	for _, subscriber := range subscribers {
+		subscriber <- event
+	}
 }
 
-func (e *EventBus) Subscribe() <- chan Event {
-	return e.bus
+// Subscribe возвращает канал нового подписчика. События, опубликованные раньше, в него не попадут.
+func (e *EventBus) Subscribe() <-chan Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	subscriber := make(chan Event, subscriberBuffer)
+	e.subscribers = append(e.subscribers, subscriber)
+	return subscriber
 }
@@ -0,0 +1,40 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus_FanOut(t *testing.T) {
+	bus := NewEventBus()
+	email := bus.Subscribe()
+	feed := bus.Subscribe()
+
+	bus.Publish(Event{Type: EventReleased, Data: Released{AlbumID: 3}})
+
+	for _, subscriber := range []<-chan Event{email, feed} {
+		got := <-subscriber
+		assert.Equal(t, EventReleased, got.Type)
+		assert.Equal(t, uint(3), got.Data.(Released).AlbumID)
+	}
+}
+
+func TestEventBus_SlowSubscriber(t *testing.T) {
+	bus := NewEventBus()
+	bus.Subscribe() // Подписчик, который не читает события
+	feed := bus.Subscribe()
+
+	// Пока буфер не заполнен, молчащий подписчик не задерживает издателя и остальных
+	for i := 0; i < subscriberBuffer; i++ {
+		bus.Publish(Event{Type: EventSongReleased, Data: SongReleased{SongID: uint(i)}})
+	}
+	for i := 0; i < subscriberBuffer; i++ {
+		assert.Equal(t, uint(i), (<-feed).Data.(SongReleased).SongID)
+	}
+}
+
+func TestEventBus_NoSubscribers(t *testing.T) {
+	// Событие без подписчиков теряется, а не блокирует издателя
+	NewEventBus().Publish(Event{Type: EventSendEmail})
+}
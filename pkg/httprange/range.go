@@ -0,0 +1,72 @@
+// Package httprange разбирает заголовок Range для запросов части файла.
+// Поддерживается один диапазон байт: несколько диапазонов сервер вправе проигнорировать
+// и отдать файл целиком.
+package httprange
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Диапазон не пересекается с файлом, ответ 416
+var ErrUnsatisfiable = errors.New("httprange: range not satisfiable")
+
+type Range struct {
+	Start  int64
+	Length int64
+}
+
+// ContentRange - значение заголовка Content-Range для ответа 206
+func (r Range) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// Unsatisfied - значение Content-Range для ответа 416
+func Unsatisfied(size int64) string {
+	return fmt.Sprintf("bytes */%d", size)
+}
+
+// Parse возвращает nil, если заголовок пуст, некорректен или содержит несколько диапазонов:
+// в этих случаях отдается весь файл
+func Parse(header string, size int64) (*Range, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+
+	// Суффикс: последние N байт
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return nil, nil
+		}
+		if n == 0 || size == 0 {
+			return nil, ErrUnsatisfiable
+		}
+		n = min(n, size)
+		return &Range{Start: size - n, Length: n}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return nil, ErrUnsatisfiable
+	}
+	return &Range{Start: start, Length: end - start + 1}, nil
+}
@@ -0,0 +1,40 @@
+package httprange
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		header string
+		want   *Range
+		err    error
+	}{
+		{"", nil, nil},
+		{"bytes=0-99", &Range{Start: 0, Length: 100}, nil},
+		{"bytes=100-", &Range{Start: 100, Length: 900}, nil},
+		{"bytes=900-5000", &Range{Start: 900, Length: 100}, nil},
+		{"bytes=-200", &Range{Start: 800, Length: 200}, nil},
+		{"bytes=-5000", &Range{Start: 0, Length: 1000}, nil},
+		{"bytes=1000-", nil, ErrUnsatisfiable},
+		{"bytes=-0", nil, ErrUnsatisfiable},
+		// Игнорируются: отдается весь файл
+		{"items=0-10", nil, nil},
+		{"bytes=0-10,20-30", nil, nil},
+		{"bytes=10-5", nil, nil},
+		{"bytes=abc", nil, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.header, 1000)
+		assert.Equal(t, tt.err, err, tt.header)
+		assert.Equal(t, tt.want, got, tt.header)
+	}
+}
+
+func TestContentRange(t *testing.T) {
+	assert.Equal(t, "bytes 0-99/1000", Range{Start: 0, Length: 100}.ContentRange(1000))
+	assert.Equal(t, "bytes */1000", Unsatisfied(1000))
+}
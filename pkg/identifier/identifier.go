@@ -0,0 +1,90 @@
+// Package identifier проверяет отраслевые коды записей и релизов: ISRC для песен, UPC и EAN для альбомов.
+package identifier
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidISRC = errors.New("identifier: invalid ISRC")
+	ErrInvalidUPC  = errors.New("identifier: invalid UPC/EAN")
+)
+
+// NormalizeISRC приводит код к виду из 12 символов без дефисов: "US-S1Z-99-00001" -> "USS1Z9900001".
+// Формат: страна (2 буквы), регистрант (3 буквы или цифры), год (2 цифры), номер записи (5 цифр).
+// Контрольной цифры у ISRC нет.
+func NormalizeISRC(value string) (string, error) {
+	code := strings.ToUpper(strip(value))
+	if len(code) != 12 {
+		return "", ErrInvalidISRC
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		var ok bool
+		switch {
+		case i < 2:
+			ok = isLetter(c)
+		case i < 5:
+			ok = isLetter(c) || isDigit(c)
+		default:
+			ok = isDigit(c)
+		}
+		if !ok {
+			return "", ErrInvalidISRC
+		}
+	}
+	return code, nil
+}
+
+// NormalizeUPC проверяет UPC-A (12 цифр) или EAN-13 (13 цифр) вместе с контрольной цифрой GS1.
+// UPC-A хранится как EAN-13 с ведущим нулем, чтобы один релиз не записывался двумя кодами.
+func NormalizeUPC(value string) (string, error) {
+	code := strip(value)
+	if len(code) == 12 {
+		code = "0" + code
+	}
+	if len(code) != 13 {
+		return "", ErrInvalidUPC
+	}
+	for i := 0; i < len(code); i++ {
+		if !isDigit(code[i]) {
+			return "", ErrInvalidUPC
+		}
+	}
+	if checkDigit(code[:12]) != code[12] {
+		return "", ErrInvalidUPC
+	}
+	return code, nil
+}
+
+// Контрольная цифра GS1: веса 3 и 1 справа налево
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// Дефисы и пробелы допускаются при вводе, но не хранятся
+func strip(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
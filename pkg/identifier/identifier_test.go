@@ -0,0 +1,42 @@
+package identifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeISRC(t *testing.T) {
+	for input, want := range map[string]string{
+		"USS1Z9900001":     "USS1Z9900001",
+		"us-s1z-99-00001":  "USS1Z9900001",
+		" GB AYE 15 01234": "GBAYE1501234",
+	} {
+		code, err := NormalizeISRC(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, code, input)
+	}
+
+	for _, input := range []string{"", "USS1Z990000", "USS1Z99000012", "1SS1Z9900001", "US-S1Z-9A-00001", "US_S1Z9900001"} {
+		_, err := NormalizeISRC(input)
+		assert.ErrorIs(t, err, ErrInvalidISRC, input)
+	}
+}
+
+func TestNormalizeUPC(t *testing.T) {
+	for input, want := range map[string]string{
+		"036000291452":    "0036000291452",
+		"4006381333931":   "4006381333931",
+		"400-6381-333931": "4006381333931",
+	} {
+		code, err := NormalizeUPC(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, code, input)
+	}
+
+	// Неверная контрольная цифра, длина или символы
+	for _, input := range []string{"036000291453", "4006381333932", "12345", "40063813339a1", ""} {
+		_, err := NormalizeUPC(input)
+		assert.ErrorIs(t, err, ErrInvalidUPC, input)
+	}
+}
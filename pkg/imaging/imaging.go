@@ -0,0 +1,137 @@
+// Package imaging проверяет загружаемые изображения и строит квадратные миниатюры.
+// Изображение всегда перекодируется, поэтому EXIF и другие метаданные в результат не попадают.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strconv"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Поддерживаемые форматы
+const (
+	MimeJPEG = "image/jpeg"
+	MimePNG  = "image/png"
+	MimeWebP = "image/webp"
+)
+
+// Стороны квадратных миниатюр
+var ThumbnailSizes = []int{64, 300, 1000}
+
+// Ограничение на число пикселей защищает от распаковки огромных изображений
+const MaxPixels = 50_000_000
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("imaging: unsupported image format")
+	ErrTooLarge          = errors.New("imaging: image dimensions are too large")
+)
+
+// Sniff определяет формат изображения по сигнатуре
+func Sniff(header []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(header, []byte("\xFF\xD8\xFF")):
+		return MimeJPEG, true
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return MimePNG, true
+	case len(header) >= 12 && bytes.HasPrefix(header, []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")):
+		return MimeWebP, true
+	}
+	return "", false
+}
+
+// Decode проверяет формат и размеры и декодирует изображение.
+// Для JPEG применяется поворот из EXIF Orientation, т.к. сами метаданные отбрасываются.
+func Decode(data []byte) (image.Image, string, error) {
+	mime, ok := Sniff(data)
+	if !ok {
+		return nil, "", ErrUnsupportedFormat
+	}
+
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch mime {
+	case MimeJPEG:
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case MimePNG:
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case MimeWebP:
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	}
+
+	conf, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imaging: %w", err)
+	}
+	if conf.Width <= 0 || conf.Height <= 0 || int64(conf.Width)*int64(conf.Height) > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imaging: %w", err)
+	}
+
+	if mime == MimeJPEG {
+		img = orient(img, jpegOrientation(data))
+	}
+	return img, mime, nil
+}
+
+// Thumbnail вырезает центральный квадрат и уменьшает его до size.
+// Изображение меньше size не увеличивается.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Point{
+		X: bounds.Min.X + (bounds.Dx()-side)/2,
+		Y: bounds.Min.Y + (bounds.Dy()-side)/2,
+	})
+
+	size = min(size, side)
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// Format выбирает формат миниатюр: JPEG, если нет прозрачности, иначе PNG
+func Format(img image.Image) string {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		return MimePNG
+	}
+	return MimeJPEG
+}
+
+func Encode(w io.Writer, img image.Image, mime string) error {
+	switch mime {
+	case MimeJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case MimePNG:
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		return encoder.Encode(w, img)
+	}
+	return ErrUnsupportedFormat
+}
+
+func Extension(mime string) string {
+	if mime == MimePNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// ThumbnailKey - ключ миниатюры по ключу набора вида "album/1/abc.jpg": "album/1/abc/300.jpg"
+func ThumbnailKey(key string, size int) string {
+	ext := path.Ext(key)
+	return key[:len(key)-len(ext)] + "/" + strconv.Itoa(size) + ext
+}
@@ -0,0 +1,121 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Левая половина красная, правая синяя
+func halves(w, h int, alpha uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.NRGBA{R: 255, A: alpha})
+			} else {
+				img.Set(x, y, color.NRGBA{B: 255, A: alpha})
+			}
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	return buf.Bytes()
+}
+
+// Вставляет после SOI сегмент APP1 с EXIF, содержащим только Orientation
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestDecode_AppliesOrientationAndStripsExif(t *testing.T) {
+	data := withOrientation(encodeJPEG(t, halves(40, 20, 255)), 6)
+	assert.Equal(t, 6, jpegOrientation(data))
+
+	img, mime, err := Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, MimeJPEG, mime)
+	// Поворот на 90° по часовой: левая красная половина оказывается сверху
+	assert.Equal(t, image.Rect(0, 0, 20, 40), img.Bounds())
+	assert.True(t, isRed(img.At(10, 5)))
+	assert.False(t, isRed(img.At(10, 35)))
+
+	var out bytes.Buffer
+	assert.NoError(t, Encode(&out, img, Format(img)))
+	assert.NotContains(t, out.String(), "Exif")
+	assert.Equal(t, 1, jpegOrientation(out.Bytes()))
+}
+
+func TestDecode_Formats(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, halves(8, 8, 128)))
+	img, mime, err := Decode(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, MimePNG, mime)
+	// Прозрачность сохраняется только в PNG
+	assert.Equal(t, MimePNG, Format(img))
+
+	// Lossless WebP 1x1
+	webpData, _ := base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+	img, mime, err = Decode(webpData)
+	assert.NoError(t, err)
+	assert.Equal(t, MimeWebP, mime)
+	assert.Equal(t, image.Rect(0, 0, 1, 1), img.Bounds())
+
+	_, _, err = Decode([]byte("GIF89a......"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, _, err = Decode([]byte("\x89PNG\r\n\x1a\ntruncated"))
+	assert.Error(t, err)
+}
+
+func TestThumbnail(t *testing.T) {
+	src := halves(1200, 800, 255)
+
+	thumb := Thumbnail(src, 300)
+	assert.Equal(t, image.Rect(0, 0, 300, 300), thumb.Bounds())
+	// Центральный квадрат: слева красный, справа синий
+	assert.True(t, isRed(thumb.At(10, 150)))
+	assert.False(t, isRed(thumb.At(290, 150)))
+
+	// Маленькие изображения не увеличиваются
+	thumb = Thumbnail(halves(100, 50, 255), 1000)
+	assert.Equal(t, image.Rect(0, 0, 50, 50), thumb.Bounds())
+	assert.Equal(t, MimeJPEG, Format(thumb))
+}
+
+func TestThumbnailKey(t *testing.T) {
+	assert.Equal(t, "album/1/abc/300.jpg", ThumbnailKey("album/1/abc.jpg", 300))
+	assert.Equal(t, "avatar/2/def/64.png", ThumbnailKey("avatar/2/def.png", 64))
+}
@@ -0,0 +1,262 @@
+package lrc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Стандартные заголовки LRC
+const (
+	TagTitle  = "ti"
+	TagArtist = "ar"
+	TagAlbum  = "al"
+	TagLang   = "la"
+	TagAuthor = "au"
+	TagBy     = "by"
+	TagLength = "length"
+	TagOffset = "offset"
+)
+
+// Порядок заголовков при сериализации
+var tagOrder = []string{TagTitle, TagArtist, TagAlbum, TagLang, TagAuthor, TagBy, TagLength}
+
+var (
+	ErrNoLines      = errors.New("lrc: no timed lines found")
+	ErrBadTimestamp = errors.New("lrc: invalid timestamp")
+)
+
+// Слово с собственной меткой времени (enhanced LRC)
+type Word struct {
+	Time time.Duration
+	Text string
+}
+
+// Строка текста с меткой времени
+type Line struct {
+	Time  time.Duration
+	Text  string
+	Words []Word
+}
+
+// Разобранный LRC файл
+type File struct {
+	Tags   map[string]string
+	Offset time.Duration // Значение заголовка [offset:], положительное - текст раньше
+	Lines  []Line
+}
+
+// Parse разбирает текст в формате LRC, включая enhanced-метки слов.
+// Строки с несколькими метками ([00:12.00][00:40.00]) разворачиваются в несколько строк,
+// результат сортируется по времени.
+func Parse(data string) (*File, error) {
+	file := &File{Tags: make(map[string]string)}
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(data, "\ufeff")))
+	for scanner.Scan() {
+		raw := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(raw, "[") {
+			continue
+		}
+
+		var times []time.Duration
+		rest := raw
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				break
+			}
+			content := rest[1:end]
+
+			if t, err := parseTimestamp(content); err == nil {
+				times = append(times, t)
+				rest = rest[end+1:]
+				continue
+			}
+
+			// Заголовок вида [key:value] допустим только в начале строки
+			if len(times) == 0 {
+				if key, value, ok := strings.Cut(content, ":"); ok {
+					if err := file.setTag(strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)); err != nil {
+						return nil, err
+					}
+				}
+			}
+			rest = ""
+			break
+		}
+
+		if len(times) == 0 {
+			continue
+		}
+
+		text, words, err := parseWords(rest)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range times {
+			file.Lines = append(file.Lines, Line{Time: t, Text: text, Words: words})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(file.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+
+	sort.SliceStable(file.Lines, func(i, j int) bool {
+		return file.Lines[i].Time < file.Lines[j].Time
+	})
+
+	return file, nil
+}
+
+func (f *File) setTag(key, value string) error {
+	if key == TagOffset {
+		ms, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("lrc: invalid offset %q", value)
+		}
+		f.Offset = time.Duration(ms) * time.Millisecond
+		return nil
+	}
+	f.Tags[key] = value
+	return nil
+}
+
+// Разбор текста строки с метками слов <mm:ss.xx>
+func parseWords(s string) (string, []Word, error) {
+	if !strings.Contains(s, "<") {
+		return strings.TrimSpace(s), nil, nil
+	}
+
+	var words []Word
+	var text strings.Builder
+	rest := s
+	for {
+		start := strings.IndexByte(rest, '<')
+		if start < 0 {
+			text.WriteString(rest)
+			if len(words) > 0 {
+				words[len(words)-1].Text += rest
+			}
+			break
+		}
+		end := strings.IndexByte(rest[start:], '>')
+		if end < 0 {
+			text.WriteString(rest)
+			break
+		}
+		end += start
+
+		t, err := parseTimestamp(rest[start+1 : end])
+		if err != nil {
+			return "", nil, err
+		}
+		text.WriteString(rest[:start])
+		if len(words) > 0 {
+			words[len(words)-1].Text += rest[:start]
+		}
+		words = append(words, Word{Time: t})
+		rest = rest[end+1:]
+	}
+
+	// Последняя метка без слова обозначает конец строки
+	result := words[:0]
+	for _, w := range words {
+		w.Text = strings.TrimSpace(w.Text)
+		if w.Text != "" {
+			result = append(result, w)
+		}
+	}
+
+	return strings.Join(strings.Fields(text.String()), " "), result, nil
+}
+
+// Разбор метки времени: mm:ss, mm:ss.x, mm:ss.xx, mm:ss.xxx или mm:ss:xx
+func parseTimestamp(s string) (time.Duration, error) {
+	minStr, secStr, ok := strings.Cut(s, ":")
+	if !ok || minStr == "" {
+		return 0, ErrBadTimestamp
+	}
+	minutes, err := strconv.Atoi(minStr)
+	if err != nil || minutes < 0 {
+		return 0, ErrBadTimestamp
+	}
+
+	fracStr := ""
+	if sec, frac, found := strings.Cut(secStr, "."); found {
+		secStr, fracStr = sec, frac
+	} else if sec, frac, found := strings.Cut(secStr, ":"); found {
+		secStr, fracStr = sec, frac
+	}
+
+	seconds, err := strconv.Atoi(secStr)
+	if err != nil || seconds < 0 || seconds >= 60 || len(secStr) > 2 {
+		return 0, ErrBadTimestamp
+	}
+
+	var ms int
+	if fracStr != "" {
+		if len(fracStr) > 3 {
+			return 0, ErrBadTimestamp
+		}
+		ms, err = strconv.Atoi(fracStr)
+		if err != nil || ms < 0 {
+			return 0, ErrBadTimestamp
+		}
+		for i := len(fracStr); i < 3; i++ {
+			ms *= 10
+		}
+	}
+
+	return time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(ms)*time.Millisecond, nil
+}
+
+// FormatTimestamp возвращает метку в формате mm:ss.xx
+func FormatTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, (cs/100)%60, cs%100)
+}
+
+// Format сериализует файл в LRC. Если у строки есть метки слов, пишется enhanced-формат.
+func Format(f *File) string {
+	var b strings.Builder
+
+	for _, key := range tagOrder {
+		if value, ok := f.Tags[key]; ok && value != "" {
+			fmt.Fprintf(&b, "[%s:%s]\n", key, value)
+		}
+	}
+	if f.Offset != 0 {
+		fmt.Fprintf(&b, "[%s:%+d]\n", TagOffset, f.Offset.Milliseconds())
+	}
+
+	for _, line := range f.Lines {
+		fmt.Fprintf(&b, "[%s]", FormatTimestamp(line.Time))
+		if len(line.Words) == 0 {
+			b.WriteString(line.Text)
+		} else {
+			for i, w := range line.Words {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				fmt.Fprintf(&b, "<%s>%s", FormatTimestamp(w.Time), w.Text)
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
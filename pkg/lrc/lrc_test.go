@@ -0,0 +1,83 @@
+package lrc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_TagsAndLines(t *testing.T) {
+	data := "[ti:Song]\n[ar:Artist]\n[offset:+250]\n[00:12.50]First line\n[00:05.00]Intro\n"
+
+	file, err := Parse(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Song", file.Tags[TagTitle])
+	assert.Equal(t, "Artist", file.Tags[TagArtist])
+	assert.Equal(t, 250*time.Millisecond, file.Offset)
+	assert.Len(t, file.Lines, 2)
+	assert.Equal(t, 5*time.Second, file.Lines[0].Time)
+	assert.Equal(t, "Intro", file.Lines[0].Text)
+	assert.Equal(t, 12500*time.Millisecond, file.Lines[1].Time)
+}
+
+func TestParse_RepeatedTimestamps(t *testing.T) {
+	file, err := Parse("[00:10.00][00:40.00]Chorus\n[00:20.00]Verse\n")
+
+	assert.NoError(t, err)
+	assert.Len(t, file.Lines, 3)
+	assert.Equal(t, "Chorus", file.Lines[0].Text)
+	assert.Equal(t, "Verse", file.Lines[1].Text)
+	assert.Equal(t, "Chorus", file.Lines[2].Text)
+	assert.Equal(t, 40*time.Second, file.Lines[2].Time)
+}
+
+func TestParse_EnhancedWords(t *testing.T) {
+	file, err := Parse("[00:01.00]<00:01.00>Hello <00:01.50>big <00:02.00>world<00:03.00>\n")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello big world", file.Lines[0].Text)
+	assert.Equal(t, []Word{
+		{Time: time.Second, Text: "Hello"},
+		{Time: 1500 * time.Millisecond, Text: "big"},
+		{Time: 2 * time.Second, Text: "world"},
+	}, file.Lines[0].Words)
+}
+
+func TestParse_TimestampFormats(t *testing.T) {
+	file, err := Parse("[01:02]a\n[01:02.5]b\n[01:02.345]c\n[01:02:10]d\n")
+
+	assert.NoError(t, err)
+	times := make(map[string]time.Duration)
+	for _, line := range file.Lines {
+		times[line.Text] = line.Time
+	}
+	assert.Equal(t, 62*time.Second, times["a"])
+	assert.Equal(t, 62500*time.Millisecond, times["b"])
+	assert.Equal(t, 62345*time.Millisecond, times["c"])
+	assert.Equal(t, 62100*time.Millisecond, times["d"])
+}
+
+func TestParse_NoLines(t *testing.T) {
+	file, err := Parse("[ti:Only tags]\nplain text\n")
+
+	assert.Nil(t, file)
+	assert.Equal(t, ErrNoLines, err)
+}
+
+func TestParse_BadOffset(t *testing.T) {
+	file, err := Parse("[offset:abc]\n[00:01.00]line\n")
+
+	assert.Nil(t, file)
+	assert.Error(t, err)
+}
+
+func TestFormat_RoundTrip(t *testing.T) {
+	data := "[ti:Song]\n[offset:-100]\n[00:01.00]Plain line\n[01:05.25]<01:05.25>Word <01:06.00>by <01:06.50>word\n"
+
+	file, err := Parse(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, data, Format(file))
+}
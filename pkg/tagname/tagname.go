@@ -0,0 +1,40 @@
+// Package tagname приводит пользовательские метки к одному написанию: "#Rainy-Day" и "rainy  day" - одна метка.
+package tagname
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength - наибольшая длина метки в символах
+const MaxLength = 40
+
+var ErrInvalid = errors.New("tagname: tag must have 2-40 letters or digits")
+
+// Normalize переводит метку в нижний регистр, убирает ведущий '#' и знаки препинания,
+// а пробелы, дефисы и подчеркивания между словами заменяет одним пробелом.
+// Апостроф и '&' сохраняются: "rock'n'roll", "r&b".
+func Normalize(value string) (string, error) {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.TrimLeft(strings.TrimSpace(value), "#") {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '&' || r == '\'':
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			space = true
+		}
+	}
+
+	name := b.String()
+	if n := utf8.RuneCountInString(name); n < 2 || n > MaxLength {
+		return "", ErrInvalid
+	}
+	return name, nil
+}
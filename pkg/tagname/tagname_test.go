@@ -0,0 +1,29 @@
+package tagname
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for input, want := range map[string]string{
+		"workout":          "workout",
+		"  Rainy   Day ":   "rainy day",
+		"#rainy-day":       "rainy day",
+		"rainy_day!":       "rainy day",
+		"R&B":              "r&b",
+		"Rock'n'Roll":      "rock'n'roll",
+		"Ночная Поездка":   "ночная поездка",
+		"lo-fi -- beats--": "lo fi beats",
+	} {
+		name, err := Normalize(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, name, input)
+	}
+
+	for _, input := range []string{"", "#", "a", " - ", "!!!", "abcdefghij abcdefghij abcdefghij abcdefghij"} {
+		_, err := Normalize(input)
+		assert.ErrorIs(t, err, ErrInvalid, input)
+	}
+}
@@ -0,0 +1,48 @@
+// Package timecode разбирает и печатает моменты записи в виде "1:23" или "1:02:03".
+package timecode
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalid = errors.New("timecode: expected seconds, m:ss or h:mm:ss")
+
+// Parse переводит "83", "1:23" или "1:02:03" в секунды.
+// Минуты и секунды после первой части должны быть двузначными и меньше 60.
+func Parse(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 3 {
+		return 0, ErrInvalid
+	}
+
+	seconds := 0
+	for i, part := range parts {
+		if part == "" || strings.TrimLeft(part, "0123456789") != "" || len(part) > 6 {
+			return 0, ErrInvalid
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, ErrInvalid
+		}
+		if i > 0 && (len(part) != 2 || n >= 60) {
+			return 0, ErrInvalid
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, nil
+}
+
+// Format печатает секунды как "m:ss" или, начиная с часа, "h:mm:ss"
+func Format(seconds int) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	h, m, s := seconds/3600, seconds/60%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
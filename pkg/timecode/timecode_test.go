@@ -0,0 +1,35 @@
+package timecode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for value, want := range map[string]int{
+		"0":       0,
+		"83":      83,
+		"1:23":    83,
+		" 0:05 ":  5,
+		"1:02:03": 3723,
+		"61:00":   3660,
+	} {
+		got, err := Parse(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	for _, value := range []string{"", "1:2", "1:60", "-5", "1:23:45:67", "a:bc", "1::23", "+1:23"} {
+		_, err := Parse(value)
+		assert.ErrorIs(t, err, ErrInvalid, value)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "0:00", Format(0))
+	assert.Equal(t, "1:23", Format(83))
+	assert.Equal(t, "59:59", Format(3599))
+	assert.Equal(t, "1:02:03", Format(3723))
+	assert.Equal(t, "0:00", Format(-1))
+}
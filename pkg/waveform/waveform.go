@@ -0,0 +1,173 @@
+// Package waveform строит пики звука (минимум и максимум на точку) в формате audiowaveform
+// (https://github.com/bbc/audiowaveform/blob/master/doc/DataFormat.md), версия 2, один канал.
+package waveform
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"music-lib/pkg/audio"
+)
+
+const (
+	Version = 2
+	// Разрядность значений: 8 бит в формате audiowaveform достаточно для отрисовки
+	Bits = 8
+	// Флаг заголовка двоичного формата: значения 8-битные
+	flag8Bit = 1
+
+	headerSize = 24
+)
+
+// Масштабы: сколько сэмплов приходится на одну точку
+var Resolutions = []int{256, 1024, 4096}
+
+const DefaultSamplesPerPixel = 1024
+
+var ErrInvalidData = errors.New("waveform: invalid data")
+
+type Waveform struct {
+	SampleRate      int
+	SamplesPerPixel int
+	Bits            int
+	// Пары минимум, максимум для каждой точки
+	Data []int16
+}
+
+// Length возвращает число точек
+func (w *Waveform) Length() int {
+	return len(w.Data) / 2
+}
+
+// Generate декодирует звук до конца и строит 8-битные пики сразу для всех масштабов
+func Generate(dec audio.Decoder, resolutions []int) ([]*Waveform, error) {
+	waveforms := make([]*Waveform, len(resolutions))
+	counts := make([]int, len(resolutions))
+	for i, spp := range resolutions {
+		waveforms[i] = &Waveform{SampleRate: dec.SampleRate(), SamplesPerPixel: spp, Bits: Bits}
+	}
+
+	buf := make([]float32, 8192)
+	for {
+		n, err := dec.Read(buf)
+		for _, sample := range buf[:n] {
+			v := to8Bit(sample)
+			for i, w := range waveforms {
+				if counts[i] == 0 {
+					w.Data = append(w.Data, v, v)
+				}
+				last := len(w.Data) - 2
+				w.Data[last] = min(w.Data[last], v)
+				w.Data[last+1] = max(w.Data[last+1], v)
+				counts[i] = (counts[i] + 1) % w.SamplesPerPixel
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return waveforms, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Как в audiowaveform: 16-битный сэмпл без младшего байта
+func to8Bit(sample float32) int16 {
+	v := math.Round(float64(sample) * math.MaxInt16)
+	v = max(math.MinInt16, min(math.MaxInt16, v))
+	return int16(v) >> 8
+}
+
+type jsonWaveform struct {
+	Version         int     `json:"version"`
+	Channels        int     `json:"channels"`
+	SampleRate      int     `json:"sample_rate"`
+	SamplesPerPixel int     `json:"samples_per_pixel"`
+	Bits            int     `json:"bits"`
+	Length          int     `json:"length"`
+	Data            []int16 `json:"data"`
+}
+
+func (w *Waveform) MarshalJSON() ([]byte, error) {
+	data := w.Data
+	if data == nil {
+		data = []int16{}
+	}
+	return json.Marshal(jsonWaveform{
+		Version:         Version,
+		Channels:        1,
+		SampleRate:      w.SampleRate,
+		SamplesPerPixel: w.SamplesPerPixel,
+		Bits:            w.Bits,
+		Length:          w.Length(),
+		Data:            data,
+	})
+}
+
+// MarshalBinary кодирует пики в формат .dat: заголовок из 24 байт и значения little-endian
+func (w *Waveform) MarshalBinary() ([]byte, error) {
+	width := 2
+	var flags uint32
+	if w.Bits == 8 {
+		width, flags = 1, flag8Bit
+	}
+
+	out := make([]byte, 0, headerSize+len(w.Data)*width)
+	out = binary.LittleEndian.AppendUint32(out, Version)
+	out = binary.LittleEndian.AppendUint32(out, flags)
+	out = binary.LittleEndian.AppendUint32(out, uint32(w.SampleRate))
+	out = binary.LittleEndian.AppendUint32(out, uint32(w.SamplesPerPixel))
+	out = binary.LittleEndian.AppendUint32(out, uint32(w.Length()))
+	out = binary.LittleEndian.AppendUint32(out, 1)
+	for _, v := range w.Data {
+		if width == 1 {
+			out = append(out, byte(int8(v)))
+		} else {
+			out = binary.LittleEndian.AppendUint16(out, uint16(v))
+		}
+	}
+	return out, nil
+}
+
+// UnmarshalBinary читает формат .dat версий 1 и 2 с одним каналом
+func (w *Waveform) UnmarshalBinary(data []byte) error {
+	if len(data) < headerSize-4 {
+		return ErrInvalidData
+	}
+	version := binary.LittleEndian.Uint32(data)
+	flags := binary.LittleEndian.Uint32(data[4:])
+	length := int(binary.LittleEndian.Uint32(data[16:]))
+
+	offset := headerSize - 4
+	if version == 2 {
+		if len(data) < headerSize || binary.LittleEndian.Uint32(data[20:]) != 1 {
+			return ErrInvalidData
+		}
+		offset = headerSize
+	} else if version != 1 {
+		return ErrInvalidData
+	}
+
+	width, bits := 2, 16
+	if flags&flag8Bit != 0 {
+		width, bits = 1, 8
+	}
+	if len(data)-offset != length*2*width {
+		return ErrInvalidData
+	}
+
+	w.SampleRate = int(binary.LittleEndian.Uint32(data[8:]))
+	w.SamplesPerPixel = int(binary.LittleEndian.Uint32(data[12:]))
+	w.Bits = bits
+	w.Data = make([]int16, length*2)
+	for i := range w.Data {
+		if width == 1 {
+			w.Data[i] = int16(int8(data[offset+i]))
+		} else {
+			w.Data[i] = int16(binary.LittleEndian.Uint16(data[offset+2*i:]))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package waveform
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Декодер, отдающий заранее заданные сэмплы небольшими порциями
+type fakeDecoder struct {
+	samples []float32
+}
+
+func (d *fakeDecoder) SampleRate() int {
+	return 8000
+}
+
+func (d *fakeDecoder) Read(buf []float32) (int, error) {
+	if len(d.samples) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(buf[:min(len(buf), 3)], d.samples)
+	d.samples = d.samples[n:]
+	return n, nil
+}
+
+func TestGenerate(t *testing.T) {
+	dec := &fakeDecoder{samples: []float32{0.5, -0.25, 1, 0, -1, -0.5, 0.1}}
+	waveforms, err := Generate(dec, []int{2, 4})
+	assert.NoError(t, err)
+	assert.Len(t, waveforms, 2)
+
+	// Последняя неполная точка тоже учитывается
+	assert.Equal(t, &Waveform{SampleRate: 8000, SamplesPerPixel: 2, Bits: 8,
+		Data: []int16{-32, 64, 0, 127, -128, -64, 12, 12}}, waveforms[0])
+	assert.Equal(t, []int16{-32, 127, -128, 12}, waveforms[1].Data)
+	assert.Equal(t, 2, waveforms[1].Length())
+}
+
+func TestMarshalJSON(t *testing.T) {
+	w := &Waveform{SampleRate: 44100, SamplesPerPixel: 256, Bits: 8, Data: []int16{-65, 63, -66, 64}}
+	data, err := json.Marshal(w)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"version":2,"channels":1,"sample_rate":44100,"samples_per_pixel":256,
+		"bits":8,"length":2,"data":[-65,63,-66,64]}`, string(data))
+
+	data, err = json.Marshal(&Waveform{SampleRate: 8000, SamplesPerPixel: 256, Bits: 8})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"data":[]`)
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	w := &Waveform{SampleRate: 44100, SamplesPerPixel: 1024, Bits: 8, Data: []int16{-128, 127, -3, 4}}
+	data, err := w.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		2, 0, 0, 0, 1, 0, 0, 0, 0x44, 0xAC, 0, 0, 0, 4, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0,
+		0x80, 0x7F, 0xFD, 0x04,
+	}, data)
+
+	var decoded Waveform
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, *w, decoded)
+
+	// 16 бит
+	w.Bits = 16
+	w.Data = []int16{-1000, 1000}
+	data, _ = w.MarshalBinary()
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, *w, decoded)
+
+	assert.ErrorIs(t, decoded.UnmarshalBinary(data[:len(data)-1]), ErrInvalidData)
+	assert.ErrorIs(t, decoded.UnmarshalBinary([]byte{3, 0, 0, 0}), ErrInvalidData)
+}